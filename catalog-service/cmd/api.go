@@ -0,0 +1,66 @@
+package main
+
+import (
+	"thanhldt060802/config"
+	"thanhldt060802/internal/client"
+	"thanhldt060802/internal/dto"
+	"thanhldt060802/internal/handler"
+	"thanhldt060802/internal/middleware"
+	"thanhldt060802/internal/service"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humagin"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// Services behind the handlers, main builds them on the database and contract tests on in-memory repositories
+type services struct {
+	categoryService service.CategoryService
+	productService  service.ProductService
+}
+
+// Registers every operation with its middlewares on r, so contract tests serve the same API as the application
+func newAPI(r *gin.Engine, services *services, redisClient *redis.Client, jwksClient client.JWKSClient, appConfig *config.Config) huma.API {
+	humaCfg := huma.DefaultConfig("Catalog Service", "v1.0.0")
+	humaCfg.DocsPath = ""
+	humaCfg.JSONSchemaDialect = ""
+	humaCfg.CreateHooks = nil
+	humaCfg.Components = &huma.Components{
+		SecuritySchemes: map[string]*huma.SecurityScheme{
+			"BearerAuth": {
+				Type:         "http",
+				Scheme:       "bearer",
+				BearerFormat: "JWT",
+			},
+		},
+	}
+
+	huma.NewError = func(status int, msg string, errs ...error) huma.StatusError {
+		details := make([]string, len(errs))
+		for i, err := range errs {
+			details[i] = err.Error()
+		}
+		res := &dto.ErrorResponse{}
+		res.Status = status
+		res.Message = msg
+		res.Details = details
+		return res
+	}
+
+	api := humagin.New(r, humaCfg)
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(api, jwksClient)
+
+	// Initialize rate limit middleware for every operation
+	rateLimitMiddleware := middleware.NewRateLimitMiddleware(api, authMiddleware, redisClient, appConfig)
+	api.UseMiddleware(rateLimitMiddleware.RateLimit)
+
+	// Initialize handlers
+	handler.NewProductHandler(api, services.productService, authMiddleware)
+	handler.NewCategoryHandler(api, services.categoryService, authMiddleware)
+	handler.NewStockReservationHandler(api, services.productService, authMiddleware)
+
+	return api
+}
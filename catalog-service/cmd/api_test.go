@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"thanhldt060802/config"
+	"thanhldt060802/internal/client"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"thanhldt060802/internal/repository/memory"
+	"thanhldt060802/internal/service"
+	"thanhldt060802/utils"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+// Database tables are in memory, Redis is miniredis and customer service is a JWKS server holding one test key,
+// so tokens are verified and rate limits are counted by the same code as in the application
+type contractFixture struct {
+	api        huma.API
+	privateKey *rsa.PrivateKey
+}
+
+// Categories Shirts (1), Hats (2) and an empty Shoes (3), products are:
+// 1 in stock shirt on sale, 2 sold out shirt, 3 hat on sale
+func newContractFixture(t *testing.T) *contractFixture {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+
+	redisServer := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()),
+		}}})
+	}))
+	t.Cleanup(jwksServer.Close)
+	jwksURL, err := url.Parse(jwksServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	appConfig := &config.Config{
+		CustomerServiceHost:              jwksURL.Hostname(),
+		CustomerServicePort:              jwksURL.Port(),
+		CustomerServiceTimeoutSeconds:    "5",
+		RateLimitDefault:                 "300/1m",
+		RateLimitOperations:              "GET /products/suggest=2/1m;POST /products/reservations=3/1m",
+		ReservationExpireSeconds:         "900",
+		OutboxBatchSize:                  "10",
+		OutboxMaxAttempts:                "2",
+		ProductSuggestCacheExpireSeconds: "30",
+		ProductCacheExpireSeconds:        "60",
+		CategoryCacheExpireSeconds:       "60",
+	}
+
+	categoryRepository := memory.NewCategoryRepository()
+	productRepository := memory.NewProductRepository()
+	ctx := context.Background()
+	for _, category := range []model.Category{{Name: "Shirts"}, {Name: "Hats"}, {Name: "Shoes"}} {
+		if err := categoryRepository.Create(ctx, &category); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, product := range []model.Product{
+		{Name: "Áo sơ mi trắng", Description: "Áo sơ mi cotton", Sex: "MALE", Price: 250000, DiscountPercentage: 10, Stock: 5,
+			ImageURL: "shirt.png", CategoryId: 1, CreatedAt: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)},
+		{Name: "Áo thun", Description: "Áo thun basic", Sex: "FEMALE", Price: 150000, Stock: 0,
+			ImageURL: "t-shirt.png", CategoryId: 1, CreatedAt: time.Date(2026, 3, 2, 20, 0, 0, 0, time.UTC)},
+		{Name: "Mũ lưỡi trai", Description: "Mũ cotton", Sex: "UNISEX", Price: 600000, DiscountPercentage: 20, Stock: 2,
+			ImageURL: "cap.png", CategoryId: 2, CreatedAt: time.Date(2026, 3, 3, 5, 0, 0, 0, time.UTC)},
+	} {
+		if err := productRepository.Create(ctx, &product); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cachedCategoryRepository := repository.NewCachedCategoryRepository(categoryRepository, redisClient, *appConfig.GetCategoryCacheExpireSeconds())
+	cachedProductRepository := repository.NewCachedProductRepository(productRepository, redisClient, *appConfig.GetProductCacheExpireSeconds())
+	api := newAPI(gin.New(), &services{
+		categoryService: service.NewCategoryService(cachedCategoryRepository),
+		productService: service.NewProductService(cachedProductRepository, memory.NewProductElasticsearchRepository(), cachedCategoryRepository,
+			memory.NewStockReservationRepository(), memory.NewOutboxEventRepository(), memory.NewTransactionManager(), redisClient, appConfig),
+	}, redisClient, client.NewJWKSClient(appConfig), appConfig)
+
+	return &contractFixture{
+		api:        api,
+		privateKey: privateKey,
+	}
+}
+
+// Access token as customer service issues it, signed with the key served by JWKS server
+func (f *contractFixture) token(t *testing.T, userId int64, roleName string, permissions ...string) string {
+	t.Helper()
+
+	claims := &utils.TokenClaims{
+		UserId:      userId,
+		RoleName:    roleName,
+		CartId:      userId,
+		SessionId:   "session-" + strconv.FormatInt(userId, 10),
+		Permissions: permissions,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "token-" + strconv.FormatInt(userId, 10),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test"
+
+	signedToken, err := token.SignedString(f.privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signedToken
+}
+
+func TestAPIContract(t *testing.T) {
+	f := newContractFixture(t)
+	c := newContractTest(t, f.api)
+
+	adminToken := f.token(t, 1, "ADMIN", model.PermissionProductWrite, model.PermissionCategoryWrite)
+	customerToken := f.token(t, 2, "CUSTOMER")
+	serviceToken := f.token(t, 0, "SERVICE", model.PermissionStockReserve)
+
+	// Categories
+	c.do("get categories", http.MethodGet, "/categories?include_total=true", "", nil, http.StatusOK)
+	c.do("get categories invalid sort", http.MethodGet, "/categories?sort_by=price", "", nil, http.StatusBadRequest)
+	c.do("get category by id", http.MethodGet, "/categories/id/1", "", nil, http.StatusOK)
+	c.do("get unknown category by id", http.MethodGet, "/categories/id/99", "", nil, http.StatusBadRequest)
+	c.do("get category by name", http.MethodGet, "/categories/name/hats", "", nil, http.StatusOK)
+
+	// Auth and permission middleware
+	c.do("create category without token", http.MethodPost, "/categories", "", map[string]any{"name": "Bags"}, http.StatusUnauthorized)
+	c.do("create category with invalid token", http.MethodPost, "/categories", "invalid", map[string]any{"name": "Bags"}, http.StatusUnauthorized)
+	c.do("create category with forged signature", http.MethodPost, "/categories", adminToken[:len(adminToken)-4]+"AAAA", map[string]any{"name": "Bags"}, http.StatusUnauthorized)
+	c.do("create category without permission", http.MethodPost, "/categories", customerToken, map[string]any{"name": "Bags"}, http.StatusForbidden)
+
+	c.do("create category", http.MethodPost, "/categories", adminToken, map[string]any{"name": "Bags"}, http.StatusOK)
+	c.do("create category duplicate name", http.MethodPost, "/categories", adminToken, map[string]any{"name": "bags"}, http.StatusBadRequest)
+	c.do("create category invalid body", http.MethodPost, "/categories", adminToken, map[string]any{"name": ""}, http.StatusUnprocessableEntity)
+	c.do("update category", http.MethodPut, "/categories/id/4", adminToken, map[string]any{"name": "Backpacks"}, http.StatusOK)
+	c.do("update unknown category", http.MethodPut, "/categories/id/99", adminToken, map[string]any{"name": "Backpacks"}, http.StatusBadRequest)
+	c.do("delete category", http.MethodDelete, "/categories/id/4", adminToken, nil, http.StatusOK)
+
+	// Products
+	c.do("get products", http.MethodGet, "/products?include_total=true&sort_by=id", "", nil, http.StatusOK)
+	c.do("get product by id", http.MethodGet, "/products/id/1", "", nil, http.StatusOK)
+	c.do("get unknown product by id", http.MethodGet, "/products/id/99", "", nil, http.StatusNotFound)
+	c.do("get products by ids", http.MethodGet, "/products/ids?ids=3,1,99", "", nil, http.StatusOK)
+	c.do("get products by category id", http.MethodGet, "/products/category-id/1", "", nil, http.StatusOK)
+	product := map[string]any{
+		"name": "Giày thể thao", "description": "Giày chạy bộ", "sex": "UNISEX", "price": 900000, "discount_percentage": 0,
+		"stock": 3, "image_url": "shoes.png", "category_id": 3,
+	}
+	c.do("create product without permission", http.MethodPost, "/products", customerToken, product, http.StatusForbidden)
+	c.do("create product", http.MethodPost, "/products", adminToken, product, http.StatusOK)
+	c.do("create product invalid sex", http.MethodPost, "/products", adminToken, map[string]any{
+		"name": "Giày", "description": "Giày", "sex": "KID", "price": 1, "discount_percentage": 0, "stock": 1, "image_url": "x.png", "category_id": 3,
+	}, http.StatusUnprocessableEntity)
+	c.do("update product", http.MethodPut, "/products/id/4", adminToken, map[string]any{"price": 850000, "discount_percentage": 5}, http.StatusOK)
+	c.do("update product of unknown category", http.MethodPut, "/products/id/4", adminToken, map[string]any{"category_id": 99}, http.StatusBadRequest)
+	c.do("delete product", http.MethodDelete, "/products/id/4", adminToken, nil, http.StatusOK)
+
+	// Elasticsearch
+	c.do("sync products to elasticsearch without token", http.MethodGet, "/products/sync-to-elasticsearch", "", nil, http.StatusUnauthorized)
+	c.do("sync products to elasticsearch", http.MethodGet, "/products/sync-to-elasticsearch", adminToken, nil, http.StatusOK)
+	c.do("search products", http.MethodGet, "/products/elasticsearch?q=ao+so+mi&sort_by=id&include_total=true", "", nil, http.StatusOK)
+	c.do("search products with facets", http.MethodGet, "/products/elasticsearch?category_ids=1&on_sale=true&price_interval=200000&sort_by=id", "", nil, http.StatusOK)
+	c.do("search products invalid price", http.MethodGet, "/products/elasticsearch?price_gte=cheap", "", nil, http.StatusUnprocessableEntity)
+	c.do("suggest products", http.MethodGet, "/products/suggest?q=ao+so", "", nil, http.StatusOK)
+	c.do("suggest products within rate limit", http.MethodGet, "/products/suggest?q=mu", "", nil, http.StatusOK)
+	c.do("suggest products over rate limit", http.MethodGet, "/products/suggest?q=mu", "", nil, http.StatusTooManyRequests)
+
+	// Stock reservations
+	c.do("reserve stock without token", http.MethodPost, "/products/reservations", "", map[string]any{"items": []map[string]any{
+		{"product_id": 1, "quantity": 1},
+	}}, http.StatusUnauthorized)
+	c.do("reserve stock with user token", http.MethodPost, "/products/reservations", adminToken, map[string]any{"items": []map[string]any{
+		{"product_id": 1, "quantity": 1},
+	}}, http.StatusForbidden)
+	c.do("reserve stock", http.MethodPost, "/products/reservations", serviceToken, map[string]any{"items": []map[string]any{
+		{"product_id": 1, "quantity": 2}, {"product_id": 3, "quantity": 1},
+	}}, http.StatusOK)
+	c.do("reserve more than stock", http.MethodPost, "/products/reservations", serviceToken, map[string]any{"items": []map[string]any{
+		{"product_id": 2, "quantity": 1},
+	}}, http.StatusBadRequest)
+	c.do("get stock reservation", http.MethodGet, "/products/reservations/id/1", serviceToken, nil, http.StatusOK)
+	c.do("get unknown stock reservation", http.MethodGet, "/products/reservations/id/99", serviceToken, nil, http.StatusBadRequest)
+	c.do("confirm stock reservation without token", http.MethodPost, "/products/reservations/id/1/confirm", "", nil, http.StatusUnauthorized)
+	c.do("confirm stock reservation", http.MethodPost, "/products/reservations/id/1/confirm", serviceToken, nil, http.StatusOK)
+	c.do("release confirmed stock reservation", http.MethodPost, "/products/reservations/id/1/release", serviceToken, nil, http.StatusOK)
+	c.do("confirm released stock reservation", http.MethodPost, "/products/reservations/id/1/confirm", serviceToken, nil, http.StatusBadRequest)
+	c.do("reserve stock again", http.MethodPost, "/products/reservations", serviceToken, map[string]any{"items": []map[string]any{
+		{"product_id": 1, "quantity": 1},
+	}}, http.StatusOK)
+	c.do("reserve stock over rate limit of customer service", http.MethodPost, "/products/reservations", serviceToken, map[string]any{"items": []map[string]any{
+		{"product_id": 1, "quantity": 1},
+	}}, http.StatusTooManyRequests)
+	c.do("release stock reservation without token", http.MethodPost, "/products/reservations/id/2/release", "", nil, http.StatusUnauthorized)
+	c.do("release stock reservation", http.MethodPost, "/products/reservations/id/2/release", serviceToken, nil, http.StatusOK)
+	c.do("confirm released stock reservation", http.MethodPost, "/products/reservations/id/2/confirm", serviceToken, nil, http.StatusBadRequest)
+	c.do("get product after reservations", http.MethodGet, "/products/id/1", "", nil, http.StatusOK)
+
+	c.checkCoverage()
+	c.checkSnapshot("api_contract.json")
+}
+
+func TestOpenAPIContract(t *testing.T) {
+	f := newContractFixture(t)
+
+	resp := humatest.Wrap(t, f.api).Get("/openapi.json")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.Code, http.StatusOK)
+	}
+
+	var openAPI any
+	if err := json.Unmarshal(resp.Body.Bytes(), &openAPI); err != nil {
+		t.Fatal(err)
+	}
+	indented, err := json.MarshalIndent(openAPI, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSnapshot(t, "openapi.json", indented)
+}
+
+// Operations open to anonymous callers, every other one must ask for a token before anything else
+var publicOperations = []string{
+	"GET /categories",
+	"GET /categories/id/{id}",
+	"GET /categories/name/{name}",
+	"GET /products",
+	"GET /products/category-id/{category_id}",
+	"GET /products/elasticsearch",
+	"GET /products/id/{id}",
+	"GET /products/ids",
+	"GET /products/suggest",
+}
+
+func TestAPIAuthentication(t *testing.T) {
+	f := newContractFixture(t)
+	testAPI := humatest.Wrap(t, f.api)
+
+	for _, operation := range operationsOf(f.api) {
+		if slices.Contains(publicOperations, operation) {
+			continue
+		}
+
+		method, path, _ := strings.Cut(operation, " ")
+		path = regexp.MustCompile(`\{[^}]+\}`).ReplaceAllString(path, "1")
+		if resp := testAPI.Do(method, path); resp.Code != http.StatusUnauthorized {
+			t.Errorf("anonymous %s status = %d, want %d", operation, resp.Code, http.StatusUnauthorized)
+		}
+	}
+}
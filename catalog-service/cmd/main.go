@@ -1,18 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"expvar"
+	"log"
 	"net/http"
+	"os"
 	"thanhldt060802/config"
 	"thanhldt060802/infrastructure"
-	"thanhldt060802/internal/dto"
-	"thanhldt060802/internal/handler"
-	"thanhldt060802/internal/middleware"
+	"thanhldt060802/internal/client"
 	"thanhldt060802/internal/repository"
 	"thanhldt060802/internal/service"
+	"thanhldt060802/internal/worker"
+	"thanhldt060802/migrations"
 
-	"github.com/danielgtaylor/huma/v2"
-	"github.com/danielgtaylor/huma/v2/adapters/humagin"
 	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
 )
 
 // Huma Docs UI template by Scalar
@@ -33,64 +37,106 @@ var humaDocsEmbedded = `<!doctype html>
 
 func main() {
 
-	config.InitConfig()
-	infrastructure.InitPostgesConnection()
-	defer infrastructure.DB.Close()
-	infrastructure.InitRedisClient()
-	defer infrastructure.RedisClient.Close()
-	infrastructure.InitElasticsearchClient()
-
-	humaCfg := huma.DefaultConfig("Catalog Service", "v1.0.0")
-	humaCfg.DocsPath = ""
-	humaCfg.JSONSchemaDialect = ""
-	humaCfg.CreateHooks = nil
-	humaCfg.Components = &huma.Components{
-		SecuritySchemes: map[string]*huma.SecurityScheme{
-			"BearerAuth": {
-				Type:         "http",
-				Scheme:       "bearer",
-				BearerFormat: "JWT",
-			},
-		},
+	appConfig := config.LoadConfig()
+	db := infrastructure.NewPostgresDB(appConfig)
+	defer db.Close()
+
+	// Manage database schema as a one-off command: go run ./cmd migrate up|down|status,
+	// demo data is a separate set: go run ./cmd migrate seed up|down|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), db, appConfig.PostgresSchema, os.Args[2:]); err != nil {
+			log.Fatal("Migrate failed: ", err)
+		}
+		return
+	}
+
+	// Refuse to run against a schema older than this binary
+	if err := migrations.CheckSchema(context.Background(), db); err != nil {
+		log.Fatal(err)
 	}
 
-	huma.NewError = func(status int, msg string, errs ...error) huma.StatusError {
-		details := make([]string, len(errs))
-		for i, err := range errs {
-			details[i] = err.Error()
+	redisClient := infrastructure.NewRedisClient(appConfig)
+	defer redisClient.Close()
+	elasticsearchClient := infrastructure.NewElasticsearchClient(appConfig)
+
+	// Initialize repositories
+	categoryRepository := repository.NewCategoryRepository(db)
+	productRepository := repository.NewProductRepository(db)
+	stockReservationRepository := repository.NewStockReservationRepository(db)
+	outboxEventRepository := repository.NewOutboxEventRepository(db)
+	productEventRepository := repository.NewProductEventRepository(redisClient)
+	transactionManager := repository.NewTransactionManager(db)
+
+	// Initialize cached repositories, outbox keeps reading database directly so Elasticsearch never indexes a stale copy
+	cachedCategoryRepository := repository.NewCachedCategoryRepository(categoryRepository, redisClient, *appConfig.GetCategoryCacheExpireSeconds())
+	cachedProductRepository := repository.NewCachedProductRepository(productRepository, redisClient, *appConfig.GetProductCacheExpireSeconds())
+
+	// Initialize Elasticsearch repository
+	productElasticsearchRepository := repository.NewProductElasticsearchRepository(elasticsearchClient)
+
+	// Initialize services
+	categoryServive := service.NewCategoryService(cachedCategoryRepository)
+	productService := service.NewProductService(cachedProductRepository, productElasticsearchRepository, cachedCategoryRepository, stockReservationRepository, outboxEventRepository, transactionManager, redisClient, appConfig)
+	outboxService := service.NewOutboxService(outboxEventRepository, productRepository, productElasticsearchRepository, productEventRepository, transactionManager, appConfig)
+
+	// Rebuild Elasticsearch index as a one-off command: go run ./cmd reindex
+	if len(os.Args) > 1 && os.Args[1] == "reindex" {
+		if err := productService.SyncAllProductsToElasticsearch(context.Background()); err != nil {
+			log.Fatal("Reindex products failed: ", err)
 		}
-		res := &dto.ErrorResponse{}
-		res.Status = status
-		res.Message = msg
-		res.Details = details
-		return res
+		log.Println("Reindex products successful")
+		return
 	}
 
 	r := gin.Default()
 	r.GET("/docs", func(ctx *gin.Context) {
 		ctx.Data(http.StatusOK, "text/html", []byte(humaDocsEmbedded))
 	})
+	// Runtime metrics including repository cache hit and miss counters
+	r.GET("/debug/vars", gin.WrapH(expvar.Handler()))
 
-	api := humagin.New(r, humaCfg)
+	// Keys of customer service are fetched now and refreshed in background
+	jwksClient := client.NewJWKSClient(appConfig)
+	if err := jwksClient.Refresh(context.Background()); err != nil {
+		log.Printf("Fetch jwks failed, retry on first token: %s", err.Error())
+	}
 
-	// Initialize auth middleware
-	authMiddleware := middleware.NewAuthMiddleware(api)
+	newAPI(r, &services{
+		categoryService: categoryServive,
+		productService:  productService,
+	}, redisClient, jwksClient, appConfig)
 
-	// Initialize repositories
-	categoryRepository := repository.NewCategoryRepository()
-	productRepository := repository.NewProductRepository()
+	// Start background workers
+	worker.StartOutboxDispatcher(context.Background(), outboxService, *appConfig.GetOutboxDispatchIntervalSeconds())
+	worker.StartReservationSweeper(context.Background(), productService, *appConfig.GetReservationSweepIntervalSeconds())
+	worker.StartJWKSRefresher(context.Background(), jwksClient, *appConfig.GetJWKSRefreshIntervalSeconds())
 
-	// Initialize Elasticsearch repository
-	productElasticsearchRepository := repository.NewProductElasticsearchRepository()
+	r.Run(":" + appConfig.AppPort)
 
-	// Initialize services
-	categoryServive := service.NewCategoryService(categoryRepository)
-	productService := service.NewProductService(productRepository, productElasticsearchRepository, categoryRepository)
+}
 
-	// Initialize handlers
-	handler.NewProductHandler(api, productService, authMiddleware)
-	handler.NewCategoryHandler(api, categoryServive, authMiddleware)
+// Runs migrate subcommand with args after "migrate" in schema of the service, seed migrations need the schema to be
+// up to date
+func runMigrate(ctx context.Context, db *bun.DB, schema string, args []string) error {
+	if err := migrations.PrepareSchema(ctx, db, schema); err != nil {
+		return err
+	}
 
-	r.Run(":" + config.AppConfig.AppPort)
+	newMigrator := migrations.NewSchemaMigrator
+	if len(args) > 0 && args[0] == "seed" {
+		if err := migrations.CheckSchema(ctx, db); err != nil {
+			return err
+		}
+		newMigrator, args = migrations.NewSeedMigrator, args[1:]
+	}
+	if len(args) != 1 {
+		return errors.New("usage: migrate [seed] up|down|status")
+	}
+
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
 
+	return migrations.Run(ctx, migrator, args[0])
 }
@@ -3,6 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,26 +18,51 @@ type Config struct {
 	PostgresUser     string
 	PostgresPassword string
 	PostgresDB       string
+	PostgresSchema   string
+
+	CustomerServiceHost           string
+	CustomerServicePort           string
+	CustomerServiceTimeoutSeconds string
+	JWKSRefreshIntervalSeconds    string
 
 	RedisHost     string
 	RedisPort     string
 	RedisPassword string
 
+	RateLimitDefault    string
+	RateLimitOperations string
+
 	ElasticsearchHost     string
 	ElasticsearchPort     string
 	ElasticsearchUsername string
 	ElasticsearchPassword string
+
+	ReservationExpireSeconds        string
+	ReservationSweepIntervalSeconds string
+
+	OutboxDispatchIntervalSeconds string
+	OutboxBatchSize               string
+	OutboxMaxAttempts             string
+
+	ProductSuggestCacheExpireSeconds string
+	ProductCacheExpireSeconds        string
+	CategoryCacheExpireSeconds       string
 }
 
-var AppConfig *Config
+// Requests allowed per window, written as "limit/window" in environment like "60/1m", zero limit means unlimited
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
 
-func InitConfig() {
+// Loads configuration from .env and environment, callers pass the result to whatever needs it
+func LoadConfig() *Config {
 	err := godotenv.Load(".env")
 	if err != nil {
 		log.Fatal("Error loading .env file: ", err)
 	}
 
-	AppConfig = &Config{
+	appConfig := &Config{
 		AppPort: GetEnv("APP_PORT", "8080"),
 
 		PostgresHost:     GetEnv("POSTGRES_HOST", "localhost"),
@@ -42,18 +70,39 @@ func InitConfig() {
 		PostgresUser:     GetEnv("POSTGRES_USER", "postgres"),
 		PostgresPassword: GetEnv("POSTGRES_PASSWORD", ""),
 		PostgresDB:       GetEnv("POSTGRES_DB", "my_db"),
+		PostgresSchema:   GetEnv("POSTGRES_SCHEMA", "catalog"),
+
+		CustomerServiceHost:           GetEnv("CUSTOMER_SERVICE_HOST", "localhost"),
+		CustomerServicePort:           GetEnv("CUSTOMER_SERVICE_PORT", "8080"),
+		CustomerServiceTimeoutSeconds: GetEnv("CUSTOMER_SERVICE_TIMEOUT_SECONDS", "5"),
+		JWKSRefreshIntervalSeconds:    GetEnv("JWKS_REFRESH_INTERVAL_SECONDS", "300"),
 
 		RedisHost:     GetEnv("REDIS_HOST", "localhost"),
 		RedisPort:     GetEnv("REDIS_PORT", "6379"),
 		RedisPassword: GetEnv("REDIS_PASSWORD", ""),
 
+		RateLimitDefault:    GetEnv("RATE_LIMIT_DEFAULT", "300/1m"),
+		RateLimitOperations: GetEnv("RATE_LIMIT_OPERATIONS", "GET /products/elasticsearch=60/1m;GET /products/suggest=120/1m;POST /products/reservations=600/1m;POST /products/reservations/id/{id}/confirm=600/1m;POST /products/reservations/id/{id}/release=600/1m;GET /products/ids=1200/1m"),
+
 		ElasticsearchHost:     GetEnv("ELASTICSEARCH_HOST", "localhost"),
 		ElasticsearchPort:     GetEnv("ELASTICSEARCH_PORT", "9200"),
 		ElasticsearchUsername: GetEnv("ELASTICSEARCH_USERNAME", "elastic"),
 		ElasticsearchPassword: GetEnv("ELASTICSEARCH_PASSWORD", ""),
+
+		ReservationExpireSeconds:        GetEnv("RESERVATION_EXPIRE_SECONDS", "900"),
+		ReservationSweepIntervalSeconds: GetEnv("RESERVATION_SWEEP_INTERVAL_SECONDS", "60"),
+
+		OutboxDispatchIntervalSeconds: GetEnv("OUTBOX_DISPATCH_INTERVAL_SECONDS", "5"),
+		OutboxBatchSize:               GetEnv("OUTBOX_BATCH_SIZE", "100"),
+		OutboxMaxAttempts:             GetEnv("OUTBOX_MAX_ATTEMPTS", "10"),
+
+		ProductSuggestCacheExpireSeconds: GetEnv("PRODUCT_SUGGEST_CACHE_EXPIRE_SECONDS", "30"),
+		ProductCacheExpireSeconds:        GetEnv("PRODUCT_CACHE_EXPIRE_SECONDS", "60"),
+		CategoryCacheExpireSeconds:       GetEnv("CATEGORY_CACHE_EXPIRE_SECONDS", "300"),
 	}
 
 	log.Println("Loading .env file successful")
+	return appConfig
 }
 
 func GetEnv(key string, defaultValue string) string {
@@ -63,3 +112,158 @@ func GetEnv(key string, defaultValue string) string {
 		return defaultValue
 	}
 }
+
+func (config *Config) GetReservationExpireSeconds() *time.Duration {
+	expireSeconds, err := strconv.Atoi(config.ReservationExpireSeconds)
+	if err != nil {
+		log.Fatal("Value of environment variable RESERVATION_EXPIRE_SECONDS is not valid")
+		return nil
+	}
+
+	expireDuration := time.Duration(expireSeconds) * time.Second
+	return &expireDuration
+}
+
+func (config *Config) GetReservationSweepIntervalSeconds() *time.Duration {
+	intervalSeconds, err := strconv.Atoi(config.ReservationSweepIntervalSeconds)
+	if err != nil || intervalSeconds <= 0 {
+		log.Fatal("Value of environment variable RESERVATION_SWEEP_INTERVAL_SECONDS is not valid")
+		return nil
+	}
+
+	intervalDuration := time.Duration(intervalSeconds) * time.Second
+	return &intervalDuration
+}
+
+func (config *Config) GetOutboxDispatchIntervalSeconds() *time.Duration {
+	intervalSeconds, err := strconv.Atoi(config.OutboxDispatchIntervalSeconds)
+	if err != nil || intervalSeconds <= 0 {
+		log.Fatal("Value of environment variable OUTBOX_DISPATCH_INTERVAL_SECONDS is not valid")
+		return nil
+	}
+
+	intervalDuration := time.Duration(intervalSeconds) * time.Second
+	return &intervalDuration
+}
+
+func (config *Config) GetOutboxBatchSize() int {
+	batchSize, err := strconv.Atoi(config.OutboxBatchSize)
+	if err != nil || batchSize <= 0 {
+		log.Fatal("Value of environment variable OUTBOX_BATCH_SIZE is not valid")
+	}
+
+	return batchSize
+}
+
+func (config *Config) GetOutboxMaxAttempts() int {
+	maxAttempts, err := strconv.Atoi(config.OutboxMaxAttempts)
+	if err != nil || maxAttempts <= 0 {
+		log.Fatal("Value of environment variable OUTBOX_MAX_ATTEMPTS is not valid")
+	}
+
+	return maxAttempts
+}
+
+func (config *Config) GetProductSuggestCacheExpireSeconds() *time.Duration {
+	expireSeconds, err := strconv.Atoi(config.ProductSuggestCacheExpireSeconds)
+	if err != nil {
+		log.Fatal("Value of environment variable PRODUCT_SUGGEST_CACHE_EXPIRE_SECONDS is not valid")
+		return nil
+	}
+
+	expireDuration := time.Duration(expireSeconds) * time.Second
+	return &expireDuration
+}
+
+func (config *Config) GetProductCacheExpireSeconds() *time.Duration {
+	expireSeconds, err := strconv.Atoi(config.ProductCacheExpireSeconds)
+	if err != nil {
+		log.Fatal("Value of environment variable PRODUCT_CACHE_EXPIRE_SECONDS is not valid")
+		return nil
+	}
+
+	expireDuration := time.Duration(expireSeconds) * time.Second
+	return &expireDuration
+}
+
+func (config *Config) GetCategoryCacheExpireSeconds() *time.Duration {
+	expireSeconds, err := strconv.Atoi(config.CategoryCacheExpireSeconds)
+	if err != nil {
+		log.Fatal("Value of environment variable CATEGORY_CACHE_EXPIRE_SECONDS is not valid")
+		return nil
+	}
+
+	expireDuration := time.Duration(expireSeconds) * time.Second
+	return &expireDuration
+}
+
+func (config *Config) GetCustomerServiceTimeout() *time.Duration {
+	timeoutSeconds, err := strconv.Atoi(config.CustomerServiceTimeoutSeconds)
+	if err != nil {
+		log.Fatal("Value of environment variable CUSTOMER_SERVICE_TIMEOUT_SECONDS is not valid")
+		return nil
+	}
+
+	timeoutDuration := time.Duration(timeoutSeconds) * time.Second
+	return &timeoutDuration
+}
+
+func (config *Config) GetJWKSRefreshIntervalSeconds() *time.Duration {
+	intervalSeconds, err := strconv.Atoi(config.JWKSRefreshIntervalSeconds)
+	if err != nil || intervalSeconds <= 0 {
+		log.Fatal("Value of environment variable JWKS_REFRESH_INTERVAL_SECONDS is not valid")
+		return nil
+	}
+
+	intervalDuration := time.Duration(intervalSeconds) * time.Second
+	return &intervalDuration
+}
+
+func (config *Config) GetRateLimitDefault() *RateLimit {
+	rateLimit, ok := parseRateLimit(config.RateLimitDefault)
+	if !ok {
+		log.Fatal("Value of environment variable RATE_LIMIT_DEFAULT is not valid")
+		return nil
+	}
+
+	return rateLimit
+}
+
+// Operations are written as "METHOD /path=limit/window" separated by semicolons
+func (config *Config) GetRateLimitOperations() map[string]RateLimit {
+	rateLimits := map[string]RateLimit{}
+	for _, operation := range strings.Split(config.RateLimitOperations, ";") {
+		operation = strings.TrimSpace(operation)
+		if operation == "" {
+			continue
+		}
+
+		operationKey, value, found := strings.Cut(operation, "=")
+		rateLimit, ok := parseRateLimit(value)
+		if !found || !ok {
+			log.Fatal("Value of environment variable RATE_LIMIT_OPERATIONS is not valid")
+			return nil
+		}
+		rateLimits[strings.TrimSpace(operationKey)] = *rateLimit
+	}
+
+	return rateLimits
+}
+
+func parseRateLimit(value string) (*RateLimit, bool) {
+	limitValue, windowValue, found := strings.Cut(strings.TrimSpace(value), "/")
+	if !found {
+		return nil, false
+	}
+
+	limit, err := strconv.Atoi(limitValue)
+	if err != nil || limit < 0 {
+		return nil, false
+	}
+	window, err := time.ParseDuration(windowValue)
+	if err != nil || window <= 0 {
+		return nil, false
+	}
+
+	return &RateLimit{Limit: limit, Window: window}, true
+}
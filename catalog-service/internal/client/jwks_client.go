@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"thanhldt060802/config"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Unknown kid triggers a refetch at most this often, so forged kids can not flood customer service
+const jwksMinRefreshInterval = 30 * time.Second
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jwksClient struct {
+	jwksURL    string
+	httpClient *http.Client
+
+	mu            sync.RWMutex
+	keys          map[string]*rsa.PublicKey
+	lastRefreshAt time.Time
+	refreshMu     sync.Mutex
+}
+
+type JWKSClient interface {
+	Refresh(ctx context.Context) error
+	KeyFunc(token *jwt.Token) (interface{}, error)
+}
+
+func NewJWKSClient(appConfig *config.Config) JWKSClient {
+	return &jwksClient{
+		jwksURL:    fmt.Sprintf("http://%s:%s/.well-known/jwks.json", appConfig.CustomerServiceHost, appConfig.CustomerServicePort),
+		httpClient: &http.Client{Timeout: *appConfig.GetCustomerServiceTimeout()},
+		keys:       map[string]*rsa.PublicKey{},
+	}
+}
+
+// Replaces whole key set, keys retired by customer service stop verifying here
+func (jwksClient *jwksClient) Refresh(ctx context.Context) error {
+	jwksClient.refreshMu.Lock()
+	defer jwksClient.refreshMu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksClient.jwksURL, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := jwksClient.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch jwks failed: %s", err.Error())
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch jwks failed: status %d", res.StatusCode)
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&jwks); err != nil {
+		return fmt.Errorf("decode jwks failed: %s", err.Error())
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, key := range jwks.Keys {
+		if key.Kty != "RSA" || key.Kid == "" {
+			continue
+		}
+		publicKey, err := toRSAPublicKey(&key)
+		if err != nil {
+			log.Printf("Skip invalid jwk %s: %s", key.Kid, err.Error())
+			continue
+		}
+		keys[key.Kid] = publicKey
+	}
+
+	jwksClient.mu.Lock()
+	jwksClient.keys = keys
+	jwksClient.lastRefreshAt = time.Now()
+	jwksClient.mu.Unlock()
+
+	return nil
+}
+
+func (jwksClient *jwksClient) KeyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, fmt.Errorf("token has no key id")
+	}
+
+	if publicKey := jwksClient.getKey(kid); publicKey != nil {
+		return publicKey, nil
+	}
+
+	// Customer service may have rotated to a key not cached yet
+	jwksClient.mu.RLock()
+	canRefresh := time.Since(jwksClient.lastRefreshAt) >= jwksMinRefreshInterval
+	jwksClient.mu.RUnlock()
+	if canRefresh {
+		ctx, cancel := context.WithTimeout(context.Background(), jwksClient.httpClient.Timeout)
+		defer cancel()
+		if err := jwksClient.Refresh(ctx); err != nil {
+			log.Printf("Refresh jwks failed: %s", err.Error())
+		}
+		if publicKey := jwksClient.getKey(kid); publicKey != nil {
+			return publicKey, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+func (jwksClient *jwksClient) getKey(kid string) *rsa.PublicKey {
+	jwksClient.mu.RLock()
+	defer jwksClient.mu.RUnlock()
+
+	return jwksClient.keys[kid]
+}
+
+func toRSAPublicKey(key *jsonWebKey) (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(key.N)
+	if err != nil {
+		return nil, err
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(key.E)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(nBytes),
+		E: int(new(big.Int).SetBytes(eBytes).Int64()),
+	}, nil
+}
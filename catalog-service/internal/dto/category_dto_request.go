@@ -1,9 +1,10 @@
 package dto
 
 type GetCategoriesRequest struct {
-	Offset int    `query:"offset" default:"0" minimum:"0" example:"0" doc:"Skip item by offset."`
-	Limit  int    `query:"limit" default:"5" minimum:"1" maximum:"10" example:"10" doc:"Limit item from offset."`
-	SortBy string `query:"sort_by" default:"id:desc" example:"created_at:desc,id" doc:"Sort by one or more fields separated by commas. Format: \"field:asc/desc\" (default is asc if not declare after commas)"`
+	Cursor       string `query:"cursor" doc:"Cursor from next_cursor or prev_cursor of previous page, omit to get first page."`
+	Limit        int    `query:"limit" default:"5" minimum:"1" maximum:"100" example:"10" doc:"Max number of items in a page."`
+	SortBy       string `query:"sort_by" default:"id:desc" example:"created_at:desc,id" doc:"Sort by one or more fields separated by commas. Format: \"field:asc/desc\" (default is asc if not declare after commas)"`
+	IncludeTotal bool   `query:"include_total" doc:"Count total of matched items, costs one more query."`
 }
 
 type GetCategoryByIdRequest struct {
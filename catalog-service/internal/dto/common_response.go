@@ -5,10 +5,24 @@ package dto
 
 type PaginationBodyResponseList[T any] struct {
 	Body struct {
-		Code    string `json:"code" example:"string"`
-		Message string `json:"message" example:"string"`
-		Data    []T    `json:"data"`
-		Total   int    `json:"total" example:"1"`
+		Code       string `json:"code" example:"string"`
+		Message    string `json:"message" example:"string"`
+		Data       []T    `json:"data"`
+		Total      *int64 `json:"total,omitempty" example:"1" doc:"Total of matched items, only counted when asked."`
+		NextCursor string `json:"next_cursor,omitempty" example:"string" doc:"Cursor of next page, omitted on last page."`
+		PrevCursor string `json:"prev_cursor,omitempty" example:"string" doc:"Cursor of previous page, omitted on first page."`
+	}
+}
+
+type FacetPaginationBodyResponseList[T any, F any] struct {
+	Body struct {
+		Code       string `json:"code" example:"string"`
+		Message    string `json:"message" example:"string"`
+		Data       []T    `json:"data"`
+		Total      *int64 `json:"total,omitempty" example:"1" doc:"Total of matched items."`
+		NextCursor string `json:"next_cursor,omitempty" example:"string" doc:"Cursor of next page, omitted on last page."`
+		PrevCursor string `json:"prev_cursor,omitempty" example:"string" doc:"Cursor of previous page, omitted on first page."`
+		Facets     F      `json:"facets"`
 	}
 }
 
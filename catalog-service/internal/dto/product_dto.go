@@ -42,3 +42,118 @@ func ToListProductView(products []model.Product) []ProductView {
 	}
 	return productViews
 }
+
+// Integrate with Elasticsearch
+
+type ProductSearchView struct {
+	ProductView
+	Highlight *ProductHighlightView `json:"highlight,omitempty"`
+}
+
+type ProductHighlightView struct {
+	Name        []string `json:"name,omitempty"`
+	Description []string `json:"description,omitempty"`
+}
+
+func ToListProductSearchView(result *model.ProductElasticsearchResult) []ProductSearchView {
+	productSearchViews := make([]ProductSearchView, len(result.Products))
+	for i, product := range result.Products {
+		productSearchViews[i] = ProductSearchView{ProductView: *ToProductView(&product)}
+		if highlight, ok := result.Highlights[product.Id]; ok {
+			productSearchViews[i].Highlight = &ProductHighlightView{
+				Name:        highlight.Name,
+				Description: highlight.Description,
+			}
+		}
+	}
+	return productSearchViews
+}
+
+type ProductFacetsView struct {
+	Categories []CategoryFacetView `json:"categories"`
+	Sexes      []TermFacetView     `json:"sexes"`
+	Prices     []PriceFacetView    `json:"prices"`
+	OnSale     int64               `json:"on_sale"`
+}
+
+type CategoryFacetView struct {
+	CategoryId   int64  `json:"category_id"`
+	CategoryName string `json:"category_name"`
+	Count        int64  `json:"count"`
+}
+
+type TermFacetView struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+type PriceFacetView struct {
+	From  int64 `json:"from"`
+	To    int64 `json:"to"`
+	Count int64 `json:"count"`
+}
+
+func ToProductFacetsView(result *model.ProductElasticsearchResult) *ProductFacetsView {
+	productFacetsView := &ProductFacetsView{
+		Categories: make([]CategoryFacetView, len(result.CategoryFacets)),
+		Sexes:      make([]TermFacetView, len(result.SexFacets)),
+		Prices:     make([]PriceFacetView, len(result.PriceFacets)),
+		OnSale:     result.OnSaleCount,
+	}
+	for i, bucket := range result.CategoryFacets {
+		productFacetsView.Categories[i] = CategoryFacetView{
+			CategoryId:   bucket.CategoryId,
+			CategoryName: bucket.CategoryName,
+			Count:        bucket.Count,
+		}
+	}
+	for i, bucket := range result.SexFacets {
+		productFacetsView.Sexes[i] = TermFacetView{
+			Value: bucket.Value,
+			Count: bucket.Count,
+		}
+	}
+	for i, bucket := range result.PriceFacets {
+		productFacetsView.Prices[i] = PriceFacetView{
+			From:  bucket.From,
+			To:    bucket.To,
+			Count: bucket.Count,
+		}
+	}
+	return productFacetsView
+}
+
+type ProductSuggestionView struct {
+	Products   []ProductSuggestionItemView  `json:"products"`
+	Categories []CategorySuggestionItemView `json:"categories"`
+}
+
+type ProductSuggestionItemView struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type CategorySuggestionItemView struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+func ToProductSuggestionView(suggestion *model.ProductSuggestion) *ProductSuggestionView {
+	productSuggestionView := &ProductSuggestionView{
+		Products:   make([]ProductSuggestionItemView, len(suggestion.Products)),
+		Categories: make([]CategorySuggestionItemView, len(suggestion.Categories)),
+	}
+	for i, product := range suggestion.Products {
+		productSuggestionView.Products[i] = ProductSuggestionItemView{
+			Id:   product.Id,
+			Name: product.Name,
+		}
+	}
+	for i, category := range suggestion.Categories {
+		productSuggestionView.Categories[i] = CategorySuggestionItemView{
+			Id:   category.Id,
+			Name: category.Name,
+		}
+	}
+	return productSuggestionView
+}
@@ -1,20 +1,26 @@
 package dto
 
 type GetProductsRequest struct {
-	Offset int    `query:"offset" default:"0" minimum:"0" example:"0" doc:"Skip item by offset."`
-	Limit  int    `query:"limit" default:"5" minimum:"1" maximum:"10" example:"10" doc:"Limit item from offset."`
-	SortBy string `query:"sort_by" default:"id:desc" example:"created_at:desc,id" doc:"Sort by one or more fields separated by commas. Format: \"field:asc/desc\" (default is asc if not declare after commas)"`
+	Cursor       string `query:"cursor" doc:"Cursor from next_cursor or prev_cursor of previous page, omit to get first page."`
+	Limit        int    `query:"limit" default:"5" minimum:"1" maximum:"100" example:"10" doc:"Max number of items in a page."`
+	SortBy       string `query:"sort_by" default:"id:desc" example:"created_at:desc,id" doc:"Sort by one or more fields separated by commas. Format: \"field:asc/desc\" (default is asc if not declare after commas)"`
+	IncludeTotal bool   `query:"include_total" doc:"Count total of matched items, costs one more query."`
 }
 
 type GetProductByIdRequest struct {
 	Id int64 `path:"id" required:"true" doc:"Id of product."`
 }
 
+type GetProductsByIdsRequest struct {
+	Ids []int64 `query:"ids" required:"true" minItems:"1" maxItems:"100" example:"[1,2,3]" doc:"Ids of products separated by commas."`
+}
+
 type GetProductsByCategoryIdRequest struct {
-	CategoryId int64  `path:"category_id" required:"true" doc:"Id of category."`
-	Offset     int    `query:"offset" default:"0" minimum:"0" example:"0" doc:"Skip item by offset."`
-	Limit      int    `query:"limit" default:"5" minimum:"1" maximum:"10" example:"10" doc:"Limit item from offset."`
-	SortBy     string `query:"sort_by" default:"id:desc" example:"created_at:desc,id" doc:"Sort by one or more fields separated by commas. Format: \"field:asc/desc\" (default is asc if not declare after commas)"`
+	CategoryId   int64  `path:"category_id" required:"true" doc:"Id of category."`
+	Cursor       string `query:"cursor" doc:"Cursor from next_cursor or prev_cursor of previous page, omit to get first page."`
+	Limit        int    `query:"limit" default:"5" minimum:"1" maximum:"100" example:"10" doc:"Max number of items in a page."`
+	SortBy       string `query:"sort_by" default:"id:desc" example:"created_at:desc,id" doc:"Sort by one or more fields separated by commas. Format: \"field:asc/desc\" (default is asc if not declare after commas)"`
+	IncludeTotal bool   `query:"include_total" doc:"Count total of matched items, costs one more query."`
 }
 
 type CreateProductRequest struct {
@@ -51,12 +57,24 @@ type DeleteProductByIdRequest struct {
 // Integrate with Elasticsearch
 
 type GetProductsWithElasticsearchRequest struct {
-	Offset       int    `query:"offset" default:"0" minimum:"0" example:"0" doc:"Skip item by offset."`
-	Limit        int    `query:"limit" default:"5" minimum:"1" maximum:"10" example:"10" doc:"Limit item from offset."`
+	Cursor       string `query:"cursor" doc:"Cursor from next_cursor or prev_cursor of previous page, omit to get first page."`
+	Limit        int    `query:"limit" default:"5" minimum:"1" maximum:"100" example:"10" doc:"Max number of items in a page."`
 	SortBy       string `query:"sort_by" default:"id:desc" example:"created_at:desc,id" doc:"Sort by one or more fields separated by commas. Format: \"field:asc/desc\" (default is asc if not declare after commas)"`
+	Q            string `query:"q" example:"ao so mi" doc:"Search by name and description, accent-insensitive and typo-tolerant. Results are ranked by relevance before sort_by."`
 	Name         string `query:"name" example:"áo" doc:"Filter by name."`
 	PriceGTE     string `query:"price_gte" pattern:"^[0-9]+$" example:"250000" doc:"Filter by price greater than or equal."`
 	PriceLTE     string `query:"price_lte" pattern:"^[0-9]+$" example:"300000" doc:"Filter by price less than or equal."`
 	CreatedAtGTE string `query:"created_at_gte" example:"2024-01-15T00:00:00" doc:"Filter by created_at greater than or equal, with format is YYYY-MM-ddTHH:mm:ss."`
 	CreatedAtLTE string `query:"created_at_lte" example:"2024-02-05T23:59:59" doc:"Filter by created_at less than or equal, with format is YYYY-MM-ddTHH:mm:ss."`
+
+	CategoryIds   []int64  `query:"category_ids" example:"[1,2]" doc:"Filter by one or more category ids separated by commas."`
+	Sexes         []string `query:"sexes" example:"[\"MALE\",\"UNISEX\"]" doc:"Filter by one or more sexes separated by commas."`
+	PriceBuckets  []int64  `query:"price_buckets" example:"[0,500000]" doc:"Filter by one or more price buckets separated by commas, each value is the lower bound of a bucket from price facets."`
+	PriceInterval int64    `query:"price_interval" default:"500000" minimum:"1" example:"500000" doc:"Width of each price bucket in price facets."`
+	OnSale        bool     `query:"on_sale" doc:"Filter by products having discount."`
+}
+
+type SuggestProductsRequest struct {
+	Q     string `query:"q" required:"true" minLength:"1" maxLength:"100" example:"ao so" doc:"Text being typed."`
+	Limit int    `query:"limit" default:"5" minimum:"1" maximum:"10" example:"5" doc:"Max number of products and categories suggested."`
 }
@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"thanhldt060802/internal/model"
+	"time"
+)
+
+type StockReservationView struct {
+	Id        int64                      `json:"id"`
+	Status    string                     `json:"status"`
+	Items     []StockReservationItemView `json:"items"`
+	ExpiresAt time.Time                  `json:"expires_at"`
+	CreatedAt time.Time                  `json:"created_at"`
+	UpdatedAt time.Time                  `json:"updated_at"`
+}
+
+type StockReservationItemView struct {
+	ProductId int64 `json:"product_id"`
+	Quantity  int32 `json:"quantity"`
+}
+
+func ToStockReservationView(stockReservation *model.StockReservation) *StockReservationView {
+	items := make([]StockReservationItemView, len(stockReservation.Items))
+	for i, item := range stockReservation.Items {
+		items[i] = StockReservationItemView{
+			ProductId: item.ProductId,
+			Quantity:  item.Quantity,
+		}
+	}
+
+	return &StockReservationView{
+		Id:        stockReservation.Id,
+		Status:    stockReservation.Status,
+		Items:     items,
+		ExpiresAt: stockReservation.ExpiresAt,
+		CreatedAt: stockReservation.CreatedAt,
+		UpdatedAt: stockReservation.UpdatedAt,
+	}
+}
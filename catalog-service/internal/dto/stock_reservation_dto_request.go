@@ -0,0 +1,24 @@
+package dto
+
+type StockReservationItemRequest struct {
+	ProductId int64 `json:"product_id" required:"true" minimum:"1" doc:"Id of product will be reserved."`
+	Quantity  int32 `json:"quantity" required:"true" minimum:"1" doc:"Quantity of product will be reserved."`
+}
+
+type CreateStockReservationRequest struct {
+	Body struct {
+		Items []StockReservationItemRequest `json:"items" required:"true" minItems:"1" doc:"Products and quantities will be reserved."`
+	}
+}
+
+type GetStockReservationByIdRequest struct {
+	Id int64 `path:"id" required:"true" doc:"Id of stock reservation."`
+}
+
+type ConfirmStockReservationByIdRequest struct {
+	Id int64 `path:"id" required:"true" doc:"Id of stock reservation will be confirmed."`
+}
+
+type ReleaseStockReservationByIdRequest struct {
+	Id int64 `path:"id" required:"true" doc:"Id of stock reservation will be released."`
+}
@@ -2,10 +2,13 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"thanhldt060802/internal/dto"
 	"thanhldt060802/internal/middleware"
+	"thanhldt060802/internal/model"
 	"thanhldt060802/internal/service"
+	"thanhldt060802/utils"
 
 	"github.com/danielgtaylor/huma/v2"
 )
@@ -55,7 +58,7 @@ func NewCategoryHandler(api huma.API, categorieservice service.CategoryService,
 		Summary:     "/categories",
 		Description: "Create category.",
 		Tags:        []string{"Category"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionCategoryWrite)},
 	}, categoryHandler.CreateCategory)
 
 	// Update category by id
@@ -65,7 +68,7 @@ func NewCategoryHandler(api huma.API, categorieservice service.CategoryService,
 		Summary:     "/categories/id/{id}",
 		Description: "Update category by id.",
 		Tags:        []string{"Category"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionCategoryWrite)},
 	}, categoryHandler.UpdateCategoryById)
 
 	// Delete category by id
@@ -75,15 +78,22 @@ func NewCategoryHandler(api huma.API, categorieservice service.CategoryService,
 		Summary:     "/categories/id/{id}",
 		Description: "Delete category by id.",
 		Tags:        []string{"Category"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionCategoryWrite)},
 	}, categoryHandler.DeleteCategoryById)
 
 	return categoryHandler
 }
 
 func (categoryHandler *CategoryHandler) GetCategories(ctx context.Context, reqDTO *dto.GetCategoriesRequest) (*dto.PaginationBodyResponseList[dto.CategoryView], error) {
-	categories, err := categoryHandler.categorieservice.GetCategories(ctx, reqDTO)
-	if err != nil {
+	categories, page, err := categoryHandler.categorieservice.GetCategories(ctx, reqDTO)
+	if errors.Is(err, utils.ErrInvalidPagination) {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Get categories failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	} else if err != nil {
 		res := &dto.ErrorResponse{}
 		res.Status = http.StatusInternalServerError
 		res.Code = "ERR_INTERNAL_SERVER"
@@ -97,7 +107,9 @@ func (categoryHandler *CategoryHandler) GetCategories(ctx context.Context, reqDT
 	res.Body.Code = "OK"
 	res.Body.Message = "Get categories successful"
 	res.Body.Data = data
-	res.Body.Total = len(data)
+	res.Body.Total = page.Total
+	res.Body.NextCursor = page.NextCursor
+	res.Body.PrevCursor = page.PrevCursor
 	return res, nil
 }
 
@@ -2,10 +2,14 @@ package handler
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"net/http"
 	"thanhldt060802/internal/dto"
 	"thanhldt060802/internal/middleware"
+	"thanhldt060802/internal/model"
 	"thanhldt060802/internal/service"
+	"thanhldt060802/utils"
 
 	"github.com/danielgtaylor/huma/v2"
 )
@@ -39,6 +43,15 @@ func NewProductHandler(api huma.API, productService service.ProductService, auth
 		Tags:        []string{"Product"},
 	}, productHandler.GetProductById)
 
+	// Get products by ids
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodGet,
+		Path:        "/products/ids",
+		Summary:     "/products/ids",
+		Description: "Get products by ids.",
+		Tags:        []string{"Product"},
+	}, productHandler.GetProductsByIds)
+
 	// Get products by category id
 	huma.Register(api, huma.Operation{
 		Method:      http.MethodGet,
@@ -55,7 +68,7 @@ func NewProductHandler(api huma.API, productService service.ProductService, auth
 		Summary:     "/products",
 		Description: "Create product.",
 		Tags:        []string{"Product"},
-		// Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionProductWrite)},
 	}, productHandler.CreateProduct)
 
 	// Update product by id
@@ -65,7 +78,7 @@ func NewProductHandler(api huma.API, productService service.ProductService, auth
 		Summary:     "/products/id/{id}",
 		Description: "Update product by id.",
 		Tags:        []string{"Product"},
-		// Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionProductWrite)},
 	}, productHandler.UpdateProductById)
 
 	// Delete product by id
@@ -75,7 +88,7 @@ func NewProductHandler(api huma.API, productService service.ProductService, auth
 		Summary:     "/products/id/{id}",
 		Description: "Delete product by id.",
 		Tags:        []string{"Product"},
-		// Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionProductWrite)},
 	}, productHandler.DeleteProductById)
 
 	// Sync all products to Elasticsearch
@@ -83,9 +96,9 @@ func NewProductHandler(api huma.API, productService service.ProductService, auth
 		Method:      http.MethodGet,
 		Path:        "/products/sync-to-elasticsearch",
 		Summary:     "/products/sync-to-elasticsearch",
-		Description: "Sync all products to Elasticsearch.",
+		Description: "Rebuild products index on Elasticsearch and switch products alias to it.",
 		Tags:        []string{"Product"},
-		// Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionProductWrite)},
 	}, productHandler.SyncAllProductsToElasticsearch)
 
 	// Get products with Elasticsearch
@@ -93,16 +106,32 @@ func NewProductHandler(api huma.API, productService service.ProductService, auth
 		Method:      http.MethodGet,
 		Path:        "/products/elasticsearch",
 		Summary:     "/products/elasticsearch",
-		Description: "Get products with Elasticsearch.",
+		Description: "Get products with Elasticsearch, along with category, sex, price and on sale facets.",
 		Tags:        []string{"Product"},
 	}, productHandler.GetProducsWithElasticsearch)
 
+	// Suggest products
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodGet,
+		Path:        "/products/suggest",
+		Summary:     "/products/suggest",
+		Description: "Suggest in stock product names and categories while typing.",
+		Tags:        []string{"Product"},
+	}, productHandler.SuggestProducts)
+
 	return productHandler
 }
 
 func (productHandler *ProductHandler) GetProducts(ctx context.Context, reqDTO *dto.GetProductsRequest) (*dto.PaginationBodyResponseList[dto.ProductView], error) {
-	products, err := productHandler.productService.GetProducts(ctx, reqDTO)
-	if err != nil {
+	products, page, err := productHandler.productService.GetProducts(ctx, reqDTO)
+	if errors.Is(err, utils.ErrInvalidPagination) {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Get products failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	} else if err != nil {
 		res := &dto.ErrorResponse{}
 		res.Status = http.StatusInternalServerError
 		res.Code = "ERR_INTERNAL_SERVER"
@@ -116,16 +145,25 @@ func (productHandler *ProductHandler) GetProducts(ctx context.Context, reqDTO *d
 	res.Body.Code = "OK"
 	res.Body.Message = "Get products successful"
 	res.Body.Data = data
-	res.Body.Total = len(data)
+	res.Body.Total = page.Total
+	res.Body.NextCursor = page.NextCursor
+	res.Body.PrevCursor = page.PrevCursor
 	return res, nil
 }
 
 func (productHandler *ProductHandler) GetProductById(ctx context.Context, reqDTO *dto.GetProductByIdRequest) (*dto.BodyResponse[dto.ProductView], error) {
 	foundProduct, err := productHandler.productService.GetProductById(ctx, reqDTO)
-	if err != nil {
+	if errors.Is(err, sql.ErrNoRows) {
 		res := &dto.ErrorResponse{}
-		res.Status = http.StatusBadRequest
-		res.Code = "ERR_BAD_REQUEST"
+		res.Status = http.StatusNotFound
+		res.Code = "ERR_NOT_FOUND"
+		res.Message = "Get product by id failed"
+		res.Details = []string{"id of product not found"}
+		return nil, res
+	} else if err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusInternalServerError
+		res.Code = "ERR_INTERNAL_SERVER"
 		res.Message = "Get product by id failed"
 		res.Details = []string{err.Error()}
 		return nil, res
@@ -139,9 +177,37 @@ func (productHandler *ProductHandler) GetProductById(ctx context.Context, reqDTO
 	return res, nil
 }
 
-func (productHandler *ProductHandler) GetProductsByCategoryId(ctx context.Context, reqDTO *dto.GetProductsByCategoryIdRequest) (*dto.PaginationBodyResponseList[dto.ProductView], error) {
-	products, err := productHandler.productService.GetProductsByCategoryId(ctx, reqDTO)
+func (productHandler *ProductHandler) GetProductsByIds(ctx context.Context, reqDTO *dto.GetProductsByIdsRequest) (*dto.PaginationBodyResponseList[dto.ProductView], error) {
+	products, err := productHandler.productService.GetProductsByIds(ctx, reqDTO)
 	if err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusInternalServerError
+		res.Code = "ERR_INTERNAL_SERVER"
+		res.Message = "Get products by ids failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	data := dto.ToListProductView(products)
+	res := &dto.PaginationBodyResponseList[dto.ProductView]{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Get products by ids successful"
+	res.Body.Data = data
+	total := int64(len(data))
+	res.Body.Total = &total
+	return res, nil
+}
+
+func (productHandler *ProductHandler) GetProductsByCategoryId(ctx context.Context, reqDTO *dto.GetProductsByCategoryIdRequest) (*dto.PaginationBodyResponseList[dto.ProductView], error) {
+	products, page, err := productHandler.productService.GetProductsByCategoryId(ctx, reqDTO)
+	if errors.Is(err, utils.ErrInvalidPagination) {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Get products by category id failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	} else if err != nil {
 		res := &dto.ErrorResponse{}
 		res.Status = http.StatusInternalServerError
 		res.Code = "ERR_INTERNAL_SERVER"
@@ -155,7 +221,9 @@ func (productHandler *ProductHandler) GetProductsByCategoryId(ctx context.Contex
 	res.Body.Code = "OK"
 	res.Body.Message = "Get products category id successful"
 	res.Body.Data = data
-	res.Body.Total = len(data)
+	res.Body.Total = page.Total
+	res.Body.NextCursor = page.NextCursor
+	res.Body.PrevCursor = page.PrevCursor
 	return res, nil
 }
 
@@ -223,9 +291,16 @@ func (productHandler *ProductHandler) SyncAllProductsToElasticsearch(ctx context
 	return res, nil
 }
 
-func (productHandler *ProductHandler) GetProducsWithElasticsearch(ctx context.Context, reqDTO *dto.GetProductsWithElasticsearchRequest) (*dto.PaginationBodyResponseList[dto.ProductView], error) {
-	products, err := productHandler.productService.GetProductsWithElasticsearch(ctx, reqDTO)
-	if err != nil {
+func (productHandler *ProductHandler) GetProducsWithElasticsearch(ctx context.Context, reqDTO *dto.GetProductsWithElasticsearchRequest) (*dto.FacetPaginationBodyResponseList[dto.ProductSearchView, dto.ProductFacetsView], error) {
+	result, page, err := productHandler.productService.GetProductsWithElasticsearch(ctx, reqDTO)
+	if errors.Is(err, utils.ErrInvalidPagination) {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Get products with Elasticsearch failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	} else if err != nil {
 		res := &dto.ErrorResponse{}
 		res.Status = http.StatusInternalServerError
 		res.Code = "ERR_INTERNAL_SERVER"
@@ -234,11 +309,33 @@ func (productHandler *ProductHandler) GetProducsWithElasticsearch(ctx context.Co
 		return nil, res
 	}
 
-	data := dto.ToListProductView(products)
-	res := &dto.PaginationBodyResponseList[dto.ProductView]{}
+	data := dto.ToListProductSearchView(result)
+	res := &dto.FacetPaginationBodyResponseList[dto.ProductSearchView, dto.ProductFacetsView]{}
 	res.Body.Code = "OK"
 	res.Body.Message = "Get products with Elasticsearch successful"
 	res.Body.Data = data
-	res.Body.Total = len(data)
+	res.Body.Total = page.Total
+	res.Body.NextCursor = page.NextCursor
+	res.Body.PrevCursor = page.PrevCursor
+	res.Body.Facets = *dto.ToProductFacetsView(result)
+	return res, nil
+}
+
+func (productHandler *ProductHandler) SuggestProducts(ctx context.Context, reqDTO *dto.SuggestProductsRequest) (*dto.BodyResponse[dto.ProductSuggestionView], error) {
+	suggestion, err := productHandler.productService.SuggestProducts(ctx, reqDTO)
+	if err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusInternalServerError
+		res.Code = "ERR_INTERNAL_SERVER"
+		res.Message = "Suggest products failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	data := dto.ToProductSuggestionView(suggestion)
+	res := &dto.BodyResponse[dto.ProductSuggestionView]{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Suggest products successful"
+	res.Body.Data = *data
 	return res, nil
 }
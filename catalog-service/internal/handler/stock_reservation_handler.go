@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"thanhldt060802/internal/dto"
+	"thanhldt060802/internal/middleware"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/service"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type StockReservationHandler struct {
+	productService service.ProductService
+	authMiddleware *middleware.AuthMiddleware
+}
+
+func NewStockReservationHandler(api huma.API, productService service.ProductService, authMiddleware *middleware.AuthMiddleware) *StockReservationHandler {
+	stockReservationHandler := &StockReservationHandler{
+		productService: productService,
+		authMiddleware: authMiddleware,
+	}
+
+	// Create stock reservation
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodPost,
+		Path:        "/products/reservations",
+		Summary:     "/products/reservations",
+		Description: "Create stock reservation.",
+		Tags:        []string{"Stock Reservation"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionStockReserve)},
+	}, stockReservationHandler.CreateStockReservation)
+
+	// Get stock reservation by id
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodGet,
+		Path:        "/products/reservations/id/{id}",
+		Summary:     "/products/reservations/id/{id}",
+		Description: "Get stock reservation by id.",
+		Tags:        []string{"Stock Reservation"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionStockReserve)},
+	}, stockReservationHandler.GetStockReservationById)
+
+	// Confirm stock reservation by id
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodPost,
+		Path:        "/products/reservations/id/{id}/confirm",
+		Summary:     "/products/reservations/id/{id}/confirm",
+		Description: "Confirm stock reservation by id.",
+		Tags:        []string{"Stock Reservation"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionStockReserve)},
+	}, stockReservationHandler.ConfirmStockReservationById)
+
+	// Release stock reservation by id
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodPost,
+		Path:        "/products/reservations/id/{id}/release",
+		Summary:     "/products/reservations/id/{id}/release",
+		Description: "Release stock reservation by id.",
+		Tags:        []string{"Stock Reservation"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionStockReserve)},
+	}, stockReservationHandler.ReleaseStockReservationById)
+
+	return stockReservationHandler
+}
+
+func (stockReservationHandler *StockReservationHandler) CreateStockReservation(ctx context.Context, reqDTO *dto.CreateStockReservationRequest) (*dto.BodyResponse[dto.StockReservationView], error) {
+	newStockReservation, err := stockReservationHandler.productService.ReserveStock(ctx, reqDTO)
+	if err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Create stock reservation failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	data := dto.ToStockReservationView(newStockReservation)
+	res := &dto.BodyResponse[dto.StockReservationView]{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Create stock reservation successful"
+	res.Body.Data = *data
+	return res, nil
+}
+
+func (stockReservationHandler *StockReservationHandler) GetStockReservationById(ctx context.Context, reqDTO *dto.GetStockReservationByIdRequest) (*dto.BodyResponse[dto.StockReservationView], error) {
+	foundStockReservation, err := stockReservationHandler.productService.GetStockReservationById(ctx, reqDTO)
+	if err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Get stock reservation by id failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	data := dto.ToStockReservationView(foundStockReservation)
+	res := &dto.BodyResponse[dto.StockReservationView]{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Get stock reservation by id successful"
+	res.Body.Data = *data
+	return res, nil
+}
+
+func (stockReservationHandler *StockReservationHandler) ConfirmStockReservationById(ctx context.Context, reqDTO *dto.ConfirmStockReservationByIdRequest) (*dto.SuccessResponse, error) {
+	if err := stockReservationHandler.productService.ConfirmStockReservationById(ctx, reqDTO); err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Confirm stock reservation failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	res := &dto.SuccessResponse{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Confirm stock reservation successful"
+	return res, nil
+}
+
+func (stockReservationHandler *StockReservationHandler) ReleaseStockReservationById(ctx context.Context, reqDTO *dto.ReleaseStockReservationByIdRequest) (*dto.SuccessResponse, error) {
+	if err := stockReservationHandler.productService.ReleaseStockReservationById(ctx, reqDTO); err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Release stock reservation failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	res := &dto.SuccessResponse{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Release stock reservation successful"
+	return res, nil
+}
@@ -1,26 +1,30 @@
 package middleware
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
-	"thanhldt060802/infrastructure"
+	"thanhldt060802/internal/client"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/utils"
 
 	"github.com/danielgtaylor/huma/v2"
-	"github.com/redis/go-redis/v9"
 )
 
 type AuthMiddleware struct {
-	API huma.API
+	API        huma.API
+	jwksClient client.JWKSClient
 }
 
-func NewAuthMiddleware(api huma.API) *AuthMiddleware {
+func NewAuthMiddleware(api huma.API, jwksClient client.JWKSClient) *AuthMiddleware {
 	return &AuthMiddleware{
-		API: api,
+		API:        api,
+		jwksClient: jwksClient,
 	}
 }
 
+// Tokens are verified offline with keys of customer service, revoked tokens stay usable here until they expire
 func (authMiddleware *AuthMiddleware) Authentication(ctx huma.Context, next func(huma.Context)) {
 	authHeader := ctx.Header("Authorization")
 	if authHeader == "" {
@@ -29,40 +33,58 @@ func (authMiddleware *AuthMiddleware) Authentication(ctx huma.Context, next func
 	}
 
 	token := strings.TrimPrefix(authHeader, "Bearer ")
-	redisKey := fmt.Sprintf("token:%s", token)
 
-	userDataJson, err := infrastructure.RedisClient.Get(ctx.Context(), redisKey).Result()
-	if err == redis.Nil {
-		CustomerHumaWriteErr(ctx, http.StatusUnauthorized, "ERR_UNAUTHORIZED", "Token not found or expired", []string{"invalid token"})
-		return
-	} else if err != nil {
-		CustomerHumaWriteErr(ctx, http.StatusUnauthorized, "ERR_UNAUTHORIZED", "Failed to check token in Redis", []string{"some thing wrong in redis"})
+	claims, err := utils.ValidateToken(token, authMiddleware.jwksClient.KeyFunc)
+	if err != nil {
+		CustomerHumaWriteErr(ctx, http.StatusUnauthorized, "ERR_UNAUTHORIZED", "Token invalid or expired", []string{"invalid token"})
 		return
 	}
 
-	var userData struct {
-		UserId   int64  `json:"user_id"`
-		RoleName string `json:"role_name"`
-		CartId   int64  `json:"cart_id"`
-	}
+	ctx = huma.WithValue(ctx, "user_id", claims.UserId)
+	ctx = huma.WithValue(ctx, "role_name", claims.RoleName)
+	ctx = huma.WithValue(ctx, "cart_id", claims.CartId)
+	ctx = huma.WithValue(ctx, "permissions", claims.Permissions)
 
-	if err := json.Unmarshal([]byte(userDataJson), &userData); err != nil {
-		CustomerHumaWriteErr(ctx, http.StatusUnauthorized, "ERR_UNAUTHORIZED", "Invalid user data in token", []string{"invalid token"})
-		return
+	next(ctx)
+}
+
+// Identifies caller by a valid bearer token without rejecting the request, customer service is one caller however many
+// instances it runs
+func (authMiddleware *AuthMiddleware) tokenIdentity(ctx huma.Context) (string, bool) {
+	authHeader := ctx.Header("Authorization")
+	if authHeader == "" {
+		return "", false
 	}
 
-	ctx = huma.WithValue(ctx, "user_id", userData.UserId)
-	ctx = huma.WithValue(ctx, "role_name", userData.RoleName)
-	ctx = huma.WithValue(ctx, "cart_id", userData.CartId)
+	claims, err := utils.ValidateToken(strings.TrimPrefix(authHeader, "Bearer "), authMiddleware.jwksClient.KeyFunc)
+	if err != nil {
+		return "", false
+	}
 
-	next(ctx)
+	if claims.RoleName == model.RoleService {
+		return "service:customer", true
+	}
+	return fmt.Sprintf("user:%d", claims.UserId), true
 }
 
-func (authMiddleware *AuthMiddleware) RequireAdmin(ctx huma.Context, next func(huma.Context)) {
-	if roleName, _ := ctx.Context().Value("role_name").(string); roleName != "ADMIN" {
-		CustomerHumaWriteErr(ctx, http.StatusForbidden, "ERR_FORBIDDEN", "Access denied", []string{"no permission"})
-		return
+// Permissions come from token claims, so role changes apply from next login or token refresh
+func (authMiddleware *AuthMiddleware) RequirePermission(permission string) func(huma.Context, func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		if !HasPermission(ctx.Context(), permission) {
+			CustomerHumaWriteErr(ctx, http.StatusForbidden, "ERR_FORBIDDEN", "Access denied", []string{fmt.Sprintf("missing permission %s", permission)})
+			return
+		}
+
+		next(ctx)
 	}
+}
 
-	next(ctx)
+func HasPermission(ctx context.Context, permission string) bool {
+	permissions, _ := ctx.Value("permissions").([]string)
+	for _, grantedPermission := range permissions {
+		if grantedPermission == permission {
+			return true
+		}
+	}
+	return false
 }
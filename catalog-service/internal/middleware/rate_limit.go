@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"thanhldt060802/config"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// Sliding window log, every accepted request is a member of the sorted set scored by its time in milliseconds
+var rateLimitScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[4])
+	redis.call('PEXPIRE', KEYS[1], window)
+	count = count + 1
+	allowed = 1
+end
+local reset = window
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, limit - count, reset}
+`)
+
+type RateLimitMiddleware struct {
+	API                 huma.API
+	authMiddleware      *AuthMiddleware
+	redisClient         *redis.Client
+	defaultRateLimit    config.RateLimit
+	operationRateLimits map[string]config.RateLimit
+	sequence            atomic.Uint64
+}
+
+func NewRateLimitMiddleware(api huma.API, authMiddleware *AuthMiddleware, redisClient *redis.Client, appConfig *config.Config) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		API:                 api,
+		authMiddleware:      authMiddleware,
+		redisClient:         redisClient,
+		defaultRateLimit:    *appConfig.GetRateLimitDefault(),
+		operationRateLimits: appConfig.GetRateLimitOperations(),
+	}
+}
+
+// Registered for the whole API, it runs before operation middlewares so caller is identified from token here
+// instead of auth context, requests without a valid token are counted per client ip
+func (rateLimitMiddleware *RateLimitMiddleware) RateLimit(ctx huma.Context, next func(huma.Context)) {
+	operationKey := fmt.Sprintf("%s %s", ctx.Operation().Method, ctx.Operation().Path)
+	rateLimit, ok := rateLimitMiddleware.operationRateLimits[operationKey]
+	if !ok {
+		rateLimit = rateLimitMiddleware.defaultRateLimit
+	}
+	if rateLimit.Limit == 0 {
+		next(ctx)
+		return
+	}
+
+	identity := fmt.Sprintf("ip:%s", clientIp(ctx))
+	if tokenIdentity, ok := rateLimitMiddleware.authMiddleware.tokenIdentity(ctx); ok {
+		identity = tokenIdentity
+	}
+
+	now := time.Now()
+	member := fmt.Sprintf("%d-%d", now.UnixNano(), rateLimitMiddleware.sequence.Add(1))
+	result, err := rateLimitScript.Run(ctx.Context(), rateLimitMiddleware.redisClient, []string{fmt.Sprintf("rate-limit:%s:%s", operationKey, identity)},
+		now.UnixMilli(), rateLimit.Window.Milliseconds(), rateLimit.Limit, member,
+	).Int64Slice()
+	if err != nil {
+		// Redis outage must not take the API down with it, requests pass unlimited until it is back
+		log.Printf("Check rate limit failed: %s", err.Error())
+		next(ctx)
+		return
+	}
+
+	remaining := max(result[1], 0)
+	resetSeconds := (result[2] + 999) / 1000
+	ctx.SetHeader("RateLimit-Limit", strconv.Itoa(rateLimit.Limit))
+	ctx.SetHeader("RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+	ctx.SetHeader("RateLimit-Reset", strconv.FormatInt(resetSeconds, 10))
+	ctx.SetHeader("RateLimit-Policy", fmt.Sprintf("%d;w=%d", rateLimit.Limit, int64(rateLimit.Window/time.Second)))
+
+	if result[0] == 0 {
+		ctx.SetHeader("Retry-After", strconv.FormatInt(resetSeconds, 10))
+		CustomerHumaWriteErr(ctx, http.StatusTooManyRequests, "ERR_TOO_MANY_REQUESTS", "Rate limit exceeded", []string{fmt.Sprintf("too many requests, retry after %d seconds", resetSeconds)})
+		return
+	}
+
+	next(ctx)
+}
+
+// Ip is taken from the connection so forwarded headers set by clients can not spoof it
+func clientIp(ctx huma.Context) string {
+	clientIp := ctx.RemoteAddr()
+	if host, _, err := net.SplitHostPort(clientIp); err == nil {
+		return host
+	}
+
+	return clientIp
+}
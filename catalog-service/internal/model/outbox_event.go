@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	OutboxEventStatusPending = "PENDING"
+	OutboxEventStatusDone    = "DONE"
+	OutboxEventStatusDead    = "DEAD"
+
+	OutboxEventTypeUpsert = "UPSERT"
+	OutboxEventTypeDelete = "DELETE"
+
+	// Publishes deletion of a product to other services, kept apart from DELETE so an Elasticsearch outage does not hold it back
+	OutboxEventTypePublishDeleted = "PUBLISH_DELETED"
+
+	OutboxAggregateTypeProduct = "PRODUCT"
+)
+
+// Change of an aggregate waiting to be pushed to Elasticsearch and other services, written in the same transaction as the change
+type OutboxEvent struct {
+	bun.BaseModel `bun:"table:catalog_outbox_events"`
+
+	Id            int64     `bun:"id,pk,autoincrement"`
+	AggregateType string    `bun:"aggregate_type,notnull"`
+	AggregateId   int64     `bun:"aggregate_id,notnull"`
+	EventType     string    `bun:"event_type,notnull"`
+	Status        string    `bun:"status,notnull"`
+	Attempts      int32     `bun:"attempts,notnull"`
+	LastError     string    `bun:"last_error,nullzero"`
+	NextAttemptAt time.Time `bun:"next_attempt_at,notnull,default:current_timestamp"`
+	CreatedAt     time.Time `bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt     time.Time `bun:"updated_at,notnull,default:current_timestamp"`
+}
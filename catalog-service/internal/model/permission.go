@@ -0,0 +1,13 @@
+package model
+
+// Role of tokens customer service signs for its own calls, one caller shared by every instance of customer service
+const RoleService = "SERVICE"
+
+// Granted to roles in customer service and carried in access token claims
+const (
+	PermissionProductWrite  = "product:write"
+	PermissionCategoryWrite = "category:write"
+
+	// Granted only to service tokens customer service signs for checkout, never to a role
+	PermissionStockReserve = "stock:reserve"
+)
@@ -24,8 +24,21 @@ type Product struct {
 
 // Integrate with Elasticsearch
 
+// Folded subfields strip Vietnamese accents, so "ao so mi" also matches "Áo sơ mi"
+// name.suggest serves typeahead, folded as well
 var ProductSchemaElasticsearch = `
 {
+  "settings": {
+    "analysis": {
+      "analyzer": {
+        "folding": {
+          "type": "custom",
+          "tokenizer": "standard",
+          "filter": ["lowercase", "asciifolding"]
+        }
+      }
+    }
+  },
   "mappings": {
     "properties": {
       "id": { "type": "long" },
@@ -33,14 +46,17 @@ var ProductSchemaElasticsearch = `
         "type": "text",
         "analyzer": "standard",
         "fields": {
-          "keyword": { "type": "keyword" }
+          "keyword": { "type": "keyword" },
+          "folded": { "type": "text", "analyzer": "folding" },
+          "suggest": { "type": "search_as_you_type", "analyzer": "folding" }
         }
       },
       "description": {
         "type": "text",
         "analyzer": "standard",
         "fields": {
-          "keyword": { "type": "keyword" }
+          "keyword": { "type": "keyword" },
+          "folded": { "type": "text", "analyzer": "folding" }
         }
       },
       "sex": {
@@ -74,3 +90,65 @@ var MapSortFieldProductSchemaElasticsearch = map[string]string{
 	"created_at":          "created_at",
 	"updated_at":          "updated_at",
 }
+
+type ProductElasticsearchFilter struct {
+	Query        string
+	Name         string
+	PriceGTE     string
+	PriceLTE     string
+	CreatedAtGTE string
+	CreatedAtLTE string
+
+	// Multi-select facet filters
+	CategoryIds   []int64
+	Sexes         []string
+	PriceBuckets  []int64
+	PriceInterval int64
+	OnSale        bool
+}
+
+type ProductElasticsearchResult struct {
+	Products       []Product
+	Highlights     map[int64]ProductHighlight
+	CategoryFacets []CategoryFacetBucket
+	SexFacets      []TermFacetBucket
+	PriceFacets    []PriceFacetBucket
+	OnSaleCount    int64
+}
+
+type ProductHighlight struct {
+	Name        []string
+	Description []string
+}
+
+type CategoryFacetBucket struct {
+	CategoryId   int64
+	CategoryName string
+	Count        int64
+}
+
+type TermFacetBucket struct {
+	Value string
+	Count int64
+}
+
+type PriceFacetBucket struct {
+	From  int64
+	To    int64
+	Count int64
+}
+
+type ProductSuggestion struct {
+	Products   []ProductSuggestionItem  `json:"products"`
+	Categories []CategorySuggestionItem `json:"categories"`
+}
+
+type ProductSuggestionItem struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type CategorySuggestionItem struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
+}
@@ -0,0 +1,13 @@
+package model
+
+const (
+	// Redis stream other services read to react to product changes, instead of holding foreign keys into products
+	ProductEventStream = "catalog:product-events"
+
+	ProductEventTypeDeleted = "PRODUCT_DELETED"
+)
+
+type ProductEvent struct {
+	Type      string
+	ProductId int64
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	StockReservationStatusPending   = "PENDING"
+	StockReservationStatusConfirmed = "CONFIRMED"
+	StockReservationStatusReleased  = "RELEASED"
+	StockReservationStatusExpired   = "EXPIRED"
+)
+
+type StockReservation struct {
+	bun.BaseModel `bun:"table:stock_reservations"`
+
+	Id        int64                  `bun:"id,pk,autoincrement"`
+	Status    string                 `bun:"status,notnull"`
+	ExpiresAt time.Time              `bun:"expires_at,notnull"`
+	CreatedAt time.Time              `bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt time.Time              `bun:"updated_at,notnull,default:current_timestamp"`
+	Items     []StockReservationItem `bun:"rel:has-many,join:id=reservation_id"`
+}
+
+type StockReservationItem struct {
+	bun.BaseModel `bun:"table:stock_reservation_items"`
+
+	Id            int64 `bun:"id,pk,autoincrement"`
+	ReservationId int64 `bun:"reservation_id,notnull"`
+	ProductId     int64 `bun:"product_id,notnull"`
+	Quantity      int32 `bun:"quantity,notnull"`
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/utils"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Decorates CategoryRepository with read-through cache for list and reads by id,
+// methods that are not overridden here go straight to the wrapped repository
+type cachedCategoryRepository struct {
+	CategoryRepository
+	cache *repositoryCache
+}
+
+func NewCachedCategoryRepository(categoryRepository CategoryRepository, redisClient *redis.Client, expireDuration time.Duration) CategoryRepository {
+	return &cachedCategoryRepository{
+		CategoryRepository: categoryRepository,
+		cache:              newRepositoryCache(redisClient, "category", expireDuration),
+	}
+}
+
+func (cachedCategoryRepository *cachedCategoryRepository) Get(ctx context.Context, pageRequest *utils.PageRequest) ([]model.Category, *utils.Page, error) {
+	key, err := cachedCategoryRepository.cache.listKey(ctx, "all", pageRequest)
+	if err != nil {
+		return cachedCategoryRepository.CategoryRepository.Get(ctx, pageRequest)
+	}
+
+	return getOrLoadPage(ctx, cachedCategoryRepository.cache, key, func(ctx context.Context) ([]model.Category, *utils.Page, error) {
+		return cachedCategoryRepository.CategoryRepository.Get(ctx, pageRequest)
+	})
+}
+
+func (cachedCategoryRepository *cachedCategoryRepository) GetById(ctx context.Context, id int64) (*model.Category, error) {
+	return getOrLoad(ctx, cachedCategoryRepository.cache, cachedCategoryRepository.cache.idKey(id), func(ctx context.Context) (*model.Category, error) {
+		return cachedCategoryRepository.CategoryRepository.GetById(ctx, id)
+	})
+}
+
+func (cachedCategoryRepository *cachedCategoryRepository) Create(ctx context.Context, newCategory *model.Category) error {
+	if err := cachedCategoryRepository.CategoryRepository.Create(ctx, newCategory); err != nil {
+		return err
+	}
+
+	cachedCategoryRepository.cache.invalidate(ctx)
+	return nil
+}
+
+func (cachedCategoryRepository *cachedCategoryRepository) Update(ctx context.Context, updatedCategory *model.Category) error {
+	if err := cachedCategoryRepository.CategoryRepository.Update(ctx, updatedCategory); err != nil {
+		return err
+	}
+
+	cachedCategoryRepository.cache.invalidate(ctx, updatedCategory.Id)
+	return nil
+}
+
+func (cachedCategoryRepository *cachedCategoryRepository) DeleteById(ctx context.Context, id int64) error {
+	if err := cachedCategoryRepository.CategoryRepository.DeleteById(ctx, id); err != nil {
+		return err
+	}
+
+	cachedCategoryRepository.cache.invalidate(ctx, id)
+	return nil
+}
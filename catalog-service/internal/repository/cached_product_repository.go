@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/utils"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Decorates ProductRepository with read-through cache for reads by id and by category,
+// methods that are not overridden here go straight to the wrapped repository
+type cachedProductRepository struct {
+	ProductRepository
+	cache *repositoryCache
+}
+
+func NewCachedProductRepository(productRepository ProductRepository, redisClient *redis.Client, expireDuration time.Duration) ProductRepository {
+	return &cachedProductRepository{
+		ProductRepository: productRepository,
+		cache:             newRepositoryCache(redisClient, "product", expireDuration),
+	}
+}
+
+func (cachedProductRepository *cachedProductRepository) GetById(ctx context.Context, id int64) (*model.Product, error) {
+	return getOrLoad(ctx, cachedProductRepository.cache, cachedProductRepository.cache.idKey(id), func(ctx context.Context) (*model.Product, error) {
+		return cachedProductRepository.ProductRepository.GetById(ctx, id)
+	})
+}
+
+func (cachedProductRepository *cachedProductRepository) GetByCategoryId(ctx context.Context, categoryId int64, pageRequest *utils.PageRequest) ([]model.Product, *utils.Page, error) {
+	key, err := cachedProductRepository.cache.listKey(ctx, fmt.Sprintf("category-%d", categoryId), pageRequest)
+	if err != nil {
+		return cachedProductRepository.ProductRepository.GetByCategoryId(ctx, categoryId, pageRequest)
+	}
+
+	return getOrLoadPage(ctx, cachedProductRepository.cache, key, func(ctx context.Context) ([]model.Product, *utils.Page, error) {
+		return cachedProductRepository.ProductRepository.GetByCategoryId(ctx, categoryId, pageRequest)
+	})
+}
+
+func (cachedProductRepository *cachedProductRepository) Create(ctx context.Context, newProduct *model.Product) error {
+	if err := cachedProductRepository.ProductRepository.Create(ctx, newProduct); err != nil {
+		return err
+	}
+
+	cachedProductRepository.cache.invalidate(ctx)
+	return nil
+}
+
+func (cachedProductRepository *cachedProductRepository) Update(ctx context.Context, updatedProduct *model.Product) error {
+	if err := cachedProductRepository.ProductRepository.Update(ctx, updatedProduct); err != nil {
+		return err
+	}
+
+	cachedProductRepository.cache.invalidate(ctx, updatedProduct.Id)
+	return nil
+}
+
+func (cachedProductRepository *cachedProductRepository) DeleteById(ctx context.Context, id int64) error {
+	if err := cachedProductRepository.ProductRepository.DeleteById(ctx, id); err != nil {
+		return err
+	}
+
+	cachedProductRepository.cache.invalidate(ctx, id)
+	return nil
+}
+
+func (cachedProductRepository *cachedProductRepository) AddStock(ctx context.Context, id int64, quantity int32) error {
+	if err := cachedProductRepository.ProductRepository.AddStock(ctx, id, quantity); err != nil {
+		return err
+	}
+
+	cachedProductRepository.cache.invalidate(ctx, id)
+	return nil
+}
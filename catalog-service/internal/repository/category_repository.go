@@ -2,38 +2,51 @@ package repository
 
 import (
 	"context"
-	"fmt"
-	"thanhldt060802/infrastructure"
+	"strings"
 	"thanhldt060802/internal/model"
 	"thanhldt060802/utils"
+
+	"github.com/uptrace/bun"
 )
 
 type categoryRepository struct {
+	db *bun.DB
 }
 
 type CategoryRepository interface {
-	Get(ctx context.Context, offset int, limit int, sortFields []utils.SortField) ([]model.Category, error)
+	Get(ctx context.Context, pageRequest *utils.PageRequest) ([]model.Category, *utils.Page, error)
 	GetById(ctx context.Context, id int64) (*model.Category, error)
+	GetByIds(ctx context.Context, ids []int64) ([]model.Category, error)
 	GetByName(ctx context.Context, name string) (*model.Category, error)
+	GetByNamePrefix(ctx context.Context, prefix string, limit int) ([]model.Category, error)
 	Create(ctx context.Context, newCategory *model.Category) error
 	Update(ctx context.Context, updatedCategory *model.Category) error
 	DeleteById(ctx context.Context, id int64) error
 }
 
-func NewCategoryRepository() CategoryRepository {
-	return &categoryRepository{}
+func NewCategoryRepository(db *bun.DB) CategoryRepository {
+	return &categoryRepository{db: db}
 }
 
-func (categoryRepository *categoryRepository) Get(ctx context.Context, offset int, limit int, sortFields []utils.SortField) ([]model.Category, error) {
-	var categories []model.Category
+func (categoryRepository *categoryRepository) Get(ctx context.Context, pageRequest *utils.PageRequest) ([]model.Category, *utils.Page, error) {
+	return paginate[model.Category](ctx, categoryRepository.db, pageRequest, nil)
+}
+
+func (categoryRepository *categoryRepository) GetById(ctx context.Context, id int64) (*model.Category, error) {
+	var category model.Category
 
-	query := infrastructure.DB.NewSelect().Model(&categories).
-		Offset(offset).
-		Limit(limit)
-	for _, sortField := range sortFields {
-		query = query.Order(fmt.Sprintf("%s %s", sortField.Field, sortField.Direction))
+	err := getDB(ctx, categoryRepository.db).NewSelect().Model(&category).Where("id = ?", id).Scan(ctx)
+	if err != nil {
+		return nil, err
 	}
-	err := query.Scan(ctx)
+
+	return &category, nil
+}
+
+func (categoryRepository *categoryRepository) GetByIds(ctx context.Context, ids []int64) ([]model.Category, error) {
+	var categories []model.Category
+
+	err := getDB(ctx, categoryRepository.db).NewSelect().Model(&categories).Where("id IN (?)", bun.In(ids)).Order("id ASC").Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -41,10 +54,10 @@ func (categoryRepository *categoryRepository) Get(ctx context.Context, offset in
 	return categories, nil
 }
 
-func (categoryRepository *categoryRepository) GetById(ctx context.Context, id int64) (*model.Category, error) {
+func (categoryRepository *categoryRepository) GetByName(ctx context.Context, name string) (*model.Category, error) {
 	var category model.Category
 
-	err := infrastructure.DB.NewSelect().Model(&category).Where("id = ?", id).Scan(ctx)
+	err := getDB(ctx, categoryRepository.db).NewSelect().Model(&category).Where("LOWER(name) = LOWER(?)", name).Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -52,31 +65,42 @@ func (categoryRepository *categoryRepository) GetById(ctx context.Context, id in
 	return &category, nil
 }
 
-func (categoryRepository *categoryRepository) GetByName(ctx context.Context, name string) (*model.Category, error) {
-	var category model.Category
+// Wildcards typed by the user are matched literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Categories having a word of name starting with prefix, ignoring case
+func (categoryRepository *categoryRepository) GetByNamePrefix(ctx context.Context, prefix string, limit int) ([]model.Category, error) {
+	var categories []model.Category
 
-	err := infrastructure.DB.NewSelect().Model(&category).Where("LOWER(name) = LOWER(?)", name).Scan(ctx)
+	escapedPrefix := likeEscaper.Replace(prefix)
+	err := getDB(ctx, categoryRepository.db).NewSelect().Model(&categories).
+		WhereGroup(" AND ", func(query *bun.SelectQuery) *bun.SelectQuery {
+			return query.Where("name ILIKE ? || '%'", escapedPrefix).WhereOr("name ILIKE '% ' || ? || '%'", escapedPrefix)
+		}).
+		Order("id ASC").
+		Limit(limit).
+		Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return &category, nil
+	return categories, nil
 }
 
 func (categoryRepository *categoryRepository) Create(ctx context.Context, newCategory *model.Category) error {
-	_, err := infrastructure.DB.NewInsert().Model(newCategory).Exec(ctx)
+	_, err := getDB(ctx, categoryRepository.db).NewInsert().Model(newCategory).Exec(ctx)
 
 	return err
 }
 
 func (categoryRepository *categoryRepository) Update(ctx context.Context, updatedCategory *model.Category) error {
-	_, err := infrastructure.DB.NewUpdate().Model(updatedCategory).Where("id = ?", updatedCategory.Id).Exec(ctx)
+	_, err := getDB(ctx, categoryRepository.db).NewUpdate().Model(updatedCategory).Where("id = ?", updatedCategory.Id).Exec(ctx)
 
 	return err
 }
 
 func (categoryRepository *categoryRepository) DeleteById(ctx context.Context, id int64) error {
-	_, err := infrastructure.DB.NewDelete().Model(&model.Category{}).Where("id = ?", id).Exec(ctx)
+	_, err := getDB(ctx, categoryRepository.db).NewDelete().Model(&model.Category{}).Where("id = ?", id).Exec(ctx)
 
 	return err
 }
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"thanhldt060802/utils"
+)
+
+type categoryRepository struct {
+	categories *table[model.Category]
+}
+
+func NewCategoryRepository() repository.CategoryRepository {
+	return &categoryRepository{
+		categories: newTable(func(category *model.Category) *int64 { return &category.Id }),
+	}
+}
+
+func (categoryRepository *categoryRepository) Get(ctx context.Context, pageRequest *utils.PageRequest) ([]model.Category, *utils.Page, error) {
+	return paginate(categoryRepository.categories.filter(nil), pageRequest)
+}
+
+func (categoryRepository *categoryRepository) GetById(ctx context.Context, id int64) (*model.Category, error) {
+	return categoryRepository.categories.getById(id)
+}
+
+func (categoryRepository *categoryRepository) GetByIds(ctx context.Context, ids []int64) ([]model.Category, error) {
+	return categoryRepository.categories.filter(func(category *model.Category) bool {
+		return slices.Contains(ids, category.Id)
+	}), nil
+}
+
+// Compared ignoring case like LOWER(name) = LOWER(?)
+func (categoryRepository *categoryRepository) GetByName(ctx context.Context, name string) (*model.Category, error) {
+	return categoryRepository.categories.find(func(category *model.Category) bool {
+		return strings.EqualFold(category.Name, name)
+	})
+}
+
+// Compared ignoring case like name ILIKE ? || '%' on the start of every word
+func (categoryRepository *categoryRepository) GetByNamePrefix(ctx context.Context, prefix string, limit int) ([]model.Category, error) {
+	prefix = strings.ToLower(prefix)
+	categories := categoryRepository.categories.filter(func(category *model.Category) bool {
+		name := strings.ToLower(category.Name)
+		return strings.HasPrefix(name, prefix) || strings.Contains(name, " "+prefix)
+	})
+	if len(categories) > limit {
+		categories = categories[:limit]
+	}
+
+	return categories, nil
+}
+
+func (categoryRepository *categoryRepository) Create(ctx context.Context, newCategory *model.Category) error {
+	defaultNow(&newCategory.CreatedAt, &newCategory.UpdatedAt)
+	categoryRepository.categories.insert(newCategory)
+	return nil
+}
+
+func (categoryRepository *categoryRepository) Update(ctx context.Context, updatedCategory *model.Category) error {
+	categoryRepository.categories.updateById(updatedCategory.Id, updatedCategory)
+	return nil
+}
+
+func (categoryRepository *categoryRepository) DeleteById(ctx context.Context, id int64) error {
+	categoryRepository.categories.delete(func(category *model.Category) bool {
+		return category.Id == id
+	})
+	return nil
+}
@@ -0,0 +1,228 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"thanhldt060802/utils"
+	"time"
+)
+
+// Documents of versioned indices behind one alias, same layout the Elasticsearch repositories maintain:
+// writes go through the alias, or to an index named like the alias when no alias exists yet.
+type elasticsearchIndices[T any] struct {
+	mutex   sync.RWMutex
+	alias   string
+	indices map[string]map[int64]T
+	aliased []string
+	idOf    func(document *T) int64
+}
+
+func newElasticsearchIndices[T any](alias string, idOf func(document *T) int64) *elasticsearchIndices[T] {
+	return &elasticsearchIndices[T]{
+		alias:   alias,
+		indices: map[string]map[int64]T{},
+		idOf:    idOf,
+	}
+}
+
+func (elasticsearchIndices *elasticsearchIndices[T]) createNextVersionIndex() string {
+	elasticsearchIndices.mutex.Lock()
+	defer elasticsearchIndices.mutex.Unlock()
+
+	nextVersion := 1
+	for index := range elasticsearchIndices.indices {
+		version, err := strconv.Atoi(strings.TrimPrefix(index, elasticsearchIndices.alias+"_v"))
+		if err == nil && version >= nextVersion {
+			nextVersion = version + 1
+		}
+	}
+	newIndex := fmt.Sprintf("%s_v%d", elasticsearchIndices.alias, nextVersion)
+	elasticsearchIndices.indices[newIndex] = map[int64]T{}
+
+	return newIndex
+}
+
+func (elasticsearchIndices *elasticsearchIndices[T]) getVersionIndices() []string {
+	elasticsearchIndices.mutex.RLock()
+	defer elasticsearchIndices.mutex.RUnlock()
+
+	versionIndices := []string{}
+	for index := range elasticsearchIndices.indices {
+		if strings.HasPrefix(index, elasticsearchIndices.alias+"_v") {
+			versionIndices = append(versionIndices, index)
+		}
+	}
+	sort.Strings(versionIndices)
+
+	return versionIndices
+}
+
+func (elasticsearchIndices *elasticsearchIndices[T]) bulkIndex(index string, documents []T) error {
+	elasticsearchIndices.mutex.Lock()
+	defer elasticsearchIndices.mutex.Unlock()
+
+	indexDocuments, ok := elasticsearchIndices.indices[index]
+	if !ok {
+		return fmt.Errorf("index %s does not exist", index)
+	}
+	for _, document := range documents {
+		indexDocuments[elasticsearchIndices.idOf(&document)] = document
+	}
+
+	return nil
+}
+
+func (elasticsearchIndices *elasticsearchIndices[T]) count(index string) (int64, error) {
+	elasticsearchIndices.mutex.RLock()
+	defer elasticsearchIndices.mutex.RUnlock()
+
+	indexDocuments, ok := elasticsearchIndices.indices[index]
+	if !ok {
+		return 0, fmt.Errorf("count documents of %s index failed: index does not exist", index)
+	}
+
+	return int64(len(indexDocuments)), nil
+}
+
+// Index holding the alias name is dropped when the alias is first created, like the real switch
+func (elasticsearchIndices *elasticsearchIndices[T]) switchAlias(index string) ([]string, error) {
+	elasticsearchIndices.mutex.Lock()
+	defer elasticsearchIndices.mutex.Unlock()
+
+	if _, ok := elasticsearchIndices.indices[index]; !ok {
+		return nil, fmt.Errorf("switch %s alias failed: index %s does not exist", elasticsearchIndices.alias, index)
+	}
+
+	oldIndices := elasticsearchIndices.aliased
+	if oldIndices == nil {
+		oldIndices = []string{}
+		delete(elasticsearchIndices.indices, elasticsearchIndices.alias)
+	}
+	elasticsearchIndices.aliased = []string{index}
+
+	return oldIndices, nil
+}
+
+func (elasticsearchIndices *elasticsearchIndices[T]) deleteIndices(indices []string) {
+	elasticsearchIndices.mutex.Lock()
+	defer elasticsearchIndices.mutex.Unlock()
+
+	for _, index := range indices {
+		delete(elasticsearchIndices.indices, index)
+	}
+}
+
+func (elasticsearchIndices *elasticsearchIndices[T]) put(document *T) {
+	elasticsearchIndices.mutex.Lock()
+	defer elasticsearchIndices.mutex.Unlock()
+
+	index := elasticsearchIndices.writeIndexLocked()
+	if _, ok := elasticsearchIndices.indices[index]; !ok {
+		elasticsearchIndices.indices[index] = map[int64]T{}
+	}
+	elasticsearchIndices.indices[index][elasticsearchIndices.idOf(document)] = *document
+}
+
+func (elasticsearchIndices *elasticsearchIndices[T]) delete(id int64) {
+	elasticsearchIndices.mutex.Lock()
+	defer elasticsearchIndices.mutex.Unlock()
+
+	delete(elasticsearchIndices.indices[elasticsearchIndices.writeIndexLocked()], id)
+}
+
+// Documents searchable through the alias in id order
+func (elasticsearchIndices *elasticsearchIndices[T]) search(match func(document *T) bool) []T {
+	elasticsearchIndices.mutex.RLock()
+	defer elasticsearchIndices.mutex.RUnlock()
+
+	documents := []T{}
+	for _, document := range elasticsearchIndices.indices[elasticsearchIndices.writeIndexLocked()] {
+		if match == nil || match(&document) {
+			documents = append(documents, document)
+		}
+	}
+	sort.Slice(documents, func(i, j int) bool {
+		return elasticsearchIndices.idOf(&documents[i]) < elasticsearchIndices.idOf(&documents[j])
+	})
+
+	return documents
+}
+
+func (elasticsearchIndices *elasticsearchIndices[T]) writeIndexLocked() string {
+	if len(elasticsearchIndices.aliased) > 0 {
+		return elasticsearchIndices.aliased[0]
+	}
+	return elasticsearchIndices.alias
+}
+
+// Only fields mapped for sorting in the index can be sorted by
+func checkElasticsearchSort(pageRequest *utils.PageRequest, fieldMap map[string]string) error {
+	for _, sortField := range pageRequest.SortFields {
+		if _, ok := fieldMap[sortField.Field]; !ok {
+			return fmt.Errorf("%w: can not sort by %s", utils.ErrInvalidPagination, sortField.Field)
+		}
+	}
+
+	return nil
+}
+
+// Date bound in strict_date_optional_time format, missing parts of an upper bound are filled up to the end
+// of the unit like Elasticsearch rounds lte, bounds without offset are read in location
+func parseDateBound(value string, location *time.Location, roundUp bool) (time.Time, error) {
+	if date, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return date, nil
+	}
+
+	layouts := []struct {
+		layout string
+		unit   time.Duration
+	}{
+		{"2006-01-02T15:04:05.999999999", time.Millisecond},
+		{"2006-01-02T15:04:05", time.Second},
+		{"2006-01-02T15:04", time.Minute},
+		{"2006-01-02T15", time.Hour},
+		{"2006-01-02", 24 * time.Hour},
+	}
+	for _, layout := range layouts {
+		date, err := time.ParseInLocation(layout.layout, value, location)
+		if err != nil {
+			continue
+		}
+		if roundUp {
+			if layout.unit == 24*time.Hour {
+				date = date.AddDate(0, 0, 1).Add(-time.Millisecond)
+			} else {
+				date = date.Add(layout.unit - time.Millisecond)
+			}
+		}
+		return date, nil
+	}
+
+	return time.Time{}, fmt.Errorf("failed to parse date field [%s] with format [strict_date_optional_time]", value)
+}
+
+// Range filter on a date, empty bounds are open
+func newDateRange(gte string, lte string, location *time.Location) (func(date time.Time) bool, error) {
+	var from, to *time.Time
+	if gte != "" {
+		date, err := parseDateBound(gte, location, false)
+		if err != nil {
+			return nil, err
+		}
+		from = &date
+	}
+	if lte != "" {
+		date, err := parseDateBound(lte, location, true)
+		if err != nil {
+			return nil, err
+		}
+		to = &date
+	}
+
+	return func(date time.Time) bool {
+		return (from == nil || !date.Before(*from)) && (to == nil || !date.After(*to))
+	}, nil
+}
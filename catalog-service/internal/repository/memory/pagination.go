@@ -0,0 +1,167 @@
+package memory
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"thanhldt060802/utils"
+	"time"
+
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/schema"
+)
+
+// Same table schemas the SQL repositories sort by, so both accept and reject the same sort fields
+var tables = pgdialect.New().Tables()
+
+// Keyset pagination over rows already narrowed by the caller, mirrors the SQL one:
+// same sort field checks, same cursors, one extra row to know whether another page exists.
+func paginate[T any](rows []T, pageRequest *utils.PageRequest) ([]T, *utils.Page, error) {
+	table := tables.Get(reflect.TypeOf((*T)(nil)).Elem())
+	fields := make([]*schema.Field, len(pageRequest.SortFields))
+	for i, sortField := range pageRequest.SortFields {
+		field, ok := table.FieldMap[sortField.Field]
+		// Nullable columns have no position to continue from
+		if !ok || field.IsPtr || field.Tag.HasOption("nullzero") {
+			return nil, nil, fmt.Errorf("%w: can not sort by %s", utils.ErrInvalidPagination, sortField.Field)
+		}
+		fields[i] = field
+	}
+	if pageRequest.Cursor != nil && len(pageRequest.Cursor.Values) != len(fields) {
+		return nil, nil, fmt.Errorf("%w: cursor does not match sort_by", utils.ErrInvalidPagination)
+	}
+
+	backward := pageRequest.Cursor != nil && pageRequest.Cursor.Backward
+	descending := make([]bool, len(fields))
+	for i, sortField := range pageRequest.SortFields {
+		descending[i] = (sortField.Direction == "DESC") != backward
+	}
+
+	// Cursor values went through JSON, they are read back into column types before comparing
+	var cursorValues []reflect.Value
+	if pageRequest.Cursor != nil {
+		cursorValues = make([]reflect.Value, len(fields))
+		for i, field := range fields {
+			cursorValue, err := convertValue(pageRequest.Cursor.Values[i], field.IndirectType)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%w: cursor is malformed", utils.ErrInvalidPagination)
+			}
+			cursorValues[i] = cursorValue
+		}
+	}
+
+	sortedRows := slices.Clone(rows)
+	slices.SortStableFunc(sortedRows, func(a T, b T) int {
+		return compareSortValues(fields, descending, rowValues(fields, &a), rowValues(fields, &b))
+	})
+
+	items := []T{}
+	for i := range sortedRows {
+		if cursorValues != nil && compareSortValues(fields, descending, rowValues(fields, &sortedRows[i]), cursorValues) <= 0 {
+			continue
+		}
+		items = append(items, sortedRows[i])
+		if len(items) > pageRequest.Limit {
+			break
+		}
+	}
+
+	hasMore := len(items) > pageRequest.Limit
+	if hasMore {
+		items = items[:pageRequest.Limit]
+	}
+	if backward {
+		slices.Reverse(items)
+	}
+
+	page := pageRequest.NewPage(nil, nil, false)
+	if len(items) > 0 {
+		page = pageRequest.NewPage(sortValues(fields, &items[0]), sortValues(fields, &items[len(items)-1]), hasMore)
+	}
+
+	if pageRequest.IncludeTotal {
+		total := int64(len(rows))
+		page.Total = &total
+	}
+
+	return items, page, nil
+}
+
+func rowValues[T any](fields []*schema.Field, item *T) []reflect.Value {
+	values := make([]reflect.Value, len(fields))
+	for i, field := range fields {
+		values[i] = field.Value(reflect.ValueOf(item).Elem())
+	}
+
+	return values
+}
+
+func sortValues[T any](fields []*schema.Field, item *T) []any {
+	values := make([]any, len(fields))
+	for i, value := range rowValues(fields, item) {
+		values[i] = value.Interface()
+	}
+
+	return values
+}
+
+// First differing field decides, direction of each field is already flipped for descending sort
+func compareSortValues(fields []*schema.Field, descending []bool, a []reflect.Value, b []reflect.Value) int {
+	for i := range fields {
+		result := compareValues(a[i], b[i])
+		if descending[i] {
+			result = -result
+		}
+		if result != 0 {
+			return result
+		}
+	}
+
+	return 0
+}
+
+func compareValues(a reflect.Value, b reflect.Value) int {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.String:
+		return cmp.Compare(a.String(), b.String())
+	case reflect.Bool:
+		if a.Bool() == b.Bool() {
+			return 0
+		} else if b.Bool() {
+			return -1
+		}
+		return 1
+	}
+
+	if aTime, ok := a.Interface().(time.Time); ok {
+		return aTime.Compare(b.Interface().(time.Time))
+	}
+	// Numeric types like decimal.Decimal order themselves
+	if method := a.MethodByName("Cmp"); method.IsValid() {
+		return int(method.Call([]reflect.Value{b})[0].Int())
+	}
+
+	return cmp.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+}
+
+func convertValue(value any, valueType reflect.Type) (reflect.Value, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	converted := reflect.New(valueType)
+	if err := json.Unmarshal(data, converted.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+
+	return converted.Elem(), nil
+}
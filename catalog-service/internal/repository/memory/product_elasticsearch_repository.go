@@ -0,0 +1,305 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"thanhldt060802/utils"
+	"time"
+)
+
+type productElasticsearchRepository struct {
+	products *elasticsearchIndices[model.Product]
+}
+
+func NewProductElasticsearchRepository() repository.ProductElasticsearchRepository {
+	return &productElasticsearchRepository{
+		products: newElasticsearchIndices("products", func(product *model.Product) int64 { return product.Id }),
+	}
+}
+
+func (productElasticsearchRepository *productElasticsearchRepository) CreateNextVersionIndex(ctx context.Context) (string, error) {
+	return productElasticsearchRepository.products.createNextVersionIndex(), nil
+}
+
+func (productElasticsearchRepository *productElasticsearchRepository) GetVersionIndices(ctx context.Context) ([]string, error) {
+	return productElasticsearchRepository.products.getVersionIndices(), nil
+}
+
+func (productElasticsearchRepository *productElasticsearchRepository) BulkIndex(ctx context.Context, index string, products []model.Product) error {
+	return productElasticsearchRepository.products.bulkIndex(index, products)
+}
+
+func (productElasticsearchRepository *productElasticsearchRepository) Count(ctx context.Context, index string) (int64, error) {
+	return productElasticsearchRepository.products.count(index)
+}
+
+func (productElasticsearchRepository *productElasticsearchRepository) SwitchAlias(ctx context.Context, index string) ([]string, error) {
+	return productElasticsearchRepository.products.switchAlias(index)
+}
+
+func (productElasticsearchRepository *productElasticsearchRepository) DeleteIndices(ctx context.Context, indices []string) error {
+	productElasticsearchRepository.products.deleteIndices(indices)
+	return nil
+}
+
+func (productElasticsearchRepository *productElasticsearchRepository) SyncCreating(ctx context.Context, newProduct *model.Product) error {
+	productElasticsearchRepository.products.put(newProduct)
+	return nil
+}
+
+func (productElasticsearchRepository *productElasticsearchRepository) SyncUpdating(ctx context.Context, updatedProduct *model.Product) error {
+	productElasticsearchRepository.products.put(updatedProduct)
+	return nil
+}
+
+func (productElasticsearchRepository *productElasticsearchRepository) SyncDeletingById(ctx context.Context, id int64) error {
+	productElasticsearchRepository.products.delete(id)
+	return nil
+}
+
+// Same matching, post filters and facets as the Elasticsearch query, without relevance:
+// hits are ordered by sort_by only, so a cursor holds the sort values alone.
+func (productElasticsearchRepository *productElasticsearchRepository) Get(ctx context.Context, pageRequest *utils.PageRequest, filter *model.ProductElasticsearchFilter) (*model.ProductElasticsearchResult, *utils.Page, error) {
+	if err := checkElasticsearchSort(pageRequest, model.MapSortFieldProductSchemaElasticsearch); err != nil {
+		return nil, nil, err
+	}
+
+	if filter.PriceInterval <= 0 {
+		return nil, nil, fmt.Errorf("get products from elasticsearch failed: [interval] must be >0 for histogram aggregation [values]")
+	}
+	priceRange, err := newPriceRange(filter.PriceGTE, filter.PriceLTE)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get products from elasticsearch failed: %s", err.Error())
+	}
+	createdAtRange, err := newDateRange(filter.CreatedAtGTE, filter.CreatedAtLTE, time.UTC)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get products from elasticsearch failed: %s", err.Error())
+	}
+
+	queryTerms, foldedQueryTerms := analyze(filter.Query), analyzeFolded(filter.Query)
+	nameTerms := analyze(filter.Name)
+	matched := productElasticsearchRepository.products.search(func(product *model.Product) bool {
+		if filter.Query != "" && !matchQuery(product, queryTerms, foldedQueryTerms) {
+			return false
+		}
+		if filter.Name != "" && !matchAny(nameTerms, analyze(product.Name), func(term string, token string) bool { return term == token }) {
+			return false
+		}
+		return priceRange(product.Price) && createdAtRange(product.CreatedAt)
+	})
+
+	// Facet filters of every facet except the given one, like the filter aggregations
+	facetFilters := map[string]func(product *model.Product) bool{}
+	if len(filter.CategoryIds) > 0 {
+		facetFilters["categories"] = func(product *model.Product) bool {
+			return slices.Contains(filter.CategoryIds, product.CategoryId)
+		}
+	}
+	if len(filter.Sexes) > 0 {
+		facetFilters["sexes"] = func(product *model.Product) bool {
+			return slices.Contains(filter.Sexes, product.Sex)
+		}
+	}
+	if len(filter.PriceBuckets) > 0 {
+		facetFilters["prices"] = func(product *model.Product) bool {
+			return slices.ContainsFunc(filter.PriceBuckets, func(priceBucket int64) bool {
+				return product.Price >= priceBucket && product.Price < priceBucket+filter.PriceInterval
+			})
+		}
+	}
+	onSaleCondition := func(product *model.Product) bool {
+		return product.DiscountPercentage > 0
+	}
+	if filter.OnSale {
+		facetFilters["on_sale"] = onSaleCondition
+	}
+	otherFacetFilters := func(except string) []model.Product {
+		products := []model.Product{}
+		for _, product := range matched {
+			ok := true
+			for name, condition := range facetFilters {
+				if name != except && !condition(&product) {
+					ok = false
+					break
+				}
+			}
+			if ok {
+				products = append(products, product)
+			}
+		}
+		return products
+	}
+
+	products, page, err := paginate(otherFacetFilters(""), pageRequest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := &model.ProductElasticsearchResult{
+		Products:   products,
+		Highlights: map[int64]model.ProductHighlight{},
+	}
+
+	// Prefer highlight on accent-sensitive field, fall back to folded one
+	if filter.Query != "" {
+		for _, product := range products {
+			exact := func(token string) bool {
+				return slices.ContainsFunc(queryTerms, func(term string) bool { return fuzzyMatch(term, token) })
+			}
+			folded := func(token string) bool {
+				return slices.ContainsFunc(foldedQueryTerms, func(term string) bool { return fuzzyMatch(term, fold(token)) })
+			}
+
+			productHighlight := model.ProductHighlight{
+				Name:        highlight(product.Name, exact),
+				Description: highlight(product.Description, exact),
+			}
+			if len(productHighlight.Name) == 0 {
+				productHighlight.Name = highlight(product.Name, folded)
+			}
+			if len(productHighlight.Description) == 0 {
+				productHighlight.Description = highlight(product.Description, folded)
+			}
+			if len(productHighlight.Name) > 0 || len(productHighlight.Description) > 0 {
+				result.Highlights[product.Id] = productHighlight
+			}
+		}
+	}
+
+	for _, bucket := range termsBuckets(otherFacetFilters("categories"), 100, func(product *model.Product) int64 { return product.CategoryId }) {
+		result.CategoryFacets = append(result.CategoryFacets, model.CategoryFacetBucket{CategoryId: bucket.key, Count: bucket.count})
+	}
+	for _, bucket := range termsBuckets(otherFacetFilters("sexes"), 10, func(product *model.Product) string { return product.Sex }) {
+		result.SexFacets = append(result.SexFacets, model.TermFacetBucket{Value: bucket.key, Count: bucket.count})
+	}
+	priceCounts := map[int64]int64{}
+	for _, product := range otherFacetFilters("prices") {
+		priceCounts[product.Price-product.Price%filter.PriceInterval]++
+	}
+	for _, from := range slices.Sorted(maps.Keys(priceCounts)) {
+		result.PriceFacets = append(result.PriceFacets, model.PriceFacetBucket{From: from, To: from + filter.PriceInterval, Count: priceCounts[from]})
+	}
+	for _, product := range otherFacetFilters("on_sale") {
+		if onSaleCondition(&product) {
+			result.OnSaleCount++
+		}
+	}
+
+	return result, page, nil
+}
+
+// Every typed word but the last must be whole, the last one is a prefix, any of them matching is enough.
+// Hits come in id order since nothing is scored.
+func (productElasticsearchRepository *productElasticsearchRepository) Suggest(ctx context.Context, text string, limit int) (*model.ProductSuggestion, error) {
+	terms := analyzeFolded(text)
+
+	matched := productElasticsearchRepository.products.search(func(product *model.Product) bool {
+		if product.Stock <= 0 || len(terms) == 0 {
+			return false
+		}
+		tokens := analyzeFolded(product.Name)
+		for i, term := range terms {
+			for _, token := range tokens {
+				if token == term || (i == len(terms)-1 && strings.HasPrefix(token, term)) {
+					return true
+				}
+			}
+		}
+		return false
+	})
+
+	// Category names are resolved by caller, only ids are known here
+	suggestion := &model.ProductSuggestion{
+		Products:   []model.ProductSuggestionItem{},
+		Categories: []model.CategorySuggestionItem{},
+	}
+	for i, product := range matched {
+		if i == limit {
+			break
+		}
+		suggestion.Products = append(suggestion.Products, model.ProductSuggestionItem{Id: product.Id, Name: product.Name})
+	}
+	for _, bucket := range termsBuckets(matched, limit, func(product *model.Product) int64 { return product.CategoryId }) {
+		suggestion.Categories = append(suggestion.Categories, model.CategorySuggestionItem{Id: bucket.key})
+	}
+
+	return suggestion, nil
+}
+
+// Product matches when any term of the query fuzzily matches a word of name or description, accents are
+// compared as typed against the original fields and folded against the folded ones
+func matchQuery(product *model.Product, queryTerms []string, foldedQueryTerms []string) bool {
+	text := product.Name + " " + product.Description
+
+	return matchAny(queryTerms, analyze(text), fuzzyMatch) || matchAny(foldedQueryTerms, analyzeFolded(text), fuzzyMatch)
+}
+
+func matchAny(terms []string, tokens []string, match func(term string, token string) bool) bool {
+	for _, term := range terms {
+		for _, token := range tokens {
+			if match(term, token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Range on the long price field, bounds are sent as strings and must be whole numbers
+func newPriceRange(gte string, lte string) (func(price int64) bool, error) {
+	var from, to *int64
+	for _, bound := range []struct {
+		value  string
+		target **int64
+	}{{gte, &from}, {lte, &to}} {
+		if bound.value == "" {
+			continue
+		}
+		price, err := strconv.ParseInt(bound.value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create query: For input string: \"%s\"", bound.value)
+		}
+		*bound.target = &price
+	}
+
+	return func(price int64) bool {
+		return (from == nil || price >= *from) && (to == nil || price <= *to)
+	}, nil
+}
+
+type termsBucket[K cmp.Ordered] struct {
+	key   K
+	count int64
+}
+
+// Terms aggregation, most documents first and ties by key, at most size buckets
+func termsBuckets[K cmp.Ordered](products []model.Product, size int, keyOf func(product *model.Product) K) []termsBucket[K] {
+	counts := map[K]int64{}
+	for _, product := range products {
+		counts[keyOf(&product)]++
+	}
+
+	buckets := make([]termsBucket[K], 0, len(counts))
+	for key, count := range counts {
+		buckets = append(buckets, termsBucket[K]{key: key, count: count})
+	}
+	slices.SortFunc(buckets, func(a termsBucket[K], b termsBucket[K]) int {
+		if a.count != b.count {
+			return cmp.Compare(b.count, a.count)
+		}
+		return cmp.Compare(a.key, b.key)
+	})
+	if len(buckets) > size {
+		buckets = buckets[:size]
+	}
+
+	return buckets
+}
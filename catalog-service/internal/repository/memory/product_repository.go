@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"thanhldt060802/utils"
+	"time"
+)
+
+type productRepository struct {
+	products *table[model.Product]
+}
+
+func NewProductRepository() repository.ProductRepository {
+	return &productRepository{
+		products: newTable(func(product *model.Product) *int64 { return &product.Id }),
+	}
+}
+
+func (productRepository *productRepository) Get(ctx context.Context, pageRequest *utils.PageRequest) ([]model.Product, *utils.Page, error) {
+	return paginate(productRepository.products.filter(nil), pageRequest)
+}
+
+func (productRepository *productRepository) GetById(ctx context.Context, id int64) (*model.Product, error) {
+	return productRepository.products.getById(id)
+}
+
+func (productRepository *productRepository) GetByIds(ctx context.Context, ids []int64) ([]model.Product, error) {
+	return productRepository.products.filter(func(product *model.Product) bool {
+		return slices.Contains(ids, product.Id)
+	}), nil
+}
+
+// Rows come back in id order like the SQL one, there is nothing to lock
+func (productRepository *productRepository) GetByIdsForUpdate(ctx context.Context, ids []int64) ([]model.Product, error) {
+	return productRepository.GetByIds(ctx, ids)
+}
+
+func (productRepository *productRepository) GetByCategoryId(ctx context.Context, categoryId int64, pageRequest *utils.PageRequest) ([]model.Product, *utils.Page, error) {
+	return paginate(productRepository.products.filter(func(product *model.Product) bool {
+		return product.CategoryId == categoryId
+	}), pageRequest)
+}
+
+func (productRepository *productRepository) Create(ctx context.Context, newProduct *model.Product) error {
+	defaultNow(&newProduct.CreatedAt, &newProduct.UpdatedAt)
+	productRepository.products.insert(newProduct)
+	return nil
+}
+
+func (productRepository *productRepository) Update(ctx context.Context, updatedProduct *model.Product) error {
+	productRepository.products.updateById(updatedProduct.Id, updatedProduct)
+	return nil
+}
+
+func (productRepository *productRepository) DeleteById(ctx context.Context, id int64) error {
+	productRepository.products.delete(func(product *model.Product) bool {
+		return product.Id == id
+	})
+	return nil
+}
+
+func (productRepository *productRepository) AddStock(ctx context.Context, id int64, quantity int32) error {
+	productRepository.products.update(func(product *model.Product) bool {
+		return product.Id == id
+	}, func(product *model.Product) {
+		product.Stock += quantity
+		product.UpdatedAt = time.Now().UTC()
+	})
+	return nil
+}
+
+// Integrate with Elasticsearch
+
+func (productRepository *productRepository) GetAll(ctx context.Context, afterId int64, limit int) ([]model.Product, error) {
+	products := productRepository.products.filter(func(product *model.Product) bool {
+		return product.Id > afterId
+	})
+	if len(products) > limit {
+		products = products[:limit]
+	}
+
+	return products, nil
+}
@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"time"
+)
+
+type stockReservationRepository struct {
+	stockReservations     *table[model.StockReservation]
+	stockReservationItems *table[model.StockReservationItem]
+}
+
+func NewStockReservationRepository() repository.StockReservationRepository {
+	return &stockReservationRepository{
+		stockReservations:     newTable(func(stockReservation *model.StockReservation) *int64 { return &stockReservation.Id }),
+		stockReservationItems: newTable(func(stockReservationItem *model.StockReservationItem) *int64 { return &stockReservationItem.Id }),
+	}
+}
+
+func (stockReservationRepository *stockReservationRepository) GetById(ctx context.Context, id int64) (*model.StockReservation, error) {
+	stockReservation, err := stockReservationRepository.stockReservations.getById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	stockReservation.Items = stockReservationRepository.stockReservationItems.filter(func(stockReservationItem *model.StockReservationItem) bool {
+		return stockReservationItem.ReservationId == id
+	})
+
+	return stockReservation, nil
+}
+
+// Items come back in product id order, the order products are locked in
+func (stockReservationRepository *stockReservationRepository) GetByIdForUpdate(ctx context.Context, id int64) (*model.StockReservation, error) {
+	stockReservation, err := stockReservationRepository.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(stockReservation.Items, func(i, j int) bool {
+		return stockReservation.Items[i].ProductId < stockReservation.Items[j].ProductId
+	})
+
+	return stockReservation, nil
+}
+
+func (stockReservationRepository *stockReservationRepository) GetExpiredIds(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	stockReservations := stockReservationRepository.stockReservations.filter(func(stockReservation *model.StockReservation) bool {
+		return stockReservation.Status == model.StockReservationStatusPending && !stockReservation.ExpiresAt.After(now)
+	})
+	slices.SortStableFunc(stockReservations, func(a model.StockReservation, b model.StockReservation) int {
+		return a.ExpiresAt.Compare(b.ExpiresAt)
+	})
+	if len(stockReservations) > limit {
+		stockReservations = stockReservations[:limit]
+	}
+
+	ids := make([]int64, len(stockReservations))
+	for i, stockReservation := range stockReservations {
+		ids[i] = stockReservation.Id
+	}
+
+	return ids, nil
+}
+
+// Items are stored apart like the has-many relation, the reservation row keeps none
+func (stockReservationRepository *stockReservationRepository) Create(ctx context.Context, newStockReservation *model.StockReservation) error {
+	defaultNow(&newStockReservation.CreatedAt, &newStockReservation.UpdatedAt)
+
+	items := newStockReservation.Items
+	newStockReservation.Items = nil
+	stockReservationRepository.stockReservations.insert(newStockReservation)
+	newStockReservation.Items = items
+
+	for i := range newStockReservation.Items {
+		newStockReservation.Items[i].ReservationId = newStockReservation.Id
+		stockReservationRepository.stockReservationItems.insert(&newStockReservation.Items[i])
+	}
+
+	return nil
+}
+
+// Only status is written, like the column list of the SQL update
+func (stockReservationRepository *stockReservationRepository) Update(ctx context.Context, updatedStockReservation *model.StockReservation) error {
+	stockReservationRepository.stockReservations.update(func(stockReservation *model.StockReservation) bool {
+		return stockReservation.Id == updatedStockReservation.Id
+	}, func(stockReservation *model.StockReservation) {
+		stockReservation.Status = updatedStockReservation.Status
+		stockReservation.UpdatedAt = updatedStockReservation.UpdatedAt
+	})
+	return nil
+}
@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"cmp"
+	"database/sql"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Rows of one table kept in id order, ids are handed out like a serial column.
+// Rows are copied in and out, so callers can not change stored rows behind the repository.
+type table[T any] struct {
+	mutex  sync.RWMutex
+	rows   []T
+	lastId int64
+	idOf   func(row *T) *int64
+}
+
+func newTable[T any](idOf func(row *T) *int64) *table[T] {
+	return &table[T]{idOf: idOf}
+}
+
+func (table *table[T]) insert(row *T) {
+	table.mutex.Lock()
+	defer table.mutex.Unlock()
+
+	table.insertLocked(row)
+}
+
+// Explicit ids are kept, sequence moves past them like setval after a seed
+func (table *table[T]) insertLocked(row *T) {
+	id := table.idOf(row)
+	if *id == 0 {
+		table.lastId++
+		*id = table.lastId
+	} else if *id > table.lastId {
+		table.lastId = *id
+	}
+
+	table.rows = append(table.rows, *row)
+	slices.SortStableFunc(table.rows, func(a T, b T) int {
+		return cmp.Compare(*table.idOf(&a), *table.idOf(&b))
+	})
+}
+
+func (table *table[T]) getById(id int64) (*T, error) {
+	return table.find(func(row *T) bool {
+		return *table.idOf(row) == id
+	})
+}
+
+// First matching row in id order, not found is reported the way database/sql does
+func (table *table[T]) find(match func(row *T) bool) (*T, error) {
+	table.mutex.RLock()
+	defer table.mutex.RUnlock()
+
+	for i := range table.rows {
+		if match(&table.rows[i]) {
+			row := table.rows[i]
+			return &row, nil
+		}
+	}
+
+	return nil, sql.ErrNoRows
+}
+
+func (table *table[T]) filter(match func(row *T) bool) []T {
+	table.mutex.RLock()
+	defer table.mutex.RUnlock()
+
+	rows := []T{}
+	for i := range table.rows {
+		if match == nil || match(&table.rows[i]) {
+			rows = append(rows, table.rows[i])
+		}
+	}
+
+	return rows
+}
+
+func (table *table[T]) count(match func(row *T) bool) int {
+	return len(table.filter(match))
+}
+
+// Like an UPDATE ... WHERE id = ?, nothing happens when the row is gone
+func (table *table[T]) updateById(id int64, updatedRow *T) {
+	table.mutex.Lock()
+	defer table.mutex.Unlock()
+
+	for i := range table.rows {
+		if *table.idOf(&table.rows[i]) == id {
+			table.rows[i] = *updatedRow
+			*table.idOf(&table.rows[i]) = id
+			return
+		}
+	}
+}
+
+func (table *table[T]) update(match func(row *T) bool, change func(row *T)) {
+	table.mutex.Lock()
+	defer table.mutex.Unlock()
+
+	for i := range table.rows {
+		if match(&table.rows[i]) {
+			change(&table.rows[i])
+		}
+	}
+}
+
+func (table *table[T]) delete(match func(row *T) bool) int {
+	table.mutex.Lock()
+	defer table.mutex.Unlock()
+
+	count := len(table.rows)
+	table.rows = slices.DeleteFunc(table.rows, func(row T) bool {
+		return match(&row)
+	})
+
+	return count - len(table.rows)
+}
+
+// Columns declared with default:current_timestamp are filled on insert when left zero
+func defaultNow(timestamps ...*time.Time) {
+	now := time.Now().UTC()
+	for _, timestamp := range timestamps {
+		if timestamp.IsZero() {
+			*timestamp = now
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Tokens of text the way the standard analyzer splits and lowercases them
+func analyze(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.Is(unicode.Mn, r)
+	})
+}
+
+// Tokens of text the way the folding analyzer does, accents are stripped after lowercasing
+func analyzeFolded(text string) []string {
+	tokens := analyze(text)
+	for i, token := range tokens {
+		tokens[i] = fold(token)
+	}
+
+	return tokens
+}
+
+// Same as asciifolding for Vietnamese, đ has no decomposition so it is mapped by hand
+func fold(text string) string {
+	var builder strings.Builder
+	for _, r := range norm.NFD.String(text) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		switch r {
+		case 'đ':
+			r = 'd'
+		case 'Đ':
+			r = 'D'
+		}
+		builder.WriteRune(r)
+	}
+
+	return builder.String()
+}
+
+// Fuzzy term match with fuzziness AUTO and prefix_length 1
+func fuzzyMatch(term string, token string) bool {
+	termRunes, tokenRunes := []rune(term), []rune(token)
+	if len(termRunes) == 0 || len(tokenRunes) == 0 || termRunes[0] != tokenRunes[0] {
+		return false
+	}
+
+	maxEdits := 0
+	if len(termRunes) > 5 {
+		maxEdits = 2
+	} else if len(termRunes) > 2 {
+		maxEdits = 1
+	}
+
+	return editDistance(termRunes, tokenRunes) <= maxEdits
+}
+
+// Damerau-Levenshtein distance, a transposition counts as one edit like Lucene does
+func editDistance(a []rune, b []rune) int {
+	distances := make([][]int, len(a)+1)
+	for i := range distances {
+		distances[i] = make([]int, len(b)+1)
+		distances[i][0] = i
+	}
+	for j := range distances[0] {
+		distances[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			distances[i][j] = min(distances[i-1][j]+1, distances[i][j-1]+1, distances[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				distances[i][j] = min(distances[i][j], distances[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return distances[len(a)][len(b)]
+}
+
+// Wraps every word of text whose token matches in <em>, the whole field is one fragment
+func highlight(text string, match func(token string) bool) []string {
+	var builder strings.Builder
+	highlighted := false
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if !isTokenRune(runes[i]) {
+			builder.WriteRune(runes[i])
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(runes) && isTokenRune(runes[j]) {
+			j++
+		}
+		word := string(runes[i:j])
+		if match(strings.ToLower(word)) {
+			builder.WriteString("<em>" + word + "</em>")
+			highlighted = true
+		} else {
+			builder.WriteString(word)
+		}
+		i = j
+	}
+
+	if !highlighted {
+		return nil
+	}
+	return []string{builder.String()}
+}
+
+func isTokenRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.Is(unicode.Mn, r)
+}
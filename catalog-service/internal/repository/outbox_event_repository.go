@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"thanhldt060802/internal/model"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type outboxEventRepository struct {
+	db *bun.DB
+}
+
+type OutboxEventRepository interface {
+	ClaimDue(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]model.OutboxEvent, error)
+	CreateMany(ctx context.Context, newOutboxEvents []model.OutboxEvent) error
+	GetAggregateIdsSince(ctx context.Context, aggregateType string, since time.Time) ([]int64, error)
+	Update(ctx context.Context, updatedOutboxEvent *model.OutboxEvent) error
+	DeleteDoneBefore(ctx context.Context, before time.Time) error
+}
+
+func NewOutboxEventRepository(db *bun.DB) OutboxEventRepository {
+	return &outboxEventRepository{db: db}
+}
+
+// Claimed events stay pending with next_attempt_at pushed to leaseUntil, so they are dispatched outside of a transaction
+// without other dispatchers taking them, and are claimed again once the lease runs out if the dispatcher dies.
+// SKIP LOCKED lets several dispatchers claim at the same time without waiting on each other
+func (outboxEventRepository *outboxEventRepository) ClaimDue(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]model.OutboxEvent, error) {
+	db := getDB(ctx, outboxEventRepository.db)
+
+	dueIds := db.NewSelect().Model((*model.OutboxEvent)(nil)).
+		Column("id").
+		Where("status = ?", model.OutboxEventStatusPending).
+		Where("next_attempt_at <= ?", now).
+		Order("id ASC").
+		Limit(limit).
+		For("UPDATE SKIP LOCKED")
+
+	var outboxEvents []model.OutboxEvent
+
+	err := db.NewUpdate().Model((*model.OutboxEvent)(nil)).
+		Set("next_attempt_at = ?", leaseUntil).
+		Set("updated_at = ?", now).
+		Where("id IN (?)", dueIds).
+		Returning("*").
+		Scan(ctx, &outboxEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(outboxEvents, func(a, b model.OutboxEvent) int { return cmp.Compare(a.Id, b.Id) })
+
+	return outboxEvents, nil
+}
+
+func (outboxEventRepository *outboxEventRepository) CreateMany(ctx context.Context, newOutboxEvents []model.OutboxEvent) error {
+	if len(newOutboxEvents) == 0 {
+		return nil
+	}
+
+	_, err := getDB(ctx, outboxEventRepository.db).NewInsert().Model(&newOutboxEvents).Exec(ctx)
+	return err
+}
+
+func (outboxEventRepository *outboxEventRepository) GetAggregateIdsSince(ctx context.Context, aggregateType string, since time.Time) ([]int64, error) {
+	var ids []int64
+
+	err := getDB(ctx, outboxEventRepository.db).NewSelect().Model((*model.OutboxEvent)(nil)).
+		ColumnExpr("DISTINCT aggregate_id").
+		Where("aggregate_type = ?", aggregateType).
+		Where("created_at >= ?", since).
+		Scan(ctx, &ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (outboxEventRepository *outboxEventRepository) Update(ctx context.Context, updatedOutboxEvent *model.OutboxEvent) error {
+	_, err := getDB(ctx, outboxEventRepository.db).NewUpdate().Model(updatedOutboxEvent).
+		Column("status", "attempts", "last_error", "next_attempt_at", "updated_at").
+		Where("id = ?", updatedOutboxEvent.Id).
+		Exec(ctx)
+
+	return err
+}
+
+func (outboxEventRepository *outboxEventRepository) DeleteDoneBefore(ctx context.Context, before time.Time) error {
+	_, err := getDB(ctx, outboxEventRepository.db).NewDelete().Model(&model.OutboxEvent{}).
+		Where("status = ?", model.OutboxEventStatusDone).
+		Where("updated_at < ?", before).
+		Exec(ctx)
+	return err
+}
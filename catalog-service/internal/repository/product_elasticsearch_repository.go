@@ -6,106 +6,269 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
 	"strconv"
-	"thanhldt060802/infrastructure"
+	"strings"
 	"thanhldt060802/internal/model"
 	"thanhldt060802/utils"
 
+	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/esutil"
 )
 
 type productElasticsearchRepository struct {
+	elasticsearchClient *elasticsearch.Client
 }
 
 type ProductElasticsearchRepository interface {
-	SyncAll(ctx context.Context, products []model.Product) error
+	CreateNextVersionIndex(ctx context.Context) (string, error)
+	GetVersionIndices(ctx context.Context) ([]string, error)
+	BulkIndex(ctx context.Context, index string, products []model.Product) error
+	Count(ctx context.Context, index string) (int64, error)
+	SwitchAlias(ctx context.Context, index string) ([]string, error)
+	DeleteIndices(ctx context.Context, indices []string) error
 
 	SyncCreating(ctx context.Context, newProduct *model.Product) error
 	SyncUpdating(ctx context.Context, updatedProduct *model.Product) error
 	SyncDeletingById(ctx context.Context, id int64) error
 
-	Get(ctx context.Context, offset int, limit int, sortFields []utils.SortField, name string, priceGTE string, priceLTE string, createdAtGTE string, createdAtLTE string) ([]model.Product, error)
+	Get(ctx context.Context, pageRequest *utils.PageRequest, filter *model.ProductElasticsearchFilter) (*model.ProductElasticsearchResult, *utils.Page, error)
+	Suggest(ctx context.Context, text string, limit int) (*model.ProductSuggestion, error)
 }
 
-func NewProductElasticsearchRepository() ProductElasticsearchRepository {
-	return &productElasticsearchRepository{}
+func NewProductElasticsearchRepository(elasticsearchClient *elasticsearch.Client) ProductElasticsearchRepository {
+	return &productElasticsearchRepository{elasticsearchClient: elasticsearchClient}
 }
 
-func (productElasticsearchRepository *productElasticsearchRepository) SyncAll(ctx context.Context, products []model.Product) error {
-	// Check if index already exists
-	existsRes, err := infrastructure.ElasticsearchClient.Indices.Exists([]string{"products"})
+func (productElasticsearchRepository *productElasticsearchRepository) CreateNextVersionIndex(ctx context.Context) (string, error) {
+	versionIndices, err := productElasticsearchRepository.GetVersionIndices(ctx)
 	if err != nil {
-		return fmt.Errorf("check index existence failed: %s", err.Error())
+		return "", err
 	}
-	defer existsRes.Body.Close()
 
-	// If index does not exists
-	if existsRes.StatusCode == 404 {
-		// Create index using custom product schema
-		createRes, err := infrastructure.ElasticsearchClient.Indices.Create("products",
-			infrastructure.ElasticsearchClient.Indices.Create.WithBody(bytes.NewReader([]byte(model.ProductSchemaElasticsearch))))
+	// Next version is one above the highest existing version
+	nextVersion := 1
+	for _, versionIndex := range versionIndices {
+		version, err := strconv.Atoi(strings.TrimPrefix(versionIndex, "products_v"))
+		if err == nil && version >= nextVersion {
+			nextVersion = version + 1
+		}
+	}
+	newIndex := fmt.Sprintf("products_v%d", nextVersion)
+
+	// Create index using custom product schema
+	res, err := productElasticsearchRepository.elasticsearchClient.Indices.Create(newIndex,
+		productElasticsearchRepository.elasticsearchClient.Indices.Create.WithBody(bytes.NewReader([]byte(model.ProductSchemaElasticsearch))),
+		productElasticsearchRepository.elasticsearchClient.Indices.Create.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("create %s index on elasticsearch failed: %s", newIndex, err.Error())
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return "", fmt.Errorf("create %s index on elasticsearch failed: %s", newIndex, res.String())
+	}
+
+	return newIndex, nil
+}
+
+func (productElasticsearchRepository *productElasticsearchRepository) GetVersionIndices(ctx context.Context) ([]string, error) {
+	res, err := productElasticsearchRepository.elasticsearchClient.Indices.Get([]string{"products_v*"},
+		productElasticsearchRepository.elasticsearchClient.Indices.Get.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("get products indices failed: %s", err.Error())
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("get products indices failed: %s", res.String())
+	}
+
+	var indices map[string]any
+	if err := json.NewDecoder(res.Body).Decode(&indices); err != nil {
+		return nil, fmt.Errorf("parse products indices failed: %s", err.Error())
+	}
+
+	versionIndices := make([]string, 0, len(indices))
+	for index := range indices {
+		versionIndices = append(versionIndices, index)
+	}
+	sort.Strings(versionIndices)
+
+	return versionIndices, nil
+}
+
+func (productElasticsearchRepository *productElasticsearchRepository) BulkIndex(ctx context.Context, index string, products []model.Product) error {
+	// Create BulkIndexer on Elasticsearch
+	indexer, err := esutil.NewBulkIndexer(esutil.BulkIndexerConfig{
+		Client: productElasticsearchRepository.elasticsearchClient,
+		Index:  index,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Add all product to BulkIndexer on Elasticsearch
+	for _, product := range products {
+		data, err := json.Marshal(product)
+		if err != nil {
+			indexer.Close(ctx)
+			return fmt.Errorf("marshal product with id = %d failed: %s", product.Id, err.Error())
+		}
+
+		err = indexer.Add(ctx, esutil.BulkIndexerItem{
+			Action:     "index",
+			DocumentID: strconv.FormatInt(product.Id, 10),
+			Body:       bytes.NewReader(data),
+			OnFailure: func(ctx context.Context, item esutil.BulkIndexerItem, resp esutil.BulkIndexerResponseItem, err error) {
+				if err != nil {
+					log.Printf("Bulk index failed: %s", err.Error())
+				} else {
+					log.Printf("Index product with id = %s failed: %s", item.DocumentID, resp.Error.Reason)
+				}
+			},
+		})
 		if err != nil {
+			indexer.Close(ctx)
 			return err
 		}
-		defer createRes.Body.Close()
+	}
+
+	if err := indexer.Close(ctx); err != nil {
+		return fmt.Errorf("close bulk indexer failed: %s", err.Error())
+	}
+	if stats := indexer.Stats(); stats.NumFailed > 0 {
+		return fmt.Errorf("bulk index %d products into %s failed", stats.NumFailed, index)
+	}
+
+	return nil
+}
+
+func (productElasticsearchRepository *productElasticsearchRepository) Count(ctx context.Context, index string) (int64, error) {
+	// Make every indexed document visible to count
+	refreshRes, err := productElasticsearchRepository.elasticsearchClient.Indices.Refresh(
+		productElasticsearchRepository.elasticsearchClient.Indices.Refresh.WithIndex(index),
+		productElasticsearchRepository.elasticsearchClient.Indices.Refresh.WithContext(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("refresh %s index failed: %s", index, err.Error())
+	}
+	refreshRes.Body.Close()
+
+	res, err := productElasticsearchRepository.elasticsearchClient.Count(
+		productElasticsearchRepository.elasticsearchClient.Count.WithIndex(index),
+		productElasticsearchRepository.elasticsearchClient.Count.WithContext(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("count documents of %s index failed: %s", index, err.Error())
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return 0, fmt.Errorf("count documents of %s index failed: %s", index, res.String())
+	}
+
+	var countRes struct {
+		Count int64 `json:"count"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&countRes); err != nil {
+		return 0, fmt.Errorf("parse count response failed: %s", err.Error())
+	}
+
+	return countRes.Count, nil
+}
+
+// Points products alias to index in one atomic request and returns indices the alias pointed to before
+func (productElasticsearchRepository *productElasticsearchRepository) SwitchAlias(ctx context.Context, index string) ([]string, error) {
+	aliasRes, err := productElasticsearchRepository.elasticsearchClient.Indices.GetAlias(
+		productElasticsearchRepository.elasticsearchClient.Indices.GetAlias.WithName("products"),
+		productElasticsearchRepository.elasticsearchClient.Indices.GetAlias.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("get products alias failed: %s", err.Error())
+	}
+	defer aliasRes.Body.Close()
+
+	oldIndices := []string{}
+	if aliasRes.StatusCode != 404 {
+		if aliasRes.IsError() {
+			return nil, fmt.Errorf("get products alias failed: %s", aliasRes.String())
+		}
 
-		if createRes.IsError() {
-			return fmt.Errorf("create products index on elasticsearch faield: %s", createRes.String())
+		var aliases map[string]any
+		if err := json.NewDecoder(aliasRes.Body).Decode(&aliases); err != nil {
+			return nil, fmt.Errorf("parse products alias failed: %s", err.Error())
+		}
+		for oldIndex := range aliases {
+			oldIndices = append(oldIndices, oldIndex)
 		}
+	}
 
-		// Create BulkIndexer on Elasticsearch
-		indexer, err := esutil.NewBulkIndexer(esutil.BulkIndexerConfig{
-			Client: infrastructure.ElasticsearchClient,
-			Index:  "products",
+	actions := []map[string]any{}
+	for _, oldIndex := range oldIndices {
+		actions = append(actions, map[string]any{
+			"remove": map[string]any{"index": oldIndex, "alias": "products"},
 		})
+	}
+
+	// Index created by the old sync all holds the alias name, drop it in the same request
+	if len(oldIndices) == 0 {
+		existsRes, err := productElasticsearchRepository.elasticsearchClient.Indices.Exists([]string{"products"},
+			productElasticsearchRepository.elasticsearchClient.Indices.Exists.WithContext(ctx))
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("check index existence failed: %s", err.Error())
 		}
-		defer func() {
-			if err := indexer.Close(ctx); err != nil {
-				log.Printf("Close bulk indexer failed: %s", err.Error())
-			}
-		}()
-
-		// Add all product to BulkIndexer on Elasticsearch
-		for _, product := range products {
-			data, err := json.Marshal(product)
-			if err != nil {
-				log.Printf("Marshal product with id = %d: %s", product.Id, err.Error())
-				continue
-			}
+		existsRes.Body.Close()
 
-			// Add product to BulkIndexer
-			err = indexer.Add(ctx, esutil.BulkIndexerItem{
-				Action:     "index",
-				DocumentID: strconv.FormatInt(product.Id, 10),
-				Body:       bytes.NewReader(data),
-				OnFailure: func(ctx context.Context, item esutil.BulkIndexerItem, resp esutil.BulkIndexerResponseItem, err error) {
-					if err != nil {
-						log.Printf("Bulk index failed: %s", err.Error())
-					} else {
-						log.Printf("Index product with id = %s failed: %s", item.DocumentID, resp.Error.Reason)
-					}
-				},
+		if existsRes.StatusCode == 200 {
+			actions = append(actions, map[string]any{
+				"remove_index": map[string]any{"index": "products"},
 			})
-			if err != nil {
-				return err
-			}
 		}
+	}
+
+	actions = append(actions, map[string]any{
+		"add": map[string]any{"index": index, "alias": "products"},
+	})
 
+	res, err := productElasticsearchRepository.elasticsearchClient.Indices.UpdateAliases(
+		esutil.NewJSONReader(map[string]any{"actions": actions}),
+		productElasticsearchRepository.elasticsearchClient.Indices.UpdateAliases.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("switch products alias failed: %s", err.Error())
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("switch products alias failed: %s", res.String())
+	}
+
+	return oldIndices, nil
+}
+
+func (productElasticsearchRepository *productElasticsearchRepository) DeleteIndices(ctx context.Context, indices []string) error {
+	if len(indices) == 0 {
 		return nil
 	}
 
-	return fmt.Errorf("index products already exists after first sync all")
+	res, err := productElasticsearchRepository.elasticsearchClient.Indices.Delete(indices,
+		productElasticsearchRepository.elasticsearchClient.Indices.Delete.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("delete indices failed: %s", err.Error())
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("delete indices failed: %s", res.String())
+	}
+
+	return nil
 }
 
 func (productElasticsearchRepository *productElasticsearchRepository) SyncCreating(ctx context.Context, newProduct *model.Product) error {
 	// Add product to Elasticsearch
-	res, err := infrastructure.ElasticsearchClient.Index(
+	res, err := productElasticsearchRepository.elasticsearchClient.Index(
 		"products",
 		esutil.NewJSONReader(newProduct),
-		infrastructure.ElasticsearchClient.Index.WithDocumentID(strconv.FormatInt(newProduct.Id, 10)),
-		infrastructure.ElasticsearchClient.Index.WithRefresh("true"),
+		productElasticsearchRepository.elasticsearchClient.Index.WithDocumentID(strconv.FormatInt(newProduct.Id, 10)),
+		productElasticsearchRepository.elasticsearchClient.Index.WithRefresh("true"),
 	)
 	if err != nil {
 		return fmt.Errorf("add product to elasticsearch failed: %s", err.Error())
@@ -121,11 +284,11 @@ func (productElasticsearchRepository *productElasticsearchRepository) SyncCreati
 
 func (productElasticsearchRepository *productElasticsearchRepository) SyncUpdating(ctx context.Context, updatedProduct *model.Product) error {
 	// Update product on Elasticsearch
-	res, err := infrastructure.ElasticsearchClient.Index(
+	res, err := productElasticsearchRepository.elasticsearchClient.Index(
 		"products",
 		esutil.NewJSONReader(updatedProduct),
-		infrastructure.ElasticsearchClient.Index.WithDocumentID(strconv.FormatInt(updatedProduct.Id, 10)),
-		infrastructure.ElasticsearchClient.Index.WithRefresh("true"),
+		productElasticsearchRepository.elasticsearchClient.Index.WithDocumentID(strconv.FormatInt(updatedProduct.Id, 10)),
+		productElasticsearchRepository.elasticsearchClient.Index.WithRefresh("true"),
 	)
 	if err != nil {
 		return fmt.Errorf("update product on elasticsearch failed: %s", err.Error())
@@ -141,16 +304,21 @@ func (productElasticsearchRepository *productElasticsearchRepository) SyncUpdati
 
 func (productElasticsearchRepository *productElasticsearchRepository) SyncDeletingById(ctx context.Context, id int64) error {
 	// Delete product from Elasticsearch
-	res, err := infrastructure.ElasticsearchClient.Delete(
+	res, err := productElasticsearchRepository.elasticsearchClient.Delete(
 		"products",
 		strconv.FormatInt(id, 10),
-		infrastructure.ElasticsearchClient.Delete.WithRefresh("true"),
+		productElasticsearchRepository.elasticsearchClient.Delete.WithRefresh("true"),
 	)
 	if err != nil {
 		return fmt.Errorf("delete product from elasticsearch failed: %s", err.Error())
 	}
 	defer res.Body.Close()
 
+	// Already deleted
+	if res.StatusCode == 404 {
+		return nil
+	}
+
 	if res.IsError() {
 		return fmt.Errorf("delete product from elasticsearch failed: %s", res.String())
 	}
@@ -158,25 +326,38 @@ func (productElasticsearchRepository *productElasticsearchRepository) SyncDeleti
 	return nil
 }
 
-func (productElasticsearchRepository *productElasticsearchRepository) Get(ctx context.Context, offset int, limit int, sortFields []utils.SortField, name string, priceGTE string, priceLTE string, createdAtGTE string, createdAtLTE string) ([]model.Product, error) {
+func (productElasticsearchRepository *productElasticsearchRepository) Get(ctx context.Context, pageRequest *utils.PageRequest, filter *model.ProductElasticsearchFilter) (*model.ProductElasticsearchResult, *utils.Page, error) {
 	mustConditions := []map[string]interface{}{}
 
+	// If searching by text, accent-sensitive fields weigh more than folded ones so exact accents rank first
+	if filter.Query != "" {
+		mustConditions = append(mustConditions, map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":         filter.Query,
+				"type":          "most_fields",
+				"fields":        []string{"name^3", "name.folded^2", "description", "description.folded^0.5"},
+				"fuzziness":     "AUTO",
+				"prefix_length": 1,
+			},
+		})
+	}
+
 	// If filtering by name
-	if name != "" {
+	if filter.Name != "" {
 		mustConditions = append(mustConditions, map[string]interface{}{
 			"match": map[string]interface{}{
-				"name": name,
+				"name": filter.Name,
 			},
 		})
 	}
 
 	// If filtering by price in range or partial range
 	priceRange := map[string]interface{}{}
-	if priceGTE != "" {
-		priceRange["gte"] = priceGTE
+	if filter.PriceGTE != "" {
+		priceRange["gte"] = filter.PriceGTE
 	}
-	if priceLTE != "" {
-		priceRange["lte"] = priceLTE
+	if filter.PriceLTE != "" {
+		priceRange["lte"] = filter.PriceLTE
 	}
 	if len(priceRange) > 0 {
 		mustConditions = append(mustConditions, map[string]interface{}{
@@ -188,11 +369,11 @@ func (productElasticsearchRepository *productElasticsearchRepository) Get(ctx co
 
 	// If filtering by created_at in range or partial range
 	createdAtRange := map[string]interface{}{}
-	if createdAtGTE != "" {
-		createdAtRange["gte"] = createdAtGTE
+	if filter.CreatedAtGTE != "" {
+		createdAtRange["gte"] = filter.CreatedAtGTE
 	}
-	if createdAtLTE != "" {
-		createdAtRange["lte"] = createdAtLTE
+	if filter.CreatedAtLTE != "" {
+		createdAtRange["lte"] = filter.CreatedAtLTE
 	}
 	if len(createdAtRange) > 0 {
 		createdAtRange["format"] = "strict_date_optional_time" // For format YYYY-MM-ddTHH:mm:ss
@@ -210,66 +391,274 @@ func (productElasticsearchRepository *productElasticsearchRepository) Get(ctx co
 		})
 	}
 
-	// Setup query
+	// Facet filters go to post_filter, so each facet can be counted without its own selection (multi-select)
+	facetFilters := map[string]map[string]interface{}{}
+	if len(filter.CategoryIds) > 0 {
+		facetFilters["categories"] = map[string]interface{}{
+			"terms": map[string]interface{}{
+				"category_id": filter.CategoryIds,
+			},
+		}
+	}
+	if len(filter.Sexes) > 0 {
+		facetFilters["sexes"] = map[string]interface{}{
+			"terms": map[string]interface{}{
+				"sex.keyword": filter.Sexes,
+			},
+		}
+	}
+	if len(filter.PriceBuckets) > 0 {
+		priceBucketRanges := make([]map[string]interface{}, len(filter.PriceBuckets))
+		for i, priceBucket := range filter.PriceBuckets {
+			priceBucketRanges[i] = map[string]interface{}{
+				"range": map[string]interface{}{
+					"price": map[string]interface{}{
+						"gte": priceBucket,
+						"lt":  priceBucket + filter.PriceInterval,
+					},
+				},
+			}
+		}
+		facetFilters["prices"] = map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should":               priceBucketRanges,
+				"minimum_should_match": 1,
+			},
+		}
+	}
+	onSaleCondition := map[string]interface{}{
+		"range": map[string]interface{}{
+			"discount_percentage": map[string]interface{}{
+				"gt": 0,
+			},
+		},
+	}
+	if filter.OnSale {
+		facetFilters["on_sale"] = onSaleCondition
+	}
+
+	// Facet filters of every facet except the given one
+	otherFacetFilters := func(except string) []map[string]interface{} {
+		conditions := []map[string]interface{}{}
+		for name, condition := range facetFilters {
+			if name != except {
+				conditions = append(conditions, condition)
+			}
+		}
+		return conditions
+	}
+
+	// Apply sorting to query, relevance goes first when searching by text, pages continue after sort values of the cursor hit
+	sortFields, err := elasticsearchSort(pageRequest, model.MapSortFieldProductSchemaElasticsearch, filter.Query != "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Setup query, one hit over the limit tells whether another page exists
 	query := map[string]interface{}{
-		"from": offset,
-		"size": limit,
+		"size":             pageRequest.Limit + 1,
+		"sort":             sortFields,
+		"track_total_hits": true,
 		"query": map[string]interface{}{
 			"bool": map[string]interface{}{
 				"must": mustConditions,
 			},
 		},
+		"post_filter": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": otherFacetFilters(""),
+			},
+		},
+		"aggs": map[string]interface{}{
+			"categories": map[string]interface{}{
+				"filter": map[string]interface{}{
+					"bool": map[string]interface{}{"filter": otherFacetFilters("categories")},
+				},
+				"aggs": map[string]interface{}{
+					"values": map[string]interface{}{
+						"terms": map[string]interface{}{"field": "category_id", "size": 100},
+					},
+				},
+			},
+			"sexes": map[string]interface{}{
+				"filter": map[string]interface{}{
+					"bool": map[string]interface{}{"filter": otherFacetFilters("sexes")},
+				},
+				"aggs": map[string]interface{}{
+					"values": map[string]interface{}{
+						"terms": map[string]interface{}{"field": "sex.keyword", "size": 10},
+					},
+				},
+			},
+			"prices": map[string]interface{}{
+				"filter": map[string]interface{}{
+					"bool": map[string]interface{}{"filter": otherFacetFilters("prices")},
+				},
+				"aggs": map[string]interface{}{
+					"values": map[string]interface{}{
+						"histogram": map[string]interface{}{"field": "price", "interval": filter.PriceInterval, "min_doc_count": 1},
+					},
+				},
+			},
+			"on_sale": map[string]interface{}{
+				"filter": map[string]interface{}{
+					"bool": map[string]interface{}{"filter": append(otherFacetFilters("on_sale"), onSaleCondition)},
+				},
+			},
+		},
 	}
 
-	// Apply sorting to query
-	if len(sortFields) > 0 {
-		_sortFields := []map[string]interface{}{}
-		for _, sortField := range sortFields {
-			_sortFields = append(_sortFields, map[string]interface{}{
-				model.MapSortFieldProductSchemaElasticsearch[sortField.Field]: sortField.Direction,
-			})
+	// Highlight matched text of name and description
+	if filter.Query != "" {
+		query["highlight"] = map[string]interface{}{
+			"pre_tags":  []string{"<em>"},
+			"post_tags": []string{"</em>"},
+			"fields": map[string]interface{}{
+				"name":               map[string]interface{}{"number_of_fragments": 0},
+				"name.folded":        map[string]interface{}{"number_of_fragments": 0},
+				"description":        map[string]interface{}{"fragment_size": 150, "number_of_fragments": 3},
+				"description.folded": map[string]interface{}{"fragment_size": 150, "number_of_fragments": 3},
+			},
 		}
-		query["sort"] = _sortFields
-	}
-
-	fmt.Println(query)
-
-	// query := map[string]interface{}{
-	// 	"from": 0,
-	// 	"size": 20,
-	// 	"query": map[string]interface{}{
-	// 		"bool": map[string]interface{}{
-	// 			"must": []interface{}{
-	// 				map[string]interface{}{
-	// 					"match": map[string]interface{}{
-	// 						"name": "Áo",
-	// 					},
-	// 				},
-	// 				map[string]interface{}{
-	// 					"range": map[string]interface{}{
-	// 						"price": map[string]interface{}{
-	// 							"gte": "100000",
-	// 							"lte": "1000000",
-	// 						},
-	// 					},
-	// 				},
-	// 				map[string]interface{}{
-	// 					"range": map[string]interface{}{
-	// 						"created_at": map[string]interface{}{
-	// 							"format": "strict_date_optional_time",
-	// 							"gte":    "2024-01-15T00:00:00",
-	// 							"lte":    "2024-02-05T23:59:59",
-	// 						},
-	// 					},
-	// 				},
-	// 			},
-	// 		},
-	// 	},
-	// 	"sort": []map[string]interface{}{
-	// 		{"price": "asc"},
-	// 		{"name.keyword": "desc"},
-	// 	},
-	// }
+	}
+
+	if pageRequest.Cursor != nil {
+		query["search_after"] = pageRequest.Cursor.Values
+	}
+
+	// Convert query to JSON
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal query failed")
+	}
+
+	// Send request to Elasticsearch
+	res, err := productElasticsearchRepository.elasticsearchClient.Search(
+		productElasticsearchRepository.elasticsearchClient.Search.WithContext(ctx),
+		productElasticsearchRepository.elasticsearchClient.Search.WithIndex("products"),
+		productElasticsearchRepository.elasticsearchClient.Search.WithBody(bytes.NewReader(queryJSON)),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	// Parse response
+	if res.IsError() {
+		return nil, nil, fmt.Errorf("get products from elasticsearch failed: %s", res.String())
+	}
+	var elasticsearchResponse struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []elasticsearchHit[model.Product] `json:"hits"`
+		} `json:"hits"`
+		Aggregations struct {
+			Categories struct {
+				Values struct {
+					Buckets []struct {
+						Key      int64 `json:"key"`
+						DocCount int64 `json:"doc_count"`
+					} `json:"buckets"`
+				} `json:"values"`
+			} `json:"categories"`
+			Sexes struct {
+				Values struct {
+					Buckets []struct {
+						Key      string `json:"key"`
+						DocCount int64  `json:"doc_count"`
+					} `json:"buckets"`
+				} `json:"values"`
+			} `json:"sexes"`
+			Prices struct {
+				Values struct {
+					Buckets []struct {
+						Key      float64 `json:"key"`
+						DocCount int64   `json:"doc_count"`
+					} `json:"buckets"`
+				} `json:"values"`
+			} `json:"prices"`
+			OnSale struct {
+				DocCount int64 `json:"doc_count"`
+			} `json:"on_sale"`
+		} `json:"aggregations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&elasticsearchResponse); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal elasticsearch response failed: %s", err.Error())
+	}
+
+	// Extract products and facets
+	hits, page := elasticsearchPage(pageRequest, elasticsearchResponse.Hits.Hits)
+	page.Total = &elasticsearchResponse.Hits.Total.Value
+	result := &model.ProductElasticsearchResult{
+		Products:    make([]model.Product, len(hits)),
+		Highlights:  map[int64]model.ProductHighlight{},
+		OnSaleCount: elasticsearchResponse.Aggregations.OnSale.DocCount,
+	}
+	for i, hit := range hits {
+		result.Products[i] = hit.Source
+
+		// Prefer highlight on accent-sensitive field, fall back to folded one
+		if len(hit.Highlight) > 0 {
+			highlight := model.ProductHighlight{
+				Name:        hit.Highlight["name"],
+				Description: hit.Highlight["description"],
+			}
+			if len(highlight.Name) == 0 {
+				highlight.Name = hit.Highlight["name.folded"]
+			}
+			if len(highlight.Description) == 0 {
+				highlight.Description = hit.Highlight["description.folded"]
+			}
+			result.Highlights[hit.Source.Id] = highlight
+		}
+	}
+	for _, bucket := range elasticsearchResponse.Aggregations.Categories.Values.Buckets {
+		result.CategoryFacets = append(result.CategoryFacets, model.CategoryFacetBucket{CategoryId: bucket.Key, Count: bucket.DocCount})
+	}
+	for _, bucket := range elasticsearchResponse.Aggregations.Sexes.Values.Buckets {
+		result.SexFacets = append(result.SexFacets, model.TermFacetBucket{Value: bucket.Key, Count: bucket.DocCount})
+	}
+	for _, bucket := range elasticsearchResponse.Aggregations.Prices.Values.Buckets {
+		from := int64(bucket.Key)
+		result.PriceFacets = append(result.PriceFacets, model.PriceFacetBucket{From: from, To: from + filter.PriceInterval, Count: bucket.DocCount})
+	}
+
+	return result, page, nil
+}
+
+func (productElasticsearchRepository *productElasticsearchRepository) Suggest(ctx context.Context, text string, limit int) (*model.ProductSuggestion, error) {
+	// Prefix match on name while typing, only in stock products
+	query := map[string]interface{}{
+		"size":    limit,
+		"_source": []string{"id", "name"},
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": []map[string]interface{}{
+					{
+						"multi_match": map[string]interface{}{
+							"query":  text,
+							"type":   "bool_prefix",
+							"fields": []string{"name.suggest", "name.suggest._2gram", "name.suggest._3gram"},
+						},
+					},
+				},
+				"filter": []map[string]interface{}{
+					{
+						"range": map[string]interface{}{
+							"stock": map[string]interface{}{"gt": 0},
+						},
+					},
+				},
+			},
+		},
+		"aggs": map[string]interface{}{
+			"categories": map[string]interface{}{
+				"terms": map[string]interface{}{"field": "category_id", "size": limit},
+			},
+		},
+	}
 
 	// Convert query to JSON
 	queryJSON, err := json.Marshal(query)
@@ -278,10 +667,10 @@ func (productElasticsearchRepository *productElasticsearchRepository) Get(ctx co
 	}
 
 	// Send request to Elasticsearch
-	res, err := infrastructure.ElasticsearchClient.Search(
-		infrastructure.ElasticsearchClient.Search.WithContext(ctx),
-		infrastructure.ElasticsearchClient.Search.WithIndex("products"),
-		infrastructure.ElasticsearchClient.Search.WithBody(bytes.NewReader(queryJSON)),
+	res, err := productElasticsearchRepository.elasticsearchClient.Search(
+		productElasticsearchRepository.elasticsearchClient.Search.WithContext(ctx),
+		productElasticsearchRepository.elasticsearchClient.Search.WithIndex("products"),
+		productElasticsearchRepository.elasticsearchClient.Search.WithBody(bytes.NewReader(queryJSON)),
 	)
 	if err != nil {
 		return nil, err
@@ -290,24 +679,37 @@ func (productElasticsearchRepository *productElasticsearchRepository) Get(ctx co
 
 	// Parse response
 	if res.IsError() {
-		return nil, fmt.Errorf("get products from elasticsearch failed: %s", res.String())
+		return nil, fmt.Errorf("suggest products from elasticsearch failed: %s", res.String())
 	}
 	var elasticsearchResponse struct {
 		Hits struct {
 			Hits []struct {
-				Source model.Product `json:"_source"`
+				Source model.ProductSuggestionItem `json:"_source"`
 			} `json:"hits"`
 		} `json:"hits"`
+		Aggregations struct {
+			Categories struct {
+				Buckets []struct {
+					Key int64 `json:"key"`
+				} `json:"buckets"`
+			} `json:"categories"`
+		} `json:"aggregations"`
 	}
 	if err := json.NewDecoder(res.Body).Decode(&elasticsearchResponse); err != nil {
 		return nil, fmt.Errorf("unmarshal elasticsearch response failed: %s", err.Error())
 	}
 
-	// Extract products
-	products := make([]model.Product, len(elasticsearchResponse.Hits.Hits))
+	// Category names are resolved by caller, only ids are known here
+	suggestion := &model.ProductSuggestion{
+		Products:   make([]model.ProductSuggestionItem, len(elasticsearchResponse.Hits.Hits)),
+		Categories: make([]model.CategorySuggestionItem, len(elasticsearchResponse.Aggregations.Categories.Buckets)),
+	}
 	for i, hit := range elasticsearchResponse.Hits.Hits {
-		products[i] = hit.Source
+		suggestion.Products[i] = hit.Source
+	}
+	for i, bucket := range elasticsearchResponse.Aggregations.Categories.Buckets {
+		suggestion.Categories[i] = model.CategorySuggestionItem{Id: bucket.Key}
 	}
 
-	return products, nil
+	return suggestion, nil
 }
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"thanhldt060802/internal/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type productEventRepository struct {
+	redisClient *redis.Client
+}
+
+type ProductEventRepository interface {
+	Publish(ctx context.Context, productEvent *model.ProductEvent) error
+	TrimAcknowledged(ctx context.Context) (int64, error)
+}
+
+func NewProductEventRepository(redisClient *redis.Client) ProductEventRepository {
+	return &productEventRepository{redisClient: redisClient}
+}
+
+// Stream is not capped on publish, events are only removed by TrimAcknowledged once every consumer group handled them
+func (productEventRepository *productEventRepository) Publish(ctx context.Context, productEvent *model.ProductEvent) error {
+	return productEventRepository.redisClient.XAdd(ctx, &redis.XAddArgs{
+		Stream: model.ProductEventStream,
+		Values: map[string]any{
+			"type":       productEvent.Type,
+			"product_id": productEvent.ProductId,
+		},
+	}).Err()
+}
+
+// Removes events older than the oldest one a consumer group still needs, either unacknowledged or not delivered yet.
+// Nothing is removed before a group exists, and a group that stays offline keeps its events however long it takes
+func (productEventRepository *productEventRepository) TrimAcknowledged(ctx context.Context) (int64, error) {
+	exists, err := productEventRepository.redisClient.Exists(ctx, model.ProductEventStream).Result()
+	if err != nil || exists == 0 {
+		return 0, err
+	}
+
+	groups, err := productEventRepository.redisClient.XInfoGroups(ctx, model.ProductEventStream).Result()
+	if err != nil || len(groups) == 0 {
+		return 0, err
+	}
+
+	minId := ""
+	for _, group := range groups {
+		groupMinId := group.LastDeliveredID
+		if group.Pending > 0 {
+			pending, err := productEventRepository.redisClient.XPending(ctx, model.ProductEventStream, group.Name).Result()
+			if err != nil {
+				return 0, err
+			}
+			groupMinId = pending.Lower
+		}
+
+		less, err := streamIdLess(groupMinId, minId)
+		if err != nil {
+			return 0, err
+		}
+		if less {
+			minId = groupMinId
+		}
+	}
+
+	return productEventRepository.redisClient.XTrimMinID(ctx, model.ProductEventStream, minId).Result()
+}
+
+func streamIdLess(id string, otherId string) (bool, error) {
+	if otherId == "" {
+		return true, nil
+	}
+
+	idParts, err := parseStreamId(id)
+	if err != nil {
+		return false, err
+	}
+	otherIdParts, err := parseStreamId(otherId)
+	if err != nil {
+		return false, err
+	}
+
+	if idParts[0] != otherIdParts[0] {
+		return idParts[0] < otherIdParts[0], nil
+	}
+	return idParts[1] < otherIdParts[1], nil
+}
+
+// Stream ids are "<milliseconds>-<sequence>"
+func parseStreamId(id string) ([2]uint64, error) {
+	milliseconds, sequence, _ := strings.Cut(id, "-")
+
+	var parts [2]uint64
+	var err error
+	if parts[0], err = strconv.ParseUint(milliseconds, 10, 64); err != nil {
+		return parts, fmt.Errorf("stream id %q is not valid", id)
+	}
+	if sequence != "" {
+		if parts[1], err = strconv.ParseUint(sequence, 10, 64); err != nil {
+			return parts, fmt.Errorf("stream id %q is not valid", id)
+		}
+	}
+
+	return parts, nil
+}
@@ -2,68 +2,66 @@ package repository
 
 import (
 	"context"
-	"fmt"
-	"thanhldt060802/infrastructure"
 	"thanhldt060802/internal/model"
 	"thanhldt060802/utils"
+	"time"
+
+	"github.com/uptrace/bun"
 )
 
 type productRepository struct {
+	db *bun.DB
 }
 
 type ProductRepository interface {
-	Get(ctx context.Context, offset int, limit int, sortFields []utils.SortField) ([]model.Product, error)
+	Get(ctx context.Context, pageRequest *utils.PageRequest) ([]model.Product, *utils.Page, error)
 	GetById(ctx context.Context, id int64) (*model.Product, error)
-	GetByCategoryId(ctx context.Context, categoryId int64, offset int, limit int, sortFields []utils.SortField) ([]model.Product, error)
+	GetByIds(ctx context.Context, ids []int64) ([]model.Product, error)
+	GetByIdsForUpdate(ctx context.Context, ids []int64) ([]model.Product, error)
+	GetByCategoryId(ctx context.Context, categoryId int64, pageRequest *utils.PageRequest) ([]model.Product, *utils.Page, error)
 	Create(ctx context.Context, newProduct *model.Product) error
 	Update(ctx context.Context, updatedProduct *model.Product) error
 	DeleteById(ctx context.Context, id int64) error
+	AddStock(ctx context.Context, id int64, quantity int32) error
 
-	GetAll(ctx context.Context) ([]model.Product, error)
+	GetAll(ctx context.Context, afterId int64, limit int) ([]model.Product, error)
 }
 
-func NewProductRepository() ProductRepository {
-	return &productRepository{}
+func NewProductRepository(db *bun.DB) ProductRepository {
+	return &productRepository{db: db}
 }
 
-func (productRepository *productRepository) Get(ctx context.Context, offset int, limit int, sortFields []utils.SortField) ([]model.Product, error) {
-	var products []model.Product
+func (productRepository *productRepository) Get(ctx context.Context, pageRequest *utils.PageRequest) ([]model.Product, *utils.Page, error) {
+	return paginate[model.Product](ctx, productRepository.db, pageRequest, nil)
+}
 
-	query := infrastructure.DB.NewSelect().Model(&products).
-		Offset(offset).
-		Limit(limit)
-	for _, sortField := range sortFields {
-		query = query.Order(fmt.Sprintf("%s %s", sortField.Field, sortField.Direction))
-	}
-	err := query.Scan(ctx)
+func (productRepository *productRepository) GetById(ctx context.Context, id int64) (*model.Product, error) {
+	var product model.Product
+
+	err := getDB(ctx, productRepository.db).NewSelect().Model(&product).Where("id = ?", id).Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return products, nil
+	return &product, nil
 }
 
-func (productRepository *productRepository) GetById(ctx context.Context, id int64) (*model.Product, error) {
-	var product model.Product
+func (productRepository *productRepository) GetByIds(ctx context.Context, ids []int64) ([]model.Product, error) {
+	var products []model.Product
 
-	err := infrastructure.DB.NewSelect().Model(&product).Where("id = ?", id).Scan(ctx)
+	err := getDB(ctx, productRepository.db).NewSelect().Model(&products).Where("id IN (?)", bun.In(ids)).Order("id ASC").Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return &product, nil
+	return products, nil
 }
 
-func (productRepository *productRepository) GetByCategoryId(ctx context.Context, categoryId int64, offset int, limit int, sortFields []utils.SortField) ([]model.Product, error) {
+// Rows are locked in id order so concurrent reservations never deadlock each other
+func (productRepository *productRepository) GetByIdsForUpdate(ctx context.Context, ids []int64) ([]model.Product, error) {
 	var products []model.Product
 
-	query := infrastructure.DB.NewSelect().Model(&products).Where("category_id = ?", categoryId).
-		Offset(offset).
-		Limit(limit)
-	for _, sortField := range sortFields {
-		query = query.Order(fmt.Sprintf("%s %s", sortField.Field, sortField.Direction))
-	}
-	err := query.Scan(ctx)
+	err := getDB(ctx, productRepository.db).NewSelect().Model(&products).Where("id IN (?)", bun.In(ids)).Order("id ASC").For("UPDATE").Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -71,30 +69,51 @@ func (productRepository *productRepository) GetByCategoryId(ctx context.Context,
 	return products, nil
 }
 
+func (productRepository *productRepository) GetByCategoryId(ctx context.Context, categoryId int64, pageRequest *utils.PageRequest) ([]model.Product, *utils.Page, error) {
+	return paginate[model.Product](ctx, productRepository.db, pageRequest, func(query *bun.SelectQuery) *bun.SelectQuery {
+		return query.Where("category_id = ?", categoryId)
+	})
+}
+
 func (productRepository *productRepository) Create(ctx context.Context, newProduct *model.Product) error {
-	_, err := infrastructure.DB.NewInsert().Model(newProduct).Returning("*").Exec(ctx)
+	_, err := getDB(ctx, productRepository.db).NewInsert().Model(newProduct).Returning("*").Exec(ctx)
 
 	return err
 }
 
 func (productRepository *productRepository) Update(ctx context.Context, updatedProduct *model.Product) error {
-	_, err := infrastructure.DB.NewUpdate().Model(updatedProduct).Where("id = ?", updatedProduct.Id).Returning("*").Exec(ctx)
+	_, err := getDB(ctx, productRepository.db).NewUpdate().Model(updatedProduct).Where("id = ?", updatedProduct.Id).Returning("*").Exec(ctx)
 
 	return err
 }
 
 func (productRepository *productRepository) DeleteById(ctx context.Context, id int64) error {
-	_, err := infrastructure.DB.NewDelete().Model(&model.Product{}).Where("id = ?", id).Exec(ctx)
+	_, err := getDB(ctx, productRepository.db).NewDelete().Model(&model.Product{}).Where("id = ?", id).Exec(ctx)
+
+	return err
+}
+
+func (productRepository *productRepository) AddStock(ctx context.Context, id int64, quantity int32) error {
+	_, err := getDB(ctx, productRepository.db).NewUpdate().Model((*model.Product)(nil)).
+		Set("stock = stock + ?", quantity).
+		Set("updated_at = ?", time.Now().UTC()).
+		Where("id = ?", id).
+		Exec(ctx)
 
 	return err
 }
 
 // Integrate with Elasticsearch
 
-func (productRepository *productRepository) GetAll(ctx context.Context) ([]model.Product, error) {
+// Pages by id so rows inserted while paging can not shift pages
+func (productRepository *productRepository) GetAll(ctx context.Context, afterId int64, limit int) ([]model.Product, error) {
 	var products []model.Product
 
-	err := infrastructure.DB.NewSelect().Model(&products).Scan(ctx)
+	err := getDB(ctx, productRepository.db).NewSelect().Model(&products).
+		Where("id > ?", afterId).
+		Order("id ASC").
+		Limit(limit).
+		Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"log"
+	"thanhldt060802/utils"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+// Hit and miss counters of every repository cache, published at /debug/vars
+var repositoryCacheMetrics = expvar.NewMap("repository_cache")
+
+// Read-through cache in Redis shared by cached repositories, list keys carry a version so one INCR drops every cached page
+type repositoryCache struct {
+	redisClient    *redis.Client
+	name           string
+	expireDuration time.Duration
+	group          singleflight.Group
+}
+
+func newRepositoryCache(redisClient *redis.Client, name string, expireDuration time.Duration) *repositoryCache {
+	return &repositoryCache{
+		redisClient:    redisClient,
+		name:           name,
+		expireDuration: expireDuration,
+	}
+}
+
+func (repositoryCache *repositoryCache) idKey(id int64) string {
+	return fmt.Sprintf("cache:%s:id:%d", repositoryCache.name, id)
+}
+
+func (repositoryCache *repositoryCache) listKey(ctx context.Context, scope string, pageRequest *utils.PageRequest) (string, error) {
+	version, err := repositoryCache.redisClient.Get(ctx, repositoryCache.listVersionKey()).Int64()
+	if err != nil && err != redis.Nil {
+		return "", err
+	}
+
+	cursor := ""
+	if pageRequest.Cursor != nil {
+		cursor = utils.EncodeCursor(pageRequest.Cursor)
+	}
+
+	return fmt.Sprintf("cache:%s:list:v%d:%s:%s:%d:%t:%s", repositoryCache.name, version, scope, pageRequest.SortBy(), pageRequest.Limit, pageRequest.IncludeTotal, cursor), nil
+}
+
+func (repositoryCache *repositoryCache) listVersionKey() string {
+	return fmt.Sprintf("cache:%s:list-version", repositoryCache.name)
+}
+
+func (repositoryCache *repositoryCache) record(metric string) {
+	repositoryCacheMetrics.Add(fmt.Sprintf("%s.%s", repositoryCache.name, metric), 1)
+}
+
+// Entries are dropped only after commit, so a reader can not cache rows of a transaction that is still open
+func (repositoryCache *repositoryCache) invalidate(ctx context.Context, ids ...int64) {
+	afterCommit(ctx, func() {
+		ctx := context.WithoutCancel(ctx)
+		pipe := repositoryCache.redisClient.TxPipeline()
+		for _, id := range ids {
+			pipe.Del(ctx, repositoryCache.idKey(id))
+		}
+		pipe.Incr(ctx, repositoryCache.listVersionKey())
+		if _, err := pipe.Exec(ctx); err != nil {
+			log.Printf("Invalidate %s cache failed: %s", repositoryCache.name, err.Error())
+		}
+	})
+}
+
+// Items of a list are cached together with cursors and total of their page
+type cachedPage[T any] struct {
+	Items []T         `json:"items"`
+	Page  *utils.Page `json:"page"`
+}
+
+func getOrLoadPage[T any](ctx context.Context, repositoryCache *repositoryCache, key string, load func(ctx context.Context) ([]T, *utils.Page, error)) ([]T, *utils.Page, error) {
+	cached, err := getOrLoad(ctx, repositoryCache, key, func(ctx context.Context) (*cachedPage[T], error) {
+		items, page, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &cachedPage[T]{Items: items, Page: page}, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cached.Items, cached.Page, nil
+}
+
+// Concurrent misses of the same key share one load, every caller decodes its own copy so results are never shared
+// Reads inside a transaction skip the cache, they may see rows other readers must not
+func getOrLoad[T any](ctx context.Context, repositoryCache *repositoryCache, key string, load func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	if inTransaction(ctx) {
+		return load(ctx)
+	}
+
+	data, err := repositoryCache.redisClient.Get(ctx, key).Bytes()
+	if err == nil && json.Unmarshal(data, &result) == nil {
+		repositoryCache.record("hit")
+		return result, nil
+	}
+	repositoryCache.record("miss")
+
+	value, err, shared := repositoryCache.group.Do(key, func() (any, error) {
+		// Load must finish for the other waiters even if the caller that started it goes away
+		ctx := context.WithoutCancel(ctx)
+
+		loaded, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(loaded)
+		if err != nil {
+			return nil, err
+		}
+		if err := repositoryCache.redisClient.Set(ctx, key, data, repositoryCache.expireDuration).Err(); err != nil {
+			log.Printf("Save %s cache failed: %s", repositoryCache.name, err.Error())
+		}
+		return data, nil
+	})
+	if err != nil {
+		return result, err
+	}
+	if shared {
+		repositoryCache.record("shared")
+	}
+
+	if err := json.Unmarshal(value.([]byte), &result); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
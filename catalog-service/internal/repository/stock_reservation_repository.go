@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"thanhldt060802/internal/model"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type stockReservationRepository struct {
+	db *bun.DB
+}
+
+type StockReservationRepository interface {
+	GetById(ctx context.Context, id int64) (*model.StockReservation, error)
+	GetByIdForUpdate(ctx context.Context, id int64) (*model.StockReservation, error)
+	GetExpiredIds(ctx context.Context, now time.Time, limit int) ([]int64, error)
+	Create(ctx context.Context, newStockReservation *model.StockReservation) error
+	Update(ctx context.Context, updatedStockReservation *model.StockReservation) error
+}
+
+func NewStockReservationRepository(db *bun.DB) StockReservationRepository {
+	return &stockReservationRepository{db: db}
+}
+
+func (stockReservationRepository *stockReservationRepository) GetById(ctx context.Context, id int64) (*model.StockReservation, error) {
+	var stockReservation model.StockReservation
+
+	err := getDB(ctx, stockReservationRepository.db).NewSelect().Model(&stockReservation).Relation("Items").Where("stock_reservation.id = ?", id).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stockReservation, nil
+}
+
+func (stockReservationRepository *stockReservationRepository) GetByIdForUpdate(ctx context.Context, id int64) (*model.StockReservation, error) {
+	var stockReservation model.StockReservation
+
+	err := getDB(ctx, stockReservationRepository.db).NewSelect().Model(&stockReservation).Where("id = ?", id).For("UPDATE").Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = getDB(ctx, stockReservationRepository.db).NewSelect().Model(&stockReservation.Items).Where("reservation_id = ?", id).Order("product_id ASC").Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stockReservation, nil
+}
+
+func (stockReservationRepository *stockReservationRepository) GetExpiredIds(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	var ids []int64
+
+	err := getDB(ctx, stockReservationRepository.db).NewSelect().Model((*model.StockReservation)(nil)).Column("id").
+		Where("status = ?", model.StockReservationStatusPending).
+		Where("expires_at <= ?", now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Scan(ctx, &ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (stockReservationRepository *stockReservationRepository) Create(ctx context.Context, newStockReservation *model.StockReservation) error {
+	if _, err := getDB(ctx, stockReservationRepository.db).NewInsert().Model(newStockReservation).Returning("*").Exec(ctx); err != nil {
+		return err
+	}
+
+	for i := range newStockReservation.Items {
+		newStockReservation.Items[i].ReservationId = newStockReservation.Id
+	}
+	_, err := getDB(ctx, stockReservationRepository.db).NewInsert().Model(&newStockReservation.Items).Exec(ctx)
+
+	return err
+}
+
+func (stockReservationRepository *stockReservationRepository) Update(ctx context.Context, updatedStockReservation *model.StockReservation) error {
+	_, err := getDB(ctx, stockReservationRepository.db).NewUpdate().Model(updatedStockReservation).
+		Column("status", "updated_at").
+		Where("id = ?", updatedStockReservation.Id).
+		Exec(ctx)
+
+	return err
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/uptrace/bun"
+)
+
+type txContextKey struct{}
+
+type afterCommitContextKey struct{}
+
+type transactionManager struct {
+	db *bun.DB
+}
+
+type TransactionManager interface {
+	RunInTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+func NewTransactionManager(db *bun.DB) TransactionManager {
+	return &transactionManager{db: db}
+}
+
+// Every repository call made with the context passed to fn runs inside the same transaction
+func (transactionManager *transactionManager) RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	// Already inside a transaction -> join it
+	if _, ok := ctx.Value(txContextKey{}).(bun.Tx); ok {
+		return fn(ctx)
+	}
+
+	afterCommitFns := &[]func(){}
+	err := transactionManager.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		ctx = context.WithValue(ctx, txContextKey{}, tx)
+		ctx = context.WithValue(ctx, afterCommitContextKey{}, afterCommitFns)
+		return fn(ctx)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, afterCommitFn := range *afterCommitFns {
+		afterCommitFn()
+	}
+
+	return nil
+}
+
+// Defers fn until the surrounding transaction commits, outside a transaction fn runs right away
+func afterCommit(ctx context.Context, fn func()) {
+	if afterCommitFns, ok := ctx.Value(afterCommitContextKey{}).(*[]func()); ok {
+		*afterCommitFns = append(*afterCommitFns, fn)
+		return
+	}
+
+	fn()
+}
+
+func inTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(txContextKey{}).(bun.Tx)
+	return ok
+}
+
+// Transaction of ctx if there is one, otherwise db of the repository
+func getDB(ctx context.Context, db *bun.DB) bun.IDB {
+	if tx, ok := ctx.Value(txContextKey{}).(bun.Tx); ok {
+		return tx
+	}
+	return db
+}
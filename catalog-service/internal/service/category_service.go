@@ -16,7 +16,7 @@ type categoryService struct {
 }
 
 type CategoryService interface {
-	GetCategories(ctx context.Context, reqDTO *dto.GetCategoriesRequest) ([]model.Category, error)
+	GetCategories(ctx context.Context, reqDTO *dto.GetCategoriesRequest) ([]model.Category, *utils.Page, error)
 	GetCategoryById(ctx context.Context, reqDTO *dto.GetCategoryByIdRequest) (*model.Category, error)
 	GetCategoryByName(ctx context.Context, reqDTO *dto.GetCategoryByNameRequest) (*model.Category, error)
 	CreateCategory(ctx context.Context, reqDTO *dto.CreateCategoryRequest) error
@@ -28,15 +28,18 @@ func NewCategoryService(categoryRepository repository.CategoryRepository) Catego
 	return &categoryService{categoryRepository: categoryRepository}
 }
 
-func (categoryService *categoryService) GetCategories(ctx context.Context, reqDTO *dto.GetCategoriesRequest) ([]model.Category, error) {
-	sortFields := utils.ParseSortBy(reqDTO.SortBy)
+func (categoryService *categoryService) GetCategories(ctx context.Context, reqDTO *dto.GetCategoriesRequest) ([]model.Category, *utils.Page, error) {
+	pageRequest, err := utils.NewPageRequest(reqDTO.Cursor, reqDTO.Limit, reqDTO.SortBy, reqDTO.IncludeTotal)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	categories, err := categoryService.categoryRepository.Get(ctx, reqDTO.Offset, reqDTO.Limit, sortFields)
+	categories, page, err := categoryService.categoryRepository.Get(ctx, pageRequest)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return categories, nil
+	return categories, page, nil
 }
 
 func (categoryService *categoryService) GetCategoryById(ctx context.Context, reqDTO *dto.GetCategoryByIdRequest) (*model.Category, error) {
@@ -58,7 +61,7 @@ func (categoryService *categoryService) GetCategoryByName(ctx context.Context, r
 }
 
 func (categoryService *categoryService) CreateCategory(ctx context.Context, reqDTO *dto.CreateCategoryRequest) error {
-	if _, err := categoryService.categoryRepository.GetByName(ctx, reqDTO.Body.Name); err != nil {
+	if _, err := categoryService.categoryRepository.GetByName(ctx, reqDTO.Body.Name); err == nil {
 		return fmt.Errorf("name of category already exists")
 	}
 
@@ -78,9 +81,12 @@ func (categoryService *categoryService) UpdateCategoryById(ctx context.Context,
 		return fmt.Errorf("id of category not found")
 	}
 
-	if reqDTO.Body.Name != nil && strings.EqualFold(foundCategory.Name, *reqDTO.Body.Name) {
-		if _, err := categoryService.categoryRepository.GetByName(ctx, *reqDTO.Body.Name); err == nil {
-			return fmt.Errorf("name of category already exists")
+	if reqDTO.Body.Name != nil {
+		// Names are unique ignoring case, so changing only the case must not find the category itself
+		if !strings.EqualFold(foundCategory.Name, *reqDTO.Body.Name) {
+			if _, err := categoryService.categoryRepository.GetByName(ctx, *reqDTO.Body.Name); err == nil {
+				return fmt.Errorf("name of category already exists")
+			}
 		}
 		foundCategory.Name = *reqDTO.Body.Name
 	}
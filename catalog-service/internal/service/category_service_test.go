@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+	"thanhldt060802/internal/dto"
+)
+
+func TestCategoryService_GetCategories(t *testing.T) {
+	tests := []struct {
+		name      string
+		sortBy    string
+		wantNames []string
+		wantErr   string
+	}{
+		{name: "by id", wantNames: []string{"Shirts", "Hats", "Shoes"}},
+		{name: "by name", sortBy: "name", wantNames: []string{"Hats", "Shirts", "Shoes"}},
+		{name: "newest first", sortBy: "id:desc", wantNames: []string{"Shoes", "Hats", "Shirts"}},
+		{name: "unknown sort field", sortBy: "price", wantErr: "can not sort by price"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			categoryService := NewCategoryService(f.categoryRepository)
+
+			categories, page, err := categoryService.GetCategories(context.Background(), &dto.GetCategoriesRequest{
+				Limit: 10, SortBy: tt.sortBy, IncludeTotal: true,
+			})
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			names := []string{}
+			for _, category := range categories {
+				names = append(names, category.Name)
+			}
+			if !slices.Equal(names, tt.wantNames) {
+				t.Errorf("names = %v, want %v", names, tt.wantNames)
+			}
+			if *page.Total != int64(len(tt.wantNames)) {
+				t.Errorf("total = %d, want %d", *page.Total, len(tt.wantNames))
+			}
+		})
+	}
+}
+
+func TestCategoryService_GetCategoryById(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       int64
+		wantName string
+		wantErr  error
+	}{
+		{name: "found", id: 2, wantName: "Hats"},
+		{name: "unknown", id: 99, wantErr: sql.ErrNoRows},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			categoryService := NewCategoryService(f.categoryRepository)
+
+			category, err := categoryService.GetCategoryById(context.Background(), &dto.GetCategoryByIdRequest{Id: tt.id})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && category.Name != tt.wantName {
+				t.Errorf("category = %s, want %s", category.Name, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestCategoryService_GetCategoryByName(t *testing.T) {
+	tests := []struct {
+		name         string
+		categoryName string
+		wantId       int64
+		wantErr      error
+	}{
+		{name: "found", categoryName: "Hats", wantId: 2},
+		{name: "ignoring case", categoryName: "hATS", wantId: 2},
+		{name: "unknown", categoryName: "Bags", wantErr: sql.ErrNoRows},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			categoryService := NewCategoryService(f.categoryRepository)
+
+			category, err := categoryService.GetCategoryByName(context.Background(), &dto.GetCategoryByNameRequest{Name: tt.categoryName})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && category.Id != tt.wantId {
+				t.Errorf("category = %d, want %d", category.Id, tt.wantId)
+			}
+		})
+	}
+}
+
+func TestCategoryService_CreateCategory(t *testing.T) {
+	tests := []struct {
+		name         string
+		categoryName string
+		wantErr      string
+	}{
+		{name: "created", categoryName: "Bags"},
+		{name: "duplicate", categoryName: "Hats", wantErr: "name of category already exists"},
+		{name: "duplicate ignoring case", categoryName: "hats", wantErr: "name of category already exists"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			categoryService := NewCategoryService(f.categoryRepository)
+
+			reqDTO := &dto.CreateCategoryRequest{}
+			reqDTO.Body.Name = tt.categoryName
+
+			checkErr(t, categoryService.CreateCategory(context.Background(), reqDTO), tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			category, err := f.categoryRepository.GetByName(context.Background(), tt.categoryName)
+			if err != nil {
+				t.Fatalf("created category: %v", err)
+			}
+			if category.Id != 4 || category.CreatedAt.IsZero() {
+				t.Errorf("category = %+v, want id 4 with created_at", category)
+			}
+		})
+	}
+}
+
+func TestCategoryService_UpdateCategoryById(t *testing.T) {
+	tests := []struct {
+		name         string
+		id           int64
+		categoryName *string
+		wantName     string
+		wantErr      string
+	}{
+		{name: "renamed", id: 2, categoryName: ptr("Caps"), wantName: "Caps"},
+		{name: "only case changed", id: 2, categoryName: ptr("HATS"), wantName: "HATS"},
+		{name: "nothing changed", id: 2, wantName: "Hats"},
+		{name: "name of another category", id: 2, categoryName: ptr("shirts"), wantErr: "name of category already exists"},
+		{name: "unknown", id: 99, categoryName: ptr("Caps"), wantErr: "id of category not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			categoryService := NewCategoryService(f.categoryRepository)
+
+			reqDTO := &dto.UpdateCategoryByIdRequest{Id: tt.id}
+			reqDTO.Body.Name = tt.categoryName
+
+			checkErr(t, categoryService.UpdateCategoryById(context.Background(), reqDTO), tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			category, err := f.categoryRepository.GetById(context.Background(), tt.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if category.Name != tt.wantName {
+				t.Errorf("name = %s, want %s", category.Name, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestCategoryService_DeleteCategoryById(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      int64
+		wantErr string
+	}{
+		{name: "deleted", id: 3},
+		{name: "unknown", id: 99, wantErr: "id of category not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			categoryService := NewCategoryService(f.categoryRepository)
+
+			checkErr(t, categoryService.DeleteCategoryById(context.Background(), &dto.DeleteCategoryByIdRequest{Id: tt.id}), tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			if _, err := f.categoryRepository.GetById(context.Background(), tt.id); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("category still exists: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"thanhldt060802/internal/dto"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestOutboxService_DispatchOutboxEvents(t *testing.T) {
+	tests := []struct {
+		name            string
+		outboxEvents    []model.OutboxEvent
+		wantDispatched  int
+		wantIds         []int64
+		wantPending     int
+		wantLastError   string
+		wantNextAttempt bool
+		wantPublished   []int64
+
+		elasticsearchDown bool
+		claimed           bool
+	}{
+		{name: "upsert", outboxEvents: []model.OutboxEvent{
+			{AggregateType: model.OutboxAggregateTypeProduct, AggregateId: 1, EventType: model.OutboxEventTypeUpsert},
+			{AggregateType: model.OutboxAggregateTypeProduct, AggregateId: 3, EventType: model.OutboxEventTypeUpsert},
+		}, wantDispatched: 2, wantIds: []int64{1, 3}},
+		{name: "upsert of deleted product removes it", outboxEvents: []model.OutboxEvent{
+			{AggregateType: model.OutboxAggregateTypeProduct, AggregateId: 1, EventType: model.OutboxEventTypeUpsert},
+			{AggregateType: model.OutboxAggregateTypeProduct, AggregateId: 99, EventType: model.OutboxEventTypeUpsert},
+		}, wantDispatched: 2, wantIds: []int64{1}},
+		{name: "delete", outboxEvents: []model.OutboxEvent{
+			{AggregateType: model.OutboxAggregateTypeProduct, AggregateId: 1, EventType: model.OutboxEventTypeUpsert},
+			{AggregateType: model.OutboxAggregateTypeProduct, AggregateId: 1, EventType: model.OutboxEventTypeDelete},
+			{AggregateType: model.OutboxAggregateTypeProduct, AggregateId: 1, EventType: model.OutboxEventTypePublishDeleted},
+		}, wantDispatched: 3, wantIds: []int64{}, wantPublished: []int64{1}},
+		{name: "publish is not held back by elasticsearch outage", outboxEvents: []model.OutboxEvent{
+			{AggregateType: model.OutboxAggregateTypeProduct, AggregateId: 1, EventType: model.OutboxEventTypeDelete},
+			{AggregateType: model.OutboxAggregateTypeProduct, AggregateId: 1, EventType: model.OutboxEventTypePublishDeleted},
+		}, wantDispatched: 1, wantIds: []int64{}, wantPending: 1, wantLastError: "elasticsearch is down", wantNextAttempt: true,
+			wantPublished: []int64{1}, elasticsearchDown: true},
+		{name: "not due yet", outboxEvents: []model.OutboxEvent{
+			{AggregateType: model.OutboxAggregateTypeProduct, AggregateId: 1, EventType: model.OutboxEventTypeUpsert, NextAttemptAt: time.Now().UTC().Add(time.Minute)},
+		}, wantDispatched: 0, wantIds: []int64{}, wantPending: 1},
+		{name: "claimed by a stopped dispatcher is left until its lease ends", outboxEvents: []model.OutboxEvent{
+			{AggregateType: model.OutboxAggregateTypeProduct, AggregateId: 1, EventType: model.OutboxEventTypeUpsert},
+		}, wantDispatched: 0, wantIds: []int64{}, wantPending: 1, claimed: true},
+		{name: "unsupported aggregate type is retried later", outboxEvents: []model.OutboxEvent{
+			{AggregateType: "CATEGORY", AggregateId: 1, EventType: model.OutboxEventTypeUpsert},
+		}, wantDispatched: 0, wantIds: []int64{}, wantPending: 1, wantLastError: "aggregate type CATEGORY is not supported", wantNextAttempt: true},
+		{name: "unsupported event type is dead after max attempts", outboxEvents: []model.OutboxEvent{
+			{AggregateType: model.OutboxAggregateTypeProduct, AggregateId: 1, EventType: "PATCH", Attempts: 1},
+		}, wantDispatched: 0, wantIds: []int64{}, wantPending: 0},
+		{name: "one batch per run", outboxEvents: slices.Repeat([]model.OutboxEvent{
+			{AggregateType: model.OutboxAggregateTypeProduct, AggregateId: 2, EventType: model.OutboxEventTypeUpsert},
+		}, 12), wantDispatched: 10, wantIds: []int64{2}, wantPending: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			if tt.elasticsearchDown {
+				f.productElasticsearchRepository = &downProductElasticsearchRepository{f.productElasticsearchRepository}
+			}
+			for i := range tt.outboxEvents {
+				tt.outboxEvents[i].Status = model.OutboxEventStatusPending
+			}
+			if err := f.outboxEventRepository.CreateMany(context.Background(), tt.outboxEvents); err != nil {
+				t.Fatal(err)
+			}
+			if tt.claimed {
+				now := time.Now().UTC()
+				if _, err := f.outboxEventRepository.ClaimDue(context.Background(), now, now.Add(time.Minute), 10); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Elasticsearch and the product event stream must not be called while a transaction holds claimed rows
+			transactionManager := &txTrackingTransactionManager{TransactionManager: f.transactionManager}
+			f.transactionManager = transactionManager
+			f.productElasticsearchRepository = &outsideTxProductElasticsearchRepository{f.productElasticsearchRepository, transactionManager}
+			f.productEventRepository = &outsideTxProductEventRepository{f.productEventRepository, transactionManager}
+
+			dispatched, err := f.newOutboxService().DispatchOutboxEvents(context.Background())
+			checkErr(t, err, "")
+			if dispatched != tt.wantDispatched {
+				t.Errorf("dispatched = %d, want %d", dispatched, tt.wantDispatched)
+			}
+
+			result, _, err := f.newProductService().GetProductsWithElasticsearch(context.Background(), &dto.GetProductsWithElasticsearchRequest{
+				Limit: 10, SortBy: "id", PriceInterval: 500000,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ids := productIdsOf(result.Products); !slices.Equal(ids, tt.wantIds) {
+				t.Errorf("indexed ids = %v, want %v", ids, tt.wantIds)
+			}
+
+			pendingOutboxEvents := f.pendingOutboxEvents(t)
+			if len(pendingOutboxEvents) != tt.wantPending {
+				t.Fatalf("got %d pending outbox events, want %d", len(pendingOutboxEvents), tt.wantPending)
+			}
+			if tt.wantLastError != "" && pendingOutboxEvents[0].LastError != tt.wantLastError {
+				t.Errorf("last error = %s, want %s", pendingOutboxEvents[0].LastError, tt.wantLastError)
+			}
+			if tt.wantNextAttempt && (pendingOutboxEvents[0].Attempts != 1 || !pendingOutboxEvents[0].NextAttemptAt.After(time.Now().UTC())) {
+				t.Errorf("outbox event = %+v, want one attempt and next attempt backed off", pendingOutboxEvents[0])
+			}
+
+			if published := f.publishedProductIds(t, model.ProductEventTypeDeleted); !slices.Equal(published, tt.wantPublished) {
+				t.Errorf("published deleted product ids = %v, want %v", published, tt.wantPublished)
+			}
+		})
+	}
+}
+
+func TestOutboxService_TrimsAcknowledgedProductEvents(t *testing.T) {
+	tests := []struct {
+		name          string
+		group         bool
+		read          int64
+		ack           int
+		wantPublished []int64
+	}{
+		{name: "kept until a group exists", wantPublished: []int64{1, 2, 3}},
+		{name: "kept until delivered", group: true, wantPublished: []int64{1, 2, 3}},
+		{name: "kept from oldest unacknowledged", group: true, read: 3, ack: 1, wantPublished: []int64{2, 3}},
+		{name: "acknowledged are trimmed", group: true, read: 2, ack: 2, wantPublished: []int64{2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			ctx := context.Background()
+			for _, productId := range []int64{1, 2, 3} {
+				f.productEventRepository.Publish(ctx, &model.ProductEvent{Type: model.ProductEventTypeDeleted, ProductId: productId})
+			}
+			if tt.group {
+				f.redisClient.XGroupCreate(ctx, model.ProductEventStream, "customer-service", "0")
+			}
+			if tt.read > 0 {
+				streams, err := f.redisClient.XReadGroup(ctx, &redis.XReadGroupArgs{
+					Group: "customer-service", Consumer: "customer-1", Streams: []string{model.ProductEventStream, ">"}, Count: tt.read,
+				}).Result()
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, message := range streams[0].Messages[:tt.ack] {
+					f.redisClient.XAck(ctx, model.ProductEventStream, "customer-service", message.ID)
+				}
+			}
+
+			if _, err := f.newOutboxService().DispatchOutboxEvents(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if published := f.publishedProductIds(t, model.ProductEventTypeDeleted); !slices.Equal(published, tt.wantPublished) {
+				t.Errorf("product ids left in stream = %v, want %v", published, tt.wantPublished)
+			}
+		})
+	}
+}
+
+type downProductElasticsearchRepository struct {
+	repository.ProductElasticsearchRepository
+}
+
+func (productElasticsearchRepository *downProductElasticsearchRepository) SyncDeletingById(ctx context.Context, id int64) error {
+	return errors.New("elasticsearch is down")
+}
+
+type txTrackingTransactionManager struct {
+	repository.TransactionManager
+	open bool
+}
+
+func (transactionManager *txTrackingTransactionManager) RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	transactionManager.open = true
+	defer func() { transactionManager.open = false }()
+
+	return transactionManager.TransactionManager.RunInTx(ctx, fn)
+}
+
+type outsideTxProductElasticsearchRepository struct {
+	repository.ProductElasticsearchRepository
+	transactionManager *txTrackingTransactionManager
+}
+
+func (productElasticsearchRepository *outsideTxProductElasticsearchRepository) SyncUpdating(ctx context.Context, updatedProduct *model.Product) error {
+	if productElasticsearchRepository.transactionManager.open {
+		return errors.New("elasticsearch called inside transaction")
+	}
+	return productElasticsearchRepository.ProductElasticsearchRepository.SyncUpdating(ctx, updatedProduct)
+}
+
+func (productElasticsearchRepository *outsideTxProductElasticsearchRepository) SyncDeletingById(ctx context.Context, id int64) error {
+	if productElasticsearchRepository.transactionManager.open {
+		return errors.New("elasticsearch called inside transaction")
+	}
+	return productElasticsearchRepository.ProductElasticsearchRepository.SyncDeletingById(ctx, id)
+}
+
+type outsideTxProductEventRepository struct {
+	repository.ProductEventRepository
+	transactionManager *txTrackingTransactionManager
+}
+
+func (productEventRepository *outsideTxProductEventRepository) Publish(ctx context.Context, productEvent *model.ProductEvent) error {
+	if productEventRepository.transactionManager.open {
+		return errors.New("product event stream called inside transaction")
+	}
+	return productEventRepository.ProductEventRepository.Publish(ctx, productEvent)
+}
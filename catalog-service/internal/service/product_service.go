@@ -2,52 +2,97 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"thanhldt060802/config"
 	"thanhldt060802/internal/dto"
 	"thanhldt060802/internal/model"
 	"thanhldt060802/internal/repository"
 	"thanhldt060802/utils"
 	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
+// Rows read from database per bulk request when reindexing
+const reindexBatchSize = 500
+
 type productService struct {
 	productRepository              repository.ProductRepository
 	productElasticsearchRepository repository.ProductElasticsearchRepository
 
 	categoryRepository repository.CategoryRepository
+
+	stockReservationRepository repository.StockReservationRepository
+	outboxEventRepository      repository.OutboxEventRepository
+	transactionManager         repository.TransactionManager
+
+	redisClient *redis.Client
+	appConfig   *config.Config
 }
 
 type ProductService interface {
-	GetProducts(ctx context.Context, reqDTO *dto.GetProductsRequest) ([]model.Product, error)
+	GetProducts(ctx context.Context, reqDTO *dto.GetProductsRequest) ([]model.Product, *utils.Page, error)
 	GetProductById(ctx context.Context, reqDTO *dto.GetProductByIdRequest) (*model.Product, error)
-	GetProductsByCategoryId(ctx context.Context, reqDTO *dto.GetProductsByCategoryIdRequest) ([]model.Product, error)
+	GetProductsByIds(ctx context.Context, reqDTO *dto.GetProductsByIdsRequest) ([]model.Product, error)
+	GetProductsByCategoryId(ctx context.Context, reqDTO *dto.GetProductsByCategoryIdRequest) ([]model.Product, *utils.Page, error)
 	CreateProduct(ctx context.Context, reqDTO *dto.CreateProductRequest) error
 	UpdateProductById(ctx context.Context, reqDTO *dto.UpdateProductByIdRequest) error
 	DeleteProductById(ctx context.Context, reqDTO *dto.DeleteProductByIdRequest) error
 
 	SyncAllProductsToElasticsearch(ctx context.Context) error
 
-	GetProductsWithElasticsearch(ctx context.Context, reqDTO *dto.GetProductsWithElasticsearchRequest) ([]model.Product, error)
+	GetProductsWithElasticsearch(ctx context.Context, reqDTO *dto.GetProductsWithElasticsearchRequest) (*model.ProductElasticsearchResult, *utils.Page, error)
+	SuggestProducts(ctx context.Context, reqDTO *dto.SuggestProductsRequest) (*model.ProductSuggestion, error)
+
+	ReserveStock(ctx context.Context, reqDTO *dto.CreateStockReservationRequest) (*model.StockReservation, error)
+	GetStockReservationById(ctx context.Context, reqDTO *dto.GetStockReservationByIdRequest) (*model.StockReservation, error)
+	ConfirmStockReservationById(ctx context.Context, reqDTO *dto.ConfirmStockReservationByIdRequest) error
+	ReleaseStockReservationById(ctx context.Context, reqDTO *dto.ReleaseStockReservationByIdRequest) error
+	ReleaseExpiredStockReservations(ctx context.Context) (int, error)
 }
 
-func NewProductService(productRepository repository.ProductRepository, productElasticsearchRepository repository.ProductElasticsearchRepository, categoryRepository repository.CategoryRepository) ProductService {
+func NewProductService(
+	productRepository repository.ProductRepository,
+	productElasticsearchRepository repository.ProductElasticsearchRepository,
+	categoryRepository repository.CategoryRepository,
+	stockReservationRepository repository.StockReservationRepository,
+	outboxEventRepository repository.OutboxEventRepository,
+	transactionManager repository.TransactionManager,
+	redisClient *redis.Client,
+	appConfig *config.Config,
+) ProductService {
 	return &productService{
 		productRepository:              productRepository,
 		productElasticsearchRepository: productElasticsearchRepository,
 
 		categoryRepository: categoryRepository,
+
+		stockReservationRepository: stockReservationRepository,
+		outboxEventRepository:      outboxEventRepository,
+		transactionManager:         transactionManager,
+
+		redisClient: redisClient,
+		appConfig:   appConfig,
 	}
 }
 
-func (productService *productService) GetProducts(ctx context.Context, reqDTO *dto.GetProductsRequest) ([]model.Product, error) {
-	sortFields := utils.ParseSortBy(reqDTO.SortBy)
+func (productService *productService) GetProducts(ctx context.Context, reqDTO *dto.GetProductsRequest) ([]model.Product, *utils.Page, error) {
+	pageRequest, err := utils.NewPageRequest(reqDTO.Cursor, reqDTO.Limit, reqDTO.SortBy, reqDTO.IncludeTotal)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	products, err := productService.productRepository.Get(ctx, reqDTO.Offset, reqDTO.Limit, sortFields)
+	products, page, err := productService.productRepository.Get(ctx, pageRequest)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return products, nil
+	return products, page, nil
 }
 
 func (productService *productService) GetProductById(ctx context.Context, reqDTO *dto.GetProductByIdRequest) (*model.Product, error) {
@@ -59,10 +104,8 @@ func (productService *productService) GetProductById(ctx context.Context, reqDTO
 	return foundProduct, nil
 }
 
-func (productService *productService) GetProductsByCategoryId(ctx context.Context, reqDTO *dto.GetProductsByCategoryIdRequest) ([]model.Product, error) {
-	sortFields := utils.ParseSortBy(reqDTO.SortBy)
-
-	products, err := productService.productRepository.GetByCategoryId(ctx, reqDTO.CategoryId, reqDTO.Offset, reqDTO.Limit, sortFields)
+func (productService *productService) GetProductsByIds(ctx context.Context, reqDTO *dto.GetProductsByIdsRequest) ([]model.Product, error) {
+	products, err := productService.productRepository.GetByIds(ctx, reqDTO.Ids)
 	if err != nil {
 		return nil, err
 	}
@@ -70,6 +113,20 @@ func (productService *productService) GetProductsByCategoryId(ctx context.Contex
 	return products, nil
 }
 
+func (productService *productService) GetProductsByCategoryId(ctx context.Context, reqDTO *dto.GetProductsByCategoryIdRequest) ([]model.Product, *utils.Page, error) {
+	pageRequest, err := utils.NewPageRequest(reqDTO.Cursor, reqDTO.Limit, reqDTO.SortBy, reqDTO.IncludeTotal)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	products, page, err := productService.productRepository.GetByCategoryId(ctx, reqDTO.CategoryId, pageRequest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return products, page, nil
+}
+
 func (productService *productService) CreateProduct(ctx context.Context, reqDTO *dto.CreateProductRequest) error {
 	if _, err := productService.categoryRepository.GetById(ctx, reqDTO.Body.CategoryId); err != nil {
 		return fmt.Errorf("id of category not found")
@@ -85,102 +142,456 @@ func (productService *productService) CreateProduct(ctx context.Context, reqDTO
 		ImageURL:           reqDTO.Body.ImageURL,
 		CategoryId:         reqDTO.Body.CategoryId,
 	}
-	if err := productService.productRepository.Create(ctx, &newProduct); err != nil {
-		return err
+
+	return productService.transactionManager.RunInTx(ctx, func(ctx context.Context) error {
+		if err := productService.productRepository.Create(ctx, &newProduct); err != nil {
+			return err
+		}
+
+		return productService.outboxEventRepository.CreateMany(ctx, newProductOutboxEvents(model.OutboxEventTypeUpsert, newProduct.Id))
+	})
+}
+
+// Row is read locked inside the transaction, a cached or stale copy would write back old stock
+func (productService *productService) UpdateProductById(ctx context.Context, reqDTO *dto.UpdateProductByIdRequest) error {
+	if reqDTO.Body.CategoryId != nil {
+		if _, err := productService.categoryRepository.GetById(ctx, *reqDTO.Body.CategoryId); err != nil {
+			return fmt.Errorf("id of category not found")
+		}
 	}
 
-	if err := productService.productElasticsearchRepository.SyncCreating(ctx, &newProduct); err != nil {
-		return err
+	return productService.transactionManager.RunInTx(ctx, func(ctx context.Context) error {
+		foundProducts, err := productService.productRepository.GetByIdsForUpdate(ctx, []int64{reqDTO.Id})
+		if err != nil {
+			return err
+		}
+		if len(foundProducts) == 0 {
+			return fmt.Errorf("id of product not found")
+		}
+		foundProduct := &foundProducts[0]
+
+		if reqDTO.Body.Name != nil {
+			foundProduct.Name = *reqDTO.Body.Name
+		}
+		if reqDTO.Body.Description != nil {
+			foundProduct.Description = *reqDTO.Body.Description
+		}
+		if reqDTO.Body.Sex != nil {
+			foundProduct.Sex = *reqDTO.Body.Sex
+		}
+		if reqDTO.Body.Price != nil {
+			foundProduct.Price = *reqDTO.Body.Price
+		}
+		if reqDTO.Body.DiscountPercentage != nil {
+			foundProduct.DiscountPercentage = *reqDTO.Body.DiscountPercentage
+		}
+		if reqDTO.Body.Stock != nil {
+			foundProduct.Stock = *reqDTO.Body.Stock
+		}
+		if reqDTO.Body.ImageURL != nil {
+			foundProduct.ImageURL = *reqDTO.Body.ImageURL
+		}
+		if reqDTO.Body.CategoryId != nil {
+			foundProduct.CategoryId = *reqDTO.Body.CategoryId
+		}
+		foundProduct.UpdatedAt = time.Now().UTC()
+
+		if err := productService.productRepository.Update(ctx, foundProduct); err != nil {
+			return err
+		}
+
+		return productService.outboxEventRepository.CreateMany(ctx, newProductOutboxEvents(model.OutboxEventTypeUpsert, foundProduct.Id))
+	})
+}
+
+func (productService *productService) DeleteProductById(ctx context.Context, reqDTO *dto.DeleteProductByIdRequest) error {
+	if _, err := productService.productRepository.GetById(ctx, reqDTO.Id); err != nil {
+		return fmt.Errorf("id of product not found")
 	}
 
-	return nil
+	return productService.transactionManager.RunInTx(ctx, func(ctx context.Context) error {
+		if err := productService.productRepository.DeleteById(ctx, reqDTO.Id); err != nil {
+			return err
+		}
+
+		outboxEvents := append(
+			newProductOutboxEvents(model.OutboxEventTypeDelete, reqDTO.Id),
+			newProductOutboxEvents(model.OutboxEventTypePublishDeleted, reqDTO.Id)...,
+		)
+		return productService.outboxEventRepository.CreateMany(ctx, outboxEvents)
+	})
 }
 
-func (productService *productService) UpdateProductById(ctx context.Context, reqDTO *dto.UpdateProductByIdRequest) error {
-	foundProduct, err := productService.productRepository.GetById(ctx, reqDTO.Id)
+func (productService *productService) SyncAllProductsToElasticsearch(ctx context.Context) error {
+	startedAt := time.Now().UTC()
+
+	newIndex, err := productService.productElasticsearchRepository.CreateNextVersionIndex(ctx)
 	if err != nil {
-		return fmt.Errorf("id of product not found")
+		return err
 	}
 
-	if reqDTO.Body.Name != nil {
-		foundProduct.Name = *reqDTO.Body.Name
+	if err := productService.buildProductsIndex(ctx, newIndex); err != nil {
+		// Drop the half built index so the next run starts clean
+		if err := productService.productElasticsearchRepository.DeleteIndices(ctx, []string{newIndex}); err != nil {
+			log.Printf("Delete index %s failed: %s", newIndex, err.Error())
+		}
+		return err
 	}
-	if reqDTO.Body.Description != nil {
-		foundProduct.Description = *reqDTO.Body.Description
+
+	if _, err := productService.productElasticsearchRepository.SwitchAlias(ctx, newIndex); err != nil {
+		return err
 	}
-	if reqDTO.Body.Sex != nil {
-		foundProduct.Sex = *reqDTO.Body.Sex
+
+	// Changes dispatched while building went to the old index only, replay them on the new one
+	productIds, err := productService.outboxEventRepository.GetAggregateIdsSince(ctx, model.OutboxAggregateTypeProduct, startedAt)
+	if err != nil {
+		return err
 	}
-	if reqDTO.Body.Price != nil {
-		foundProduct.Price = *reqDTO.Body.Price
+	if err := productService.outboxEventRepository.CreateMany(ctx, newProductOutboxEvents(model.OutboxEventTypeUpsert, productIds...)); err != nil {
+		return err
 	}
-	if reqDTO.Body.DiscountPercentage != nil {
-		foundProduct.DiscountPercentage = *reqDTO.Body.DiscountPercentage
+
+	versionIndices, err := productService.productElasticsearchRepository.GetVersionIndices(ctx)
+	if err != nil {
+		return err
 	}
-	if reqDTO.Body.Stock != nil {
-		foundProduct.Stock = *reqDTO.Body.Stock
+	oldIndices := make([]string, 0, len(versionIndices))
+	for _, versionIndex := range versionIndices {
+		if versionIndex != newIndex {
+			oldIndices = append(oldIndices, versionIndex)
+		}
 	}
-	if reqDTO.Body.ImageURL != nil {
-		foundProduct.ImageURL = *reqDTO.Body.ImageURL
+	if err := productService.productElasticsearchRepository.DeleteIndices(ctx, oldIndices); err != nil {
+		log.Printf("Clean up old products indices failed: %s", err.Error())
 	}
-	if reqDTO.Body.CategoryId != nil {
-		if _, err := productService.categoryRepository.GetById(ctx, *reqDTO.Body.CategoryId); err != nil {
-			return fmt.Errorf("id of category not found")
+
+	return nil
+}
+
+func (productService *productService) buildProductsIndex(ctx context.Context, index string) error {
+	indexed := int64(0)
+	afterId := int64(0)
+	for {
+		products, err := productService.productRepository.GetAll(ctx, afterId, reindexBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(products) == 0 {
+			break
 		}
-		foundProduct.CategoryId = *reqDTO.Body.CategoryId
-	}
-	foundProduct.UpdatedAt = time.Now().UTC()
 
-	if err := productService.productRepository.Update(ctx, foundProduct); err != nil {
-		return err
+		if err := productService.productElasticsearchRepository.BulkIndex(ctx, index, products); err != nil {
+			return err
+		}
+
+		indexed += int64(len(products))
+		afterId = products[len(products)-1].Id
 	}
 
-	if err := productService.productElasticsearchRepository.SyncUpdating(ctx, foundProduct); err != nil {
+	count, err := productService.productElasticsearchRepository.Count(ctx, index)
+	if err != nil {
 		return err
 	}
+	if count != indexed {
+		return fmt.Errorf("index %s has %d documents but %d products were indexed", index, count, indexed)
+	}
 
 	return nil
 }
 
-func (productService *productService) DeleteProductById(ctx context.Context, reqDTO *dto.DeleteProductByIdRequest) error {
-	if _, err := productService.productRepository.GetById(ctx, reqDTO.Id); err != nil {
-		return fmt.Errorf("id of product not found")
+func (productService *productService) GetProductsWithElasticsearch(ctx context.Context, reqDTO *dto.GetProductsWithElasticsearchRequest) (*model.ProductElasticsearchResult, *utils.Page, error) {
+	// Search always counts total along with facets
+	pageRequest, err := utils.NewPageRequest(reqDTO.Cursor, reqDTO.Limit, reqDTO.SortBy, true)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if err := productService.productRepository.DeleteById(ctx, reqDTO.Id); err != nil {
-		return err
+	filter := &model.ProductElasticsearchFilter{
+		Query:         reqDTO.Q,
+		Name:          reqDTO.Name,
+		PriceGTE:      reqDTO.PriceGTE,
+		PriceLTE:      reqDTO.PriceLTE,
+		CreatedAtGTE:  reqDTO.CreatedAtGTE,
+		CreatedAtLTE:  reqDTO.CreatedAtLTE,
+		CategoryIds:   reqDTO.CategoryIds,
+		Sexes:         reqDTO.Sexes,
+		PriceBuckets:  reqDTO.PriceBuckets,
+		PriceInterval: reqDTO.PriceInterval,
+		OnSale:        reqDTO.OnSale,
 	}
 
-	if err := productService.productElasticsearchRepository.SyncDeletingById(ctx, reqDTO.Id); err != nil {
-		return err
+	result, page, err := productService.productElasticsearchRepository.Get(ctx, pageRequest, filter)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return nil
+	// Resolve category names of category facets
+	if len(result.CategoryFacets) > 0 {
+		categoryIds := make([]int64, len(result.CategoryFacets))
+		for i, bucket := range result.CategoryFacets {
+			categoryIds[i] = bucket.CategoryId
+		}
+
+		categories, err := productService.categoryRepository.GetByIds(ctx, categoryIds)
+		if err != nil {
+			return nil, nil, err
+		}
+		categoryNames := make(map[int64]string, len(categories))
+		for _, category := range categories {
+			categoryNames[category.Id] = category.Name
+		}
+
+		for i := range result.CategoryFacets {
+			result.CategoryFacets[i].CategoryName = categoryNames[result.CategoryFacets[i].CategoryId]
+		}
+	}
+
+	return result, page, nil
 }
 
-func (productService *productService) SyncAllProductsToElasticsearch(ctx context.Context) error {
-	products, err := productService.productRepository.GetAll(ctx)
+func (productService *productService) SuggestProducts(ctx context.Context, reqDTO *dto.SuggestProductsRequest) (*model.ProductSuggestion, error) {
+	redisKey := fmt.Sprintf("product-suggest:%d:%s", reqDTO.Limit, strings.ToLower(strings.TrimSpace(reqDTO.Q)))
+
+	// Typeahead sends a request per keystroke, serve repeated prefixes from cache
+	if suggestionJson, err := productService.redisClient.Get(ctx, redisKey).Bytes(); err == nil {
+		var suggestion model.ProductSuggestion
+		if err := json.Unmarshal(suggestionJson, &suggestion); err == nil {
+			return &suggestion, nil
+		}
+	}
+
+	suggestion, err := productService.productElasticsearchRepository.Suggest(ctx, reqDTO.Q, reqDTO.Limit)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err := productService.productElasticsearchRepository.SyncAll(ctx, products); err != nil {
-		return err
+	// Resolve category names of suggested categories
+	if len(suggestion.Categories) > 0 {
+		categoryIds := make([]int64, len(suggestion.Categories))
+		for i, category := range suggestion.Categories {
+			categoryIds[i] = category.Id
+		}
+
+		categories, err := productService.categoryRepository.GetByIds(ctx, categoryIds)
+		if err != nil {
+			return nil, err
+		}
+		categoryNames := make(map[int64]string, len(categories))
+		for _, category := range categories {
+			categoryNames[category.Id] = category.Name
+		}
+
+		for i := range suggestion.Categories {
+			suggestion.Categories[i].Name = categoryNames[suggestion.Categories[i].Id]
+		}
 	}
 
-	return nil
+	// Categories named like the query come first, even when none of their products matches it
+	namedCategories, err := productService.categoryRepository.GetByNamePrefix(ctx, strings.TrimSpace(reqDTO.Q), reqDTO.Limit)
+	if err != nil {
+		return nil, err
+	}
+	categorySuggestions := make([]model.CategorySuggestionItem, 0, len(namedCategories)+len(suggestion.Categories))
+	for _, category := range namedCategories {
+		categorySuggestions = append(categorySuggestions, model.CategorySuggestionItem{Id: category.Id, Name: category.Name})
+	}
+	for _, categorySuggestion := range suggestion.Categories {
+		if !slices.ContainsFunc(categorySuggestions, func(item model.CategorySuggestionItem) bool { return item.Id == categorySuggestion.Id }) {
+			categorySuggestions = append(categorySuggestions, categorySuggestion)
+		}
+	}
+	if len(categorySuggestions) > reqDTO.Limit {
+		categorySuggestions = categorySuggestions[:reqDTO.Limit]
+	}
+	suggestion.Categories = categorySuggestions
+
+	if suggestionJson, err := json.Marshal(suggestion); err == nil {
+		if err := productService.redisClient.SetEx(ctx, redisKey, suggestionJson, *productService.appConfig.GetProductSuggestCacheExpireSeconds()).Err(); err != nil {
+			log.Printf("Cache product suggestion failed: %s", err.Error())
+		}
+	}
+
+	return suggestion, nil
 }
 
-func (productService *productService) GetProductsWithElasticsearch(ctx context.Context, reqDTO *dto.GetProductsWithElasticsearchRequest) ([]model.Product, error) {
-	sortFields := utils.ParseSortBy(reqDTO.SortBy)
+// Stock reservation
+
+func (productService *productService) ReserveStock(ctx context.Context, reqDTO *dto.CreateStockReservationRequest) (*model.StockReservation, error) {
+	// Merge duplicated products into one item
+	quantities := map[int64]int32{}
+	productIds := []int64{}
+	for _, item := range reqDTO.Body.Items {
+		if _, ok := quantities[item.ProductId]; !ok {
+			productIds = append(productIds, item.ProductId)
+		}
+		quantities[item.ProductId] += item.Quantity
+	}
+	sort.Slice(productIds, func(i, j int) bool { return productIds[i] < productIds[j] })
+
+	var newStockReservation model.StockReservation
+
+	err := productService.transactionManager.RunInTx(ctx, func(ctx context.Context) error {
+		products, err := productService.productRepository.GetByIdsForUpdate(ctx, productIds)
+		if err != nil {
+			return err
+		}
+		productMap := make(map[int64]model.Product, len(products))
+		for _, product := range products {
+			productMap[product.Id] = product
+		}
+
+		items := make([]model.StockReservationItem, len(productIds))
+		for i, productId := range productIds {
+			product, ok := productMap[productId]
+			if !ok {
+				return fmt.Errorf("product with id = %d not found", productId)
+			}
+			if product.Stock < quantities[productId] {
+				return fmt.Errorf("stock of product with id = %d is not enough", productId)
+			}
+
+			if err := productService.productRepository.AddStock(ctx, productId, -quantities[productId]); err != nil {
+				return err
+			}
+
+			items[i] = model.StockReservationItem{
+				ProductId: productId,
+				Quantity:  quantities[productId],
+			}
+		}
 
-	products, err := productService.productElasticsearchRepository.Get(ctx, reqDTO.Offset, reqDTO.Limit, sortFields,
-		reqDTO.Name, reqDTO.PriceGTE, reqDTO.PriceLTE, reqDTO.CreatedAtGTE, reqDTO.CreatedAtLTE)
+		newStockReservation = model.StockReservation{
+			Status:    model.StockReservationStatusPending,
+			ExpiresAt: time.Now().UTC().Add(*productService.appConfig.GetReservationExpireSeconds()),
+			Items:     items,
+		}
+		if err := productService.stockReservationRepository.Create(ctx, &newStockReservation); err != nil {
+			return err
+		}
+
+		return productService.outboxEventRepository.CreateMany(ctx, newProductOutboxEvents(model.OutboxEventTypeUpsert, productIds...))
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return products, nil
+	return &newStockReservation, nil
+}
+
+func (productService *productService) GetStockReservationById(ctx context.Context, reqDTO *dto.GetStockReservationByIdRequest) (*model.StockReservation, error) {
+	foundStockReservation, err := productService.stockReservationRepository.GetById(ctx, reqDTO.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return foundStockReservation, nil
+}
+
+func (productService *productService) ConfirmStockReservationById(ctx context.Context, reqDTO *dto.ConfirmStockReservationByIdRequest) error {
+	return productService.transactionManager.RunInTx(ctx, func(ctx context.Context) error {
+		foundStockReservation, err := productService.stockReservationRepository.GetByIdForUpdate(ctx, reqDTO.Id)
+		if err != nil {
+			return fmt.Errorf("id of stock reservation not found")
+		}
+
+		// A pending reservation past its expiry still holds its stock until it is swept, so a late confirm is accepted
+		switch foundStockReservation.Status {
+		case model.StockReservationStatusConfirmed:
+			return nil
+		case model.StockReservationStatusPending:
+		default:
+			return fmt.Errorf("stock reservation is already %s", strings.ToLower(foundStockReservation.Status))
+		}
+
+		// Stock was already taken when reserving, confirming only makes it permanent
+		foundStockReservation.Status = model.StockReservationStatusConfirmed
+		foundStockReservation.UpdatedAt = time.Now().UTC()
+
+		return productService.stockReservationRepository.Update(ctx, foundStockReservation)
+	})
+}
+
+func (productService *productService) ReleaseStockReservationById(ctx context.Context, reqDTO *dto.ReleaseStockReservationByIdRequest) error {
+	return productService.transactionManager.RunInTx(ctx, func(ctx context.Context) error {
+		foundStockReservation, err := productService.stockReservationRepository.GetByIdForUpdate(ctx, reqDTO.Id)
+		if err != nil {
+			return fmt.Errorf("id of stock reservation not found")
+		}
+
+		// Releasing a confirmed reservation puts its stock back, it is how a cancelled or refunded order returns goods
+		switch foundStockReservation.Status {
+		case model.StockReservationStatusReleased, model.StockReservationStatusExpired:
+			return nil
+		}
+
+		return productService.returnReservedStock(ctx, foundStockReservation, model.StockReservationStatusReleased)
+	})
+}
+
+func (productService *productService) ReleaseExpiredStockReservations(ctx context.Context) (int, error) {
+	expiredIds, err := productService.stockReservationRepository.GetExpiredIds(ctx, time.Now().UTC(), 100)
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, id := range expiredIds {
+		expired := false
+
+		err := productService.transactionManager.RunInTx(ctx, func(ctx context.Context) error {
+			foundStockReservation, err := productService.stockReservationRepository.GetByIdForUpdate(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			// Confirmed or released by someone else in the meantime
+			if foundStockReservation.Status != model.StockReservationStatusPending {
+				return nil
+			}
+
+			if err := productService.returnReservedStock(ctx, foundStockReservation, model.StockReservationStatusExpired); err != nil {
+				return err
+			}
+			expired = true
+
+			return nil
+		})
+		if err != nil {
+			log.Printf("Release expired stock reservation with id = %d failed: %s", id, err.Error())
+			continue
+		}
+
+		if expired {
+			released++
+		}
+	}
+
+	return released, nil
+}
+
+func (productService *productService) returnReservedStock(ctx context.Context, stockReservation *model.StockReservation, status string) error {
+	productIds := make([]int64, len(stockReservation.Items))
+	for i, item := range stockReservation.Items {
+		productIds[i] = item.ProductId
+	}
+
+	// Lock products in the same order as ReserveStock
+	if _, err := productService.productRepository.GetByIdsForUpdate(ctx, productIds); err != nil {
+		return err
+	}
+	for _, item := range stockReservation.Items {
+		if err := productService.productRepository.AddStock(ctx, item.ProductId, item.Quantity); err != nil {
+			return err
+		}
+	}
+
+	stockReservation.Status = status
+	stockReservation.UpdatedAt = time.Now().UTC()
+	if err := productService.stockReservationRepository.Update(ctx, stockReservation); err != nil {
+		return err
+	}
+
+	return productService.outboxEventRepository.CreateMany(ctx, newProductOutboxEvents(model.OutboxEventTypeUpsert, productIds...))
 }
 
 // func (productService *productService) SyncAllProductsToElasticsearch(ctx context.Context) error {
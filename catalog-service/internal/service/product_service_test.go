@@ -0,0 +1,696 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"thanhldt060802/internal/dto"
+	"thanhldt060802/internal/model"
+	"time"
+)
+
+func TestProductService_GetProducts(t *testing.T) {
+	tests := []struct {
+		name     string
+		sortBy   string
+		nextPage bool
+		wantIds  []int64
+		wantErr  string
+	}{
+		{name: "by id", wantIds: []int64{1, 2}},
+		{name: "next page by id", nextPage: true, wantIds: []int64{3}},
+		{name: "cheapest first", sortBy: "price", wantIds: []int64{2, 1}},
+		{name: "next page cheapest first", sortBy: "price", nextPage: true, wantIds: []int64{3}},
+		{name: "unknown sort field", sortBy: "rating", wantErr: "can not sort by rating"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			productService := f.newProductService()
+
+			products, page, err := productService.GetProducts(context.Background(), &dto.GetProductsRequest{Limit: 2, SortBy: tt.sortBy})
+			if tt.nextPage && err == nil {
+				products, _, err = productService.GetProducts(context.Background(), &dto.GetProductsRequest{
+					Cursor: page.NextCursor, Limit: 2, SortBy: tt.sortBy,
+				})
+			}
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			if ids := productIdsOf(products); !slices.Equal(ids, tt.wantIds) {
+				t.Errorf("ids = %v, want %v", ids, tt.wantIds)
+			}
+		})
+	}
+}
+
+func TestProductService_GetProductById(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      int64
+		wantErr error
+	}{
+		{name: "found", id: 3},
+		{name: "unknown", id: 99, wantErr: sql.ErrNoRows},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+
+			product, err := f.newProductService().GetProductById(context.Background(), &dto.GetProductByIdRequest{Id: tt.id})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && product.Id != tt.id {
+				t.Errorf("got product %d, want %d", product.Id, tt.id)
+			}
+		})
+	}
+}
+
+func TestProductService_GetProductsByIds(t *testing.T) {
+	tests := []struct {
+		name    string
+		ids     []int64
+		wantIds []int64
+	}{
+		{name: "in id order", ids: []int64{3, 1}, wantIds: []int64{1, 3}},
+		{name: "unknown ids are left out", ids: []int64{2, 99}, wantIds: []int64{2}},
+		{name: "none found", ids: []int64{99}, wantIds: []int64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+
+			products, err := f.newProductService().GetProductsByIds(context.Background(), &dto.GetProductsByIdsRequest{Ids: tt.ids})
+			checkErr(t, err, "")
+			if ids := productIdsOf(products); !slices.Equal(ids, tt.wantIds) {
+				t.Errorf("ids = %v, want %v", ids, tt.wantIds)
+			}
+		})
+	}
+}
+
+func TestProductService_GetProductsByCategoryId(t *testing.T) {
+	tests := []struct {
+		name       string
+		categoryId int64
+		sortBy     string
+		wantIds    []int64
+		wantErr    string
+	}{
+		{name: "shirts", categoryId: 1, wantIds: []int64{1, 2}},
+		{name: "shirts newest first", categoryId: 1, sortBy: "created_at:desc", wantIds: []int64{2, 1}},
+		{name: "empty category", categoryId: 3, wantIds: []int64{}},
+		{name: "unknown sort field", categoryId: 1, sortBy: "rating", wantErr: "can not sort by rating"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+
+			products, page, err := f.newProductService().GetProductsByCategoryId(context.Background(), &dto.GetProductsByCategoryIdRequest{
+				CategoryId: tt.categoryId, Limit: 10, SortBy: tt.sortBy, IncludeTotal: true,
+			})
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			if ids := productIdsOf(products); !slices.Equal(ids, tt.wantIds) {
+				t.Errorf("ids = %v, want %v", ids, tt.wantIds)
+			}
+			if *page.Total != int64(len(tt.wantIds)) {
+				t.Errorf("total = %d, want %d", *page.Total, len(tt.wantIds))
+			}
+		})
+	}
+}
+
+func TestProductService_CreateProduct(t *testing.T) {
+	tests := []struct {
+		name       string
+		categoryId int64
+		wantErr    string
+	}{
+		{name: "created", categoryId: 3},
+		{name: "unknown category", categoryId: 99, wantErr: "id of category not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+
+			reqDTO := &dto.CreateProductRequest{}
+			reqDTO.Body.Name = "Giày thể thao"
+			reqDTO.Body.Description = "Giày chạy bộ"
+			reqDTO.Body.Sex = "UNISEX"
+			reqDTO.Body.Price = 900000
+			reqDTO.Body.Stock = 3
+			reqDTO.Body.ImageURL = "sneaker.png"
+			reqDTO.Body.CategoryId = tt.categoryId
+
+			checkErr(t, f.newProductService().CreateProduct(context.Background(), reqDTO), tt.wantErr)
+			if tt.wantErr != "" {
+				if outboxEvents := f.pendingOutboxEvents(t); len(outboxEvents) != 0 {
+					t.Errorf("outbox events = %+v, want none", outboxEvents)
+				}
+				return
+			}
+			product, err := f.productRepository.GetById(context.Background(), 4)
+			if err != nil {
+				t.Fatalf("created product: %v", err)
+			}
+			if product.Name != reqDTO.Body.Name || product.CategoryId != tt.categoryId {
+				t.Errorf("product = %+v", product)
+			}
+			outboxEvents := f.pendingOutboxEvents(t)
+			if len(outboxEvents) != 1 || outboxEvents[0].AggregateId != 4 || outboxEvents[0].EventType != model.OutboxEventTypeUpsert {
+				t.Errorf("outbox events = %+v, want one upsert of product 4", outboxEvents)
+			}
+		})
+	}
+}
+
+func TestProductService_UpdateProductById(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      int64
+		body    func(reqDTO *dto.UpdateProductByIdRequest)
+		check   func(t *testing.T, product *model.Product)
+		wantErr string
+	}{
+		{name: "price and stock", id: 1, body: func(reqDTO *dto.UpdateProductByIdRequest) {
+			reqDTO.Body.Price = ptr(int64(300000))
+			reqDTO.Body.Stock = ptr(int32(0))
+		}, check: func(t *testing.T, product *model.Product) {
+			if product.Price != 300000 || product.Stock != 0 || product.Name != "Áo sơ mi trắng" {
+				t.Errorf("product = %+v, want only price and stock changed", product)
+			}
+		}},
+		{name: "moved to another category", id: 2, body: func(reqDTO *dto.UpdateProductByIdRequest) {
+			reqDTO.Body.CategoryId = ptr(int64(3))
+			reqDTO.Body.Sex = ptr("UNISEX")
+		}, check: func(t *testing.T, product *model.Product) {
+			if product.CategoryId != 3 || product.Sex != "UNISEX" {
+				t.Errorf("product = %+v, want category 3 and sex UNISEX", product)
+			}
+		}},
+		{name: "unknown category", id: 1, body: func(reqDTO *dto.UpdateProductByIdRequest) {
+			reqDTO.Body.CategoryId = ptr(int64(99))
+		}, wantErr: "id of category not found"},
+		{name: "unknown product", id: 99, body: func(reqDTO *dto.UpdateProductByIdRequest) {
+			reqDTO.Body.Name = ptr("Khăn")
+		}, wantErr: "id of product not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+
+			reqDTO := &dto.UpdateProductByIdRequest{Id: tt.id}
+			tt.body(reqDTO)
+
+			checkErr(t, f.newProductService().UpdateProductById(context.Background(), reqDTO), tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			product, err := f.productRepository.GetById(context.Background(), tt.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, product)
+			outboxEvents := f.pendingOutboxEvents(t)
+			if len(outboxEvents) != 1 || outboxEvents[0].AggregateId != tt.id || outboxEvents[0].EventType != model.OutboxEventTypeUpsert {
+				t.Errorf("outbox events = %+v, want one upsert of product %d", outboxEvents, tt.id)
+			}
+		})
+	}
+}
+
+func TestProductService_DeleteProductById(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      int64
+		wantErr string
+	}{
+		{name: "deleted", id: 2},
+		{name: "unknown", id: 99, wantErr: "id of product not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+
+			checkErr(t, f.newProductService().DeleteProductById(context.Background(), &dto.DeleteProductByIdRequest{Id: tt.id}), tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			if _, err := f.productRepository.GetById(context.Background(), tt.id); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("product still exists: %v", err)
+			}
+			outboxEvents := f.pendingOutboxEvents(t)
+			if len(outboxEvents) != 2 || outboxEvents[0].AggregateId != tt.id || outboxEvents[0].EventType != model.OutboxEventTypeDelete ||
+				outboxEvents[1].EventType != model.OutboxEventTypePublishDeleted {
+				t.Errorf("outbox events = %+v, want delete and publish of product %d", outboxEvents, tt.id)
+			}
+		})
+	}
+}
+
+func TestProductService_SyncAllProductsToElasticsearch(t *testing.T) {
+	f := newFixture(t)
+	productService := f.newProductService()
+	ctx := context.Background()
+
+	// Written before any reindex, goes to the index holding the alias name
+	if err := f.productElasticsearchRepository.SyncCreating(ctx, &model.Product{Id: 99, Name: "Stale"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, wantIndex := range []string{"products_v1", "products_v2"} {
+		checkErr(t, productService.SyncAllProductsToElasticsearch(ctx), "")
+
+		versionIndices, err := f.productElasticsearchRepository.GetVersionIndices(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(versionIndices, []string{wantIndex}) {
+			t.Errorf("sync %d: version indices = %v, want [%s]", i+1, versionIndices, wantIndex)
+		}
+
+		result, _, err := productService.GetProductsWithElasticsearch(ctx, &dto.GetProductsWithElasticsearchRequest{Limit: 10, SortBy: "id", PriceInterval: 500000})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := productIdsOf(result.Products); !slices.Equal(ids, []int64{1, 2, 3}) {
+			t.Errorf("sync %d: indexed ids = %v, want [1 2 3]", i+1, ids)
+		}
+	}
+}
+
+func TestProductService_GetProductsWithElasticsearch(t *testing.T) {
+	tests := []struct {
+		name    string
+		reqDTO  dto.GetProductsWithElasticsearchRequest
+		wantIds []int64
+		wantErr string
+	}{
+		{name: "all", wantIds: []int64{1, 2, 3}},
+		{name: "query without accents", reqDTO: dto.GetProductsWithElasticsearchRequest{Q: "ao so mi"}, wantIds: []int64{1, 2}},
+		{name: "query with accents", reqDTO: dto.GetProductsWithElasticsearchRequest{Q: "mũ"}, wantIds: []int64{3}},
+		{name: "query with typo", reqDTO: dto.GetProductsWithElasticsearchRequest{Q: "cottn"}, wantIds: []int64{1, 3}},
+		{name: "name", reqDTO: dto.GetProductsWithElasticsearchRequest{Name: "thun"}, wantIds: []int64{2}},
+		{name: "name is accent sensitive", reqDTO: dto.GetProductsWithElasticsearchRequest{Name: "ao"}, wantIds: []int64{}},
+		{name: "price range", reqDTO: dto.GetProductsWithElasticsearchRequest{PriceGTE: "200000", PriceLTE: "600000"}, wantIds: []int64{1, 3}},
+		{name: "created until end of day", reqDTO: dto.GetProductsWithElasticsearchRequest{CreatedAtLTE: "2026-03-02"}, wantIds: []int64{1, 2}},
+		{name: "created since", reqDTO: dto.GetProductsWithElasticsearchRequest{CreatedAtGTE: "2026-03-02T21:00:00"}, wantIds: []int64{3}},
+		{name: "categories", reqDTO: dto.GetProductsWithElasticsearchRequest{CategoryIds: []int64{2, 3}}, wantIds: []int64{3}},
+		{name: "sexes", reqDTO: dto.GetProductsWithElasticsearchRequest{Sexes: []string{"MALE", "FEMALE"}}, wantIds: []int64{1, 2}},
+		{name: "price buckets", reqDTO: dto.GetProductsWithElasticsearchRequest{PriceBuckets: []int64{500000}}, wantIds: []int64{3}},
+		{name: "on sale", reqDTO: dto.GetProductsWithElasticsearchRequest{OnSale: true}, wantIds: []int64{1, 3}},
+		{name: "on sale shirts", reqDTO: dto.GetProductsWithElasticsearchRequest{OnSale: true, CategoryIds: []int64{1}}, wantIds: []int64{1}},
+		{name: "most expensive first", reqDTO: dto.GetProductsWithElasticsearchRequest{SortBy: "price:desc"}, wantIds: []int64{3, 1, 2}},
+		{name: "malformed date", reqDTO: dto.GetProductsWithElasticsearchRequest{CreatedAtGTE: "yesterday"}, wantErr: "failed to parse date field [yesterday]"},
+		{name: "unknown sort field", reqDTO: dto.GetProductsWithElasticsearchRequest{SortBy: "rating"}, wantErr: "can not sort by rating"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.indexProducts(t)
+
+			reqDTO := tt.reqDTO
+			reqDTO.Limit = 10
+			reqDTO.PriceInterval = 500000
+			if reqDTO.SortBy == "" {
+				reqDTO.SortBy = "id"
+			}
+
+			result, page, err := f.newProductService().GetProductsWithElasticsearch(context.Background(), &reqDTO)
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			if ids := productIdsOf(result.Products); !slices.Equal(ids, tt.wantIds) {
+				t.Errorf("ids = %v, want %v", ids, tt.wantIds)
+			}
+			if *page.Total != int64(len(tt.wantIds)) {
+				t.Errorf("total = %d, want %d", *page.Total, len(tt.wantIds))
+			}
+		})
+	}
+}
+
+// Each facet is counted without its own selection, so picking a category still shows the others.
+// Highlights come from the accent-sensitive fields when they match at all, otherwise from the folded ones.
+func TestProductService_GetProductsWithElasticsearch_Facets(t *testing.T) {
+	f := newFixture(t)
+	f.indexProducts(t)
+
+	result, _, err := f.newProductService().GetProductsWithElasticsearch(context.Background(), &dto.GetProductsWithElasticsearchRequest{
+		Limit: 10, SortBy: "id", Q: "ao so mi", CategoryIds: []int64{1}, PriceInterval: 200000,
+	})
+	checkErr(t, err, "")
+
+	want := &model.ProductElasticsearchResult{
+		Products: result.Products,
+		Highlights: map[int64]model.ProductHighlight{
+			1: {Name: []string{"Áo sơ <em>mi</em> trắng"}, Description: []string{"Áo sơ <em>mi</em> cotton"}},
+			2: {Name: []string{"<em>Áo</em> thun"}, Description: []string{"<em>Áo</em> thun basic"}},
+		},
+		CategoryFacets: []model.CategoryFacetBucket{{CategoryId: 1, CategoryName: "Shirts", Count: 2}},
+		SexFacets:      []model.TermFacetBucket{{Value: "FEMALE", Count: 1}, {Value: "MALE", Count: 1}},
+		PriceFacets:    []model.PriceFacetBucket{{From: 0, To: 200000, Count: 1}, {From: 200000, To: 400000, Count: 1}},
+		OnSaleCount:    1,
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("result = %+v, want %+v", result, want)
+	}
+}
+
+func TestProductService_SuggestProducts(t *testing.T) {
+	tests := []struct {
+		name           string
+		q              string
+		limit          int
+		wantSuggestion *model.ProductSuggestion
+	}{
+		{name: "last word is a prefix", q: "ao s", limit: 5, wantSuggestion: &model.ProductSuggestion{
+			Products:   []model.ProductSuggestionItem{{Id: 1, Name: "Áo sơ mi trắng"}},
+			Categories: []model.CategorySuggestionItem{{Id: 1, Name: "Shirts"}},
+		}},
+		{name: "accents and case are ignored", q: "MŨ L", limit: 5, wantSuggestion: &model.ProductSuggestion{
+			Products:   []model.ProductSuggestionItem{{Id: 3, Name: "Mũ lưỡi trai"}},
+			Categories: []model.CategorySuggestionItem{{Id: 2, Name: "Hats"}},
+		}},
+		{name: "any word matching is enough", q: "ao mu", limit: 5, wantSuggestion: &model.ProductSuggestion{
+			Products:   []model.ProductSuggestionItem{{Id: 1, Name: "Áo sơ mi trắng"}, {Id: 3, Name: "Mũ lưỡi trai"}},
+			Categories: []model.CategorySuggestionItem{{Id: 1, Name: "Shirts"}, {Id: 2, Name: "Hats"}},
+		}},
+		{name: "limited", q: "ao mu", limit: 1, wantSuggestion: &model.ProductSuggestion{
+			Products:   []model.ProductSuggestionItem{{Id: 1, Name: "Áo sơ mi trắng"}},
+			Categories: []model.CategorySuggestionItem{{Id: 1, Name: "Shirts"}},
+		}},
+		{name: "sold out products are left out", q: "thun", limit: 5, wantSuggestion: &model.ProductSuggestion{
+			Products:   []model.ProductSuggestionItem{},
+			Categories: []model.CategorySuggestionItem{},
+		}},
+		{name: "category named like the query without matching products", q: "SHO", limit: 5, wantSuggestion: &model.ProductSuggestion{
+			Products:   []model.ProductSuggestionItem{},
+			Categories: []model.CategorySuggestionItem{{Id: 3, Name: "Shoes"}},
+		}},
+		{name: "category named like the query is not repeated", q: "s", limit: 5, wantSuggestion: &model.ProductSuggestion{
+			Products:   []model.ProductSuggestionItem{{Id: 1, Name: "Áo sơ mi trắng"}},
+			Categories: []model.CategorySuggestionItem{{Id: 1, Name: "Shirts"}, {Id: 3, Name: "Shoes"}},
+		}},
+		{name: "wildcards are matched literally", q: "%", limit: 5, wantSuggestion: &model.ProductSuggestion{
+			Products:   []model.ProductSuggestionItem{},
+			Categories: []model.CategorySuggestionItem{},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.indexProducts(t)
+
+			suggestion, err := f.newProductService().SuggestProducts(context.Background(), &dto.SuggestProductsRequest{Q: tt.q, Limit: tt.limit})
+			checkErr(t, err, "")
+			if !reflect.DeepEqual(suggestion, tt.wantSuggestion) {
+				t.Errorf("suggestion = %+v, want %+v", suggestion, tt.wantSuggestion)
+			}
+		})
+	}
+}
+
+func TestProductService_SuggestProducts_Cache(t *testing.T) {
+	f := newFixture(t)
+	f.indexProducts(t)
+	productService := f.newProductService()
+	ctx := context.Background()
+
+	suggest := func() []model.ProductSuggestionItem {
+		suggestion, err := productService.SuggestProducts(ctx, &dto.SuggestProductsRequest{Q: " Ao S ", Limit: 5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return suggestion.Products
+	}
+
+	if products := suggest(); len(products) != 1 {
+		t.Fatalf("suggested products = %+v, want product 1", products)
+	}
+	if !f.redisServer.Exists("product-suggest:5:ao s") {
+		t.Fatalf("suggestion is not cached, keys = %v", f.redisServer.Keys())
+	}
+
+	if err := f.productElasticsearchRepository.SyncDeletingById(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if products := suggest(); len(products) != 1 {
+		t.Errorf("suggested products = %+v, want cached product 1", products)
+	}
+
+	f.redisServer.FastForward(31 * time.Second)
+	if products := suggest(); len(products) != 0 {
+		t.Errorf("suggested products = %+v, want none after cache expired", products)
+	}
+}
+
+func TestProductService_ReserveStock(t *testing.T) {
+	tests := []struct {
+		name      string
+		items     []dto.StockReservationItemRequest
+		wantItems []model.StockReservationItem
+		wantStock map[int64]int32
+		wantErr   string
+	}{
+		{name: "duplicated products are merged", items: []dto.StockReservationItemRequest{
+			{ProductId: 3, Quantity: 1}, {ProductId: 1, Quantity: 2}, {ProductId: 1, Quantity: 1},
+		}, wantItems: []model.StockReservationItem{
+			{ProductId: 1, Quantity: 3}, {ProductId: 3, Quantity: 1},
+		}, wantStock: map[int64]int32{1: 2, 3: 1}},
+		{name: "whole stock", items: []dto.StockReservationItemRequest{{ProductId: 3, Quantity: 2}},
+			wantItems: []model.StockReservationItem{{ProductId: 3, Quantity: 2}}, wantStock: map[int64]int32{3: 0}},
+		{name: "unknown product", items: []dto.StockReservationItemRequest{{ProductId: 99, Quantity: 1}},
+			wantErr: "product with id = 99 not found"},
+		{name: "sold out product", items: []dto.StockReservationItemRequest{{ProductId: 2, Quantity: 1}},
+			wantErr: "stock of product with id = 2 is not enough"},
+		{name: "merged quantity over stock", items: []dto.StockReservationItemRequest{{ProductId: 3, Quantity: 2}, {ProductId: 3, Quantity: 1}},
+			wantErr: "stock of product with id = 3 is not enough"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+
+			reqDTO := &dto.CreateStockReservationRequest{}
+			reqDTO.Body.Items = tt.items
+
+			stockReservation, err := f.newProductService().ReserveStock(context.Background(), reqDTO)
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			if stockReservation.Status != model.StockReservationStatusPending || !stockReservation.ExpiresAt.After(time.Now().UTC().Add(14*time.Minute)) {
+				t.Errorf("stock reservation = %+v, want pending for 15 minutes", stockReservation)
+			}
+
+			foundStockReservation, err := f.stockReservationRepository.GetById(context.Background(), stockReservation.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			items := []model.StockReservationItem{}
+			for _, item := range foundStockReservation.Items {
+				if item.ReservationId != stockReservation.Id {
+					t.Errorf("item %+v does not belong to reservation %d", item, stockReservation.Id)
+				}
+				items = append(items, model.StockReservationItem{ProductId: item.ProductId, Quantity: item.Quantity})
+			}
+			if !reflect.DeepEqual(items, tt.wantItems) {
+				t.Errorf("items = %+v, want %+v", items, tt.wantItems)
+			}
+			for productId, wantStock := range tt.wantStock {
+				if stock := f.stockOf(t, productId); stock != wantStock {
+					t.Errorf("stock of product %d = %d, want %d", productId, stock, wantStock)
+				}
+			}
+			if outboxEvents := f.pendingOutboxEvents(t); len(outboxEvents) != len(tt.wantItems) {
+				t.Errorf("outbox events = %+v, want one per reserved product", outboxEvents)
+			}
+		})
+	}
+}
+
+func TestProductService_GetStockReservationById(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      int64
+		wantErr error
+	}{
+		{name: "found", id: 1},
+		{name: "unknown", id: 99, wantErr: sql.ErrNoRows},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			productService := f.newProductService()
+			f.reserve(t, productService, 1, 2)
+
+			stockReservation, err := productService.GetStockReservationById(context.Background(), &dto.GetStockReservationByIdRequest{Id: tt.id})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (stockReservation.Id != tt.id || len(stockReservation.Items) != 1) {
+				t.Errorf("stock reservation = %+v, want %d with one item", stockReservation, tt.id)
+			}
+		})
+	}
+}
+
+func TestProductService_ConfirmStockReservationById(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         int64
+		expired    bool
+		prepare    func(t *testing.T, productService ProductService)
+		wantStatus string
+		wantErr    string
+	}{
+		{name: "confirmed", id: 1, wantStatus: model.StockReservationStatusConfirmed},
+		{name: "confirmed twice", id: 1, prepare: func(t *testing.T, productService ProductService) {
+			checkErr(t, productService.ConfirmStockReservationById(context.Background(), &dto.ConfirmStockReservationByIdRequest{Id: 1}), "")
+		}, wantStatus: model.StockReservationStatusConfirmed},
+		{name: "expired but not swept yet", id: 1, expired: true, wantStatus: model.StockReservationStatusConfirmed},
+		{name: "expired and swept", id: 1, expired: true, prepare: func(t *testing.T, productService ProductService) {
+			if _, err := productService.ReleaseExpiredStockReservations(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}, wantErr: "stock reservation is already expired"},
+		{name: "released", id: 1, prepare: func(t *testing.T, productService ProductService) {
+			checkErr(t, productService.ReleaseStockReservationById(context.Background(), &dto.ReleaseStockReservationByIdRequest{Id: 1}), "")
+		}, wantErr: "stock reservation is already released"},
+		{name: "unknown", id: 99, wantErr: "id of stock reservation not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			if tt.expired {
+				f.appConfig.ReservationExpireSeconds = "-60"
+			}
+			productService := f.newProductService()
+			f.reserve(t, productService, 1, 2)
+			if tt.prepare != nil {
+				tt.prepare(t, productService)
+			}
+
+			checkErr(t, productService.ConfirmStockReservationById(context.Background(), &dto.ConfirmStockReservationByIdRequest{Id: tt.id}), tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			stockReservation, err := f.stockReservationRepository.GetById(context.Background(), tt.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stockReservation.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", stockReservation.Status, tt.wantStatus)
+			}
+			if stock := f.stockOf(t, 1); stock != 3 {
+				t.Errorf("stock = %d, want reserved stock kept taken", stock)
+			}
+		})
+	}
+}
+
+func TestProductService_ReleaseStockReservationById(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         int64
+		prepare    func(t *testing.T, productService ProductService)
+		wantStatus string
+		wantErr    string
+	}{
+		{name: "released", id: 1, wantStatus: model.StockReservationStatusReleased},
+		{name: "released twice returns stock once", id: 1, prepare: func(t *testing.T, productService ProductService) {
+			checkErr(t, productService.ReleaseStockReservationById(context.Background(), &dto.ReleaseStockReservationByIdRequest{Id: 1}), "")
+		}, wantStatus: model.StockReservationStatusReleased},
+		{name: "confirmed returns stock", id: 1, prepare: func(t *testing.T, productService ProductService) {
+			checkErr(t, productService.ConfirmStockReservationById(context.Background(), &dto.ConfirmStockReservationByIdRequest{Id: 1}), "")
+		}, wantStatus: model.StockReservationStatusReleased},
+		{name: "unknown", id: 99, wantErr: "id of stock reservation not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			productService := f.newProductService()
+			f.reserve(t, productService, 1, 2)
+			if tt.prepare != nil {
+				tt.prepare(t, productService)
+			}
+
+			checkErr(t, productService.ReleaseStockReservationById(context.Background(), &dto.ReleaseStockReservationByIdRequest{Id: tt.id}), tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			stockReservation, err := f.stockReservationRepository.GetById(context.Background(), tt.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stockReservation.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", stockReservation.Status, tt.wantStatus)
+			}
+			if stock := f.stockOf(t, 1); stock != 5 {
+				t.Errorf("stock = %d, want 5", stock)
+			}
+		})
+	}
+}
+
+func TestProductService_ReleaseExpiredStockReservations(t *testing.T) {
+	f := newFixture(t)
+	productService := f.newProductService()
+	ctx := context.Background()
+
+	f.appConfig.ReservationExpireSeconds = "-60"
+	expired := f.reserve(t, productService, 1, 2)
+	releasedBefore := f.reserve(t, productService, 1, 1)
+	checkErr(t, productService.ReleaseStockReservationById(ctx, &dto.ReleaseStockReservationByIdRequest{Id: releasedBefore.Id}), "")
+	f.appConfig.ReservationExpireSeconds = "900"
+	pending := f.reserve(t, productService, 3, 1)
+
+	released, err := productService.ReleaseExpiredStockReservations(ctx)
+	checkErr(t, err, "")
+	if released != 1 {
+		t.Errorf("released = %d, want 1", released)
+	}
+
+	for id, wantStatus := range map[int64]string{
+		expired.Id:        model.StockReservationStatusExpired,
+		releasedBefore.Id: model.StockReservationStatusReleased,
+		pending.Id:        model.StockReservationStatusPending,
+	} {
+		stockReservation, err := f.stockReservationRepository.GetById(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stockReservation.Status != wantStatus {
+			t.Errorf("status of reservation %d = %s, want %s", id, stockReservation.Status, wantStatus)
+		}
+	}
+	if stock := f.stockOf(t, 1); stock != 5 {
+		t.Errorf("stock of product 1 = %d, want 5", stock)
+	}
+	if stock := f.stockOf(t, 3); stock != 1 {
+		t.Errorf("stock of product 3 = %d, want 1", stock)
+	}
+}
+
+func (f *fixture) reserve(t *testing.T, productService ProductService, productId int64, quantity int32) *model.StockReservation {
+	t.Helper()
+
+	reqDTO := &dto.CreateStockReservationRequest{}
+	reqDTO.Body.Items = []dto.StockReservationItemRequest{{ProductId: productId, Quantity: quantity}}
+	stockReservation, err := productService.ReserveStock(context.Background(), reqDTO)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return stockReservation
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"thanhldt060802/config"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"thanhldt060802/internal/repository/memory"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// Services of one test share these in-memory repositories, like they share a database in the application
+type fixture struct {
+	categoryRepository             repository.CategoryRepository
+	productRepository              repository.ProductRepository
+	stockReservationRepository     repository.StockReservationRepository
+	outboxEventRepository          repository.OutboxEventRepository
+	transactionManager             repository.TransactionManager
+	productElasticsearchRepository repository.ProductElasticsearchRepository
+	productEventRepository         repository.ProductEventRepository
+
+	redisServer *miniredis.Miniredis
+	redisClient *redis.Client
+	appConfig   *config.Config
+}
+
+// Categories Shirts (1), Hats (2) and an empty Shoes (3), products are:
+// 1 in stock shirt on sale, 2 sold out shirt, 3 hat on sale over the first price bucket
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	redisServer := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+
+	f := &fixture{
+		categoryRepository:             memory.NewCategoryRepository(),
+		productRepository:              memory.NewProductRepository(),
+		stockReservationRepository:     memory.NewStockReservationRepository(),
+		outboxEventRepository:          memory.NewOutboxEventRepository(),
+		transactionManager:             memory.NewTransactionManager(),
+		productElasticsearchRepository: memory.NewProductElasticsearchRepository(),
+		productEventRepository:         repository.NewProductEventRepository(redisClient),
+
+		redisServer: redisServer,
+		redisClient: redisClient,
+		appConfig: &config.Config{
+			ReservationExpireSeconds:         "900",
+			OutboxBatchSize:                  "10",
+			OutboxMaxAttempts:                "2",
+			ProductSuggestCacheExpireSeconds: "30",
+		},
+	}
+
+	ctx := context.Background()
+	for _, category := range []model.Category{{Name: "Shirts"}, {Name: "Hats"}, {Name: "Shoes"}} {
+		if err := f.categoryRepository.Create(ctx, &category); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, product := range []model.Product{
+		{Name: "Áo sơ mi trắng", Description: "Áo sơ mi cotton", Sex: "MALE", Price: 250000, DiscountPercentage: 10, Stock: 5,
+			ImageURL: "shirt.png", CategoryId: 1, CreatedAt: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)},
+		{Name: "Áo thun", Description: "Áo thun basic", Sex: "FEMALE", Price: 150000, Stock: 0,
+			ImageURL: "t-shirt.png", CategoryId: 1, CreatedAt: time.Date(2026, 3, 2, 20, 0, 0, 0, time.UTC)},
+		{Name: "Mũ lưỡi trai", Description: "Mũ cotton", Sex: "UNISEX", Price: 600000, DiscountPercentage: 20, Stock: 2,
+			ImageURL: "cap.png", CategoryId: 2, CreatedAt: time.Date(2026, 3, 3, 5, 0, 0, 0, time.UTC)},
+	} {
+		if err := f.productRepository.Create(ctx, &product); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return f
+}
+
+func (f *fixture) newProductService() ProductService {
+	return NewProductService(f.productRepository, f.productElasticsearchRepository, f.categoryRepository,
+		f.stockReservationRepository, f.outboxEventRepository, f.transactionManager, f.redisClient, f.appConfig)
+}
+
+func (f *fixture) newOutboxService() OutboxService {
+	return NewOutboxService(f.outboxEventRepository, f.productRepository, f.productElasticsearchRepository, f.productEventRepository, f.transactionManager, f.appConfig)
+}
+
+func (f *fixture) indexProducts(t *testing.T) {
+	t.Helper()
+
+	if err := f.newProductService().SyncAllProductsToElasticsearch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (f *fixture) stockOf(t *testing.T, productId int64) int32 {
+	t.Helper()
+
+	product, err := f.productRepository.GetById(context.Background(), productId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return product.Stock
+}
+
+// Read from the in-memory table, claiming them would lease them to the test
+func (f *fixture) pendingOutboxEvents(t *testing.T) []model.OutboxEvent {
+	t.Helper()
+
+	return f.outboxEventRepository.(interface{ GetPending() []model.OutboxEvent }).GetPending()
+}
+
+// Ids of products in events of eventType published to the product event stream, in publishing order
+func (f *fixture) publishedProductIds(t *testing.T, eventType string) []int64 {
+	t.Helper()
+
+	messages, err := f.redisClient.XRange(context.Background(), model.ProductEventStream, "-", "+").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var productIds []int64
+	for _, message := range messages {
+		if message.Values["type"] != eventType {
+			continue
+		}
+		productId, err := strconv.ParseInt(message.Values["product_id"].(string), 10, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		productIds = append(productIds, productId)
+	}
+
+	return productIds
+}
+
+func productIdsOf(products []model.Product) []int64 {
+	ids := []int64{}
+	for _, product := range products {
+		ids = append(ids, product.Id)
+	}
+
+	return ids
+}
+
+// Empty wantErr means no error is expected, otherwise error message must contain it
+func checkErr(t *testing.T, err error, wantErr string) {
+	t.Helper()
+
+	if wantErr == "" {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+	if err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Fatalf("error = %v, want error containing %q", err, wantErr)
+	}
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
@@ -0,0 +1,27 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"thanhldt060802/internal/client"
+	"time"
+)
+
+// Periodically refetches public keys of customer service so retired keys are dropped
+func StartJWKSRefresher(ctx context.Context, jwksClient client.JWKSClient, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := jwksClient.Refresh(ctx); err != nil {
+					log.Printf("Refresh jwks failed: %s", err.Error())
+				}
+			}
+		}
+	}()
+}
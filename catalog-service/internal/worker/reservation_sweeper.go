@@ -0,0 +1,32 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"thanhldt060802/internal/service"
+	"time"
+)
+
+// Periodically gives stock of expired reservations back to products
+func StartReservationSweeper(ctx context.Context, productService service.ProductService, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				released, err := productService.ReleaseExpiredStockReservations(ctx)
+				if err != nil {
+					log.Printf("Release expired stock reservations failed: %s", err.Error())
+					continue
+				}
+				if released > 0 {
+					log.Printf("Released %d expired stock reservations", released)
+				}
+			}
+		}
+	}()
+}
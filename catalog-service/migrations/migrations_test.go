@@ -0,0 +1,66 @@
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	tests := []struct {
+		name  string
+		files embed.FS
+		dir   string
+	}{
+		{name: "schema", files: schemaFiles, dir: "schema"},
+		{name: "seed", files: seedFiles, dir: "seed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := discover(tt.files, tt.dir)
+			if err != nil {
+				t.Fatalf("discover() error = %v", err)
+			}
+
+			sorted := migrations.Sorted()
+			if len(sorted) == 0 {
+				t.Fatal("no migrations embedded")
+			}
+			for _, migration := range sorted {
+				if migration.Up == nil || migration.Down == nil {
+					t.Errorf("migration %s needs both up and down files", migration)
+				}
+			}
+		})
+	}
+}
+
+// Tables missing from ownedTables would stay behind in public when an existing deployment moves to its schema
+func TestOwnedTablesCoverSchema(t *testing.T) {
+	createTablePattern := regexp.MustCompile(`(?m)^CREATE TABLE IF NOT EXISTS (\w+)`)
+
+	files, err := fs.Glob(schemaFiles, "schema/*.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := 0
+	for _, file := range files {
+		content, err := schemaFiles.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, match := range createTablePattern.FindAllStringSubmatch(string(content), -1) {
+			created++
+			if !slices.Contains(ownedTables, match[1]) {
+				t.Errorf("table %s created by %s is missing from ownedTables", match[1], file)
+			}
+		}
+	}
+
+	if created == 0 {
+		t.Fatal("no CREATE TABLE found in schema migrations")
+	}
+}
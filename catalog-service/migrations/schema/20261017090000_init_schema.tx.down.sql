@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS stock_reservation_items;
+DROP TABLE IF EXISTS stock_reservations;
+DROP TABLE IF EXISTS catalog_outbox_events;
+DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS categories;
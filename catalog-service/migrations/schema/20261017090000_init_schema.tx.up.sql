@@ -0,0 +1,55 @@
+-- Bảng danh mục sản phẩm
+CREATE TABLE IF NOT EXISTS categories (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Bảng sản phẩm
+CREATE TABLE IF NOT EXISTS products (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL,
+    sex VARCHAR(255) NOT NULL,
+    price BIGINT NOT NULL,
+    discount_percentage INT NOT NULL,
+    stock INT NOT NULL,
+    image_url TEXT NOT NULL,
+    category_id BIGINT NOT NULL REFERENCES categories(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Bảng outbox đồng bộ Elasticsearch của catalog service
+CREATE TABLE IF NOT EXISTS catalog_outbox_events (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    aggregate_type VARCHAR(255) NOT NULL,
+    aggregate_id BIGINT NOT NULL,
+    event_type VARCHAR(255) NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_catalog_outbox_events_status_next_attempt_at ON catalog_outbox_events (status, next_attempt_at);
+
+-- Bảng giữ hàng
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    status VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_status_expires_at ON stock_reservations (status, expires_at);
+
+-- Bảng chi tiết giữ hàng
+CREATE TABLE IF NOT EXISTS stock_reservation_items (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    reservation_id BIGINT NOT NULL REFERENCES stock_reservations(id),
+    product_id BIGINT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL
+);
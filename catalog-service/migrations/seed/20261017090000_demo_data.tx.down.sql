@@ -0,0 +1,2 @@
+-- Dữ liệu mẫu chỉ dùng cho database local, rollback xóa toàn bộ dữ liệu của các bảng được seed
+TRUNCATE stock_reservation_items, stock_reservations, products, categories RESTART IDENTITY;
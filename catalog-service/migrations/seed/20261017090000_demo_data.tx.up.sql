@@ -0,0 +1,53 @@
+INSERT INTO categories (name, created_at) VALUES
+('Áo sơ mi Uniqlo', '2024-01-05 10:00:00'), -- 1
+('Áo sơ mi Zara', '2024-01-06 11:00:00'), -- 2
+('Áo thun Adidas', '2024-01-07 09:30:00'), -- 3
+('Áo thun Nike', '2024-01-08 14:00:00'), -- 4
+('Áo khoác Kaki Routine', '2024-01-09 13:45:00'), -- 5
+('Áo khoác Jean H&M', '2024-01-10 15:20:00'), -- 6
+('Quần Jean H&M', '2024-01-11 16:30:00'), -- 7
+('Quần Jean Routine', '2024-01-12 10:40:00'), -- 8
+('Giày thể thao Nike', '2024-01-13 12:00:00'), -- 9
+('Giày thể thao Bitis Hunter', '2024-01-14 08:15:00'), -- 10
+('Váy đầm GU', '2024-01-15 14:10:00'), -- 11
+('Túi xách Charles & Keith', '2024-01-16 13:25:00'), -- 12
+('Balo Adidas', '2024-01-17 11:50:00'), -- 13
+('Thắt lưng Gucci', '2024-01-18 10:10:00'), -- 14
+('Kính mát Ray-Ban', '2024-01-19 09:00:00'); -- 15
+
+INSERT INTO products (name, description, sex, price, discount_percentage, stock, image_url, category_id, created_at) VALUES
+('Áo sơ mi Uniqlo màu xanh', 'Áo sơ mi Uniqlo chất liệu cotton, màu xanh dương, phù hợp công sở', 'UNISEX', 499000, 10, 100, 'image.com', 1, '2024-01-05 10:00:00'), -- 1
+('Áo sơ mi Uniqlo kẻ sọc', 'Áo sơ mi Uniqlo kẻ sọc, thiết kế lịch sự, dễ phối đồ', 'UNISEX', 549000, 12, 120, 'image.com', 1, '2024-01-06 11:30:00'), -- 2
+('Áo sơ mi Uniqlo màu trắng', 'Áo sơ mi Uniqlo màu trắng, dễ dàng kết hợp với nhiều trang phục', 'UNISEX', 459000, 8, 90, 'image.com', 1, '2024-01-07 09:00:00'), -- 3
+('Áo sơ mi Zara cổ đứng', 'Áo sơ mi Zara, cổ đứng phong cách, thích hợp công sở và dạo phố', 'UNISEX', 599000, 15, 150, 'image.com', 2, '2024-01-08 14:00:00'), -- 4
+('Áo sơ mi Zara họa tiết', 'Áo sơ mi Zara họa tiết hoa, nhẹ nhàng và thanh thoát', 'UNISEX', 629000, 18, 180, 'image.com', 2, '2024-01-09 13:30:00'), -- 5
+('Áo thun Adidas phong cách', 'Áo thun Adidas phù hợp cho các hoạt động thể thao và dạo phố', 'MALE', 349000, 20, 200, 'image.com', 3, '2024-01-10 10:00:00'), -- 6
+('Áo thun Adidas cơ bản', 'Áo thun Adidas cơ bản, thiết kế đơn giản và thoải mái', 'MALE', 329000, 25, 220, 'image.com', 3, '2024-01-11 11:30:00'), -- 7
+('Áo thun Adidas cổ tròn', 'Áo thun Adidas cổ tròn, phù hợp với mọi phong cách', 'MALE', 379000, 15, 150, 'image.com', 3, '2024-01-12 12:00:00'), -- 8
+('Áo thun Nike Essential', 'Áo thun Nike Essential, thiết kế đơn giản, thoải mái cho luyện tập', 'MALE', 399000, 10, 180, 'image.com', 4, '2024-01-13 08:15:00'), -- 9
+('Áo thun Nike Dri-FIT', 'Áo thun Nike Dri-FIT, giúp thấm hút mồ hôi tốt cho thể thao', 'MALE', 429000, 18, 160, 'image.com', 4, '2024-01-14 13:30:00'), -- 10
+('Áo thun Nike Sport', 'Áo thun Nike Sport phù hợp với những buổi tập luyện thể thao', 'MALE', 459000, 12, 140, 'image.com', 4, '2024-01-15 10:45:00'), -- 11
+('Áo khoác Kaki Routine', 'Áo khoác kaki Routine, thiết kế đơn giản và dễ phối đồ', 'UNISEX', 899000, 5, 50, 'image.com', 5, '2024-01-16 09:00:00'), -- 12
+('Áo khoác Kaki Routine mỏng', 'Áo khoác kaki Routine, mỏng nhẹ phù hợp mùa thu', 'UNISEX', 849000, 10, 70, 'image.com', 5, '2024-01-17 11:00:00'), -- 13
+('Áo khoác Kaki Routine dày', 'Áo khoác kaki Routine dày, giữ ấm tốt cho mùa đông', 'UNISEX', 999000, 8, 60, 'image.com', 5, '2024-01-18 14:30:00'), -- 14
+('Áo khoác Jean H&M', 'Áo khoác Jean H&M, phong cách trẻ trung, phù hợp mùa đông', 'UNISEX', 699000, 12, 80, 'image.com', 6, '2024-01-19 15:00:00'), -- 15
+('Áo khoác Jean H&M mỏng', 'Áo khoác Jean H&M, kiểu dáng năng động, mỏng nhẹ', 'UNISEX', 649000, 10, 100, 'image.com', 6, '2024-01-20 13:15:00'), -- 16
+('Áo khoác Jean H&M dày', 'Áo khoác Jean H&M, kiểu dáng dày dặn, giữ ấm tốt cho mùa lạnh', 'UNISEX', 749000, 5, 70, 'image.com', 6, '2024-01-21 12:30:00'), -- 17
+('Quần Jean H&M đen', 'Quần Jean H&M màu đen, thiết kế đơn giản, dễ phối đồ', 'UNISEX', 599000, 15, 100, 'image.com', 7, '2024-01-22 14:00:00'), -- 18
+('Quần Jean H&M xanh', 'Quần Jean H&M màu xanh, trẻ trung và năng động', 'UNISEX', 649000, 10, 80, 'image.com', 7, '2024-01-23 10:30:00'), -- 19
+('Quần Jean Routine xanh', 'Quần Jean Routine màu xanh, chất liệu mềm mại, thoải mái', 'UNISEX', 699000, 20, 150, 'image.com', 8, '2024-01-24 15:10:00'), -- 20
+('Quần Jean Routine đen', 'Quần Jean Routine màu đen, dễ dàng phối với nhiều trang phục', 'UNISEX', 749000, 15, 130, 'image.com', 8, '2024-01-25 14:50:00'), -- 21
+('Giày thể thao Nike Air Max', 'Giày thể thao Nike Air Max, thiết kế năng động, phù hợp cho thể thao', 'MALE', 2490000, 10, 60, 'image.com', 9, '2024-01-26 12:00:00'), -- 22
+('Giày thể thao Nike Zoom', 'Giày thể thao Nike Zoom, đặc biệt với công nghệ Zoom Air', 'MALE', 2790000, 8, 50, 'image.com', 9, '2024-01-27 13:30:00'), -- 23
+('Giày thể thao Bitis Hunter cam', 'Giày thể thao Bitis Hunter màu cam, thiết kế trẻ trung và mạnh mẽ', 'UNISEX', 899000, 15, 120, 'image.com', 10, '2024-01-28 11:00:00'), -- 24
+('Giày thể thao Bitis Hunter xanh', 'Giày thể thao Bitis Hunter màu xanh, tạo cảm giác năng động', 'UNISEX', 949000, 12, 100, 'image.com', 10, '2024-01-29 14:30:00'), -- 25
+('Váy đầm GU đen', 'Váy đầm GU màu đen, thanh lịch và sang trọng', 'FEMALE', 799000, 18, 70, 'image.com', 11, '2024-01-30 09:15:00'), -- 26
+('Váy đầm GU đỏ', 'Váy đầm GU màu đỏ, phù hợp cho các dịp tiệc tùng', 'FEMALE', 899000, 20, 50, 'image.com', 11, '2024-01-31 10:30:00'), -- 27
+('Túi xách Charles & Keith da thật', 'Túi xách Charles & Keith chất liệu da thật, thiết kế sang trọng', 'FEMALE', 1499000, 10, 30, 'image.com', 12, '2024-02-01 14:10:00'), -- 28
+('Túi xách Charles & Keith vải', 'Túi xách Charles & Keith chất liệu vải, dễ dàng phối đồ', 'FEMALE', 1099000, 15, 50, 'image.com', 12, '2024-02-02 13:25:00'), -- 29
+('Balo Adidas đen', 'Balo Adidas màu đen, thiết kế tiện dụng cho việc đi học, đi làm', 'UNISEX', 799000, 8, 60, 'image.com', 13, '2024-02-03 11:30:00'), -- 30
+('Balo Adidas xanh', 'Balo Adidas màu xanh, thể thao và năng động', 'UNISEX', 849000, 10, 50, 'image.com', 13, '2024-02-04 12:45:00'), -- 31
+('Thắt lưng Gucci da thật', 'Thắt lưng Gucci chất liệu da thật, thiết kế sang trọng và bền bỉ', 'UNISEX', 2990000, 5, 40, 'image.com', 14, '2024-02-05 09:00:00'), -- 32
+('Thắt lưng Gucci da vân', 'Thắt lưng Gucci chất liệu da vân, phong cách thời trang hiện đại', 'UNISEX', 3490000, 8, 50, 'image.com', 14, '2024-02-06 14:00:00'), -- 33
+('Kính mát Ray-Ban aviator', 'Kính mát Ray-Ban thiết kế kiểu aviator, phong cách và bảo vệ mắt tốt', 'UNISEX', 2390000, 10, 60, 'image.com', 15, '2024-02-07 12:10:00'), -- 34
+('Kính mát Ray-Ban Wayfarer', 'Kính mát Ray-Ban kiểu Wayfarer, thiết kế cổ điển và sang trọng', 'UNISEX', 2590000, 12, 50, 'image.com', 15, '2024-02-08 10:30:00'); -- 35
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Same claims as tokens issued by customer service
+type TokenClaims struct {
+	UserId      int64    `json:"user_id"`
+	RoleName    string   `json:"role_name"`
+	CartId      int64    `json:"cart_id"`
+	SessionId   string   `json:"sid"`
+	Permissions []string `json:"permissions"`
+	jwt.RegisteredClaims
+}
+
+// Signature and exp are checked offline against keys given by keyFunc
+func ValidateToken(tokenStr string, keyFunc jwt.Keyfunc) (*TokenClaims, error) {
+	claims := &TokenClaims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, keyFunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid || claims.ID == "" {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidPagination = errors.New("invalid pagination")
+
+// Position of a page boundary, sort values of the boundary row plus the sort they were taken under
+type Cursor struct {
+	SortBy   string `json:"s"`
+	Values   []any  `json:"v"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+// Sort fields always end with id, so every row has a distinct position to continue from
+type PageRequest struct {
+	Cursor       *Cursor
+	Limit        int
+	SortFields   []SortField
+	IncludeTotal bool
+}
+
+type Page struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+func NewPageRequest(cursor string, limit int, sortBy string, includeTotal bool) (*PageRequest, error) {
+	sortFields := ParseSortBy(sortBy)
+	hasId := false
+	for _, sortField := range sortFields {
+		if sortField.Field == "id" {
+			hasId = true
+		}
+	}
+	if !hasId {
+		direction := "ASC"
+		if len(sortFields) > 0 {
+			direction = sortFields[len(sortFields)-1].Direction
+		}
+		sortFields = append(sortFields, SortField{Field: "id", Direction: direction})
+	}
+
+	pageRequest := &PageRequest{
+		Limit:        limit,
+		SortFields:   sortFields,
+		IncludeTotal: includeTotal,
+	}
+	if cursor != "" {
+		decodedCursor, err := DecodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		if decodedCursor.SortBy != pageRequest.SortBy() {
+			return nil, fmt.Errorf("%w: cursor does not match sort_by", ErrInvalidPagination)
+		}
+		pageRequest.Cursor = decodedCursor
+	}
+
+	return pageRequest, nil
+}
+
+// Canonical form of sort fields, a cursor is only valid for the sort it was issued under
+func (pageRequest *PageRequest) SortBy() string {
+	sortKeys := make([]string, len(pageRequest.SortFields))
+	for i, sortField := range pageRequest.SortFields {
+		sortKeys[i] = fmt.Sprintf("%s:%s", sortField.Field, sortField.Direction)
+	}
+
+	return strings.Join(sortKeys, ",")
+}
+
+// Builds cursors of a fetched page from sort values of its first and last rows,
+// hasMore tells whether a row exists past the page in the direction it was fetched
+func (pageRequest *PageRequest) NewPage(firstValues []any, lastValues []any, hasMore bool) *Page {
+	page := &Page{}
+	if firstValues == nil || lastValues == nil {
+		return page
+	}
+
+	backward := pageRequest.Cursor != nil && pageRequest.Cursor.Backward
+	if hasMore || backward {
+		page.NextCursor = EncodeCursor(&Cursor{SortBy: pageRequest.SortBy(), Values: lastValues})
+	}
+	if (backward && hasMore) || (!backward && pageRequest.Cursor != nil) {
+		page.PrevCursor = EncodeCursor(&Cursor{SortBy: pageRequest.SortBy(), Values: firstValues, Backward: true})
+	}
+
+	return page
+}
+
+func EncodeCursor(cursor *Cursor) string {
+	data, _ := json.Marshal(cursor)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(token string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cursor is malformed", ErrInvalidPagination)
+	}
+
+	var cursor Cursor
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&cursor); err != nil || len(cursor.Values) == 0 {
+		return nil, fmt.Errorf("%w: cursor is malformed", ErrInvalidPagination)
+	}
+
+	// Numbers are kept exact, ids above 2^53 would lose precision as float64
+	for i, value := range cursor.Values {
+		if number, ok := value.(json.Number); ok {
+			if intValue, err := number.Int64(); err == nil {
+				cursor.Values[i] = intValue
+			} else if floatValue, err := number.Float64(); err == nil {
+				cursor.Values[i] = floatValue
+			}
+		}
+	}
+
+	return &cursor, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"thanhldt060802/config"
+	"thanhldt060802/internal/dto"
+	"thanhldt060802/internal/handler"
+	"thanhldt060802/internal/middleware"
+	"thanhldt060802/internal/service"
+	"thanhldt060802/utils"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humagin"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// Services behind the handlers, main builds them on the database and contract tests on in-memory repositories
+type services struct {
+	userService          service.UserService
+	roleService          service.RoleService
+	cartService          service.CartService
+	cartItemService      service.CartItemService
+	invoiceService       service.InvoiceService
+	invoiceDetailService service.InvoiceDetailService
+	auditLogService      service.AuditLogService
+}
+
+// Registers every operation with its middlewares on r, so contract tests serve the same API as the application
+func newAPI(r *gin.Engine, services *services, redisClient *redis.Client, jwtKeys *utils.JWTKeys, appConfig *config.Config) huma.API {
+	humaCfg := huma.DefaultConfig("Customer Service", "v1.0.0")
+	humaCfg.DocsPath = ""
+	humaCfg.JSONSchemaDialect = ""
+	humaCfg.CreateHooks = nil
+	humaCfg.Components = &huma.Components{
+		SecuritySchemes: map[string]*huma.SecurityScheme{
+			"BearerAuth": {
+				Type:         "http",
+				Scheme:       "bearer",
+				BearerFormat: "JWT",
+			},
+		},
+	}
+
+	huma.NewError = func(status int, msg string, errs ...error) huma.StatusError {
+		details := make([]string, len(errs))
+		for i, err := range errs {
+			details[i] = err.Error()
+		}
+		res := &dto.ErrorResponse{}
+		res.Status = status
+		res.Message = msg
+		res.Details = details
+		return res
+	}
+
+	api := humagin.New(r, humaCfg)
+	api.UseMiddleware(middleware.ClientInfo)
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(api, redisClient, jwtKeys)
+
+	// Initialize rate limit middleware for every operation
+	rateLimitMiddleware := middleware.NewRateLimitMiddleware(api, authMiddleware, redisClient, appConfig)
+	api.UseMiddleware(rateLimitMiddleware.RateLimit)
+
+	// Initialize handlers
+	handler.NewUserHandler(api, services.userService, authMiddleware)
+	handler.NewCartHandler(api, services.cartService, authMiddleware)
+	handler.NewCartItemHandler(api, services.cartItemService, authMiddleware)
+	handler.NewInvoiceHandler(api, services.invoiceService, authMiddleware)
+	handler.NewInvoiceDetailHandler(api, services.invoiceDetailService, services.invoiceService, authMiddleware)
+	handler.NewRoleHandler(api, services.roleService, authMiddleware)
+	handler.NewAuditLogHandler(api, services.auditLogService, authMiddleware)
+	handler.NewJWKSHandler(api, jwtKeys)
+
+	return api
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"thanhldt060802/config"
+	"thanhldt060802/internal/client"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"thanhldt060802/internal/repository/memory"
+	"thanhldt060802/internal/service"
+	"thanhldt060802/internal/testsupport"
+	"thanhldt060802/utils"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// Database tables are in memory and Redis is miniredis, so sessions, one-time tokens, login attempts, the token denylist
+// and rate limits go through the same Redis code as in the application
+type contractFixture struct {
+	api      huma.API
+	notifier *testsupport.MemoryNotifier
+}
+
+// Roles ADMIN with every permission and CUSTOMER with none, user admin (1) with password "admin",
+// catalog serves product 1 in stock and product 2 with one left
+func newContractFixture(t *testing.T) *contractFixture {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+
+	redisServer := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+
+	jwtKeys, err := utils.NewJWTKeys(filepath.Join(t.TempDir(), "jwt_private.pem"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	appConfig := &config.Config{
+		AccessTokenExpireMinutes:          "15",
+		RefreshTokenExpireMinutes:         "60",
+		EmailVerificationExpireMinutes:    "60",
+		PasswordResetExpireMinutes:        "30",
+		PasswordResetURL:                  "http://localhost:3000/reset-password",
+		LoginMaxFailedAttemptsPerUsername: "1",
+		LoginMaxFailedAttemptsPerIp:       "20",
+		LoginFailedAttemptWindowMinutes:   "15",
+		LoginLockoutMinutes:               "15",
+		OutboxBatchSize:                   "10",
+		OutboxMaxAttempts:                 "2",
+		RateLimitDefault:                  "300/1m",
+		RateLimitOperations:               "GET /.well-known/jwks.json=2/1m",
+	}
+
+	permissions := []model.Permission{}
+	permissionNames := []string{}
+	for _, name := range []string{
+		model.PermissionProductWrite, model.PermissionCategoryWrite, model.PermissionUserRead, model.PermissionUserWrite,
+		model.PermissionCartRead, model.PermissionInvoiceRead, model.PermissionInvoiceWrite, model.PermissionInvoiceRefund,
+		model.PermissionRoleRead, model.PermissionRoleWrite, model.PermissionAuditRead,
+	} {
+		permissions = append(permissions, model.Permission{Name: name, Description: name})
+		permissionNames = append(permissionNames, name)
+	}
+
+	userRepository := memory.NewUserRepository()
+	cartRepository := memory.NewCartRepository()
+	cartItemRepository := memory.NewCartItemRepository()
+	invoiceRepository := memory.NewInvoiceRepository()
+	invoiceDetailRepository := memory.NewInvoiceDetailRepository()
+	invoiceStatusHistoryRepository := memory.NewInvoiceStatusHistoryRepository()
+	outboxEventRepository := memory.NewOutboxEventRepository()
+	sessionRepository := repository.NewSessionRepository(redisClient)
+	roleRepository := memory.NewRoleRepository()
+	permissionRepository := memory.NewPermissionRepository(permissions...)
+	oneTimeTokenRepository := repository.NewOneTimeTokenRepository(redisClient)
+	loginAttemptRepository := repository.NewLoginAttemptRepository(redisClient)
+	auditLogRepository := memory.NewAuditLogRepository()
+	transactionManager := memory.NewTransactionManager()
+	invoiceElasticsearchRepository := memory.NewInvoiceElasticsearchRepository()
+	productClient := testsupport.NewMemoryProductClient(
+		client.Product{Id: 1, Name: "Shirt", Price: 200000, DiscountPercentage: 10, Stock: 5},
+		client.Product{Id: 2, Name: "Hat", Price: 50000, Stock: 1},
+	)
+	userNotifier := testsupport.NewMemoryNotifier()
+
+	ctx := context.Background()
+	for _, role := range []model.Role{{Name: model.RoleAdmin, Description: "Administrator"}, {Name: model.RoleCustomer, Description: "Customer"}} {
+		if err := roleRepository.Create(ctx, &role); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := roleRepository.AddPermissions(ctx, model.RoleAdmin, permissionNames); err != nil {
+		t.Fatal(err)
+	}
+
+	hashedPassword, err := utils.HashPassword("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifiedAt := time.Now().UTC()
+	admin := model.User{
+		FullName:        "Admin",
+		Email:           "admin@example.com",
+		Username:        "admin",
+		HashedPassword:  hashedPassword,
+		Address:         "Head office",
+		RoleName:        model.RoleAdmin,
+		EmailVerifiedAt: &verifiedAt,
+	}
+	if err := userRepository.Create(ctx, &admin); err != nil {
+		t.Fatal(err)
+	}
+	if err := cartRepository.Create(ctx, &model.Cart{UserId: admin.Id}); err != nil {
+		t.Fatal(err)
+	}
+
+	invoiceService := service.NewInvoiceService(invoiceRepository, invoiceElasticsearchRepository, invoiceDetailRepository, invoiceStatusHistoryRepository,
+		outboxEventRepository, userRepository, cartRepository, cartItemRepository, productClient, transactionManager)
+	api := newAPI(gin.New(), &services{
+		userService: service.NewUserService(userRepository, cartRepository, sessionRepository, roleRepository, oneTimeTokenRepository,
+			loginAttemptRepository, auditLogRepository, userNotifier, jwtKeys, appConfig),
+		roleService:          service.NewRoleService(roleRepository, permissionRepository, userRepository),
+		cartService:          service.NewCartService(cartRepository),
+		cartItemService:      service.NewCartItemService(cartItemRepository, cartRepository, productClient),
+		invoiceService:       invoiceService,
+		invoiceDetailService: service.NewInvoiceDetailService(invoiceDetailRepository),
+		auditLogService:      service.NewAuditLogService(auditLogRepository),
+	}, redisClient, jwtKeys, appConfig)
+
+	return &contractFixture{
+		api:      api,
+		notifier: userNotifier,
+	}
+}
+
+// Token of the newest message sent to the address, carried by a link or on the last line
+func (f *contractFixture) lastToken(t *testing.T, to string) string {
+	t.Helper()
+
+	messages := f.notifier.Messages(to)
+	if len(messages) == 0 {
+		t.Fatalf("no message was sent to %s", to)
+	}
+	lines := strings.Split(messages[len(messages)-1].Body, "\n")
+	for _, line := range lines {
+		if link, err := url.Parse(line); err == nil && link.Query().Has("token") {
+			return link.Query().Get("token")
+		}
+	}
+
+	return lines[len(lines)-1]
+}
+
+func dataOf(body map[string]any) map[string]any {
+	data, _ := body["data"].(map[string]any)
+	return data
+}
+
+func TestAPIContract(t *testing.T) {
+	f := newContractFixture(t)
+	c := newContractTest(t, f.api)
+
+	// Public operations and rate limit
+	c.do("jwks", http.MethodGet, "/.well-known/jwks.json", "", nil, http.StatusOK)
+	c.do("jwks within rate limit", http.MethodGet, "/.well-known/jwks.json", "", nil, http.StatusOK)
+	c.do("jwks over rate limit", http.MethodGet, "/.well-known/jwks.json", "", nil, http.StatusTooManyRequests)
+
+	// Registration
+	register := map[string]any{"full_name": "Alice", "email": "alice@example.com", "username": "alice", "password": "alice", "address": "Hanoi"}
+	c.do("register", http.MethodPost, "/register", "", register, http.StatusOK)
+	c.do("register taken username", http.MethodPost, "/register", "", register, http.StatusBadRequest)
+	c.do("register invalid body", http.MethodPost, "/register", "", map[string]any{"email": "not an email"}, http.StatusUnprocessableEntity)
+	c.do("verify email with unknown token", http.MethodPost, "/verify-email", "", map[string]any{"token": "unknown"}, http.StatusBadRequest)
+	c.do("verify email", http.MethodPost, "/verify-email", "", map[string]any{"token": f.lastToken(t, "alice@example.com")}, http.StatusOK)
+
+	// Login and lockout
+	adminToken := dataOf(c.do("login admin", http.MethodPost, "/login", "", map[string]any{"username": "admin", "password": "admin"}, http.StatusOK))["access_token"].(string)
+	login := c.do("login alice", http.MethodPost, "/login", "", map[string]any{"username": "alice", "password": "alice", "device": "Phone"}, http.StatusOK)
+	aliceToken, aliceRefreshToken := dataOf(login)["access_token"].(string), dataOf(login)["refresh_token"].(string)
+	c.do("login wrong password", http.MethodPost, "/login", "", map[string]any{"username": "mallory", "password": "wrong"}, http.StatusBadRequest)
+	c.do("login locked out username", http.MethodPost, "/login", "", map[string]any{"username": "mallory", "password": "wrong"}, http.StatusTooManyRequests)
+	c.do("get login lockouts", http.MethodGet, "/login-lockouts", adminToken, nil, http.StatusOK)
+	c.do("delete login lockout", http.MethodDelete, "/login-lockouts/username/mallory", adminToken, nil, http.StatusOK)
+	c.do("delete login lockout of unknown username", http.MethodDelete, "/login-lockouts/username/mallory", adminToken, nil, http.StatusBadRequest)
+	c.do("delete login lockout invalid scope", http.MethodDelete, "/login-lockouts/email/mallory", adminToken, nil, http.StatusUnprocessableEntity)
+	// Failures from the same ip delay its next logins, clear them so the rest of the test does not wait
+	c.do("login delayed after failure from ip", http.MethodPost, "/login", "", map[string]any{"username": "alice", "password": "alice"}, http.StatusTooManyRequests)
+	c.do("delete failed attempts of ip", http.MethodDelete, "/login-lockouts/ip/127.0.0.1", adminToken, nil, http.StatusOK)
+	c.do("get audit logs", http.MethodGet, "/audit-logs?include_total=true", adminToken, nil, http.StatusOK)
+
+	// Refresh token rotation, reusing a rotated refresh token revokes the whole session
+	refreshed := c.do("refresh token", http.MethodPost, "/token/refresh", "", map[string]any{"refresh_token": aliceRefreshToken}, http.StatusOK)
+	c.do("reuse rotated refresh token", http.MethodPost, "/token/refresh", "", map[string]any{"refresh_token": aliceRefreshToken}, http.StatusUnauthorized)
+	c.do("access token of revoked session", http.MethodGet, "/my-account", dataOf(refreshed)["access_token"].(string), nil, http.StatusUnauthorized)
+	aliceToken = dataOf(c.do("login alice again", http.MethodPost, "/login", "", map[string]any{"username": "alice", "password": "alice", "device": "Phone"}, http.StatusOK))["access_token"].(string)
+
+	// Auth and permission middleware
+	c.do("my account without token", http.MethodGet, "/my-account", "", nil, http.StatusUnauthorized)
+	c.do("my account with invalid token", http.MethodGet, "/my-account", "invalid", nil, http.StatusUnauthorized)
+	c.do("users without permission", http.MethodGet, "/users", aliceToken, nil, http.StatusForbidden)
+	c.do("audit logs without permission", http.MethodGet, "/audit-logs", aliceToken, nil, http.StatusForbidden)
+
+	// Account
+	c.do("get my account", http.MethodGet, "/my-account", aliceToken, nil, http.StatusOK)
+	c.do("update my account", http.MethodPut, "/my-account", aliceToken, map[string]any{"address": "Da Nang"}, http.StatusOK)
+	c.do("resend verification of verified email", http.MethodPost, "/my-account/verify-email/resend", aliceToken, nil, http.StatusBadRequest)
+
+	// Users
+	c.do("get users", http.MethodGet, "/users?include_total=true", adminToken, nil, http.StatusOK)
+	c.do("get users invalid sort", http.MethodGet, "/users?sort_by=password", adminToken, nil, http.StatusBadRequest)
+	c.do("get user by id", http.MethodGet, "/users/id/2", adminToken, nil, http.StatusOK)
+	c.do("get unknown user by id", http.MethodGet, "/users/id/99", adminToken, nil, http.StatusBadRequest)
+	c.do("get user by username", http.MethodGet, "/users/username/alice", adminToken, nil, http.StatusOK)
+	c.do("get user by email", http.MethodGet, "/users/email/alice@example.com", adminToken, nil, http.StatusOK)
+	c.do("create user", http.MethodPost, "/users", adminToken, map[string]any{
+		"full_name": "Bob", "email": "bob@example.com", "username": "bob", "password": "bob", "address": "Hue", "role_name": model.RoleCustomer,
+	}, http.StatusOK)
+	c.do("create user with unknown role", http.MethodPost, "/users", adminToken, map[string]any{
+		"full_name": "Carol", "email": "carol@example.com", "username": "carol", "password": "carol", "address": "Hue", "role_name": "UNKNOWN",
+	}, http.StatusBadRequest)
+	c.do("update user", http.MethodPut, "/users/id/3", adminToken, map[string]any{"address": "Can Tho"}, http.StatusOK)
+	c.do("delete user", http.MethodDelete, "/users/id/3", adminToken, nil, http.StatusOK)
+
+	// Roles and permissions
+	c.do("get roles", http.MethodGet, "/roles", adminToken, nil, http.StatusOK)
+	c.do("get role by name", http.MethodGet, "/roles/name/CUSTOMER", adminToken, nil, http.StatusOK)
+	c.do("get permissions", http.MethodGet, "/permissions", adminToken, nil, http.StatusOK)
+	c.do("create role", http.MethodPost, "/roles", adminToken, map[string]any{"name": "WAREHOUSE", "description": "Warehouse staff"}, http.StatusOK)
+	c.do("create role invalid name", http.MethodPost, "/roles", adminToken, map[string]any{"name": "warehouse"}, http.StatusUnprocessableEntity)
+	c.do("add permissions to role", http.MethodPost, "/roles/name/WAREHOUSE/permissions", adminToken, map[string]any{"permission_names": []string{"product:write", "invoice:read"}}, http.StatusOK)
+	c.do("remove permission from role", http.MethodDelete, "/roles/name/WAREHOUSE/permissions/invoice:read", adminToken, nil, http.StatusOK)
+	c.do("delete role", http.MethodDelete, "/roles/name/WAREHOUSE", adminToken, nil, http.StatusOK)
+
+	// Cart
+	c.do("get my cart", http.MethodGet, "/my-cart", aliceToken, nil, http.StatusOK)
+	c.do("add my cart item", http.MethodPost, "/my-cart-items", aliceToken, map[string]any{"product_id": 1}, http.StatusOK)
+	c.do("add my cart item of unknown product", http.MethodPost, "/my-cart-items", aliceToken, map[string]any{"product_id": 99}, http.StatusBadRequest)
+	c.do("add second my cart item", http.MethodPost, "/my-cart-items", aliceToken, map[string]any{"product_id": 2}, http.StatusOK)
+	c.do("update my cart item", http.MethodPut, "/my-cart-items/id/1", aliceToken, map[string]any{"quantity": 2}, http.StatusOK)
+	c.do("delete my cart item", http.MethodDelete, "/my-cart-items/id/2", aliceToken, nil, http.StatusOK)
+	c.do("get my cart items", http.MethodGet, "/my-cart-items", aliceToken, nil, http.StatusOK)
+	c.do("get carts", http.MethodGet, "/carts", adminToken, nil, http.StatusOK)
+	c.do("get cart by user id", http.MethodGet, "/carts/user-id/2", adminToken, nil, http.StatusOK)
+	c.do("get cart items", http.MethodGet, "/cart-items", adminToken, nil, http.StatusOK)
+	c.do("get cart item by id", http.MethodGet, "/cart-items/id/1", adminToken, nil, http.StatusOK)
+	c.do("get cart items by cart id", http.MethodGet, "/cart-items/cart-id/2", adminToken, nil, http.StatusOK)
+
+	// Checkout and invoices
+	c.do("checkout my cart", http.MethodPost, "/my-cart/checkout", aliceToken, nil, http.StatusOK)
+	c.do("checkout empty cart", http.MethodPost, "/my-cart/checkout", aliceToken, nil, http.StatusBadRequest)
+	c.do("get my invoices", http.MethodGet, "/my-invoices", aliceToken, nil, http.StatusOK)
+	c.do("get my invoice by id", http.MethodGet, "/my-invoices/id/1", aliceToken, nil, http.StatusOK)
+	c.do("get my invoice details", http.MethodGet, "/my-invoice-details/invoice-id/1", aliceToken, nil, http.StatusOK)
+	c.do("get invoices", http.MethodGet, "/invoices?include_total=true", adminToken, nil, http.StatusOK)
+	c.do("get invoice by id", http.MethodGet, "/invoices/id/1", adminToken, nil, http.StatusOK)
+	c.do("get invoices by user id", http.MethodGet, "/invoices/user-id/2", adminToken, nil, http.StatusOK)
+	c.do("get invoice details", http.MethodGet, "/invoice-details", adminToken, nil, http.StatusOK)
+	c.do("get invoice detail by id", http.MethodGet, "/invoice-details/id/1", adminToken, nil, http.StatusOK)
+	c.do("get invoice details by invoice id", http.MethodGet, "/invoice-details/invoice-id/1", adminToken, nil, http.StatusOK)
+	c.do("update invoice status", http.MethodPut, "/invoices/id/1", adminToken, map[string]any{"status": "PAID"}, http.StatusOK)
+	c.do("update invoice invalid transition", http.MethodPut, "/invoices/id/1", adminToken, map[string]any{"status": "DONE"}, http.StatusBadRequest)
+	c.do("get invoice status histories", http.MethodGet, "/invoices/id/1/status-histories", adminToken, nil, http.StatusOK)
+	c.do("cancel paid invoice", http.MethodPost, "/my-invoices/id/1/cancel", aliceToken, nil, http.StatusBadRequest)
+
+	c.do("add cart item for second checkout", http.MethodPost, "/my-cart-items", aliceToken, map[string]any{"product_id": 2}, http.StatusOK)
+	c.do("checkout my cart again", http.MethodPost, "/my-cart/checkout", aliceToken, nil, http.StatusOK)
+	c.do("cancel my invoice", http.MethodPost, "/my-invoices/id/2/cancel", aliceToken, nil, http.StatusOK)
+	c.do("delete my invoice without permission", http.MethodDelete, "/my-invoices/id/2", aliceToken, nil, http.StatusForbidden)
+	c.do("add admin cart item", http.MethodPost, "/my-cart-items", adminToken, map[string]any{"product_id": 1}, http.StatusOK)
+	c.do("checkout admin cart", http.MethodPost, "/my-cart/checkout", adminToken, nil, http.StatusOK)
+	c.do("delete my invoice", http.MethodDelete, "/my-invoices/id/3", adminToken, nil, http.StatusOK)
+
+	// Elasticsearch
+	c.do("sync invoices to elasticsearch", http.MethodGet, "/invoices/sync-to-elasticsearch", adminToken, nil, http.StatusOK)
+	c.do("get invoices with elasticsearch", http.MethodGet, "/invoices/elasticsearch?include_total=true", adminToken, nil, http.StatusOK)
+	c.do("get invoices with elasticsearch invalid date", http.MethodGet, "/invoices/elasticsearch?created_at_gte=yesterday", adminToken, nil, http.StatusInternalServerError)
+	c.do("sum invoices with elasticsearch", http.MethodGet, "/invoices/elasticsearch/sum", adminToken, nil, http.StatusOK)
+	c.do("report invoices with elasticsearch", http.MethodGet, "/invoices/elasticsearch/report", adminToken, nil, http.StatusOK)
+
+	// Sessions and passwords
+	// Sessions are listed newest first by created_at in seconds, so the second session starts in a new second
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	c.do("login alice on laptop", http.MethodPost, "/login", "", map[string]any{"username": "alice", "password": "alice", "device": "Laptop"}, http.StatusOK)
+	sessions := c.do("get my sessions", http.MethodGet, "/my-account/sessions", aliceToken, nil, http.StatusOK)
+	otherSessionId := ""
+	for _, session := range sessions["data"].([]any) {
+		if session := session.(map[string]any); !session["current"].(bool) {
+			otherSessionId = session["id"].(string)
+		}
+	}
+	c.do("delete my session", http.MethodDelete, "/my-account/sessions/"+otherSessionId, aliceToken, nil, http.StatusOK)
+	c.do("delete unknown session", http.MethodDelete, "/my-account/sessions/unknown", aliceToken, nil, http.StatusBadRequest)
+	c.do("change password with wrong current password", http.MethodPost, "/my-account/password", aliceToken, map[string]any{"current_password": "wrong", "new_password": "alice2"}, http.StatusBadRequest)
+	c.do("change password", http.MethodPost, "/my-account/password", aliceToken, map[string]any{"current_password": "alice", "new_password": "alice2"}, http.StatusOK)
+	c.do("request password reset", http.MethodPost, "/password-reset/request", "", map[string]any{"email": "alice@example.com"}, http.StatusOK)
+	c.do("request password reset of unknown email", http.MethodPost, "/password-reset/request", "", map[string]any{"email": "nobody@example.com"}, http.StatusOK)
+	c.do("confirm password reset", http.MethodPost, "/password-reset/confirm", "", map[string]any{"token": f.lastToken(t, "alice@example.com"), "new_password": "alice3"}, http.StatusOK)
+	c.do("confirm password reset with used token", http.MethodPost, "/password-reset/confirm", "", map[string]any{"token": f.lastToken(t, "alice@example.com"), "new_password": "alice4"}, http.StatusBadRequest)
+	c.do("access token after password reset", http.MethodGet, "/my-account", aliceToken, nil, http.StatusUnauthorized)
+
+	aliceToken = dataOf(c.do("login with new password", http.MethodPost, "/login", "", map[string]any{"username": "alice", "password": "alice3"}, http.StatusOK))["access_token"].(string)
+	c.do("logout", http.MethodPost, "/logout", aliceToken, nil, http.StatusOK)
+	c.do("access token after logout", http.MethodGet, "/my-account", aliceToken, nil, http.StatusUnauthorized)
+	aliceToken = dataOf(c.do("login after logout", http.MethodPost, "/login", "", map[string]any{"username": "alice", "password": "alice3"}, http.StatusOK))["access_token"].(string)
+	c.do("delete all my sessions", http.MethodDelete, "/my-account/sessions", aliceToken, nil, http.StatusOK)
+	aliceToken = dataOf(c.do("login after logout everywhere", http.MethodPost, "/login", "", map[string]any{"username": "alice", "password": "alice3"}, http.StatusOK))["access_token"].(string)
+	c.do("revoke sessions of user", http.MethodDelete, "/users/id/2/sessions", adminToken, nil, http.StatusOK)
+	c.do("access token after sessions revoked", http.MethodGet, "/my-account", aliceToken, nil, http.StatusUnauthorized)
+
+	c.checkCoverage()
+	c.checkSnapshot("api_contract.json")
+}
+
+func TestOpenAPIContract(t *testing.T) {
+	f := newContractFixture(t)
+
+	resp := humatest.Wrap(t, f.api).Get("/openapi.json")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.Code, http.StatusOK)
+	}
+
+	var openAPI any
+	if err := json.Unmarshal(resp.Body.Bytes(), &openAPI); err != nil {
+		t.Fatal(err)
+	}
+	indented, err := json.MarshalIndent(openAPI, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSnapshot(t, "openapi.json", indented)
+}
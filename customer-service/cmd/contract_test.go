@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+)
+
+var update = flag.Bool("update", false, "rewrite snapshots in testdata with current responses")
+
+// One request and its response as kept in snapshot, values that change between runs are scrubbed
+type exchange struct {
+	Name    string `json:"name"`
+	Request string `json:"request"`
+	Status  int    `json:"status"`
+	Body    any    `json:"body"`
+}
+
+// Drives the API through humatest and records every exchange, so a run can be compared with the snapshot
+// and checked to have called every registered operation
+type contractTest struct {
+	t         *testing.T
+	api       huma.API
+	testAPI   humatest.TestAPI
+	exchanges []exchange
+	called    map[string]bool
+}
+
+func newContractTest(t *testing.T, api huma.API) *contractTest {
+	return &contractTest{
+		t:       t,
+		api:     api,
+		testAPI: humatest.Wrap(t, api),
+		called:  map[string]bool{},
+	}
+}
+
+// Sends body as JSON with token as bearer when they are given, fails the test on any other status than wantStatus
+func (c *contractTest) do(name string, method string, path string, token string, body any, wantStatus int) map[string]any {
+	c.t.Helper()
+
+	args := []any{}
+	if token != "" {
+		args = append(args, "Authorization: Bearer "+token)
+	}
+	if body != nil {
+		args = append(args, body)
+	}
+	resp := c.testAPI.Do(method, path, args...)
+	if resp.Code != wantStatus {
+		c.t.Fatalf("%s: %s %s status = %d, want %d, body: %s", name, method, path, resp.Code, wantStatus, resp.Body.String())
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(resp.Body.Bytes(), &decoded); err != nil {
+		c.t.Fatalf("%s: decode body: %v", name, err)
+	}
+
+	// Request is kept with parameters of the registered path, ids generated during the run stay out of snapshot
+	operation := c.operationOf(method, path)
+	c.called[operation] = true
+	request := operation
+	if _, query, ok := strings.Cut(path, "?"); ok {
+		request += "?" + query
+	}
+	c.exchanges = append(c.exchanges, exchange{
+		Name:    name,
+		Request: request,
+		Status:  resp.Code,
+		Body:    scrub("", decoded),
+	})
+
+	return decoded
+}
+
+// Operation registered for the concrete path, literal segments win over parameters like in the router
+func (c *contractTest) operationOf(method string, path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	segments := strings.Split(path, "/")
+
+	operation, bestLiterals := method+" "+path, -1
+	for _, registered := range operationsOf(c.api) {
+		registeredMethod, registeredPath, _ := strings.Cut(registered, " ")
+		registeredSegments := strings.Split(registeredPath, "/")
+		if registeredMethod != method || len(registeredSegments) != len(segments) {
+			continue
+		}
+
+		literals, ok := 0, true
+		for i, segment := range registeredSegments {
+			if strings.HasPrefix(segment, "{") {
+				continue
+			}
+			if segment != segments[i] {
+				ok = false
+				break
+			}
+			literals++
+		}
+		if ok && literals > bestLiterals {
+			operation, bestLiterals = registered, literals
+		}
+	}
+
+	return operation
+}
+
+func (c *contractTest) checkCoverage() {
+	c.t.Helper()
+
+	for _, operation := range operationsOf(c.api) {
+		if !c.called[operation] {
+			c.t.Errorf("operation %s is not covered by contract test", operation)
+		}
+	}
+}
+
+func (c *contractTest) checkSnapshot(file string) {
+	c.t.Helper()
+
+	got, err := json.MarshalIndent(c.exchanges, "", "  ")
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	checkSnapshot(c.t, file, got)
+}
+
+// Every operation of the API as "METHOD /path", sorted
+func operationsOf(api huma.API) []string {
+	operations := []string{}
+	for path, pathItem := range api.OpenAPI().Paths {
+		for method, operation := range map[string]*huma.Operation{
+			http.MethodGet:    pathItem.Get,
+			http.MethodPost:   pathItem.Post,
+			http.MethodPut:    pathItem.Put,
+			http.MethodPatch:  pathItem.Patch,
+			http.MethodDelete: pathItem.Delete,
+		} {
+			if operation != nil {
+				operations = append(operations, method+" "+path)
+			}
+		}
+	}
+	slices.Sort(operations)
+
+	return operations
+}
+
+// Compares got with testdata/file, or rewrites the file when running with -update
+func checkSnapshot(t *testing.T, file string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", file)
+	got = append(bytes.TrimSpace(got), '\n')
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read snapshot: %v, run go test ./cmd -update to create it", err)
+	}
+	if bytes.Equal(got, want) {
+		return
+	}
+
+	gotLines, wantLines := strings.Split(string(got), "\n"), strings.Split(string(want), "\n")
+	for i := 0; i < max(len(gotLines), len(wantLines)); i++ {
+		var gotLine, wantLine string
+		if i < len(gotLines) {
+			gotLine = gotLines[i]
+		}
+		if i < len(wantLines) {
+			wantLine = wantLines[i]
+		}
+		if gotLine != wantLine {
+			t.Errorf("%s differs from snapshot at line %d:\n got: %s\nwant: %s\nrun go test ./cmd -update if the change is intended", path, i+1, gotLine, wantLine)
+			return
+		}
+	}
+}
+
+var (
+	jwtPattern  = regexp.MustCompile(`^[\w-]+\.[\w-]+\.[\w-]+$`)
+	datePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+)
+
+// Replaces values that differ between runs: times, tokens, cursors, session ids and signing keys
+func scrub(key string, value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		scrubbed := map[string]any{}
+		for k, v := range value {
+			scrubbed[k] = scrub(k, v)
+		}
+		return scrubbed
+	case []any:
+		scrubbed := make([]any, len(value))
+		for i, v := range value {
+			scrubbed[i] = scrub(key, v)
+		}
+		return scrubbed
+	case string:
+		switch {
+		case value == "":
+			return value
+		case key == "next_cursor" || key == "prev_cursor":
+			return "<cursor>"
+		case key == "id" || key == "kid" || key == "n" || key == "refresh_token":
+			return "<" + strings.ReplaceAll(key, "_", "-") + ">"
+		case jwtPattern.MatchString(value):
+			return "<jwt>"
+		case datePattern.MatchString(value):
+			return "<date>"
+		}
+		if _, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return "<time>"
+		}
+		return value
+	default:
+		return value
+	}
+}
@@ -1,18 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
+	"os"
 	"thanhldt060802/config"
 	"thanhldt060802/infrastructure"
-	"thanhldt060802/internal/dto"
-	"thanhldt060802/internal/handler"
-	"thanhldt060802/internal/middleware"
+	"thanhldt060802/internal/client"
+	"thanhldt060802/internal/notifier"
 	"thanhldt060802/internal/repository"
 	"thanhldt060802/internal/service"
+	"thanhldt060802/internal/worker"
+	"thanhldt060802/migrations"
+	"thanhldt060802/utils"
 
-	"github.com/danielgtaylor/huma/v2"
-	"github.com/danielgtaylor/huma/v2/adapters/humagin"
 	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
 )
 
 // Huma Docs UI template by Scalar
@@ -33,37 +38,84 @@ var humaDocsEmbedded = `<!doctype html>
 
 func main() {
 
-	config.InitConfig()
-	infrastructure.InitPostgesConnection()
-	defer infrastructure.DB.Close()
-	infrastructure.InitRedisClient()
-	defer infrastructure.RedisClient.Close()
-	infrastructure.InitElasticsearchClient()
-
-	humaCfg := huma.DefaultConfig("Customer Service", "v1.0.0")
-	humaCfg.DocsPath = ""
-	humaCfg.JSONSchemaDialect = ""
-	humaCfg.CreateHooks = nil
-	humaCfg.Components = &huma.Components{
-		SecuritySchemes: map[string]*huma.SecurityScheme{
-			"BearerAuth": {
-				Type:         "http",
-				Scheme:       "bearer",
-				BearerFormat: "JWT",
-			},
-		},
+	appConfig := config.LoadConfig()
+	db := infrastructure.NewPostgresDB(appConfig)
+	defer db.Close()
+
+	// Manage database schema as a one-off command: go run ./cmd migrate up|down|status,
+	// demo data is a separate set: go run ./cmd migrate seed up|down|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), db, appConfig.PostgresSchema, os.Args[2:]); err != nil {
+			log.Fatal("Migrate failed: ", err)
+		}
+		return
+	}
+
+	// Refuse to run against a schema older than this binary
+	if err := migrations.CheckSchema(context.Background(), db); err != nil {
+		log.Fatal(err)
 	}
 
-	huma.NewError = func(status int, msg string, errs ...error) huma.StatusError {
-		details := make([]string, len(errs))
-		for i, err := range errs {
-			details[i] = err.Error()
+	redisClient := infrastructure.NewRedisClient(appConfig)
+	defer redisClient.Close()
+	elasticsearchClient := infrastructure.NewElasticsearchClient(appConfig)
+	jwtKeys, err := utils.NewJWTKeys(appConfig.JWTPrivateKeyFile, appConfig.JWTPreviousPublicKeyFiles)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Initialize repositories
+	userRepository := repository.NewUserRepository(db)
+	cartRepository := repository.NewCartRepository(db)
+	cartItemRepository := repository.NewCartItemRepository(db)
+	invoiceRepository := repository.NewInvoiceRepository(db)
+	invoiceDetailRepository := repository.NewInvoiceDetailRepository(db)
+	invoiceStatusHistoryRepository := repository.NewInvoiceStatusHistoryRepository(db)
+	outboxEventRepository := repository.NewOutboxEventRepository(db)
+	sessionRepository := repository.NewSessionRepository(redisClient)
+	roleRepository := repository.NewRoleRepository(db)
+	permissionRepository := repository.NewPermissionRepository(db)
+	oneTimeTokenRepository := repository.NewOneTimeTokenRepository(redisClient)
+	loginAttemptRepository := repository.NewLoginAttemptRepository(redisClient)
+	auditLogRepository := repository.NewAuditLogRepository(db)
+	productEventRepository := repository.NewProductEventRepository(redisClient, productEventConsumer())
+
+	// Initialize transaction manager
+	transactionManager := repository.NewTransactionManager(db)
+
+	// Initialize Elasticsearch repository
+	invoiceElasticsearchRepository := repository.NewInvoiceElasticsearchRepository(elasticsearchClient)
+
+	// Initialize service clients
+	productClient := client.NewProductClient(redisClient, jwtKeys, appConfig)
+
+	// Initialize notifier
+	var userNotifier notifier.Notifier
+	switch appConfig.NotifierType {
+	case "file":
+		userNotifier = notifier.NewFileNotifier(appConfig.NotifierFilePath)
+	default:
+		userNotifier = notifier.NewLogNotifier()
+	}
+
+	// Initialize services
+	userService := service.NewUserService(userRepository, cartRepository, sessionRepository, roleRepository, oneTimeTokenRepository, loginAttemptRepository, auditLogRepository, userNotifier, jwtKeys, appConfig)
+	roleService := service.NewRoleService(roleRepository, permissionRepository, userRepository)
+	cartService := service.NewCartService(cartRepository)
+	cartItemService := service.NewCartItemService(cartItemRepository, cartRepository, productClient)
+	invoiceService := service.NewInvoiceService(invoiceRepository, invoiceElasticsearchRepository, invoiceDetailRepository, invoiceStatusHistoryRepository, outboxEventRepository, userRepository, cartRepository, cartItemRepository, productClient, transactionManager)
+	outboxService := service.NewOutboxService(outboxEventRepository, invoiceRepository, invoiceElasticsearchRepository, productClient, transactionManager, appConfig)
+	invoiceDetailService := service.NewInvoiceDetailService(invoiceDetailRepository)
+	auditLogService := service.NewAuditLogService(auditLogRepository)
+	productEventService := service.NewProductEventService(productEventRepository, cartItemRepository, appConfig)
+
+	// Rebuild Elasticsearch index as a one-off command: go run ./cmd reindex
+	if len(os.Args) > 1 && os.Args[1] == "reindex" {
+		if err := invoiceService.SyncAllInvoicesToElasticsearch(context.Background()); err != nil {
+			log.Fatal("Reindex invoices failed: ", err)
 		}
-		res := &dto.ErrorResponse{}
-		res.Status = status
-		res.Message = msg
-		res.Details = details
-		return res
+		log.Println("Reindex invoices successful")
+		return
 	}
 
 	r := gin.Default()
@@ -71,35 +123,56 @@ func main() {
 		ctx.Data(http.StatusOK, "text/html", []byte(humaDocsEmbedded))
 	})
 
-	api := humagin.New(r, humaCfg)
+	newAPI(r, &services{
+		userService:          userService,
+		roleService:          roleService,
+		cartService:          cartService,
+		cartItemService:      cartItemService,
+		invoiceService:       invoiceService,
+		invoiceDetailService: invoiceDetailService,
+		auditLogService:      auditLogService,
+	}, redisClient, jwtKeys, appConfig)
 
-	// Initialize auth middleware
-	authMiddleware := middleware.NewAuthMiddleware(api)
+	// Start background workers
+	worker.StartOutboxDispatcher(context.Background(), outboxService, *appConfig.GetOutboxDispatchIntervalSeconds())
+	worker.StartProductEventConsumer(context.Background(), productEventService, *appConfig.GetProductEventPollIntervalSeconds())
 
-	// Initialize repositories
-	userRepository := repository.NewUserRepository()
-	cartRepository := repository.NewCartRepository()
-	cartItemRepository := repository.NewCartItemRepository()
-	invoiceRepository := repository.NewInvoiceRepository()
-	invoiceDetailRepository := repository.NewInvoiceDetailRepository()
+	r.Run(":" + appConfig.AppPort)
 
-	// Initialize Elasticsearch repository
-	invoiceElasticsearchRepository := repository.NewInvoiceElasticsearchRepository()
+}
 
-	// Initialize services
-	userService := service.NewUserService(userRepository, cartRepository)
-	cartService := service.NewCartService(cartRepository)
-	cartItemService := service.NewCartItemService(cartItemRepository, cartRepository)
-	invoiceService := service.NewInvoiceService(invoiceRepository, invoiceElasticsearchRepository)
-	invoiceDetailService := service.NewInvoiceDetailService(invoiceDetailRepository)
+// Runs migrate subcommand with args after "migrate" in schema of the service, seed migrations need the schema to be
+// up to date
+func runMigrate(ctx context.Context, db *bun.DB, schema string, args []string) error {
+	if err := migrations.PrepareSchema(ctx, db, schema); err != nil {
+		return err
+	}
 
-	// Initialize handlers
-	handler.NewUserHandler(api, userService, authMiddleware)
-	handler.NewCartHandler(api, cartService, authMiddleware)
-	handler.NewCartItemHandler(api, cartItemService, authMiddleware)
-	handler.NewInvoiceHandler(api, invoiceService, authMiddleware)
-	handler.NewInvoiceDetailHandler(api, invoiceDetailService, invoiceService, authMiddleware)
+	newMigrator := migrations.NewSchemaMigrator
+	if len(args) > 0 && args[0] == "seed" {
+		if err := migrations.CheckSchema(ctx, db); err != nil {
+			return err
+		}
+		newMigrator, args = migrations.NewSeedMigrator, args[1:]
+	}
+	if len(args) != 1 {
+		return errors.New("usage: migrate [seed] up|down|status")
+	}
+
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
 
-	r.Run(":" + config.AppConfig.AppPort)
+	return migrations.Run(ctx, migrator, args[0])
+}
+
+// Consumer name of this replica in the product event group, hostname differs between containers
+func productEventConsumer() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "customer-service"
+	}
 
+	return hostname
 }
@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -17,29 +18,66 @@ type Config struct {
 	PostgresUser     string
 	PostgresPassword string
 	PostgresDB       string
+	PostgresSchema   string
 
-	JWTSecret          string
-	TokenExpireMinutes string
+	JWTPrivateKeyFile         string
+	JWTPreviousPublicKeyFiles string
+	AccessTokenExpireMinutes  string
+	RefreshTokenExpireMinutes string
+
+	EmailVerificationExpireMinutes string
+	PasswordResetExpireMinutes     string
+	PasswordResetURL               string
+
+	LoginMaxFailedAttemptsPerUsername string
+	LoginMaxFailedAttemptsPerIp       string
+	LoginFailedAttemptWindowMinutes   string
+	LoginLockoutMinutes               string
+
+	NotifierType     string
+	NotifierFilePath string
 
 	RedisHost     string
 	RedisPort     string
 	RedisPassword string
 
+	RateLimitDefault    string
+	RateLimitOperations string
+
 	ElasticsearchHost     string
 	ElasticsearchPort     string
 	ElasticsearchUsername string
 	ElasticsearchPassword string
+
+	CatalogServiceHost           string
+	CatalogServicePort           string
+	CatalogServiceTimeoutSeconds string
+	CatalogServiceMaxRetries     string
+	ProductCacheExpireSeconds    string
+
+	OutboxDispatchIntervalSeconds string
+	OutboxBatchSize               string
+	OutboxMaxAttempts             string
+
+	ProductEventPollIntervalSeconds string
+	ProductEventBatchSize           string
+	ProductEventMaxDeliveries       string
 }
 
-var AppConfig *Config
+// Requests allowed per window, written as "limit/window" in environment like "60/1m", zero limit means unlimited
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
 
-func InitConfig() {
+// Loads configuration from .env and environment, callers pass the result to whatever needs it
+func LoadConfig() *Config {
 	err := godotenv.Load(".env")
 	if err != nil {
 		log.Fatal("Error loading .env file: ", err)
 	}
 
-	AppConfig = &Config{
+	appConfig := &Config{
 		AppPort: GetEnv("APP_PORT", "8080"),
 
 		PostgresHost:     GetEnv("POSTGRES_HOST", "localhost"),
@@ -47,21 +85,54 @@ func InitConfig() {
 		PostgresUser:     GetEnv("POSTGRES_USER", "postgres"),
 		PostgresPassword: GetEnv("POSTGRES_PASSWORD", ""),
 		PostgresDB:       GetEnv("POSTGRES_DB", "my_db"),
+		PostgresSchema:   GetEnv("POSTGRES_SCHEMA", "customer"),
 
-		JWTSecret:          GetEnv("JWT_SECRET", "123"),
-		TokenExpireMinutes: GetEnv("TOKEN_EXPIRE_MINUTES", "30"),
+		JWTPrivateKeyFile:         GetEnv("JWT_PRIVATE_KEY_FILE", "keys/jwt_private.pem"),
+		JWTPreviousPublicKeyFiles: GetEnv("JWT_PREVIOUS_PUBLIC_KEY_FILES", ""),
+		AccessTokenExpireMinutes:  GetEnv("ACCESS_TOKEN_EXPIRE_MINUTES", "15"),
+		RefreshTokenExpireMinutes: GetEnv("REFRESH_TOKEN_EXPIRE_MINUTES", "10080"),
+
+		EmailVerificationExpireMinutes: GetEnv("EMAIL_VERIFICATION_EXPIRE_MINUTES", "1440"),
+		PasswordResetExpireMinutes:     GetEnv("PASSWORD_RESET_EXPIRE_MINUTES", "30"),
+		PasswordResetURL:               GetEnv("PASSWORD_RESET_URL", "http://localhost:3000/reset-password"),
+
+		LoginMaxFailedAttemptsPerUsername: GetEnv("LOGIN_MAX_FAILED_ATTEMPTS_PER_USERNAME", "5"),
+		LoginMaxFailedAttemptsPerIp:       GetEnv("LOGIN_MAX_FAILED_ATTEMPTS_PER_IP", "20"),
+		LoginFailedAttemptWindowMinutes:   GetEnv("LOGIN_FAILED_ATTEMPT_WINDOW_MINUTES", "15"),
+		LoginLockoutMinutes:               GetEnv("LOGIN_LOCKOUT_MINUTES", "15"),
+
+		NotifierType:     GetEnv("NOTIFIER_TYPE", "log"),
+		NotifierFilePath: GetEnv("NOTIFIER_FILE_PATH", "notifications.log"),
 
 		RedisHost:     GetEnv("REDIS_HOST", "localhost"),
 		RedisPort:     GetEnv("REDIS_PORT", "6379"),
 		RedisPassword: GetEnv("REDIS_PASSWORD", ""),
 
+		RateLimitDefault:    GetEnv("RATE_LIMIT_DEFAULT", "120/1m"),
+		RateLimitOperations: GetEnv("RATE_LIMIT_OPERATIONS", "POST /login=10/1m;POST /register=5/1m;POST /password-reset/request=5/1m;POST /verify-email=10/1m;POST /token/refresh=30/1m"),
+
 		ElasticsearchHost:     GetEnv("ELASTICSEARCH_HOST", "localhost"),
 		ElasticsearchPort:     GetEnv("ELASTICSEARCH_PORT", "9200"),
 		ElasticsearchUsername: GetEnv("ELASTICSEARCH_USERNAME", "elastic"),
 		ElasticsearchPassword: GetEnv("ELASTICSEARCH_PASSWORD", ""),
+
+		CatalogServiceHost:           GetEnv("CATALOG_SERVICE_HOST", "localhost"),
+		CatalogServicePort:           GetEnv("CATALOG_SERVICE_PORT", "8081"),
+		CatalogServiceTimeoutSeconds: GetEnv("CATALOG_SERVICE_TIMEOUT_SECONDS", "5"),
+		CatalogServiceMaxRetries:     GetEnv("CATALOG_SERVICE_MAX_RETRIES", "3"),
+		ProductCacheExpireSeconds:    GetEnv("PRODUCT_CACHE_EXPIRE_SECONDS", "60"),
+
+		OutboxDispatchIntervalSeconds: GetEnv("OUTBOX_DISPATCH_INTERVAL_SECONDS", "5"),
+		OutboxBatchSize:               GetEnv("OUTBOX_BATCH_SIZE", "100"),
+		OutboxMaxAttempts:             GetEnv("OUTBOX_MAX_ATTEMPTS", "10"),
+
+		ProductEventPollIntervalSeconds: GetEnv("PRODUCT_EVENT_POLL_INTERVAL_SECONDS", "5"),
+		ProductEventBatchSize:           GetEnv("PRODUCT_EVENT_BATCH_SIZE", "100"),
+		ProductEventMaxDeliveries:       GetEnv("PRODUCT_EVENT_MAX_DELIVERIES", "10"),
 	}
 
 	log.Println("Loading .env file successful")
+	return appConfig
 }
 
 func GetEnv(key string, defaultValue string) string {
@@ -72,8 +143,8 @@ func GetEnv(key string, defaultValue string) string {
 	}
 }
 
-func (config *Config) GetTokenExpireMinutes() *time.Duration {
-	tokenExpireMinutes, err := strconv.Atoi(AppConfig.TokenExpireMinutes)
+func (config *Config) GetAccessTokenExpireMinutes() *time.Duration {
+	tokenExpireMinutes, err := strconv.Atoi(config.AccessTokenExpireMinutes)
 	if err != nil {
 		log.Fatal("Value of environment variable ACCESS_TOKEN_EXPIRE_MINUTES is not valid")
 		return nil
@@ -82,3 +153,215 @@ func (config *Config) GetTokenExpireMinutes() *time.Duration {
 	expireDuration := time.Duration(tokenExpireMinutes) * time.Minute
 	return &expireDuration
 }
+
+func (config *Config) GetRefreshTokenExpireMinutes() *time.Duration {
+	tokenExpireMinutes, err := strconv.Atoi(config.RefreshTokenExpireMinutes)
+	if err != nil {
+		log.Fatal("Value of environment variable REFRESH_TOKEN_EXPIRE_MINUTES is not valid")
+		return nil
+	}
+
+	expireDuration := time.Duration(tokenExpireMinutes) * time.Minute
+	return &expireDuration
+}
+
+func (config *Config) GetEmailVerificationExpireMinutes() *time.Duration {
+	tokenExpireMinutes, err := strconv.Atoi(config.EmailVerificationExpireMinutes)
+	if err != nil {
+		log.Fatal("Value of environment variable EMAIL_VERIFICATION_EXPIRE_MINUTES is not valid")
+		return nil
+	}
+
+	expireDuration := time.Duration(tokenExpireMinutes) * time.Minute
+	return &expireDuration
+}
+
+func (config *Config) GetPasswordResetExpireMinutes() *time.Duration {
+	tokenExpireMinutes, err := strconv.Atoi(config.PasswordResetExpireMinutes)
+	if err != nil {
+		log.Fatal("Value of environment variable PASSWORD_RESET_EXPIRE_MINUTES is not valid")
+		return nil
+	}
+
+	expireDuration := time.Duration(tokenExpireMinutes) * time.Minute
+	return &expireDuration
+}
+
+func (config *Config) GetLoginMaxFailedAttemptsPerUsername() int {
+	maxAttempts, err := strconv.Atoi(config.LoginMaxFailedAttemptsPerUsername)
+	if err != nil || maxAttempts <= 0 {
+		log.Fatal("Value of environment variable LOGIN_MAX_FAILED_ATTEMPTS_PER_USERNAME is not valid")
+	}
+
+	return maxAttempts
+}
+
+func (config *Config) GetLoginMaxFailedAttemptsPerIp() int {
+	maxAttempts, err := strconv.Atoi(config.LoginMaxFailedAttemptsPerIp)
+	if err != nil || maxAttempts <= 0 {
+		log.Fatal("Value of environment variable LOGIN_MAX_FAILED_ATTEMPTS_PER_IP is not valid")
+	}
+
+	return maxAttempts
+}
+
+func (config *Config) GetLoginFailedAttemptWindowMinutes() *time.Duration {
+	windowMinutes, err := strconv.Atoi(config.LoginFailedAttemptWindowMinutes)
+	if err != nil || windowMinutes <= 0 {
+		log.Fatal("Value of environment variable LOGIN_FAILED_ATTEMPT_WINDOW_MINUTES is not valid")
+		return nil
+	}
+
+	windowDuration := time.Duration(windowMinutes) * time.Minute
+	return &windowDuration
+}
+
+func (config *Config) GetLoginLockoutMinutes() *time.Duration {
+	lockoutMinutes, err := strconv.Atoi(config.LoginLockoutMinutes)
+	if err != nil || lockoutMinutes <= 0 {
+		log.Fatal("Value of environment variable LOGIN_LOCKOUT_MINUTES is not valid")
+		return nil
+	}
+
+	lockoutDuration := time.Duration(lockoutMinutes) * time.Minute
+	return &lockoutDuration
+}
+
+func (config *Config) GetCatalogServiceTimeout() *time.Duration {
+	timeoutSeconds, err := strconv.Atoi(config.CatalogServiceTimeoutSeconds)
+	if err != nil {
+		log.Fatal("Value of environment variable CATALOG_SERVICE_TIMEOUT_SECONDS is not valid")
+		return nil
+	}
+
+	timeoutDuration := time.Duration(timeoutSeconds) * time.Second
+	return &timeoutDuration
+}
+
+func (config *Config) GetCatalogServiceMaxRetries() int {
+	maxRetries, err := strconv.Atoi(config.CatalogServiceMaxRetries)
+	if err != nil || maxRetries < 0 {
+		log.Fatal("Value of environment variable CATALOG_SERVICE_MAX_RETRIES is not valid")
+		return 0
+	}
+
+	return maxRetries
+}
+
+func (config *Config) GetProductCacheExpireSeconds() *time.Duration {
+	expireSeconds, err := strconv.Atoi(config.ProductCacheExpireSeconds)
+	if err != nil {
+		log.Fatal("Value of environment variable PRODUCT_CACHE_EXPIRE_SECONDS is not valid")
+		return nil
+	}
+
+	expireDuration := time.Duration(expireSeconds) * time.Second
+	return &expireDuration
+}
+
+func (config *Config) GetOutboxDispatchIntervalSeconds() *time.Duration {
+	intervalSeconds, err := strconv.Atoi(config.OutboxDispatchIntervalSeconds)
+	if err != nil || intervalSeconds <= 0 {
+		log.Fatal("Value of environment variable OUTBOX_DISPATCH_INTERVAL_SECONDS is not valid")
+		return nil
+	}
+
+	intervalDuration := time.Duration(intervalSeconds) * time.Second
+	return &intervalDuration
+}
+
+func (config *Config) GetOutboxBatchSize() int {
+	batchSize, err := strconv.Atoi(config.OutboxBatchSize)
+	if err != nil || batchSize <= 0 {
+		log.Fatal("Value of environment variable OUTBOX_BATCH_SIZE is not valid")
+	}
+
+	return batchSize
+}
+
+func (config *Config) GetOutboxMaxAttempts() int {
+	maxAttempts, err := strconv.Atoi(config.OutboxMaxAttempts)
+	if err != nil || maxAttempts <= 0 {
+		log.Fatal("Value of environment variable OUTBOX_MAX_ATTEMPTS is not valid")
+	}
+
+	return maxAttempts
+}
+
+func (config *Config) GetProductEventPollIntervalSeconds() *time.Duration {
+	intervalSeconds, err := strconv.Atoi(config.ProductEventPollIntervalSeconds)
+	if err != nil || intervalSeconds <= 0 {
+		log.Fatal("Value of environment variable PRODUCT_EVENT_POLL_INTERVAL_SECONDS is not valid")
+		return nil
+	}
+
+	intervalDuration := time.Duration(intervalSeconds) * time.Second
+	return &intervalDuration
+}
+
+func (config *Config) GetProductEventBatchSize() int {
+	batchSize, err := strconv.Atoi(config.ProductEventBatchSize)
+	if err != nil || batchSize <= 0 {
+		log.Fatal("Value of environment variable PRODUCT_EVENT_BATCH_SIZE is not valid")
+	}
+
+	return batchSize
+}
+
+func (config *Config) GetProductEventMaxDeliveries() int {
+	maxDeliveries, err := strconv.Atoi(config.ProductEventMaxDeliveries)
+	if err != nil || maxDeliveries <= 0 {
+		log.Fatal("Value of environment variable PRODUCT_EVENT_MAX_DELIVERIES is not valid")
+	}
+
+	return maxDeliveries
+}
+
+func (config *Config) GetRateLimitDefault() *RateLimit {
+	rateLimit, ok := parseRateLimit(config.RateLimitDefault)
+	if !ok {
+		log.Fatal("Value of environment variable RATE_LIMIT_DEFAULT is not valid")
+		return nil
+	}
+
+	return rateLimit
+}
+
+// Operations are written as "METHOD /path=limit/window" separated by semicolons
+func (config *Config) GetRateLimitOperations() map[string]RateLimit {
+	rateLimits := map[string]RateLimit{}
+	for _, operation := range strings.Split(config.RateLimitOperations, ";") {
+		operation = strings.TrimSpace(operation)
+		if operation == "" {
+			continue
+		}
+
+		operationKey, value, found := strings.Cut(operation, "=")
+		rateLimit, ok := parseRateLimit(value)
+		if !found || !ok {
+			log.Fatal("Value of environment variable RATE_LIMIT_OPERATIONS is not valid")
+			return nil
+		}
+		rateLimits[strings.TrimSpace(operationKey)] = *rateLimit
+	}
+
+	return rateLimits
+}
+
+func parseRateLimit(value string) (*RateLimit, bool) {
+	limitValue, windowValue, found := strings.Cut(strings.TrimSpace(value), "/")
+	if !found {
+		return nil, false
+	}
+
+	limit, err := strconv.Atoi(limitValue)
+	if err != nil || limit < 0 {
+		return nil, false
+	}
+	window, err := time.ParseDuration(windowValue)
+	if err != nil || window <= 0 {
+		return nil, false
+	}
+
+	return &RateLimit{Limit: limit, Window: window}, true
+}
@@ -8,26 +8,24 @@ import (
 	"github.com/elastic/go-elasticsearch/v8"
 )
 
-var ElasticsearchClient *elasticsearch.Client
-
-func InitElasticsearchClient() {
+func NewElasticsearchClient(appConfig *config.Config) *elasticsearch.Client {
 	esClient, err := elasticsearch.NewClient(elasticsearch.Config{
 		Addresses: []string{
-			fmt.Sprintf("http://%s:%s", config.AppConfig.ElasticsearchHost, config.AppConfig.ElasticsearchPort),
+			fmt.Sprintf("http://%s:%s", appConfig.ElasticsearchHost, appConfig.ElasticsearchPort),
 		},
-		Username: config.AppConfig.ElasticsearchUsername,
-		Password: config.AppConfig.ElasticsearchPassword,
+		Username: appConfig.ElasticsearchUsername,
+		Password: appConfig.ElasticsearchPassword,
 	})
 	if err != nil {
 		log.Fatal("Connect to Elasticsearch failed: ", err)
 	}
 
-	ElasticsearchClient = esClient
-
-	res, err := ElasticsearchClient.Info()
+	res, err := esClient.Info()
 	if err != nil {
 		log.Fatal("Ping to Elasticsearch failed: ", err)
 	}
 	defer res.Body.Close()
 	log.Println("Connected to Elasticsearch successful")
+
+	return esClient
 }
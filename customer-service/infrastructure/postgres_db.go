@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"net/url"
 	"thanhldt060802/config"
 
 	_ "github.com/lib/pq" // PostgreSQL driver
@@ -11,12 +12,12 @@ import (
 	"github.com/uptrace/bun/dialect/pgdialect"
 )
 
-var DB *bun.DB
-
-func InitPostgesConnection() {
+func NewPostgresDB(appConfig *config.Config) *bun.DB {
+	// Tables of the service live in its own schema, search_path keeps queries unqualified
 	dsn := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		config.AppConfig.PostgresUser, config.AppConfig.PostgresPassword, config.AppConfig.PostgresHost, config.AppConfig.PostgresPort, config.AppConfig.PostgresDB,
+		"postgres://%s:%s@%s:%s/%s?sslmode=disable&search_path=%s",
+		appConfig.PostgresUser, appConfig.PostgresPassword, appConfig.PostgresHost, appConfig.PostgresPort, appConfig.PostgresDB,
+		url.QueryEscape(appConfig.PostgresSchema),
 	)
 
 	pgdb, err := sql.Open("postgres", dsn)
@@ -24,10 +25,12 @@ func InitPostgesConnection() {
 		log.Fatal("Connect to PostgreSQL with Bun ORM failed: ", err)
 	}
 
-	DB = bun.NewDB(pgdb, pgdialect.New())
+	db := bun.NewDB(pgdb, pgdialect.New())
 
-	if err := DB.Ping(); err != nil {
+	if err := db.Ping(); err != nil {
 		log.Fatal("Ping to database failed: ", err)
 	}
 	log.Println("Connected to PostgreSQL with Bun ORM successful")
+
+	return db
 }
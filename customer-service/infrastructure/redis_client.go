@@ -9,19 +9,19 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-var RedisClient *redis.Client
-
-func InitRedisClient() {
+func NewRedisClient(appConfig *config.Config) *redis.Client {
 	ctx := context.Background()
 
-	RedisClient = redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", config.AppConfig.RedisHost, config.AppConfig.RedisPort),
-		Password: config.AppConfig.RedisPassword,
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%s", appConfig.RedisHost, appConfig.RedisPort),
+		Password: appConfig.RedisPassword,
 		DB:       0,
 	})
 
-	if _, err := RedisClient.Ping(ctx).Result(); err != nil {
+	if _, err := redisClient.Ping(ctx).Result(); err != nil {
 		log.Fatal("Connect to Redis failed: ", err)
 	}
 	log.Println("Connect to Redis successful")
+
+	return redisClient
 }
@@ -0,0 +1,367 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"thanhldt060802/config"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/utils"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrProductNotFound = errors.New("product not found")
+
+const (
+	serviceTokenExpire = 5 * time.Minute
+
+	// Token is renewed this long before it expires, so a request never leaves with a token about to expire
+	serviceTokenRenewBefore = time.Minute
+)
+
+type Product struct {
+	Id                 int64     `json:"id"`
+	Name               string    `json:"name"`
+	Description        string    `json:"description"`
+	Sex                string    `json:"sex"`
+	Price              int64     `json:"price"`
+	DiscountPercentage int32     `json:"discount_percentage"`
+	Stock              int32     `json:"stock"`
+	ImageURL           string    `json:"image_url"`
+	CategoryId         int64     `json:"category_id"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+type StockReservationItem struct {
+	ProductId int64 `json:"product_id"`
+	Quantity  int32 `json:"quantity"`
+}
+
+type StockReservation struct {
+	Id        int64                  `json:"id"`
+	Status    string                 `json:"status"`
+	Items     []StockReservationItem `json:"items"`
+	ExpiresAt time.Time              `json:"expires_at"`
+}
+
+type catalogResponse[T any] struct {
+	Code    string   `json:"code"`
+	Message string   `json:"message"`
+	Data    T        `json:"data"`
+	Details []string `json:"details"`
+}
+
+type productClient struct {
+	redisClient *redis.Client
+	jwtKeys     *utils.JWTKeys
+	baseURL     string
+	httpClient  *http.Client
+	maxRetries  int
+	cacheExpire time.Duration
+
+	serviceTokenMu        sync.Mutex
+	serviceToken          string
+	serviceTokenExpiresAt time.Time
+}
+
+type ProductClient interface {
+	GetProductById(ctx context.Context, id int64) (*Product, error)
+	GetProductsByIds(ctx context.Context, ids []int64) ([]Product, error)
+	GetProductsByIdsNoCache(ctx context.Context, ids []int64) ([]Product, error)
+
+	ReserveStock(ctx context.Context, items []StockReservationItem) (*StockReservation, error)
+	ConfirmStockReservation(ctx context.Context, id int64) error
+	ReleaseStockReservation(ctx context.Context, id int64) error
+}
+
+func NewProductClient(redisClient *redis.Client, jwtKeys *utils.JWTKeys, appConfig *config.Config) ProductClient {
+	return &productClient{
+		redisClient: redisClient,
+		jwtKeys:     jwtKeys,
+		baseURL:     fmt.Sprintf("http://%s:%s", appConfig.CatalogServiceHost, appConfig.CatalogServicePort),
+		httpClient:  &http.Client{Timeout: *appConfig.GetCatalogServiceTimeout()},
+		maxRetries:  appConfig.GetCatalogServiceMaxRetries(),
+		cacheExpire: *appConfig.GetProductCacheExpireSeconds(),
+	}
+}
+
+func (productClient *productClient) GetProductById(ctx context.Context, id int64) (*Product, error) {
+	if product, ok := productClient.getCache(ctx, id); ok {
+		return product, nil
+	}
+
+	var res catalogResponse[Product]
+	statusCode, err := productClient.do(ctx, http.MethodGet, fmt.Sprintf("/products/id/%d", id), nil, &res, true)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode == http.StatusNotFound {
+		return nil, ErrProductNotFound
+	}
+	if statusCode != http.StatusOK {
+		return nil, catalogError("get product from catalog service", statusCode, res.Details)
+	}
+
+	productClient.setCache(ctx, []Product{res.Data})
+
+	return &res.Data, nil
+}
+
+func (productClient *productClient) GetProductsByIds(ctx context.Context, ids []int64) ([]Product, error) {
+	ids = uniqueIds(ids)
+
+	products := make([]Product, 0, len(ids))
+	missingIds := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if product, ok := productClient.getCache(ctx, id); ok {
+			products = append(products, *product)
+		} else {
+			missingIds = append(missingIds, id)
+		}
+	}
+
+	if len(missingIds) > 0 {
+		fetchedProducts, err := productClient.GetProductsByIdsNoCache(ctx, missingIds)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, fetchedProducts...)
+	}
+
+	return products, nil
+}
+
+// Always asks catalog service, used where a stale price is not acceptable (e.g. checkout)
+func (productClient *productClient) GetProductsByIdsNoCache(ctx context.Context, ids []int64) ([]Product, error) {
+	ids = uniqueIds(ids)
+	if len(ids) == 0 {
+		return []Product{}, nil
+	}
+
+	strIds := make([]string, len(ids))
+	for i, id := range ids {
+		strIds[i] = strconv.FormatInt(id, 10)
+	}
+
+	query := url.Values{}
+	query.Set("ids", strings.Join(strIds, ","))
+
+	var res catalogResponse[[]Product]
+	statusCode, err := productClient.do(ctx, http.MethodGet, "/products/ids?"+query.Encode(), nil, &res, true)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("get products from catalog service failed: status %d", statusCode)
+	}
+
+	productClient.setCache(ctx, res.Data)
+
+	return res.Data, nil
+}
+
+// Not retried: a lost response would leave a second reservation holding stock until it expires
+func (productClient *productClient) ReserveStock(ctx context.Context, items []StockReservationItem) (*StockReservation, error) {
+	reqBody := map[string]any{"items": items}
+
+	var res catalogResponse[StockReservation]
+	statusCode, err := productClient.do(ctx, http.MethodPost, "/products/reservations", reqBody, &res, false)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != http.StatusOK {
+		return nil, catalogError("reserve stock", statusCode, res.Details)
+	}
+
+	return &res.Data, nil
+}
+
+func (productClient *productClient) ConfirmStockReservation(ctx context.Context, id int64) error {
+	var res catalogResponse[any]
+	statusCode, err := productClient.do(ctx, http.MethodPost, fmt.Sprintf("/products/reservations/id/%d/confirm", id), nil, &res, true)
+	if err != nil {
+		return err
+	}
+	if statusCode != http.StatusOK {
+		return catalogError("confirm stock reservation", statusCode, res.Details)
+	}
+
+	return nil
+}
+
+func (productClient *productClient) ReleaseStockReservation(ctx context.Context, id int64) error {
+	var res catalogResponse[any]
+	statusCode, err := productClient.do(ctx, http.MethodPost, fmt.Sprintf("/products/reservations/id/%d/release", id), nil, &res, true)
+	if err != nil {
+		return err
+	}
+	if statusCode != http.StatusOK {
+		return catalogError("release stock reservation", statusCode, res.Details)
+	}
+
+	return nil
+}
+
+// Retry with exponential backoff on network errors and 5xx responses when retry is enabled
+func (productClient *productClient) do(ctx context.Context, method string, path string, reqBody any, out any, retry bool) (int, error) {
+	var bodyData []byte
+	if reqBody != nil {
+		data, err := json.Marshal(reqBody)
+		if err != nil {
+			return 0, err
+		}
+		bodyData = data
+	}
+
+	serviceToken, err := productClient.getServiceToken()
+	if err != nil {
+		return 0, err
+	}
+
+	maxRetries := productClient.maxRetries
+	if !retry {
+		maxRetries = 0
+	}
+
+	var lastErr error
+
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(100*(1<<(attempt-1))) * time.Millisecond
+			select {
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			case <-time.After(backoff):
+			}
+		}
+
+		var body io.Reader
+		if bodyData != nil {
+			body = bytes.NewReader(bodyData)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, productClient.baseURL+path, body)
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Authorization", "Bearer "+serviceToken)
+		if bodyData != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		res, err := productClient.httpClient.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("call catalog service failed: %s", err.Error())
+			continue
+		}
+
+		if res.StatusCode >= http.StatusInternalServerError {
+			res.Body.Close()
+			lastErr = fmt.Errorf("call catalog service failed: status %d", res.StatusCode)
+			continue
+		}
+
+		// 4xx responses share the same envelope, decode them too so callers can read details
+		err = json.NewDecoder(res.Body).Decode(out)
+		res.Body.Close()
+		if err != nil && res.StatusCode == http.StatusOK {
+			return 0, fmt.Errorf("unmarshal catalog service response failed: %s", err.Error())
+		}
+
+		return res.StatusCode, nil
+	}
+
+	return 0, lastErr
+}
+
+func (productClient *productClient) getCache(ctx context.Context, id int64) (*Product, bool) {
+	data, err := productClient.redisClient.Get(ctx, productCacheKey(id)).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("Get product with id = %d from cache failed: %s", id, err.Error())
+		}
+		return nil, false
+	}
+
+	var product Product
+	if err := json.Unmarshal(data, &product); err != nil {
+		return nil, false
+	}
+
+	return &product, true
+}
+
+func (productClient *productClient) setCache(ctx context.Context, products []Product) {
+	if len(products) == 0 {
+		return
+	}
+
+	pipe := productClient.redisClient.Pipeline()
+	for _, product := range products {
+		data, err := json.Marshal(product)
+		if err != nil {
+			continue
+		}
+		pipe.Set(ctx, productCacheKey(product.Id), data, productClient.cacheExpire)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Set products to cache failed: %s", err.Error())
+	}
+}
+
+func catalogError(action string, statusCode int, details []string) error {
+	if len(details) > 0 {
+		return fmt.Errorf("%s failed: %s", action, strings.Join(details, ", "))
+	}
+	return fmt.Errorf("%s failed: status %d", action, statusCode)
+}
+
+func productCacheKey(id int64) string {
+	return fmt.Sprintf("product:%d", id)
+}
+
+func uniqueIds(ids []int64) []int64 {
+	seen := make(map[int64]struct{}, len(ids))
+	result := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		result = append(result, id)
+	}
+	return result
+}
+
+// Catalog service verifies the token with JWKS of customer service like a user token, stock:reserve is only ever
+// granted here
+func (productClient *productClient) getServiceToken() (string, error) {
+	productClient.serviceTokenMu.Lock()
+	defer productClient.serviceTokenMu.Unlock()
+
+	if time.Until(productClient.serviceTokenExpiresAt) > serviceTokenRenewBefore {
+		return productClient.serviceToken, nil
+	}
+
+	token, claims, err := utils.GenerateToken(productClient.jwtKeys, serviceTokenExpire, 0, model.RoleService, []string{model.PermissionStockReserve}, 0, "")
+	if err != nil {
+		return "", fmt.Errorf("generate service token failed: %s", err.Error())
+	}
+
+	productClient.serviceToken = *token
+	productClient.serviceTokenExpiresAt = claims.ExpiresAt.Time
+	return productClient.serviceToken, nil
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"thanhldt060802/config"
+	"thanhldt060802/utils"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestProductClient_GetProductById(t *testing.T) {
+	tests := []struct {
+		name         string
+		statusCode   int
+		body         string
+		wantName     string
+		wantErr      string
+		wantNotFound bool
+	}{
+		{name: "found", statusCode: http.StatusOK, body: `{"code":"OK","data":{"id":1,"name":"Shirt"}}`, wantName: "Shirt"},
+		{name: "unknown", statusCode: http.StatusNotFound,
+			body:         `{"code":"ERR_NOT_FOUND","details":["id of product not found"]}`,
+			wantNotFound: true, wantErr: "product not found"},
+		{name: "rejected", statusCode: http.StatusBadRequest,
+			body:    `{"code":"ERR_BAD_REQUEST","details":["validation failed"]}`,
+			wantErr: "get product from catalog service failed: validation failed"},
+		{name: "rejected without details", statusCode: http.StatusUnauthorized, body: `{}`,
+			wantErr: "get product from catalog service failed: status 401"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalogServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(catalogServer.Close)
+
+			productClient := newTestProductClient(t, catalogServer.URL)
+
+			product, err := productClient.GetProductById(context.Background(), 1)
+			if errors.Is(err, ErrProductNotFound) != tt.wantNotFound {
+				t.Fatalf("error = %v, want ErrProductNotFound %v", err, tt.wantNotFound)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if product.Name != tt.wantName {
+				t.Errorf("name = %q, want %q", product.Name, tt.wantName)
+			}
+		})
+	}
+}
+
+func newTestProductClient(t *testing.T, catalogURL string) ProductClient {
+	t.Helper()
+
+	redisServer := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+
+	jwtKeys, err := utils.NewJWTKeys(filepath.Join(t.TempDir(), "jwt_private.pem"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(catalogURL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewProductClient(redisClient, jwtKeys, &config.Config{
+		CatalogServiceHost:           host,
+		CatalogServicePort:           port,
+		CatalogServiceTimeoutSeconds: "5",
+		CatalogServiceMaxRetries:     "0",
+		ProductCacheExpireSeconds:    "60",
+	})
+}
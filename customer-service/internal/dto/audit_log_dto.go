@@ -0,0 +1,36 @@
+package dto
+
+import (
+	"thanhldt060802/internal/model"
+	"time"
+)
+
+type AuditLogView struct {
+	Id        int64     `json:"id"`
+	Action    string    `json:"action"`
+	ActorId   int64     `json:"actor_id,omitempty"`
+	Target    string    `json:"target"`
+	IpAddress string    `json:"ip_address,omitempty"`
+	Details   string    `json:"details,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func ToAuditLogView(auditLog *model.AuditLog) *AuditLogView {
+	return &AuditLogView{
+		Id:        auditLog.Id,
+		Action:    auditLog.Action,
+		ActorId:   auditLog.ActorId,
+		Target:    auditLog.Target,
+		IpAddress: auditLog.IpAddress,
+		Details:   auditLog.Details,
+		CreatedAt: auditLog.CreatedAt,
+	}
+}
+
+func ToListAuditLogView(auditLogs []model.AuditLog) []AuditLogView {
+	auditLogViews := make([]AuditLogView, len(auditLogs))
+	for i, auditLog := range auditLogs {
+		auditLogViews[i] = *ToAuditLogView(&auditLog)
+	}
+	return auditLogViews
+}
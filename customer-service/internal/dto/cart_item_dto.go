@@ -1,14 +1,24 @@
 package dto
 
 import (
+	"thanhldt060802/internal/client"
 	"thanhldt060802/internal/model"
 )
 
 type CartItemView struct {
-	Id        int64 `json:"id"`
-	CartId    int64 `json:"cart_id"`
-	ProductId int64 `json:"product_id"`
-	Quantity  int32 `json:"quantity"`
+	Id        int64                `json:"id"`
+	CartId    int64                `json:"cart_id"`
+	ProductId int64                `json:"product_id"`
+	Quantity  int32                `json:"quantity"`
+	Product   *CartItemProductView `json:"product,omitempty"`
+}
+
+type CartItemProductView struct {
+	Name               string `json:"name"`
+	Price              int64  `json:"price"`
+	DiscountPercentage int32  `json:"discount_percentage"`
+	Stock              int32  `json:"stock"`
+	ImageURL           string `json:"image_url"`
 }
 
 func ToCartItemView(cartItem *model.CartItem) *CartItemView {
@@ -27,3 +37,24 @@ func ToListCartItemView(cartItems []model.CartItem) []CartItemView {
 	}
 	return cartItemViews
 }
+
+// Attach live product info from catalog service, cart items whose product is missing are left untouched
+func AttachProductsToListCartItemView(cartItemViews []CartItemView, products []client.Product) []CartItemView {
+	productMap := make(map[int64]client.Product, len(products))
+	for _, product := range products {
+		productMap[product.Id] = product
+	}
+
+	for i := range cartItemViews {
+		if product, ok := productMap[cartItemViews[i].ProductId]; ok {
+			cartItemViews[i].Product = &CartItemProductView{
+				Name:               product.Name,
+				Price:              product.Price,
+				DiscountPercentage: product.DiscountPercentage,
+				Stock:              product.Stock,
+				ImageURL:           product.ImageURL,
+			}
+		}
+	}
+	return cartItemViews
+}
@@ -1,14 +1,19 @@
 package dto
 
-import "github.com/shopspring/decimal"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 // Only user request
 // ################################################################################
 
 type GetUsersWithQueryParamRequest struct {
-	Offset int    `query:"offset" default:"0" minimum:"0" example:"0" doc:"Skip item by offset."`
-	Limit  int    `query:"limit" default:"5" minimum:"1" maximum:"10" example:"10" doc:"Limit item from offset."`
-	SortBy string `query:"sort_by" default:"id:asc" example:"created_at:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=created_at:desc,id will sort by created_at in descending order, then by id in ascending order."`
+	Cursor       string `query:"cursor" doc:"Cursor from next_cursor or prev_cursor of previous page, omit to get first page."`
+	Limit        int    `query:"limit" default:"5" minimum:"1" maximum:"100" example:"10" doc:"Max number of items in a page."`
+	SortBy       string `query:"sort_by" default:"id:asc" example:"created_at:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=created_at:desc,id will sort by created_at in descending order, then by id in ascending order."`
+	IncludeTotal bool   `query:"include_total" doc:"Count total of matched items, costs one more query."`
 }
 
 type GetUserByIdRequest struct {
@@ -30,7 +35,7 @@ type CreateUserRequest struct {
 		Username string `json:"username" required:"true" minLength:"1" doc:"Username of user acount."`
 		Password string `json:"password" required:"true" minLength:"1" doc:"Password of user acount."`
 		Address  string `json:"address" required:"true" minLength:"1" doc:"Address of user acount."`
-		RoleName string `json:"role_name" required:"true" enum:"ADMIN,CUSTOMER" doc:"Role name of user account."`
+		RoleName string `json:"role_name" required:"true" minLength:"1" example:"STAFF" doc:"Role name of user account, must be an existing role."`
 	}
 }
 
@@ -41,7 +46,7 @@ type UpdateUserRequest struct {
 		Email    *string `json:"email,omitempty" minLength:"1" format:"email" doc:"Email of user account."`
 		Password *string `json:"password,omitempty" minLength:"1" doc:"Password of user account."`
 		Address  *string `json:"address,omitempty" minLength:"1" doc:"Address of user account."`
-		RoleName *string `json:"role_name,omitempty" enum:"ADMIN,CUSTOMER" doc:"Role name of user account."`
+		RoleName *string `json:"role_name,omitempty" minLength:"1" example:"STAFF" doc:"Role name of user account, must be an existing role."`
 	}
 }
 
@@ -53,15 +58,53 @@ type LoginUserRequest struct {
 	Body struct {
 		Username string `json:"username" required:"true" minLength:"1" example:"user1" doc:"Account username."`
 		Password string `json:"password" required:"true" minLength:"1" example:"123" doc:"Account password."`
+		Device   string `json:"device,omitempty" maxLength:"100" example:"My laptop" doc:"Name of device shown in session list, detected from user agent when empty."`
 	}
+	IpAddress string
+	UserAgent string
 }
 
 type LogoutUserRequest struct {
+	SessionId            string
+	AccessTokenId        string
+	AccessTokenExpiresAt time.Time
+}
+
+type RefreshTokenRequest struct {
 	Body struct {
-		Token string `json:"token" required:"true" minLength:"1" example:"XXX" doc:"Token of account will be logged out."`
+		RefreshToken string `json:"refresh_token" required:"true" minLength:"1" example:"XXX.YYY" doc:"Refresh token received from login or last refresh, it can be used only once."`
 	}
 }
 
+type GetSessionsByUserIdRequest struct {
+	UserId int64
+}
+
+type DeleteSessionUsingAccountRequest struct {
+	Id string `path:"id" required:"true" doc:"Id of session will be revoked."`
+}
+
+type DeleteSessionRequest struct {
+	UserId    int64
+	SessionId string
+}
+
+type DeleteSessionsByUserIdRequest struct {
+	Id int64 `path:"id" required:"true" doc:"Id of user whose sessions will be revoked."`
+}
+
+type DeleteLoginLockoutRequest struct {
+	Scope string `path:"scope" required:"true" enum:"username,ip" doc:"Scope of lockout."`
+	Value string `path:"value" required:"true" doc:"Locked username or client ip."`
+}
+
+type ClearLoginLockoutRequest struct {
+	Scope     string
+	Value     string
+	ActorId   int64
+	IpAddress string
+}
+
 type RegisterRequest struct {
 	Body struct {
 		FullName string `json:"full_name" required:"true" minLength:"1" doc:"Full name of user acount."`
@@ -72,24 +115,74 @@ type RegisterRequest struct {
 	}
 }
 
+type VerifyEmailRequest struct {
+	Body struct {
+		Token string `json:"token" required:"true" minLength:"1" doc:"Email verification token sent to email of user account."`
+	}
+}
+
+type ResendEmailVerificationRequest struct {
+	UserId int64
+}
+
 type UpdateUserUsingAccountRequest struct {
 	Body struct {
 		FullName *string `json:"fullname,omitempty" minLength:"1" doc:"Full name of user account."`
 		Email    *string `json:"email,omitempty" minLength:"1" format:"email" doc:"Email of user account."`
-		Password *string `json:"password,omitempty" minLength:"1" doc:"Password of user account."`
 		Address  *string `json:"address,omitempty" minLength:"1" doc:"Address of user account."`
 	}
 }
 
+type ChangePasswordUsingAccountRequest struct {
+	Body struct {
+		CurrentPassword string `json:"current_password" required:"true" minLength:"1" doc:"Current password of user account."`
+		NewPassword     string `json:"new_password" required:"true" minLength:"1" doc:"New password of user account."`
+	}
+}
+
+type ChangePasswordRequest struct {
+	UserId          int64
+	SessionId       string
+	CurrentPassword string
+	NewPassword     string
+}
+
+type RequestPasswordResetRequest struct {
+	Body struct {
+		Email string `json:"email" required:"true" format:"email" doc:"Email of user account, a reset link is sent to it if account exists."`
+	}
+}
+
+type ConfirmPasswordResetRequest struct {
+	Body struct {
+		Token       string `json:"token" required:"true" minLength:"1" doc:"Password reset token from the reset link, it can be used only once."`
+		NewPassword string `json:"new_password" required:"true" minLength:"1" doc:"New password of user account."`
+	}
+}
+
+// ################################################################################
+
+// Only audit log request
+// ################################################################################
+
+type GetAuditLogsWithQueryParamRequest struct {
+	Cursor       string `query:"cursor" doc:"Cursor from next_cursor or prev_cursor of previous page, omit to get first page."`
+	Limit        int    `query:"limit" default:"5" minimum:"1" maximum:"100" example:"10" doc:"Max number of items in a page."`
+	SortBy       string `query:"sort_by" default:"created_at:desc" example:"created_at:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=created_at:desc,id will sort by created_at in descending order, then by id in ascending order."`
+	IncludeTotal bool   `query:"include_total" doc:"Count total of matched items, costs one more query."`
+	Action       string `query:"action" enum:"LOGIN_LOCKOUT,LOGIN_LOCKOUT_CLEARED" doc:"Only get audit logs of this action."`
+}
+
 // ################################################################################
 
 // Only cart request
 // ################################################################################
 
 type GetCartsWithQueryParamRequest struct {
-	Offset int    `query:"offset" default:"0" minimum:"0" example:"0" doc:"Skip item by offset."`
-	Limit  int    `query:"limit" default:"5" minimum:"1" maximum:"10" example:"10" doc:"Limit item from offset."`
-	SortBy string `query:"sort_by" default:"id:asc" example:"created_at:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=created_at:desc,id will sort by created_at in descending order, then by id in ascending order."`
+	Cursor       string `query:"cursor" doc:"Cursor from next_cursor or prev_cursor of previous page, omit to get first page."`
+	Limit        int    `query:"limit" default:"5" minimum:"1" maximum:"100" example:"10" doc:"Max number of items in a page."`
+	SortBy       string `query:"sort_by" default:"id:asc" example:"created_at:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=created_at:desc,id will sort by created_at in descending order, then by id in ascending order."`
+	IncludeTotal bool   `query:"include_total" doc:"Count total of matched items, costs one more query."`
 }
 
 type GetCartByUserIdRequest struct {
@@ -102,9 +195,10 @@ type GetCartByUserIdRequest struct {
 // ################################################################################
 
 type GetCartItemsWithQueryParamRequest struct {
-	Offset int    `query:"offset" default:"0" minimum:"0" example:"0" doc:"Skip item by offset."`
-	Limit  int    `query:"limit" default:"5" minimum:"1" maximum:"10" example:"10" doc:"Limit item from offset."`
-	SortBy string `query:"sort_by" default:"id:asc" example:"created_at:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=created_at:desc,id will sort by created_at in descending order, then by id in ascending order."`
+	Cursor       string `query:"cursor" doc:"Cursor from next_cursor or prev_cursor of previous page, omit to get first page."`
+	Limit        int    `query:"limit" default:"5" minimum:"1" maximum:"100" example:"10" doc:"Max number of items in a page."`
+	SortBy       string `query:"sort_by" default:"id:asc" example:"created_at:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=created_at:desc,id will sort by created_at in descending order, then by id in ascending order."`
+	IncludeTotal bool   `query:"include_total" doc:"Count total of matched items, costs one more query."`
 }
 
 type GetCartItemByIdRequest struct {
@@ -112,10 +206,11 @@ type GetCartItemByIdRequest struct {
 }
 
 type GetCartItemsByCartIdWithQueryParamRequest struct {
-	CartId int64  `path:"cart_id" required:"true" doc:"Cart id of cart items will be filtered."`
-	Offset int    `query:"offset" default:"0" minimum:"0" example:"0" doc:"Skip item by offset."`
-	Limit  int    `query:"limit" default:"5" minimum:"1" maximum:"10" example:"10" doc:"Limit item from offset."`
-	SortBy string `query:"sort_by" default:"id:asc" example:"quantity:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=quantity:desc,id will sort by quantity in descending order, then by id in ascending order."`
+	CartId       int64  `path:"cart_id" required:"true" doc:"Cart id of cart items will be filtered."`
+	Cursor       string `query:"cursor" doc:"Cursor from next_cursor or prev_cursor of previous page, omit to get first page."`
+	Limit        int    `query:"limit" default:"5" minimum:"1" maximum:"100" example:"10" doc:"Max number of items in a page."`
+	SortBy       string `query:"sort_by" default:"id:asc" example:"quantity:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=quantity:desc,id will sort by quantity in descending order, then by id in ascending order."`
+	IncludeTotal bool   `query:"include_total" doc:"Count total of matched items, costs one more query."`
 }
 
 type CreateCartItemRequest struct {
@@ -139,9 +234,10 @@ type DeleteCartItemRequest struct {
 }
 
 type GetCartItemsUsingAccountWithQueryParamRequest struct {
-	Offset int    `query:"offset" default:"0" minimum:"0" example:"0" doc:"Skip item by offset."`
-	Limit  int    `query:"limit" default:"5" minimum:"1" maximum:"10" example:"10" doc:"Limit item from offset."`
-	SortBy string `query:"sort_by" default:"id:asc" example:"quantity:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=quantity:desc,id will sort by quantity in descending order, then by id in ascending order."`
+	Cursor       string `query:"cursor" doc:"Cursor from next_cursor or prev_cursor of previous page, omit to get first page."`
+	Limit        int    `query:"limit" default:"5" minimum:"1" maximum:"100" example:"10" doc:"Max number of items in a page."`
+	SortBy       string `query:"sort_by" default:"id:asc" example:"quantity:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=quantity:desc,id will sort by quantity in descending order, then by id in ascending order."`
+	IncludeTotal bool   `query:"include_total" doc:"Count total of matched items, costs one more query."`
 }
 
 type CreateCartItemUsingAccountRequest struct {
@@ -167,9 +263,10 @@ type DeleteCartItemUsingAccountRequest struct {
 // ################################################################################
 
 type GetInvoicesWithQueryParamRequest struct {
-	Offset int    `query:"offset" default:"0" minimum:"0" example:"0" doc:"Skip item by offset."`
-	Limit  int    `query:"limit" default:"5" minimum:"1" maximum:"10" example:"10" doc:"Limit item from offset."`
-	SortBy string `query:"sort_by" default:"id:asc" example:"created_at:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=created_at:desc,id will sort by created_at in descending order, then by id in ascending order."`
+	Cursor       string `query:"cursor" doc:"Cursor from next_cursor or prev_cursor of previous page, omit to get first page."`
+	Limit        int    `query:"limit" default:"5" minimum:"1" maximum:"100" example:"10" doc:"Max number of items in a page."`
+	SortBy       string `query:"sort_by" default:"id:asc" example:"created_at:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=created_at:desc,id will sort by created_at in descending order, then by id in ascending order."`
+	IncludeTotal bool   `query:"include_total" doc:"Count total of matched items, costs one more query."`
 }
 
 type GetInvoiceByIdRequest struct {
@@ -177,10 +274,11 @@ type GetInvoiceByIdRequest struct {
 }
 
 type GetInvoicesByUserIdWithQueryParamRequest struct {
-	UserId int64  `path:"user_id" required:"true" doc:"User id of invoices will be filtered."`
-	Offset int    `query:"offset" default:"0" minimum:"0" example:"0" doc:"Skip item by offset."`
-	Limit  int    `query:"limit" default:"5" minimum:"1" maximum:"10" example:"10" doc:"Limit item from offset."`
-	SortBy string `query:"sort_by" default:"id:asc" example:"quantity:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=quantity:desc,id will sort by quantity in descending order, then by id in ascending order."`
+	UserId       int64  `path:"user_id" required:"true" doc:"User id of invoices will be filtered."`
+	Cursor       string `query:"cursor" doc:"Cursor from next_cursor or prev_cursor of previous page, omit to get first page."`
+	Limit        int    `query:"limit" default:"5" minimum:"1" maximum:"100" example:"10" doc:"Max number of items in a page."`
+	SortBy       string `query:"sort_by" default:"id:asc" example:"quantity:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=quantity:desc,id will sort by quantity in descending order, then by id in ascending order."`
+	IncludeTotal bool   `query:"include_total" doc:"Count total of matched items, costs one more query."`
 }
 
 type CreateInvoiceRequest struct {
@@ -194,18 +292,41 @@ type CreateInvoiceRequest struct {
 type UpdateInvoiceRequest struct {
 	Id   int64 `path:"id" required:"true" doc:"Id of invoice will be updated."`
 	Body struct {
-		Status *string `json:"status,omitempty" minimum:"1" doc:"Status of invoice."`
+		Status string `json:"status" required:"true" enum:"PAID,SHIPPED,DONE,CANCEL,REFUNDED" doc:"Next status of invoice."`
 	}
 }
 
+type CheckoutCartRequest struct {
+	UserId   int64
+	RoleName string
+	CartId   int64
+}
+
+type ChangeInvoiceStatusRequest struct {
+	InvoiceId int64
+	Status    string
+	ActorId   int64
+	ActorRole string
+	OwnerId   *int64
+}
+
+type CancelInvoiceByIdUsingAccountRequest struct {
+	Id int64 `path:"id" required:"true" doc:"Id of invoice will be cancelled."`
+}
+
+type GetInvoiceStatusHistoriesByIdRequest struct {
+	Id int64 `path:"id" required:"true" doc:"Id of invoice."`
+}
+
 type DeleteInvoiceRequest struct {
 	Id int64 `path:"id" required:"true" doc:"Id of invoice will be deleted."`
 }
 
 type GetInvoicesUsingAccountQueryParamRequest struct {
-	Offset int    `query:"offset" default:"0" minimum:"0" example:"0" doc:"Skip item by offset."`
-	Limit  int    `query:"limit" default:"5" minimum:"1" maximum:"10" example:"10" doc:"Limit item from offset."`
-	SortBy string `query:"sort_by" default:"id:asc" example:"created_at:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=created_at:desc,id will sort by created_at in descending order, then by id in ascending order."`
+	Cursor       string `query:"cursor" doc:"Cursor from next_cursor or prev_cursor of previous page, omit to get first page."`
+	Limit        int    `query:"limit" default:"5" minimum:"1" maximum:"100" example:"10" doc:"Max number of items in a page."`
+	SortBy       string `query:"sort_by" default:"id:asc" example:"created_at:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=created_at:desc,id will sort by created_at in descending order, then by id in ascending order."`
+	IncludeTotal bool   `query:"include_total" doc:"Count total of matched items, costs one more query."`
 }
 
 type GetInvoiceByIdUsingAccountRequest struct {
@@ -217,16 +338,26 @@ type DeleteInvoiceUsingAccountRequest struct {
 }
 
 type GetInvoicesWithElasticsearchRequest struct {
-	Offset       int    `query:"offset" default:"0" minimum:"0" example:"0" doc:"Skip item by offset."`
-	Limit        int    `query:"limit" default:"5" minimum:"1" maximum:"10" example:"10" doc:"Limit item from offset."`
+	Cursor       string `query:"cursor" doc:"Cursor from next_cursor or prev_cursor of previous page, omit to get first page."`
+	Limit        int    `query:"limit" default:"5" minimum:"1" maximum:"100" example:"10" doc:"Max number of items in a page."`
 	SortBy       string `query:"sort_by" default:"id:desc" example:"created_at:desc,id" doc:"Sort by one or more fields separated by commas. Format: \"field:asc/desc\" (default is asc if not declare after commas)"`
+	IncludeTotal bool   `query:"include_total" doc:"Count total of matched items, costs one more query."`
 	CreatedAtGTE string `query:"created_at_gte" example:"2024-01-15T00:00:00" doc:"Filter by created_at greater than or equal, with format is YYYY-MM-ddTHH:mm:ss."`
 	CreatedAtLTE string `query:"created_at_lte" example:"2024-02-05T23:59:59" doc:"Filter by created_at less than or equal, with format is YYYY-MM-ddTHH:mm:ss."`
 }
 
 type AggregateInvoicesWithElasticsearchRequest struct {
-	CreatedAtGTE string `query:"created_at_gte" example:"2024-01-15T00:00:00" doc:"Filter by created_at greater than or equal, with format is YYYY-MM-ddTHH:mm:ss."`
-	CreatedAtLTE string `query:"created_at_lte" example:"2024-02-05T23:59:59" doc:"Filter by created_at less than or equal, with format is YYYY-MM-ddTHH:mm:ss."`
+	CreatedAtGTE string   `query:"created_at_gte" example:"2024-01-15T00:00:00" doc:"Filter by created_at greater than or equal, with format is YYYY-MM-ddTHH:mm:ss in Asia/Ho_Chi_Minh time."`
+	CreatedAtLTE string   `query:"created_at_lte" example:"2024-02-05T23:59:59" doc:"Filter by created_at less than or equal, with format is YYYY-MM-ddTHH:mm:ss in Asia/Ho_Chi_Minh time."`
+	Statuses     []string `query:"statuses" enum:"PENDING,PAID,SHIPPED,DONE,CANCEL,REFUNDED" example:"[\"PAID\",\"DONE\"]" doc:"Filter by one or more statuses separated by commas (default is PAID,SHIPPED,DONE which are counted as revenue)."`
+}
+
+type ReportInvoicesWithElasticsearchRequest struct {
+	CreatedAtGTE string   `query:"created_at_gte" example:"2024-01-15T00:00:00" doc:"Filter by created_at greater than or equal, with format is YYYY-MM-ddTHH:mm:ss in Asia/Ho_Chi_Minh time."`
+	CreatedAtLTE string   `query:"created_at_lte" example:"2024-02-05T23:59:59" doc:"Filter by created_at less than or equal, with format is YYYY-MM-ddTHH:mm:ss in Asia/Ho_Chi_Minh time."`
+	Statuses     []string `query:"statuses" enum:"PENDING,PAID,SHIPPED,DONE,CANCEL,REFUNDED" example:"[\"PAID\",\"DONE\"]" doc:"Filter by one or more statuses separated by commas (default is PAID,SHIPPED,DONE which are counted as revenue)."`
+	Interval     string   `query:"interval" default:"day" enum:"day,week,month" example:"week" doc:"Bucket size of revenue series."`
+	UserLimit    int      `query:"user_limit" default:"10" minimum:"1" maximum:"100" example:"10" doc:"Number of top users by revenue in breakdown."`
 }
 
 // ################################################################################
@@ -235,9 +366,10 @@ type AggregateInvoicesWithElasticsearchRequest struct {
 // ################################################################################
 
 type GetInvoiceDetailsWithQueryParamRequest struct {
-	Offset int    `query:"offset" default:"0" minimum:"0" example:"0" doc:"Skip item by offset."`
-	Limit  int    `query:"limit" default:"5" minimum:"1" maximum:"10" example:"10" doc:"Limit item from offset."`
-	SortBy string `query:"sort_by" default:"id:asc" example:"created_at:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=created_at:desc,id will sort by created_at in descending order, then by id in ascending order."`
+	Cursor       string `query:"cursor" doc:"Cursor from next_cursor or prev_cursor of previous page, omit to get first page."`
+	Limit        int    `query:"limit" default:"5" minimum:"1" maximum:"100" example:"10" doc:"Max number of items in a page."`
+	SortBy       string `query:"sort_by" default:"id:asc" example:"created_at:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=created_at:desc,id will sort by created_at in descending order, then by id in ascending order."`
+	IncludeTotal bool   `query:"include_total" doc:"Count total of matched items, costs one more query."`
 }
 
 type GetInvoiceDetailByIdRequest struct {
@@ -245,17 +377,51 @@ type GetInvoiceDetailByIdRequest struct {
 }
 
 type GetInvoiceDetailsByInvoiceIdWithQueryParamRequest struct {
-	InvoiceId int64  `path:"invoice_id" required:"true" doc:"Invoice id of invoice details will be filtered."`
-	Offset    int    `query:"offset" default:"0" minimum:"0" example:"0" doc:"Skip item by offset."`
-	Limit     int    `query:"limit" default:"5" minimum:"1" maximum:"10" example:"10" doc:"Limit item from offset."`
-	SortBy    string `query:"sort_by" default:"id:asc" example:"quantity:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=quantity:desc,id will sort by quantity in descending order, then by id in ascending order."`
+	InvoiceId    int64  `path:"invoice_id" required:"true" doc:"Invoice id of invoice details will be filtered."`
+	Cursor       string `query:"cursor" doc:"Cursor from next_cursor or prev_cursor of previous page, omit to get first page."`
+	Limit        int    `query:"limit" default:"5" minimum:"1" maximum:"100" example:"10" doc:"Max number of items in a page."`
+	SortBy       string `query:"sort_by" default:"id:asc" example:"quantity:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=quantity:desc,id will sort by quantity in descending order, then by id in ascending order."`
+	IncludeTotal bool   `query:"include_total" doc:"Count total of matched items, costs one more query."`
 }
 
 type GetInvoiceDetailsByInvoiceIdUsingAccountWithQueryParamRequest struct {
-	InvoiceId int64  `path:"invoice_id" required:"true" doc:"Invoice id of invoice details will be filtered."`
-	Offset    int    `query:"offset" default:"0" minimum:"0" example:"0" doc:"Skip item by offset."`
-	Limit     int    `query:"limit" default:"5" minimum:"1" maximum:"10" example:"10" doc:"Limit item from offset."`
-	SortBy    string `query:"sort_by" default:"id:asc" example:"quantity:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=quantity:desc,id will sort by quantity in descending order, then by id in ascending order."`
+	InvoiceId    int64  `path:"invoice_id" required:"true" doc:"Invoice id of invoice details will be filtered."`
+	Cursor       string `query:"cursor" doc:"Cursor from next_cursor or prev_cursor of previous page, omit to get first page."`
+	Limit        int    `query:"limit" default:"5" minimum:"1" maximum:"100" example:"10" doc:"Max number of items in a page."`
+	SortBy       string `query:"sort_by" default:"id:asc" example:"quantity:desc,id" doc:"Sort by one or more fields separated by commas. For example: sort_by=quantity:desc,id will sort by quantity in descending order, then by id in ascending order."`
+	IncludeTotal bool   `query:"include_total" doc:"Count total of matched items, costs one more query."`
+}
+
+// ################################################################################
+
+// Only role request
+// ################################################################################
+
+type GetRoleByNameRequest struct {
+	Name string `path:"name" required:"true" doc:"Name of role will be gotten."`
+}
+
+type CreateRoleRequest struct {
+	Body struct {
+		Name        string `json:"name" required:"true" pattern:"^[A-Z][A-Z_]*$" maxLength:"255" example:"WAREHOUSE" doc:"Name of role, upper case letters and underscores."`
+		Description string `json:"description" maxLength:"255" example:"Warehouse staff" doc:"Description of role."`
+	}
+}
+
+type DeleteRoleRequest struct {
+	Name string `path:"name" required:"true" doc:"Name of role will be deleted."`
+}
+
+type AddPermissionsToRoleRequest struct {
+	Name string `path:"name" required:"true" doc:"Name of role will be granted permissions."`
+	Body struct {
+		PermissionNames []string `json:"permission_names" required:"true" minItems:"1" example:"[\"product:write\",\"invoice:read\"]" doc:"Names of permissions will be granted."`
+	}
+}
+
+type RemovePermissionFromRoleRequest struct {
+	Name           string `path:"name" required:"true" doc:"Name of role will be revoked permission."`
+	PermissionName string `path:"permission_name" required:"true" doc:"Name of permission will be revoked."`
 }
 
 // ################################################################################
@@ -32,3 +32,75 @@ func ToListInvoiceView(invoices []model.Invoice) []InvoiceView {
 	}
 	return invoiceViews
 }
+
+type InvoiceReportView struct {
+	CreatedAtGTE string                          `json:"created_at_gte,omitempty"`
+	CreatedAtLTE string                          `json:"created_at_lte,omitempty"`
+	Statuses     []string                        `json:"statuses"`
+	Interval     string                          `json:"interval"`
+	TimeZone     string                          `json:"time_zone"`
+	Count        int64                           `json:"count"`
+	Sum          float64                         `json:"sum"`
+	Avg          *float64                        `json:"avg"`
+	Min          *float64                        `json:"min"`
+	Max          *float64                        `json:"max"`
+	Percentiles  []InvoiceReportPercentileView   `json:"percentiles"`
+	Series       []InvoiceReportTimeBucketView   `json:"series"`
+	ByStatus     []InvoiceReportStatusBucketView `json:"by_status"`
+	ByUser       []InvoiceReportUserBucketView   `json:"by_user"`
+}
+
+type InvoiceReportPercentileView struct {
+	Percent float64  `json:"percent"`
+	Value   *float64 `json:"value"`
+}
+
+type InvoiceReportTimeBucketView struct {
+	Date  string  `json:"date"`
+	Count int64   `json:"count"`
+	Sum   float64 `json:"sum"`
+}
+
+type InvoiceReportStatusBucketView struct {
+	Status string  `json:"status"`
+	Count  int64   `json:"count"`
+	Sum    float64 `json:"sum"`
+}
+
+type InvoiceReportUserBucketView struct {
+	UserId int64   `json:"user_id"`
+	Count  int64   `json:"count"`
+	Sum    float64 `json:"sum"`
+}
+
+func ToInvoiceReportView(report *model.InvoiceElasticsearchReport) *InvoiceReportView {
+	invoiceReportView := &InvoiceReportView{
+		CreatedAtGTE: report.Filter.CreatedAtGTE,
+		CreatedAtLTE: report.Filter.CreatedAtLTE,
+		Statuses:     report.Filter.Statuses,
+		Interval:     report.Interval,
+		TimeZone:     model.InvoiceReportTimeZone,
+		Count:        report.Count,
+		Sum:          report.Sum,
+		Avg:          report.Avg,
+		Min:          report.Min,
+		Max:          report.Max,
+		Percentiles:  make([]InvoiceReportPercentileView, len(report.Percentiles)),
+		Series:       make([]InvoiceReportTimeBucketView, len(report.Series)),
+		ByStatus:     make([]InvoiceReportStatusBucketView, len(report.Statuses)),
+		ByUser:       make([]InvoiceReportUserBucketView, len(report.Users)),
+	}
+	for i, percentile := range report.Percentiles {
+		invoiceReportView.Percentiles[i] = InvoiceReportPercentileView{Percent: percentile.Percent, Value: percentile.Value}
+	}
+	for i, bucket := range report.Series {
+		invoiceReportView.Series[i] = InvoiceReportTimeBucketView{Date: bucket.Date, Count: bucket.Count, Sum: bucket.Sum}
+	}
+	for i, bucket := range report.Statuses {
+		invoiceReportView.ByStatus[i] = InvoiceReportStatusBucketView{Status: bucket.Status, Count: bucket.Count, Sum: bucket.Sum}
+	}
+	for i, bucket := range report.Users {
+		invoiceReportView.ByUser[i] = InvoiceReportUserBucketView{UserId: bucket.UserId, Count: bucket.Count, Sum: bucket.Sum}
+	}
+	return invoiceReportView
+}
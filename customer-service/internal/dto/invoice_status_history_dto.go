@@ -0,0 +1,36 @@
+package dto
+
+import (
+	"thanhldt060802/internal/model"
+	"time"
+)
+
+type InvoiceStatusHistoryView struct {
+	Id         int64     `json:"id"`
+	InvoiceId  int64     `json:"invoice_id"`
+	FromStatus string    `json:"from_status,omitempty"`
+	ToStatus   string    `json:"to_status"`
+	ActorId    int64     `json:"actor_id"`
+	ActorRole  string    `json:"actor_role"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func ToInvoiceStatusHistoryView(invoiceStatusHistory *model.InvoiceStatusHistory) *InvoiceStatusHistoryView {
+	return &InvoiceStatusHistoryView{
+		Id:         invoiceStatusHistory.Id,
+		InvoiceId:  invoiceStatusHistory.InvoiceId,
+		FromStatus: invoiceStatusHistory.FromStatus,
+		ToStatus:   invoiceStatusHistory.ToStatus,
+		ActorId:    invoiceStatusHistory.ActorId,
+		ActorRole:  invoiceStatusHistory.ActorRole,
+		CreatedAt:  invoiceStatusHistory.CreatedAt,
+	}
+}
+
+func ToListInvoiceStatusHistoryView(invoiceStatusHistories []model.InvoiceStatusHistory) []InvoiceStatusHistoryView {
+	invoiceStatusHistoryViews := make([]InvoiceStatusHistoryView, len(invoiceStatusHistories))
+	for i, invoiceStatusHistory := range invoiceStatusHistories {
+		invoiceStatusHistoryViews[i] = *ToInvoiceStatusHistoryView(&invoiceStatusHistory)
+	}
+	return invoiceStatusHistoryViews
+}
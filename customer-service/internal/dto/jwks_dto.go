@@ -0,0 +1,27 @@
+package dto
+
+import "thanhldt060802/utils"
+
+type JSONWebKeySetResponse struct {
+	CacheControl string `header:"Cache-Control"`
+	Body         struct {
+		Keys []JSONWebKeyView `json:"keys"`
+	}
+}
+
+type JSONWebKeyView struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func ToListJSONWebKeyView(jsonWebKeys []utils.JSONWebKey) []JSONWebKeyView {
+	jsonWebKeyViews := make([]JSONWebKeyView, len(jsonWebKeys))
+	for i, jsonWebKey := range jsonWebKeys {
+		jsonWebKeyViews[i] = JSONWebKeyView(jsonWebKey)
+	}
+	return jsonWebKeyViews
+}
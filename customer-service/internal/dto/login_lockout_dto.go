@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"thanhldt060802/internal/model"
+	"time"
+)
+
+type LoginLockoutView struct {
+	Scope       string    `json:"scope"`
+	Value       string    `json:"value"`
+	FailedCount int       `json:"failed_count"`
+	LockedAt    time.Time `json:"locked_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func ToLoginLockoutView(loginLockout *model.LoginLockout) *LoginLockoutView {
+	return &LoginLockoutView{
+		Scope:       loginLockout.Scope,
+		Value:       loginLockout.Value,
+		FailedCount: loginLockout.FailedCount,
+		LockedAt:    loginLockout.LockedAt,
+		ExpiresAt:   loginLockout.ExpiresAt,
+	}
+}
+
+func ToListLoginLockoutView(loginLockouts []model.LoginLockout) []LoginLockoutView {
+	loginLockoutViews := make([]LoginLockoutView, len(loginLockouts))
+	for i, loginLockout := range loginLockouts {
+		loginLockoutViews[i] = *ToLoginLockoutView(&loginLockout)
+	}
+	return loginLockoutViews
+}
@@ -0,0 +1,55 @@
+package dto
+
+import (
+	"thanhldt060802/internal/model"
+	"time"
+)
+
+type RoleView struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func ToRoleView(role *model.Role) *RoleView {
+	permissions := role.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	return &RoleView{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+		CreatedAt:   role.CreatedAt,
+	}
+}
+
+func ToListRoleView(roles []model.Role) []RoleView {
+	roleViews := make([]RoleView, len(roles))
+	for i, role := range roles {
+		roleViews[i] = *ToRoleView(&role)
+	}
+	return roleViews
+}
+
+type PermissionView struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func ToPermissionView(permission *model.Permission) *PermissionView {
+	return &PermissionView{
+		Name:        permission.Name,
+		Description: permission.Description,
+	}
+}
+
+func ToListPermissionView(permissions []model.Permission) []PermissionView {
+	permissionViews := make([]PermissionView, len(permissions))
+	for i, permission := range permissions {
+		permissionViews[i] = *ToPermissionView(&permission)
+	}
+	return permissionViews
+}
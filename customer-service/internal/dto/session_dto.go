@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"thanhldt060802/internal/model"
+	"time"
+)
+
+type SessionView struct {
+	Id        string    `json:"id"`
+	Device    string    `json:"device"`
+	IpAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Current   bool      `json:"current"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func ToSessionView(session *model.Session, currentSessionId string) *SessionView {
+	return &SessionView{
+		Id:        session.Id,
+		Device:    session.Device,
+		IpAddress: session.IpAddress,
+		UserAgent: session.UserAgent,
+		Current:   session.Id == currentSessionId,
+		CreatedAt: session.CreatedAt,
+	}
+}
+
+func ToListSessionView(sessions []model.Session, currentSessionId string) []SessionView {
+	sessionViews := make([]SessionView, len(sessions))
+	for i, session := range sessions {
+		sessionViews[i] = *ToSessionView(&session, currentSessionId)
+	}
+	return sessionViews
+}
@@ -6,26 +6,28 @@ import (
 )
 
 type UserView struct {
-	Id        int64     `json:"id"`
-	FullName  string    `json:"full_name"`
-	Email     string    `json:"email"`
-	Username  string    `json:"username"`
-	Address   string    `json:"address"`
-	RoleName  string    `json:"role_name"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	Id              int64      `json:"id"`
+	FullName        string     `json:"full_name"`
+	Email           string     `json:"email"`
+	Username        string     `json:"username"`
+	Address         string     `json:"address"`
+	RoleName        string     `json:"role_name"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 func ToUserView(user *model.User) *UserView {
 	return &UserView{
-		Id:        user.Id,
-		FullName:  user.FullName,
-		Email:     user.Email,
-		Username:  user.Username,
-		Address:   user.Address,
-		RoleName:  user.RoleName,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		Id:              user.Id,
+		FullName:        user.FullName,
+		Email:           user.Email,
+		Username:        user.Username,
+		Address:         user.Address,
+		RoleName:        user.RoleName,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
 	}
 }
 
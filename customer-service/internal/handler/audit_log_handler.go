@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"thanhldt060802/internal/dto"
+	"thanhldt060802/internal/middleware"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/service"
+	"thanhldt060802/utils"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type AuditLogHandler struct {
+	auditLogService service.AuditLogService
+	authMiddleware  *middleware.AuthMiddleware
+}
+
+func NewAuditLogHandler(api huma.API, auditLogService service.AuditLogService, authMiddleware *middleware.AuthMiddleware) *AuditLogHandler {
+	auditLogHandler := &AuditLogHandler{
+		auditLogService: auditLogService,
+		authMiddleware:  authMiddleware,
+	}
+
+	// Get audit logs
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodGet,
+		Path:        "/audit-logs",
+		Summary:     "/audit-logs",
+		Description: "Get audit logs.",
+		Tags:        []string{"Audit"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionAuditRead)},
+	}, auditLogHandler.GetAuditLogs)
+
+	return auditLogHandler
+}
+
+func (auditLogHandler *AuditLogHandler) GetAuditLogs(ctx context.Context, reqDTO *dto.GetAuditLogsWithQueryParamRequest) (*dto.PaginationBodyResponseList[dto.AuditLogView], error) {
+	auditLogs, page, err := auditLogHandler.auditLogService.GetAuditLogs(ctx, reqDTO)
+	if errors.Is(err, utils.ErrInvalidPagination) {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Get audit logs failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	} else if err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusInternalServerError
+		res.Code = "ERR_INTERNAL_SERVER"
+		res.Message = "Get audit logs failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	data := dto.ToListAuditLogView(auditLogs)
+	res := &dto.PaginationBodyResponseList[dto.AuditLogView]{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Get audit logs successful"
+	res.Body.Data = data
+	res.Body.Total = page.Total
+	res.Body.NextCursor = page.NextCursor
+	res.Body.PrevCursor = page.PrevCursor
+	return res, nil
+}
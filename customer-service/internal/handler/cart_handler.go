@@ -2,10 +2,13 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"thanhldt060802/internal/dto"
 	"thanhldt060802/internal/middleware"
+	"thanhldt060802/internal/model"
 	"thanhldt060802/internal/service"
+	"thanhldt060802/utils"
 
 	"github.com/danielgtaylor/huma/v2"
 )
@@ -28,7 +31,7 @@ func NewCartHandler(api huma.API, cartService service.CartService, authMiddlewar
 		Summary:     "/carts",
 		Description: "Get carts.",
 		Tags:        []string{"Cart"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionCartRead)},
 	}, cartHandler.GetCarts)
 
 	// Get cart by user id
@@ -38,7 +41,7 @@ func NewCartHandler(api huma.API, cartService service.CartService, authMiddlewar
 		Summary:     "/carts/user-id/{user_id}",
 		Description: "Get cart by user id.",
 		Tags:        []string{"Cart"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionCartRead)},
 	}, cartHandler.GetCartByUserId)
 
 	// Get cart using account
@@ -55,8 +58,15 @@ func NewCartHandler(api huma.API, cartService service.CartService, authMiddlewar
 }
 
 func (cartHandler *CartHandler) GetCarts(ctx context.Context, reqDTO *dto.GetCartsWithQueryParamRequest) (*dto.PaginationBodyResponseList[dto.CartView], error) {
-	carts, err := cartHandler.cartService.GetCarts(ctx, reqDTO)
-	if err != nil {
+	carts, page, err := cartHandler.cartService.GetCarts(ctx, reqDTO)
+	if errors.Is(err, utils.ErrInvalidPagination) {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Get carts failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	} else if err != nil {
 		res := &dto.ErrorResponse{}
 		res.Status = http.StatusInternalServerError
 		res.Code = "ERR_INTERNAL_SERVER"
@@ -70,7 +80,9 @@ func (cartHandler *CartHandler) GetCarts(ctx context.Context, reqDTO *dto.GetCar
 	res.Body.Code = "OK"
 	res.Body.Message = "Get carts successful"
 	res.Body.Data = data
-	res.Body.Total = len(data)
+	res.Body.Total = page.Total
+	res.Body.NextCursor = page.NextCursor
+	res.Body.PrevCursor = page.PrevCursor
 	return res, nil
 }
 
@@ -2,10 +2,14 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"log"
 	"net/http"
 	"thanhldt060802/internal/dto"
 	"thanhldt060802/internal/middleware"
+	"thanhldt060802/internal/model"
 	"thanhldt060802/internal/service"
+	"thanhldt060802/utils"
 
 	"github.com/danielgtaylor/huma/v2"
 )
@@ -28,7 +32,7 @@ func NewCartItemHandler(api huma.API, cartItemService service.CartItemService, a
 		Summary:     "/cart-items",
 		Description: "Get cart items.",
 		Tags:        []string{"Cart Item"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionCartRead)},
 	}, cartItemHandler.GetCartItems)
 
 	// Get cart item by id
@@ -38,7 +42,7 @@ func NewCartItemHandler(api huma.API, cartItemService service.CartItemService, a
 		Summary:     "/cart-items/id/{id}",
 		Description: "Get cart item by id.",
 		Tags:        []string{"Cart Item"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionCartRead)},
 	}, cartItemHandler.GetCartItemById)
 
 	// Get cart items by cart id
@@ -48,7 +52,7 @@ func NewCartItemHandler(api huma.API, cartItemService service.CartItemService, a
 		Summary:     "/cart-items/cart-id/{cart_id}",
 		Description: "Get cart items by cart id.",
 		Tags:        []string{"Cart Item"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionCartRead)},
 	}, cartItemHandler.GetCartItemsByCartId)
 
 	// Get cart items using account
@@ -105,8 +109,15 @@ func NewCartItemHandler(api huma.API, cartItemService service.CartItemService, a
 }
 
 func (cartItemHandler *CartItemHandler) GetCartItems(ctx context.Context, reqDTO *dto.GetCartItemsWithQueryParamRequest) (*dto.PaginationBodyResponseList[dto.CartItemView], error) {
-	cartItems, err := cartItemHandler.cartItemService.GetCartItems(ctx, reqDTO)
-	if err != nil {
+	cartItems, page, err := cartItemHandler.cartItemService.GetCartItems(ctx, reqDTO)
+	if errors.Is(err, utils.ErrInvalidPagination) {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Get cart items failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	} else if err != nil {
 		res := &dto.ErrorResponse{}
 		res.Status = http.StatusInternalServerError
 		res.Code = "ERR_INTERNAL_SERVER"
@@ -115,12 +126,14 @@ func (cartItemHandler *CartItemHandler) GetCartItems(ctx context.Context, reqDTO
 		return nil, res
 	}
 
-	data := dto.ToListCartItemView(cartItems)
+	data := cartItemHandler.toListCartItemView(ctx, cartItems)
 	res := &dto.PaginationBodyResponseList[dto.CartItemView]{}
 	res.Body.Code = "OK"
 	res.Body.Message = "Get cart items successful"
 	res.Body.Data = data
-	res.Body.Total = len(data)
+	res.Body.Total = page.Total
+	res.Body.NextCursor = page.NextCursor
+	res.Body.PrevCursor = page.PrevCursor
 	return res, nil
 }
 
@@ -135,7 +148,7 @@ func (cartItemHandler *CartItemHandler) GetCartItemById(ctx context.Context, req
 		return nil, res
 	}
 
-	data := dto.ToCartItemView(foundCartItem)
+	data := &cartItemHandler.toListCartItemView(ctx, []model.CartItem{*foundCartItem})[0]
 	res := &dto.BodyResponse[dto.CartItemView]{}
 	res.Body.Code = "OK"
 	res.Body.Message = "Get cart item by id successful"
@@ -144,8 +157,15 @@ func (cartItemHandler *CartItemHandler) GetCartItemById(ctx context.Context, req
 }
 
 func (cartItemHandler *CartItemHandler) GetCartItemsByCartId(ctx context.Context, reqDTO *dto.GetCartItemsByCartIdWithQueryParamRequest) (*dto.PaginationBodyResponseList[dto.CartItemView], error) {
-	cartItems, err := cartItemHandler.cartItemService.GetCartItemsByCartId(ctx, reqDTO)
-	if err != nil {
+	cartItems, page, err := cartItemHandler.cartItemService.GetCartItemsByCartId(ctx, reqDTO)
+	if errors.Is(err, utils.ErrInvalidPagination) {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Get cart items by cart id failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	} else if err != nil {
 		res := &dto.ErrorResponse{}
 		res.Status = http.StatusInternalServerError
 		res.Code = "ERR_INTERNAL_SERVER"
@@ -154,12 +174,14 @@ func (cartItemHandler *CartItemHandler) GetCartItemsByCartId(ctx context.Context
 		return nil, res
 	}
 
-	data := dto.ToListCartItemView(cartItems)
+	data := cartItemHandler.toListCartItemView(ctx, cartItems)
 	res := &dto.PaginationBodyResponseList[dto.CartItemView]{}
 	res.Body.Code = "OK"
 	res.Body.Message = "Get cart items by cart id successful"
 	res.Body.Data = data
-	res.Body.Total = len(data)
+	res.Body.Total = page.Total
+	res.Body.NextCursor = page.NextCursor
+	res.Body.PrevCursor = page.PrevCursor
 	return res, nil
 }
 
@@ -167,14 +189,22 @@ func (cartItemHandler *CartItemHandler) GetCartItemsUsingAccount(ctx context.Con
 	cartId := ctx.Value("cart_id").(int64)
 
 	convertReqDTO := &dto.GetCartItemsByCartIdWithQueryParamRequest{
-		CartId: cartId,
-		Offset: reqDTO.Offset,
-		Limit:  reqDTO.Limit,
-		SortBy: reqDTO.SortBy,
+		CartId:       cartId,
+		Cursor:       reqDTO.Cursor,
+		Limit:        reqDTO.Limit,
+		SortBy:       reqDTO.SortBy,
+		IncludeTotal: reqDTO.IncludeTotal,
 	}
 
-	cartItems, err := cartItemHandler.cartItemService.GetCartItemsByCartId(ctx, convertReqDTO)
-	if err != nil {
+	cartItems, page, err := cartItemHandler.cartItemService.GetCartItemsByCartId(ctx, convertReqDTO)
+	if errors.Is(err, utils.ErrInvalidPagination) {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Get cart items using account failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	} else if err != nil {
 		res := &dto.ErrorResponse{}
 		res.Status = http.StatusInternalServerError
 		res.Code = "ERR_INTERNAL_SERVER"
@@ -183,12 +213,14 @@ func (cartItemHandler *CartItemHandler) GetCartItemsUsingAccount(ctx context.Con
 		return nil, res
 	}
 
-	data := dto.ToListCartItemView(cartItems)
+	data := cartItemHandler.toListCartItemView(ctx, cartItems)
 	res := &dto.PaginationBodyResponseList[dto.CartItemView]{}
 	res.Body.Code = "OK"
 	res.Body.Message = "Get cart items using account successful"
 	res.Body.Data = data
-	res.Body.Total = len(data)
+	res.Body.Total = page.Total
+	res.Body.NextCursor = page.NextCursor
+	res.Body.PrevCursor = page.PrevCursor
 	return res, nil
 }
 
@@ -323,3 +355,16 @@ func (cartItemHandler *CartItemHandler) DeleteCartItemUsingAccount(ctx context.C
 	res.Body.Message = "Delete cart item using account successful"
 	return res, nil
 }
+
+// Product info is best effort, cart items are still returned when catalog service is unavailable
+func (cartItemHandler *CartItemHandler) toListCartItemView(ctx context.Context, cartItems []model.CartItem) []dto.CartItemView {
+	data := dto.ToListCartItemView(cartItems)
+
+	products, err := cartItemHandler.cartItemService.GetProductsOfCartItems(ctx, cartItems)
+	if err != nil {
+		log.Printf("Get products of cart items failed: %s", err.Error())
+		return data
+	}
+
+	return dto.AttachProductsToListCartItemView(data, products)
+}
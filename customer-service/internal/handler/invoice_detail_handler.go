@@ -2,10 +2,13 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"thanhldt060802/internal/dto"
 	"thanhldt060802/internal/middleware"
+	"thanhldt060802/internal/model"
 	"thanhldt060802/internal/service"
+	"thanhldt060802/utils"
 
 	"github.com/danielgtaylor/huma/v2"
 )
@@ -30,7 +33,7 @@ func NewInvoiceDetailHandler(api huma.API, invoiceDetailService service.InvoiceD
 		Summary:     "/invoice-details",
 		Description: "Get invoice details.",
 		Tags:        []string{"Invoice Detail"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionInvoiceRead)},
 	}, invoiceDetailHandler.GetInvoiceDetails)
 
 	// Get invoice detail by id
@@ -40,7 +43,7 @@ func NewInvoiceDetailHandler(api huma.API, invoiceDetailService service.InvoiceD
 		Summary:     "/invoice-details/id/{id}",
 		Description: "Get invoice detail by id.",
 		Tags:        []string{"Invoice Detail"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionInvoiceRead)},
 	}, invoiceDetailHandler.GetInvoiceDetailById)
 
 	// Get invoice details by invoice id
@@ -50,7 +53,7 @@ func NewInvoiceDetailHandler(api huma.API, invoiceDetailService service.InvoiceD
 		Summary:     "/invoice-details/invoice-id/{invoice_id}",
 		Description: "Get invoice details by invoice id.",
 		Tags:        []string{"Invoice Detail"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionInvoiceRead)},
 	}, invoiceDetailHandler.GetInvoiceDetailsByInvoiceId)
 
 	// Get invoice details using account
@@ -71,8 +74,15 @@ func NewInvoiceDetailHandler(api huma.API, invoiceDetailService service.InvoiceD
 }
 
 func (invoiceDetailHandler *InvoiceDetailHandler) GetInvoiceDetails(ctx context.Context, reqDTO *dto.GetInvoiceDetailsWithQueryParamRequest) (*dto.PaginationBodyResponseList[dto.InvoiceDetailView], error) {
-	invoiceDetails, err := invoiceDetailHandler.invoiceDetailService.GetInvoiceDetails(ctx, reqDTO)
-	if err != nil {
+	invoiceDetails, page, err := invoiceDetailHandler.invoiceDetailService.GetInvoiceDetails(ctx, reqDTO)
+	if errors.Is(err, utils.ErrInvalidPagination) {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Get invoice details failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	} else if err != nil {
 		res := &dto.ErrorResponse{}
 		res.Status = http.StatusInternalServerError
 		res.Code = "ERR_INTERNAL_SERVER"
@@ -86,7 +96,9 @@ func (invoiceDetailHandler *InvoiceDetailHandler) GetInvoiceDetails(ctx context.
 	res.Body.Code = "OK"
 	res.Body.Message = "Get invoice details successful"
 	res.Body.Data = data
-	res.Body.Total = len(data)
+	res.Body.Total = page.Total
+	res.Body.NextCursor = page.NextCursor
+	res.Body.PrevCursor = page.PrevCursor
 	return res, nil
 }
 
@@ -110,8 +122,15 @@ func (invoiceDetailHandler *InvoiceDetailHandler) GetInvoiceDetailById(ctx conte
 }
 
 func (invoiceDetailHandler *InvoiceDetailHandler) GetInvoiceDetailsByInvoiceId(ctx context.Context, reqDTO *dto.GetInvoiceDetailsByInvoiceIdWithQueryParamRequest) (*dto.PaginationBodyResponseList[dto.InvoiceDetailView], error) {
-	invoiceDetails, err := invoiceDetailHandler.invoiceDetailService.GetInvoiceDetailsByInvoiceId(ctx, reqDTO)
-	if err != nil {
+	invoiceDetails, page, err := invoiceDetailHandler.invoiceDetailService.GetInvoiceDetailsByInvoiceId(ctx, reqDTO)
+	if errors.Is(err, utils.ErrInvalidPagination) {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Get invoice details by invoice id failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	} else if err != nil {
 		res := &dto.ErrorResponse{}
 		res.Status = http.StatusInternalServerError
 		res.Code = "ERR_INTERNAL_SERVER"
@@ -125,7 +144,9 @@ func (invoiceDetailHandler *InvoiceDetailHandler) GetInvoiceDetailsByInvoiceId(c
 	res.Body.Code = "OK"
 	res.Body.Message = "Get invoice details by invoice id successful"
 	res.Body.Data = data
-	res.Body.Total = len(data)
+	res.Body.Total = page.Total
+	res.Body.NextCursor = page.NextCursor
+	res.Body.PrevCursor = page.PrevCursor
 	return res, nil
 }
 
@@ -150,14 +171,22 @@ func (invoiceDetailHandler *InvoiceDetailHandler) GetInvoiceDetailsByInvoiceIdUs
 	}
 
 	convertReqDTO := &dto.GetInvoiceDetailsByInvoiceIdWithQueryParamRequest{
-		InvoiceId: reqDTO.InvoiceId,
-		Offset:    reqDTO.Offset,
-		Limit:     reqDTO.Limit,
-		SortBy:    reqDTO.SortBy,
+		InvoiceId:    reqDTO.InvoiceId,
+		Cursor:       reqDTO.Cursor,
+		Limit:        reqDTO.Limit,
+		SortBy:       reqDTO.SortBy,
+		IncludeTotal: reqDTO.IncludeTotal,
 	}
 
-	invoiceDetails, err := invoiceDetailHandler.invoiceDetailService.GetInvoiceDetailsByInvoiceId(ctx, convertReqDTO)
-	if err != nil {
+	invoiceDetails, page, err := invoiceDetailHandler.invoiceDetailService.GetInvoiceDetailsByInvoiceId(ctx, convertReqDTO)
+	if errors.Is(err, utils.ErrInvalidPagination) {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Get invoice details by invoice id using account failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	} else if err != nil {
 		res := &dto.ErrorResponse{}
 		res.Status = http.StatusInternalServerError
 		res.Code = "ERR_INTERNAL_SERVER"
@@ -171,6 +200,8 @@ func (invoiceDetailHandler *InvoiceDetailHandler) GetInvoiceDetailsByInvoiceIdUs
 	res.Body.Code = "OK"
 	res.Body.Message = "Get invoice details by invoice id using account successful"
 	res.Body.Data = data
-	res.Body.Total = len(data)
+	res.Body.Total = page.Total
+	res.Body.NextCursor = page.NextCursor
+	res.Body.PrevCursor = page.PrevCursor
 	return res, nil
 }
@@ -2,11 +2,14 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"thanhldt060802/internal/dto"
 	"thanhldt060802/internal/middleware"
 	"thanhldt060802/internal/model"
 	"thanhldt060802/internal/service"
+	"thanhldt060802/utils"
 
 	"github.com/danielgtaylor/huma/v2"
 )
@@ -29,7 +32,7 @@ func NewInvoiceHandler(api huma.API, invoiceService service.InvoiceService, auth
 		Summary:     "/invoices",
 		Description: "Get invoices.",
 		Tags:        []string{"Invoice"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionInvoiceRead)},
 	}, invoiceHandler.GetInvoices)
 
 	// Get invoice by id
@@ -39,7 +42,7 @@ func NewInvoiceHandler(api huma.API, invoiceService service.InvoiceService, auth
 		Summary:     "/invoices/id/{id}",
 		Description: "Get invoice by id.",
 		Tags:        []string{"Invoice"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionInvoiceRead)},
 	}, invoiceHandler.GetInvoiceById)
 
 	// Get invoices by user id
@@ -49,7 +52,7 @@ func NewInvoiceHandler(api huma.API, invoiceService service.InvoiceService, auth
 		Summary:     "/invoices/user-id/{user_id}",
 		Description: "Get invoices by user id.",
 		Tags:        []string{"Invoice"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionInvoiceRead)},
 	}, invoiceHandler.GetInvoicesByUserId)
 
 	// Update invoice by id
@@ -59,9 +62,19 @@ func NewInvoiceHandler(api huma.API, invoiceService service.InvoiceService, auth
 		Summary:     "/invoices/id/{id}",
 		Description: "Update invoice by id.",
 		Tags:        []string{"Invoice"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionInvoiceWrite)},
 	}, invoiceHandler.UpdateInvoiceById)
 
+	// Get invoice status histories by id
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodGet,
+		Path:        "/invoices/id/{id}/status-histories",
+		Summary:     "/invoices/id/{id}/status-histories",
+		Description: "Get invoice status histories by id.",
+		Tags:        []string{"Invoice"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionInvoiceRead)},
+	}, invoiceHandler.GetInvoiceStatusHistoriesById)
+
 	// Get invoices using account
 	huma.Register(api, huma.Operation{
 		Method:      http.MethodGet,
@@ -82,6 +95,26 @@ func NewInvoiceHandler(api huma.API, invoiceService service.InvoiceService, auth
 		Middlewares: huma.Middlewares{authMiddleware.Authentication},
 	}, invoiceHandler.GetInvoiceByIdUsingAccount)
 
+	// Checkout cart using account
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodPost,
+		Path:        "/my-cart/checkout",
+		Summary:     "/my-cart/checkout",
+		Description: "Checkout cart using account.",
+		Tags:        []string{"Invoice"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication},
+	}, invoiceHandler.CheckoutCartUsingAccount)
+
+	// Cancel invoice by id using account
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodPost,
+		Path:        "/my-invoices/id/{id}/cancel",
+		Summary:     "/my-invoices/id/{id}/cancel",
+		Description: "Cancel invoice by id using account.",
+		Tags:        []string{"Invoice"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication},
+	}, invoiceHandler.CancelInvoiceByIdUsingAccount)
+
 	// Delete invoice by id using account
 	huma.Register(api, huma.Operation{
 		Method:      http.MethodDelete,
@@ -89,7 +122,7 @@ func NewInvoiceHandler(api huma.API, invoiceService service.InvoiceService, auth
 		Summary:     "/my-invoices/id/{id}",
 		Description: "Delete invoice by id using account.",
 		Tags:        []string{"Invoice"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionInvoiceWrite)},
 	}, invoiceHandler.DeleteInvoiceByIdUsingAccount)
 
 	// Sync all invoices to Elasticsearch
@@ -97,9 +130,9 @@ func NewInvoiceHandler(api huma.API, invoiceService service.InvoiceService, auth
 		Method:      http.MethodGet,
 		Path:        "/invoices/sync-to-elasticsearch",
 		Summary:     "/invoices/sync-to-elasticsearch",
-		Description: "Sync all invoices to Elasticsearch.",
+		Description: "Rebuild invoices index on Elasticsearch and switch invoices alias to it.",
 		Tags:        []string{"Invoice"},
-		// Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionInvoiceWrite)},
 	}, invoiceHandler.SyncAllInvoicesToElasticsearch)
 
 	// Get invoices with Elasticsearch
@@ -109,6 +142,7 @@ func NewInvoiceHandler(api huma.API, invoiceService service.InvoiceService, auth
 		Summary:     "/invoices/elasticsearch",
 		Description: "Get invoices with Elasticsearch.",
 		Tags:        []string{"Invoice"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionInvoiceRead)},
 	}, invoiceHandler.GetInvoicesWithElasticsearch)
 
 	// Sum invoices with Elasticsearch
@@ -118,23 +152,32 @@ func NewInvoiceHandler(api huma.API, invoiceService service.InvoiceService, auth
 		Summary:     "/invoices/elasticsearch/sum",
 		Description: "Sum invoices with Elasticsearch.",
 		Tags:        []string{"Invoice"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionInvoiceRead)},
 	}, invoiceHandler.SumInvoicesWithElasticsearch)
 
-	// Sum avg invoices with Elasticsearch
+	// Report invoices with Elasticsearch
 	huma.Register(api, huma.Operation{
 		Method:      http.MethodGet,
-		Path:        "/invoices/elasticsearch/sumavg",
-		Summary:     "/invoices/elasticsearch/sumavg",
-		Description: "Sum avg invoices with Elasticsearch.",
+		Path:        "/invoices/elasticsearch/report",
+		Summary:     "/invoices/elasticsearch/report",
+		Description: "Report revenue series, breakdowns by status and user, and total_amount statistics of invoices with Elasticsearch.",
 		Tags:        []string{"Invoice"},
-	}, invoiceHandler.SumAvgInvoicesWithElasticsearch)
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionInvoiceRead)},
+	}, invoiceHandler.ReportInvoicesWithElasticsearch)
 
 	return invoiceHandler
 }
 
 func (invoiceHandler *InvoiceHandler) GetInvoices(ctx context.Context, reqDTO *dto.GetInvoicesWithQueryParamRequest) (*dto.PaginationBodyResponseList[dto.InvoiceView], error) {
-	invoices, err := invoiceHandler.invoiceService.GetInvoices(ctx, reqDTO)
-	if err != nil {
+	invoices, page, err := invoiceHandler.invoiceService.GetInvoices(ctx, reqDTO)
+	if errors.Is(err, utils.ErrInvalidPagination) {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Get invoices failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	} else if err != nil {
 		res := &dto.ErrorResponse{}
 		res.Status = http.StatusInternalServerError
 		res.Code = "ERR_INTERNAL_SERVER"
@@ -148,7 +191,9 @@ func (invoiceHandler *InvoiceHandler) GetInvoices(ctx context.Context, reqDTO *d
 	res.Body.Code = "OK"
 	res.Body.Message = "Get invoices successful"
 	res.Body.Data = data
-	res.Body.Total = len(data)
+	res.Body.Total = page.Total
+	res.Body.NextCursor = page.NextCursor
+	res.Body.PrevCursor = page.PrevCursor
 	return res, nil
 }
 
@@ -172,8 +217,15 @@ func (invoiceHandler *InvoiceHandler) GetInvoiceById(ctx context.Context, reqDTO
 }
 
 func (invoiceHandler *InvoiceHandler) GetInvoicesByUserId(ctx context.Context, reqDTO *dto.GetInvoicesByUserIdWithQueryParamRequest) (*dto.PaginationBodyResponseList[dto.InvoiceView], error) {
-	invoices, err := invoiceHandler.invoiceService.GetInvoicesByUserId(ctx, reqDTO)
-	if err != nil {
+	invoices, page, err := invoiceHandler.invoiceService.GetInvoicesByUserId(ctx, reqDTO)
+	if errors.Is(err, utils.ErrInvalidPagination) {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Get invoices failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	} else if err != nil {
 		res := &dto.ErrorResponse{}
 		res.Status = http.StatusInternalServerError
 		res.Code = "ERR_INTERNAL_SERVER"
@@ -187,12 +239,34 @@ func (invoiceHandler *InvoiceHandler) GetInvoicesByUserId(ctx context.Context, r
 	res.Body.Code = "OK"
 	res.Body.Message = "Get invoices successful"
 	res.Body.Data = data
-	res.Body.Total = len(data)
+	res.Body.Total = page.Total
+	res.Body.NextCursor = page.NextCursor
+	res.Body.PrevCursor = page.PrevCursor
 	return res, nil
 }
 
 func (invoiceHandler *InvoiceHandler) UpdateInvoiceById(ctx context.Context, reqDTO *dto.UpdateInvoiceRequest) (*dto.SuccessResponse, error) {
-	if err := invoiceHandler.invoiceService.UpdateInvoiceById(ctx, reqDTO); err != nil {
+	// Refund moves money back, so it needs its own permission on top of invoice:write
+	if reqDTO.Body.Status == model.InvoiceStatusRefunded && !middleware.HasPermission(ctx, model.PermissionInvoiceRefund) {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusForbidden
+		res.Code = "ERR_FORBIDDEN"
+		res.Message = "Update invoice failed"
+		res.Details = []string{fmt.Sprintf("missing permission %s", model.PermissionInvoiceRefund)}
+		return nil, res
+	}
+
+	userId := ctx.Value("user_id").(int64)
+	roleName := ctx.Value("role_name").(string)
+
+	convertReqDTO := &dto.ChangeInvoiceStatusRequest{
+		InvoiceId: reqDTO.Id,
+		Status:    reqDTO.Body.Status,
+		ActorId:   userId,
+		ActorRole: roleName,
+	}
+
+	if err := invoiceHandler.invoiceService.ChangeInvoiceStatus(ctx, convertReqDTO); err != nil {
 		res := &dto.ErrorResponse{}
 		res.Status = http.StatusBadRequest
 		res.Code = "ERR_BAD_REQUEST"
@@ -207,6 +281,27 @@ func (invoiceHandler *InvoiceHandler) UpdateInvoiceById(ctx context.Context, req
 	return res, nil
 }
 
+func (invoiceHandler *InvoiceHandler) GetInvoiceStatusHistoriesById(ctx context.Context, reqDTO *dto.GetInvoiceStatusHistoriesByIdRequest) (*dto.PaginationBodyResponseList[dto.InvoiceStatusHistoryView], error) {
+	invoiceStatusHistories, err := invoiceHandler.invoiceService.GetInvoiceStatusHistoriesById(ctx, reqDTO)
+	if err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Get invoice status histories by id failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	data := dto.ToListInvoiceStatusHistoryView(invoiceStatusHistories)
+	res := &dto.PaginationBodyResponseList[dto.InvoiceStatusHistoryView]{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Get invoice status histories by id successful"
+	res.Body.Data = data
+	total := int64(len(data))
+	res.Body.Total = &total
+	return res, nil
+}
+
 func (invoiceHandler *InvoiceHandler) DeleteInvoiceById(ctx context.Context, reqDTO *dto.DeleteInvoiceRequest) (*dto.SuccessResponse, error) {
 	if err := invoiceHandler.invoiceService.DeleteInvoiceById(ctx, reqDTO); err != nil {
 		res := &dto.ErrorResponse{}
@@ -227,14 +322,22 @@ func (invoiceHandler *InvoiceHandler) GetInvoicesUsingAccount(ctx context.Contex
 	userId := ctx.Value("user_id").(int64)
 
 	convertReqDTO := &dto.GetInvoicesByUserIdWithQueryParamRequest{
-		UserId: userId,
-		Offset: reqDTO.Offset,
-		Limit:  reqDTO.Limit,
-		SortBy: reqDTO.SortBy,
+		UserId:       userId,
+		Cursor:       reqDTO.Cursor,
+		Limit:        reqDTO.Limit,
+		SortBy:       reqDTO.SortBy,
+		IncludeTotal: reqDTO.IncludeTotal,
 	}
 
-	invoices, err := invoiceHandler.invoiceService.GetInvoicesByUserId(ctx, convertReqDTO)
-	if err != nil {
+	invoices, page, err := invoiceHandler.invoiceService.GetInvoicesByUserId(ctx, convertReqDTO)
+	if errors.Is(err, utils.ErrInvalidPagination) {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Get invoices failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	} else if err != nil {
 		res := &dto.ErrorResponse{}
 		res.Status = http.StatusInternalServerError
 		res.Code = "ERR_INTERNAL_SERVER"
@@ -248,7 +351,9 @@ func (invoiceHandler *InvoiceHandler) GetInvoicesUsingAccount(ctx context.Contex
 	res.Body.Code = "OK"
 	res.Body.Message = "Get invoices successful"
 	res.Body.Data = data
-	res.Body.Total = len(data)
+	res.Body.Total = page.Total
+	res.Body.NextCursor = page.NextCursor
+	res.Body.PrevCursor = page.PrevCursor
 	return res, nil
 }
 
@@ -282,6 +387,63 @@ func (invoiceHandler *InvoiceHandler) GetInvoiceByIdUsingAccount(ctx context.Con
 	return res, nil
 }
 
+func (invoiceHandler *InvoiceHandler) CheckoutCartUsingAccount(ctx context.Context, reqDTO *struct{}) (*dto.BodyResponse[dto.InvoiceView], error) {
+	userId := ctx.Value("user_id").(int64)
+	roleName := ctx.Value("role_name").(string)
+	cartId := ctx.Value("cart_id").(int64)
+
+	convertReqDTO := &dto.CheckoutCartRequest{
+		UserId:   userId,
+		RoleName: roleName,
+		CartId:   cartId,
+	}
+
+	newInvoice, err := invoiceHandler.invoiceService.CheckoutCart(ctx, convertReqDTO)
+	if err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Checkout cart using account failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	data := dto.ToInvoiceView(newInvoice)
+	res := &dto.BodyResponse[dto.InvoiceView]{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Checkout cart using account successful"
+	res.Body.Data = *data
+	return res, nil
+}
+
+func (invoiceHandler *InvoiceHandler) CancelInvoiceByIdUsingAccount(ctx context.Context, reqDTO *dto.CancelInvoiceByIdUsingAccountRequest) (*dto.SuccessResponse, error) {
+	userId := ctx.Value("user_id").(int64)
+	roleName := ctx.Value("role_name").(string)
+
+	// Only PENDING invoice can move to CANCEL, so the state machine also enforces the pending rule
+	convertReqDTO := &dto.ChangeInvoiceStatusRequest{
+		InvoiceId: reqDTO.Id,
+		Status:    model.InvoiceStatusCancel,
+		ActorId:   userId,
+		ActorRole: roleName,
+		OwnerId:   &userId,
+	}
+
+	if err := invoiceHandler.invoiceService.ChangeInvoiceStatus(ctx, convertReqDTO); err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Cancel invoice using account failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	res := &dto.SuccessResponse{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Cancel invoice using account successful"
+	return res, nil
+}
+
 func (invoiceHandler *InvoiceHandler) DeleteInvoiceByIdUsingAccount(ctx context.Context, reqDTO *dto.DeleteInvoiceUsingAccountRequest) (*dto.SuccessResponse, error) {
 	userId := ctx.Value("user_id").(int64)
 
@@ -336,8 +498,15 @@ func (invoiceHandler *InvoiceHandler) SyncAllInvoicesToElasticsearch(ctx context
 }
 
 func (invoiceHandler *InvoiceHandler) GetInvoicesWithElasticsearch(ctx context.Context, reqDTO *dto.GetInvoicesWithElasticsearchRequest) (*dto.PaginationBodyResponseList[dto.InvoiceView], error) {
-	invoices, err := invoiceHandler.invoiceService.GetInvoicesWithElasticsearch(ctx, reqDTO)
-	if err != nil {
+	invoices, page, err := invoiceHandler.invoiceService.GetInvoicesWithElasticsearch(ctx, reqDTO)
+	if errors.Is(err, utils.ErrInvalidPagination) {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Get invoices with Elasticsearch failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	} else if err != nil {
 		res := &dto.ErrorResponse{}
 		res.Status = http.StatusInternalServerError
 		res.Code = "ERR_INTERNAL_SERVER"
@@ -351,7 +520,9 @@ func (invoiceHandler *InvoiceHandler) GetInvoicesWithElasticsearch(ctx context.C
 	res.Body.Code = "OK"
 	res.Body.Message = "Get invoices with Elasticsearch successful"
 	res.Body.Data = data
-	res.Body.Total = len(data)
+	res.Body.Total = page.Total
+	res.Body.NextCursor = page.NextCursor
+	res.Body.PrevCursor = page.PrevCursor
 	return res, nil
 }
 
@@ -373,20 +544,21 @@ func (invoiceHandler *InvoiceHandler) SumInvoicesWithElasticsearch(ctx context.C
 	return res, nil
 }
 
-func (invoiceHandler *InvoiceHandler) SumAvgInvoicesWithElasticsearch(ctx context.Context, reqDTO *dto.AggregateInvoicesWithElasticsearchRequest) (*dto.BodyResponse[model.InvoiceReport], error) {
-	invoiceReport, err := invoiceHandler.invoiceService.SumAvgInvoicesWithElasticsearch(ctx, reqDTO)
+func (invoiceHandler *InvoiceHandler) ReportInvoicesWithElasticsearch(ctx context.Context, reqDTO *dto.ReportInvoicesWithElasticsearchRequest) (*dto.BodyResponse[dto.InvoiceReportView], error) {
+	invoiceReport, err := invoiceHandler.invoiceService.ReportInvoicesWithElasticsearch(ctx, reqDTO)
 	if err != nil {
 		res := &dto.ErrorResponse{}
 		res.Status = http.StatusInternalServerError
 		res.Code = "ERR_INTERNAL_SERVER"
-		res.Message = "Sum avg invoices with Elasticsearch failed"
+		res.Message = "Report invoices with Elasticsearch failed"
 		res.Details = []string{err.Error()}
 		return nil, res
 	}
 
-	res := &dto.BodyResponse[model.InvoiceReport]{}
+	data := dto.ToInvoiceReportView(invoiceReport)
+	res := &dto.BodyResponse[dto.InvoiceReportView]{}
 	res.Body.Code = "OK"
-	res.Body.Message = "Sum avg invoices with Elasticsearch successful"
-	res.Body.Data = *invoiceReport
+	res.Body.Message = "Report invoices with Elasticsearch successful"
+	res.Body.Data = *data
 	return res, nil
 }
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"thanhldt060802/internal/dto"
+	"thanhldt060802/utils"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type JWKSHandler struct {
+	jwtKeys *utils.JWTKeys
+}
+
+func NewJWKSHandler(api huma.API, jwtKeys *utils.JWTKeys) *JWKSHandler {
+	jwksHandler := &JWKSHandler{
+		jwtKeys: jwtKeys,
+	}
+
+	// Get JSON web key set
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodGet,
+		Path:        "/.well-known/jwks.json",
+		Summary:     "/.well-known/jwks.json",
+		Description: "Get public keys for verifying access tokens, other services cache them and refetch on unknown kid.",
+		Tags:        []string{"Auth"},
+	}, jwksHandler.GetJSONWebKeySet)
+
+	return jwksHandler
+}
+
+// Raw JWK set without response envelope, as verifiers expect
+func (jwksHandler *JWKSHandler) GetJSONWebKeySet(ctx context.Context, reqDTO *struct{}) (*dto.JSONWebKeySetResponse, error) {
+	res := &dto.JSONWebKeySetResponse{}
+	res.CacheControl = "public, max-age=300"
+	res.Body.Keys = dto.ToListJSONWebKeyView(utils.GetJSONWebKeys(jwksHandler.jwtKeys))
+	return res, nil
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"thanhldt060802/internal/dto"
+	"thanhldt060802/internal/middleware"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/service"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type RoleHandler struct {
+	roleService    service.RoleService
+	authMiddleware *middleware.AuthMiddleware
+}
+
+func NewRoleHandler(api huma.API, roleService service.RoleService, authMiddleware *middleware.AuthMiddleware) *RoleHandler {
+	roleHandler := &RoleHandler{
+		roleService:    roleService,
+		authMiddleware: authMiddleware,
+	}
+
+	// Get roles
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodGet,
+		Path:        "/roles",
+		Summary:     "/roles",
+		Description: "Get roles with their permissions.",
+		Tags:        []string{"Role"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionRoleRead)},
+	}, roleHandler.GetRoles)
+
+	// Get role by name
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodGet,
+		Path:        "/roles/name/{name}",
+		Summary:     "/roles/name/{name}",
+		Description: "Get role by name with its permissions.",
+		Tags:        []string{"Role"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionRoleRead)},
+	}, roleHandler.GetRoleByName)
+
+	// Create role
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodPost,
+		Path:        "/roles",
+		Summary:     "/roles",
+		Description: "Create role without permissions.",
+		Tags:        []string{"Role"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionRoleWrite)},
+	}, roleHandler.CreateRole)
+
+	// Delete role by name
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodDelete,
+		Path:        "/roles/name/{name}",
+		Summary:     "/roles/name/{name}",
+		Description: "Delete role by name, only when no user has it.",
+		Tags:        []string{"Role"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionRoleWrite)},
+	}, roleHandler.DeleteRoleByName)
+
+	// Get permissions
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodGet,
+		Path:        "/permissions",
+		Summary:     "/permissions",
+		Description: "Get permissions which can be granted to roles.",
+		Tags:        []string{"Role"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionRoleRead)},
+	}, roleHandler.GetPermissions)
+
+	// Add permissions to role
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodPost,
+		Path:        "/roles/name/{name}/permissions",
+		Summary:     "/roles/name/{name}/permissions",
+		Description: "Grant permissions to role, users get them on next login or token refresh.",
+		Tags:        []string{"Role"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionRoleWrite)},
+	}, roleHandler.AddPermissionsToRole)
+
+	// Remove permission from role
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodDelete,
+		Path:        "/roles/name/{name}/permissions/{permission_name}",
+		Summary:     "/roles/name/{name}/permissions/{permission_name}",
+		Description: "Revoke permission from role, users lose it on next login or token refresh.",
+		Tags:        []string{"Role"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionRoleWrite)},
+	}, roleHandler.RemovePermissionFromRole)
+
+	return roleHandler
+}
+
+func (roleHandler *RoleHandler) GetRoles(ctx context.Context, reqDTO *struct{}) (*dto.PaginationBodyResponseList[dto.RoleView], error) {
+	roles, err := roleHandler.roleService.GetRoles(ctx)
+	if err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusInternalServerError
+		res.Code = "ERR_INTERNAL_SERVER"
+		res.Message = "Get roles failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	data := dto.ToListRoleView(roles)
+	res := &dto.PaginationBodyResponseList[dto.RoleView]{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Get roles successful"
+	res.Body.Data = data
+	total := int64(len(data))
+	res.Body.Total = &total
+	return res, nil
+}
+
+func (roleHandler *RoleHandler) GetRoleByName(ctx context.Context, reqDTO *dto.GetRoleByNameRequest) (*dto.BodyResponse[dto.RoleView], error) {
+	foundRole, err := roleHandler.roleService.GetRoleByName(ctx, reqDTO)
+	if err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Get role by name failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	data := dto.ToRoleView(foundRole)
+	res := &dto.BodyResponse[dto.RoleView]{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Get role by name successful"
+	res.Body.Data = *data
+	return res, nil
+}
+
+func (roleHandler *RoleHandler) CreateRole(ctx context.Context, reqDTO *dto.CreateRoleRequest) (*dto.SuccessResponse, error) {
+	if err := roleHandler.roleService.CreateRole(ctx, reqDTO); err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Create role failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	res := &dto.SuccessResponse{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Create role successful"
+	return res, nil
+}
+
+func (roleHandler *RoleHandler) DeleteRoleByName(ctx context.Context, reqDTO *dto.DeleteRoleRequest) (*dto.SuccessResponse, error) {
+	if err := roleHandler.roleService.DeleteRoleByName(ctx, reqDTO); err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Delete role failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	res := &dto.SuccessResponse{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Delete role successful"
+	return res, nil
+}
+
+func (roleHandler *RoleHandler) GetPermissions(ctx context.Context, reqDTO *struct{}) (*dto.PaginationBodyResponseList[dto.PermissionView], error) {
+	permissions, err := roleHandler.roleService.GetPermissions(ctx)
+	if err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusInternalServerError
+		res.Code = "ERR_INTERNAL_SERVER"
+		res.Message = "Get permissions failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	data := dto.ToListPermissionView(permissions)
+	res := &dto.PaginationBodyResponseList[dto.PermissionView]{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Get permissions successful"
+	res.Body.Data = data
+	total := int64(len(data))
+	res.Body.Total = &total
+	return res, nil
+}
+
+func (roleHandler *RoleHandler) AddPermissionsToRole(ctx context.Context, reqDTO *dto.AddPermissionsToRoleRequest) (*dto.SuccessResponse, error) {
+	if err := roleHandler.roleService.AddPermissionsToRole(ctx, reqDTO); err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Add permissions to role failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	res := &dto.SuccessResponse{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Add permissions to role successful"
+	return res, nil
+}
+
+func (roleHandler *RoleHandler) RemovePermissionFromRole(ctx context.Context, reqDTO *dto.RemovePermissionFromRoleRequest) (*dto.SuccessResponse, error) {
+	if err := roleHandler.roleService.RemovePermissionFromRole(ctx, reqDTO); err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Remove permission from role failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	res := &dto.SuccessResponse{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Remove permission from role successful"
+	return res, nil
+}
@@ -2,10 +2,14 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"thanhldt060802/internal/dto"
 	"thanhldt060802/internal/middleware"
+	"thanhldt060802/internal/model"
 	"thanhldt060802/internal/service"
+	"thanhldt060802/utils"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 )
@@ -28,7 +32,7 @@ func NewUserHandler(api huma.API, userService service.UserService, authMiddlewar
 		Summary:     "/users",
 		Description: "Get users.",
 		Tags:        []string{"User"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionUserRead)},
 	}, userHandler.GetUsers)
 
 	// Get user by id
@@ -38,7 +42,7 @@ func NewUserHandler(api huma.API, userService service.UserService, authMiddlewar
 		Summary:     "/users/id/{id}",
 		Description: "Get user by id.",
 		Tags:        []string{"User"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionUserRead)},
 	}, userHandler.GetUserById)
 
 	// Get user by username
@@ -48,8 +52,8 @@ func NewUserHandler(api huma.API, userService service.UserService, authMiddlewar
 		Summary:     "/users/username/{username}",
 		Description: "Get user by username.",
 		Tags:        []string{"User"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
-	}, userHandler.GetUserById)
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionUserRead)},
+	}, userHandler.GetUserByUsername)
 
 	// Get user by email
 	huma.Register(api, huma.Operation{
@@ -58,8 +62,8 @@ func NewUserHandler(api huma.API, userService service.UserService, authMiddlewar
 		Summary:     "/users/email/{email}",
 		Description: "Get user by email.",
 		Tags:        []string{"User"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
-	}, userHandler.GetUserById)
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionUserRead)},
+	}, userHandler.GetUserByEmail)
 
 	// Create user
 	huma.Register(api, huma.Operation{
@@ -68,7 +72,7 @@ func NewUserHandler(api huma.API, userService service.UserService, authMiddlewar
 		Summary:     "/users",
 		Description: "Create user.",
 		Tags:        []string{"User"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionUserWrite)},
 	}, userHandler.CreateUser)
 
 	// Update user by id
@@ -78,7 +82,7 @@ func NewUserHandler(api huma.API, userService service.UserService, authMiddlewar
 		Summary:     "/users/id/{id}",
 		Description: "Update user by id.",
 		Tags:        []string{"User"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionUserWrite)},
 	}, userHandler.UpdateUserById)
 
 	// Delete user by id
@@ -88,9 +92,19 @@ func NewUserHandler(api huma.API, userService service.UserService, authMiddlewar
 		Summary:     "/users/id/{id}",
 		Description: "Delete user by id.",
 		Tags:        []string{"User"},
-		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionUserWrite)},
 	}, userHandler.DeleteUserById)
 
+	// Revoke all sessions of user by id
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodDelete,
+		Path:        "/users/id/{id}/sessions",
+		Summary:     "/users/id/{id}/sessions",
+		Description: "Revoke all sessions of user by id, every device of the user is logged out.",
+		Tags:        []string{"User"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionUserWrite)},
+	}, userHandler.DeleteSessionsByUserId)
+
 	// Login user
 	huma.Register(api, huma.Operation{
 		Method:      http.MethodPost,
@@ -100,25 +114,73 @@ func NewUserHandler(api huma.API, userService service.UserService, authMiddlewar
 		Tags:        []string{"Auth"},
 	}, userHandler.LoginUser)
 
+	// Refresh token
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodPost,
+		Path:        "/token/refresh",
+		Summary:     "/token/refresh",
+		Description: "Exchange refresh token for new access token and refresh token, reusing an old refresh token revokes its session.",
+		Tags:        []string{"Auth"},
+	}, userHandler.RefreshToken)
+
 	// Logout user
 	huma.Register(api, huma.Operation{
 		Method:      http.MethodPost,
 		Path:        "/logout",
 		Summary:     "/logout",
-		Description: "Logout user",
+		Description: "Logout user, revoke current access token and its refresh token.",
 		Tags:        []string{"Auth"},
-		// Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequireAdmin},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication},
 	}, userHandler.LogoutUser)
 
+	// Get login lockouts
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodGet,
+		Path:        "/login-lockouts",
+		Summary:     "/login-lockouts",
+		Description: "Get usernames and client ips currently locked out of login.",
+		Tags:        []string{"Auth"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionUserRead)},
+	}, userHandler.GetLoginLockouts)
+
+	// Delete login lockout
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodDelete,
+		Path:        "/login-lockouts/{scope}/{value}",
+		Summary:     "/login-lockouts/{scope}/{value}",
+		Description: "Clear login lockout and failed attempts of a username or client ip.",
+		Tags:        []string{"Auth"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication, authMiddleware.RequirePermission(model.PermissionUserWrite)},
+	}, userHandler.DeleteLoginLockout)
+
 	// Register user
 	huma.Register(api, huma.Operation{
 		Method:      http.MethodPost,
 		Path:        "/register",
 		Summary:     "/register",
-		Description: "Register user.",
+		Description: "Register customer account, a verification token is sent to email of account.",
 		Tags:        []string{"User"},
 	}, userHandler.Register)
 
+	// Verify email
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodPost,
+		Path:        "/verify-email",
+		Summary:     "/verify-email",
+		Description: "Verify email of user account with token sent to it, the token can be used only once.",
+		Tags:        []string{"User"},
+	}, userHandler.VerifyEmail)
+
+	// Resend email verification using account
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodPost,
+		Path:        "/my-account/verify-email/resend",
+		Summary:     "/my-account/verify-email/resend",
+		Description: "Send a new email verification token to email of current account.",
+		Tags:        []string{"User"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication},
+	}, userHandler.ResendEmailVerificationUsingAccount)
+
 	// Get user using account
 	huma.Register(api, huma.Operation{
 		Method:      http.MethodGet,
@@ -139,12 +201,77 @@ func NewUserHandler(api huma.API, userService service.UserService, authMiddlewar
 		Middlewares: huma.Middlewares{authMiddleware.Authentication},
 	}, userHandler.UpdateUserUsingAccount)
 
+	// Get sessions using account
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodGet,
+		Path:        "/my-account/sessions",
+		Summary:     "/my-account/sessions",
+		Description: "Get active sessions of current account, newest first.",
+		Tags:        []string{"User"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication},
+	}, userHandler.GetSessionsUsingAccount)
+
+	// Delete session using account
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodDelete,
+		Path:        "/my-account/sessions/{id}",
+		Summary:     "/my-account/sessions/{id}",
+		Description: "Revoke one session of current account.",
+		Tags:        []string{"User"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication},
+	}, userHandler.DeleteSessionUsingAccount)
+
+	// Delete all sessions using account
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodDelete,
+		Path:        "/my-account/sessions",
+		Summary:     "/my-account/sessions",
+		Description: "Logout everywhere, revoke all sessions of current account including current one.",
+		Tags:        []string{"User"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication},
+	}, userHandler.DeleteSessionsUsingAccount)
+
+	// Change password using account
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodPost,
+		Path:        "/my-account/password",
+		Summary:     "/my-account/password",
+		Description: "Change password of current account, all other sessions of the account are revoked.",
+		Tags:        []string{"User"},
+		Middlewares: huma.Middlewares{authMiddleware.Authentication},
+	}, userHandler.ChangePasswordUsingAccount)
+
+	// Request password reset
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodPost,
+		Path:        "/password-reset/request",
+		Summary:     "/password-reset/request",
+		Description: "Send a password reset link to email of user account if the account exists.",
+		Tags:        []string{"Auth"},
+	}, userHandler.RequestPasswordReset)
+
+	// Confirm password reset
+	huma.Register(api, huma.Operation{
+		Method:      http.MethodPost,
+		Path:        "/password-reset/confirm",
+		Summary:     "/password-reset/confirm",
+		Description: "Set new password with token from password reset link, all sessions of the account are revoked.",
+		Tags:        []string{"Auth"},
+	}, userHandler.ConfirmPasswordReset)
+
 	return userHandler
 }
 
 func (userHandler *UserHandler) GetUsers(ctx context.Context, reqDTO *dto.GetUsersWithQueryParamRequest) (*dto.PaginationBodyResponseList[dto.UserView], error) {
-	users, err := userHandler.userService.GetUsers(ctx, reqDTO)
-	if err != nil {
+	users, page, err := userHandler.userService.GetUsers(ctx, reqDTO)
+	if errors.Is(err, utils.ErrInvalidPagination) {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Get users failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	} else if err != nil {
 		res := &dto.ErrorResponse{}
 		res.Status = http.StatusInternalServerError
 		res.Code = "ERR_INTERNAL_SERVER"
@@ -158,7 +285,9 @@ func (userHandler *UserHandler) GetUsers(ctx context.Context, reqDTO *dto.GetUse
 	res.Body.Code = "OK"
 	res.Body.Message = "Get users successful"
 	res.Body.Data = data
-	res.Body.Total = len(data)
+	res.Body.Total = page.Total
+	res.Body.NextCursor = page.NextCursor
+	res.Body.PrevCursor = page.PrevCursor
 	return res, nil
 }
 
@@ -267,9 +396,35 @@ func (userHandler *UserHandler) DeleteUserById(ctx context.Context, reqDTO *dto.
 	return res, nil
 }
 
-func (userHandler *UserHandler) LoginUser(ctx context.Context, reqDTO *dto.LoginUserRequest) (*dto.BodyResponse[string], error) {
+func (userHandler *UserHandler) DeleteSessionsByUserId(ctx context.Context, reqDTO *dto.DeleteSessionsByUserIdRequest) (*dto.SuccessResponse, error) {
+	if err := userHandler.userService.DeleteSessionsByUserId(ctx, reqDTO); err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Revoke sessions of user failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	res := &dto.SuccessResponse{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Revoke sessions of user successful"
+	return res, nil
+}
+
+func (userHandler *UserHandler) LoginUser(ctx context.Context, reqDTO *dto.LoginUserRequest) (*dto.BodyResponse[dto.TokenView], error) {
+	reqDTO.IpAddress, _ = ctx.Value("client_ip").(string)
+	reqDTO.UserAgent, _ = ctx.Value("user_agent").(string)
+
 	token, err := userHandler.userService.LoginUser(ctx, reqDTO)
-	if err != nil {
+	if errors.Is(err, service.ErrTooManyLoginAttempts) {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusTooManyRequests
+		res.Code = "ERR_TOO_MANY_REQUESTS"
+		res.Message = "Login user failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	} else if err != nil {
 		res := &dto.ErrorResponse{}
 		res.Status = http.StatusBadRequest
 		res.Code = "ERR_BAD_REQUEST"
@@ -278,15 +433,45 @@ func (userHandler *UserHandler) LoginUser(ctx context.Context, reqDTO *dto.Login
 		return nil, res
 	}
 
-	res := &dto.BodyResponse[string]{}
+	data := dto.ToTokenView(token)
+	res := &dto.BodyResponse[dto.TokenView]{}
 	res.Body.Code = "OK"
 	res.Body.Message = "Login user successful"
-	res.Body.Data = *token
+	res.Body.Data = *data
 	return res, nil
 }
 
-func (userHandler *UserHandler) LogoutUser(ctx context.Context, reqDTO *dto.LogoutUserRequest) (*dto.SuccessResponse, error) {
-	if err := userHandler.userService.LogoutUser(ctx, reqDTO); err != nil {
+func (userHandler *UserHandler) RefreshToken(ctx context.Context, reqDTO *dto.RefreshTokenRequest) (*dto.BodyResponse[dto.TokenView], error) {
+	token, err := userHandler.userService.RefreshToken(ctx, reqDTO)
+	if err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusUnauthorized
+		res.Code = "ERR_UNAUTHORIZED"
+		res.Message = "Refresh token failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	data := dto.ToTokenView(token)
+	res := &dto.BodyResponse[dto.TokenView]{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Refresh token successful"
+	res.Body.Data = *data
+	return res, nil
+}
+
+func (userHandler *UserHandler) LogoutUser(ctx context.Context, reqDTO *struct{}) (*dto.SuccessResponse, error) {
+	sessionId := ctx.Value("session_id").(string)
+	accessTokenId := ctx.Value("token_id").(string)
+	accessTokenExpiresAt := ctx.Value("token_expires_at").(time.Time)
+
+	convertReqDTO := &dto.LogoutUserRequest{
+		SessionId:            sessionId,
+		AccessTokenId:        accessTokenId,
+		AccessTokenExpiresAt: accessTokenExpiresAt,
+	}
+
+	if err := userHandler.userService.LogoutUser(ctx, convertReqDTO); err != nil {
 		res := &dto.ErrorResponse{}
 		res.Status = http.StatusBadRequest
 		res.Code = "ERR_BAD_REQUEST"
@@ -301,16 +486,55 @@ func (userHandler *UserHandler) LogoutUser(ctx context.Context, reqDTO *dto.Logo
 	return res, nil
 }
 
-func (userHandler *UserHandler) Register(ctx context.Context, reqDTO *dto.RegisterRequest) (*dto.SuccessResponse, error) {
-	convertReqDTO := &dto.CreateUserRequest{}
-	convertReqDTO.Body.FullName = reqDTO.Body.FullName
-	convertReqDTO.Body.Email = reqDTO.Body.Email
-	convertReqDTO.Body.Username = reqDTO.Body.Username
-	convertReqDTO.Body.Password = reqDTO.Body.Password
-	convertReqDTO.Body.Address = reqDTO.Body.Address
-	convertReqDTO.Body.RoleName = "CUSTOMER"
+func (userHandler *UserHandler) GetLoginLockouts(ctx context.Context, reqDTO *struct{}) (*dto.PaginationBodyResponseList[dto.LoginLockoutView], error) {
+	loginLockouts, err := userHandler.userService.GetLoginLockouts(ctx)
+	if err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusInternalServerError
+		res.Code = "ERR_INTERNAL_SERVER"
+		res.Message = "Get login lockouts failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	data := dto.ToListLoginLockoutView(loginLockouts)
+	res := &dto.PaginationBodyResponseList[dto.LoginLockoutView]{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Get login lockouts successful"
+	res.Body.Data = data
+	total := int64(len(data))
+	res.Body.Total = &total
+	return res, nil
+}
+
+func (userHandler *UserHandler) DeleteLoginLockout(ctx context.Context, reqDTO *dto.DeleteLoginLockoutRequest) (*dto.SuccessResponse, error) {
+	userId := ctx.Value("user_id").(int64)
+	clientIp, _ := ctx.Value("client_ip").(string)
+
+	convertReqDTO := &dto.ClearLoginLockoutRequest{
+		Scope:     reqDTO.Scope,
+		Value:     reqDTO.Value,
+		ActorId:   userId,
+		IpAddress: clientIp,
+	}
+
+	if err := userHandler.userService.ClearLoginLockout(ctx, convertReqDTO); err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Delete login lockout failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	res := &dto.SuccessResponse{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Delete login lockout successful"
+	return res, nil
+}
 
-	if err := userHandler.userService.CreateUser(ctx, convertReqDTO); err != nil {
+func (userHandler *UserHandler) Register(ctx context.Context, reqDTO *dto.RegisterRequest) (*dto.SuccessResponse, error) {
+	if err := userHandler.userService.RegisterUser(ctx, reqDTO); err != nil {
 		res := &dto.ErrorResponse{}
 		res.Status = http.StatusBadRequest
 		res.Code = "ERR_BAD_REQUEST"
@@ -325,6 +549,42 @@ func (userHandler *UserHandler) Register(ctx context.Context, reqDTO *dto.Regist
 	return res, nil
 }
 
+func (userHandler *UserHandler) VerifyEmail(ctx context.Context, reqDTO *dto.VerifyEmailRequest) (*dto.SuccessResponse, error) {
+	if err := userHandler.userService.VerifyEmail(ctx, reqDTO); err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Verify email failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	res := &dto.SuccessResponse{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Verify email successful"
+	return res, nil
+}
+
+func (userHandler *UserHandler) ResendEmailVerificationUsingAccount(ctx context.Context, reqDTO *struct{}) (*dto.SuccessResponse, error) {
+	userId := ctx.Value("user_id").(int64)
+
+	convertReqDTO := &dto.ResendEmailVerificationRequest{UserId: userId}
+
+	if err := userHandler.userService.ResendEmailVerification(ctx, convertReqDTO); err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Resend email verification failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	res := &dto.SuccessResponse{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Resend email verification successful"
+	return res, nil
+}
+
 func (userHandler *UserHandler) GetUserUsingAccount(ctx context.Context, reqDTO *struct{}) (*dto.BodyResponse[dto.UserView], error) {
 	userId := ctx.Value("user_id").(int64)
 
@@ -353,8 +613,7 @@ func (userHandler *UserHandler) UpdateUserUsingAccount(ctx context.Context, reqD
 
 	convertReqDTO := &dto.UpdateUserRequest{Id: userId}
 	convertReqDTO.Body.FullName = reqDTO.Body.FullName
-	convertReqDTO.Body.Email = reqDTO.Body.Password
-	convertReqDTO.Body.Password = reqDTO.Body.Password
+	convertReqDTO.Body.Email = reqDTO.Body.Email
 	convertReqDTO.Body.Address = reqDTO.Body.Address
 
 	if err := userHandler.userService.UpdateUserById(ctx, convertReqDTO); err != nil {
@@ -371,3 +630,130 @@ func (userHandler *UserHandler) UpdateUserUsingAccount(ctx context.Context, reqD
 	res.Body.Message = "Update account info successful"
 	return res, nil
 }
+
+func (userHandler *UserHandler) ChangePasswordUsingAccount(ctx context.Context, reqDTO *dto.ChangePasswordUsingAccountRequest) (*dto.SuccessResponse, error) {
+	userId := ctx.Value("user_id").(int64)
+	sessionId := ctx.Value("session_id").(string)
+
+	convertReqDTO := &dto.ChangePasswordRequest{
+		UserId:          userId,
+		SessionId:       sessionId,
+		CurrentPassword: reqDTO.Body.CurrentPassword,
+		NewPassword:     reqDTO.Body.NewPassword,
+	}
+
+	if err := userHandler.userService.ChangePassword(ctx, convertReqDTO); err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Change password failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	res := &dto.SuccessResponse{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Change password successful"
+	return res, nil
+}
+
+func (userHandler *UserHandler) RequestPasswordReset(ctx context.Context, reqDTO *dto.RequestPasswordResetRequest) (*dto.SuccessResponse, error) {
+	if err := userHandler.userService.RequestPasswordReset(ctx, reqDTO); err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusInternalServerError
+		res.Code = "ERR_INTERNAL_SERVER"
+		res.Message = "Request password reset failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	res := &dto.SuccessResponse{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Request password reset successful"
+	return res, nil
+}
+
+func (userHandler *UserHandler) ConfirmPasswordReset(ctx context.Context, reqDTO *dto.ConfirmPasswordResetRequest) (*dto.SuccessResponse, error) {
+	if err := userHandler.userService.ConfirmPasswordReset(ctx, reqDTO); err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Confirm password reset failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	res := &dto.SuccessResponse{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Confirm password reset successful"
+	return res, nil
+}
+
+func (userHandler *UserHandler) GetSessionsUsingAccount(ctx context.Context, reqDTO *struct{}) (*dto.PaginationBodyResponseList[dto.SessionView], error) {
+	userId := ctx.Value("user_id").(int64)
+	sessionId := ctx.Value("session_id").(string)
+
+	convertReqDTO := &dto.GetSessionsByUserIdRequest{UserId: userId}
+
+	sessions, err := userHandler.userService.GetSessionsByUserId(ctx, convertReqDTO)
+	if err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusInternalServerError
+		res.Code = "ERR_INTERNAL_SERVER"
+		res.Message = "Get sessions using account failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	data := dto.ToListSessionView(sessions, sessionId)
+	res := &dto.PaginationBodyResponseList[dto.SessionView]{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Get sessions using account successful"
+	res.Body.Data = data
+	total := int64(len(data))
+	res.Body.Total = &total
+	return res, nil
+}
+
+func (userHandler *UserHandler) DeleteSessionUsingAccount(ctx context.Context, reqDTO *dto.DeleteSessionUsingAccountRequest) (*dto.SuccessResponse, error) {
+	userId := ctx.Value("user_id").(int64)
+
+	convertReqDTO := &dto.DeleteSessionRequest{
+		UserId:    userId,
+		SessionId: reqDTO.Id,
+	}
+
+	if err := userHandler.userService.DeleteSession(ctx, convertReqDTO); err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Delete session using account failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	res := &dto.SuccessResponse{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Delete session using account successful"
+	return res, nil
+}
+
+func (userHandler *UserHandler) DeleteSessionsUsingAccount(ctx context.Context, reqDTO *struct{}) (*dto.SuccessResponse, error) {
+	userId := ctx.Value("user_id").(int64)
+
+	convertReqDTO := &dto.DeleteSessionsByUserIdRequest{Id: userId}
+
+	if err := userHandler.userService.DeleteSessionsByUserId(ctx, convertReqDTO); err != nil {
+		res := &dto.ErrorResponse{}
+		res.Status = http.StatusBadRequest
+		res.Code = "ERR_BAD_REQUEST"
+		res.Message = "Delete sessions using account failed"
+		res.Details = []string{err.Error()}
+		return nil, res
+	}
+
+	res := &dto.SuccessResponse{}
+	res.Body.Code = "OK"
+	res.Body.Message = "Delete sessions using account successful"
+	return res, nil
+}
@@ -1,23 +1,28 @@
 package middleware
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
-	"thanhldt060802/infrastructure"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/utils"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/redis/go-redis/v9"
 )
 
 type AuthMiddleware struct {
-	API huma.API
+	API         huma.API
+	redisClient *redis.Client
+	jwtKeys     *utils.JWTKeys
 }
 
-func NewAuthMiddleware(api huma.API) *AuthMiddleware {
+func NewAuthMiddleware(api huma.API, redisClient *redis.Client, jwtKeys *utils.JWTKeys) *AuthMiddleware {
 	return &AuthMiddleware{
-		API: api,
+		API:         api,
+		redisClient: redisClient,
+		jwtKeys:     jwtKeys,
 	}
 }
 
@@ -29,40 +34,73 @@ func (authMiddleware *AuthMiddleware) Authentication(ctx huma.Context, next func
 	}
 
 	token := strings.TrimPrefix(authHeader, "Bearer ")
-	redisKey := fmt.Sprintf("token:%s", token)
 
-	userDataJson, err := infrastructure.RedisClient.Get(ctx.Context(), redisKey).Result()
-	if err == redis.Nil {
-		CustomerHumaWriteErr(ctx, http.StatusUnauthorized, "ERR_UNAUTHORIZED", "Token not found or expired", []string{"invalid token"})
+	claims, err := utils.ValidateToken(authMiddleware.jwtKeys, token)
+	if err != nil {
+		CustomerHumaWriteErr(ctx, http.StatusUnauthorized, "ERR_UNAUTHORIZED", "Token invalid or expired", []string{"invalid token"})
 		return
-	} else if err != nil {
-		CustomerHumaWriteErr(ctx, http.StatusUnauthorized, "ERR_UNAUTHORIZED", "Failed to check token in Redis", []string{"some thing wrong in redis"})
+	}
+	if claims.RoleName == model.RoleService {
+		CustomerHumaWriteErr(ctx, http.StatusUnauthorized, "ERR_UNAUTHORIZED", "Service token not accepted", []string{"invalid token"})
 		return
 	}
 
-	var userData struct {
-		UserId   int64  `json:"user_id"`
-		RoleName string `json:"role_name"`
-		CartId   int64  `json:"cart_id"`
+	// Only revoked tokens are looked up, valid ones are trusted by signature
+	redisKey := fmt.Sprintf("token-denylist:%s", claims.ID)
+	denied, err := authMiddleware.redisClient.Exists(ctx.Context(), redisKey).Result()
+	if err != nil {
+		CustomerHumaWriteErr(ctx, http.StatusUnauthorized, "ERR_UNAUTHORIZED", "Failed to check token in Redis", []string{"some thing wrong in redis"})
+		return
 	}
-
-	if err := json.Unmarshal([]byte(userDataJson), &userData); err != nil {
-		CustomerHumaWriteErr(ctx, http.StatusUnauthorized, "ERR_UNAUTHORIZED", "Invalid user data in token", []string{"invalid token"})
+	if denied > 0 {
+		CustomerHumaWriteErr(ctx, http.StatusUnauthorized, "ERR_UNAUTHORIZED", "Token revoked", []string{"invalid token"})
 		return
 	}
 
-	ctx = huma.WithValue(ctx, "user_id", userData.UserId)
-	ctx = huma.WithValue(ctx, "role_name", userData.RoleName)
-	ctx = huma.WithValue(ctx, "cart_id", userData.CartId)
+	ctx = huma.WithValue(ctx, "user_id", claims.UserId)
+	ctx = huma.WithValue(ctx, "role_name", claims.RoleName)
+	ctx = huma.WithValue(ctx, "cart_id", claims.CartId)
+	ctx = huma.WithValue(ctx, "permissions", claims.Permissions)
+	ctx = huma.WithValue(ctx, "session_id", claims.SessionId)
+	ctx = huma.WithValue(ctx, "token_id", claims.ID)
+	ctx = huma.WithValue(ctx, "token_expires_at", claims.ExpiresAt.Time)
 
 	next(ctx)
 }
 
-func (authMiddleware *AuthMiddleware) RequireAdmin(ctx huma.Context, next func(huma.Context)) {
-	if roleName, _ := ctx.Context().Value("role_name").(string); roleName != "ADMIN" {
-		CustomerHumaWriteErr(ctx, http.StatusForbidden, "ERR_FORBIDDEN", "Access denied", []string{"no permission"})
-		return
+// Identifies caller by a valid bearer token without rejecting the request, revoked tokens are not checked here
+func (authMiddleware *AuthMiddleware) tokenUserId(ctx huma.Context) (int64, bool) {
+	authHeader := ctx.Header("Authorization")
+	if authHeader == "" {
+		return 0, false
 	}
 
-	next(ctx)
+	claims, err := utils.ValidateToken(authMiddleware.jwtKeys, strings.TrimPrefix(authHeader, "Bearer "))
+	if err != nil {
+		return 0, false
+	}
+
+	return claims.UserId, true
+}
+
+// Permissions come from token claims, so role changes apply from next login or token refresh
+func (authMiddleware *AuthMiddleware) RequirePermission(permission string) func(huma.Context, func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		if !HasPermission(ctx.Context(), permission) {
+			CustomerHumaWriteErr(ctx, http.StatusForbidden, "ERR_FORBIDDEN", "Access denied", []string{fmt.Sprintf("missing permission %s", permission)})
+			return
+		}
+
+		next(ctx)
+	}
+}
+
+func HasPermission(ctx context.Context, permission string) bool {
+	permissions, _ := ctx.Value("permissions").([]string)
+	for _, grantedPermission := range permissions {
+		if grantedPermission == permission {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// Puts client ip and user agent into context of every operation
+func ClientInfo(ctx huma.Context, next func(huma.Context)) {
+	ctx = huma.WithValue(ctx, "client_ip", clientIp(ctx))
+	ctx = huma.WithValue(ctx, "user_agent", ctx.Header("User-Agent"))
+
+	next(ctx)
+}
+
+// Ip is taken from the connection so forwarded headers set by clients can not spoof it
+func clientIp(ctx huma.Context) string {
+	clientIp := ctx.RemoteAddr()
+	if host, _, err := net.SplitHostPort(clientIp); err == nil {
+		return host
+	}
+
+	return clientIp
+}
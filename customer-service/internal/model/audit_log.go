@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	AuditActionLoginLockout        = "LOGIN_LOCKOUT"
+	AuditActionLoginLockoutCleared = "LOGIN_LOCKOUT_CLEARED"
+)
+
+type AuditLog struct {
+	bun.BaseModel `bun:"table:audit_logs"`
+
+	Id        int64     `bun:"id,pk,autoincrement"`
+	Action    string    `bun:"action,notnull"`
+	ActorId   int64     `bun:"actor_id,nullzero"`
+	Target    string    `bun:"target,notnull"`
+	IpAddress string    `bun:"ip_address,nullzero"`
+	Details   string    `bun:"details,nullzero"`
+	CreatedAt time.Time `bun:"created_at,notnull,default:current_timestamp"`
+}
@@ -6,6 +6,37 @@ import (
 	"github.com/uptrace/bun"
 )
 
+const (
+	InvoiceStatusPending  = "PENDING"
+	InvoiceStatusPaid     = "PAID"
+	InvoiceStatusShipped  = "SHIPPED"
+	InvoiceStatusDone     = "DONE"
+	InvoiceStatusCancel   = "CANCEL"
+	InvoiceStatusRefunded = "REFUNDED"
+)
+
+// Allowed next statuses of each status, CANCEL and REFUNDED are final
+var InvoiceStatusTransitions = map[string][]string{
+	InvoiceStatusPending:  {InvoiceStatusPaid, InvoiceStatusCancel},
+	InvoiceStatusPaid:     {InvoiceStatusShipped, InvoiceStatusRefunded},
+	InvoiceStatusShipped:  {InvoiceStatusDone, InvoiceStatusRefunded},
+	InvoiceStatusDone:     {InvoiceStatusRefunded},
+	InvoiceStatusCancel:   {},
+	InvoiceStatusRefunded: {},
+}
+
+func CanChangeInvoiceStatus(from string, to string) bool {
+	for _, status := range InvoiceStatusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Statuses whose stock goes back to catalog service
+var InvoiceStockReturningStatuses = []string{InvoiceStatusCancel, InvoiceStatusRefunded}
+
 type Invoice struct {
 	bun.BaseModel `bun:"table:invoices"`
 
@@ -15,6 +46,9 @@ type Invoice struct {
 	Status      string    `bun:"status,notnull" json:"status"`
 	CreatedAt   time.Time `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
 	UpdatedAt   time.Time `bun:"updated_at,notnull,default:current_timestamp" json:"updated_at"`
+
+	// Stock held in catalog service for this invoice, kept out of Elasticsearch
+	StockReservationId int64 `bun:"stock_reservation_id,nullzero" json:"-"`
 }
 
 // Integrate with Elasticsearch
@@ -48,9 +82,53 @@ var MapSortFieldInvoiceSchemaElasticsearch = map[string]string{
 	"updated_at":   "updated_at",
 }
 
-type InvoiceReport struct {
-	Sum       float64    `json:"sum"`
-	Avg       float64    `json:"avg"`
-	StartTime *time.Time `json:"start_time"`
-	EndTime   *time.Time `json:"end_time"`
+// Statuses counted as revenue when report does not filter by status
+var InvoiceRevenueStatuses = []string{InvoiceStatusPaid, InvoiceStatusShipped, InvoiceStatusDone}
+
+// Business days start at local midnight, so reports bucket by this timezone
+const InvoiceReportTimeZone = "Asia/Ho_Chi_Minh"
+
+var InvoiceReportPercents = []float64{50, 90, 95, 99}
+
+type InvoiceElasticsearchFilter struct {
+	CreatedAtGTE string
+	CreatedAtLTE string
+	Statuses     []string
+}
+
+type InvoiceElasticsearchReport struct {
+	Filter      *InvoiceElasticsearchFilter
+	Interval    string
+	Count       int64
+	Sum         float64
+	Avg         *float64
+	Min         *float64
+	Max         *float64
+	Percentiles []InvoiceReportPercentile
+	Series      []InvoiceReportTimeBucket
+	Statuses    []InvoiceReportStatusBucket
+	Users       []InvoiceReportUserBucket
+}
+
+type InvoiceReportPercentile struct {
+	Percent float64
+	Value   *float64
+}
+
+type InvoiceReportTimeBucket struct {
+	Date  string
+	Count int64
+	Sum   float64
+}
+
+type InvoiceReportStatusBucket struct {
+	Status string
+	Count  int64
+	Sum    float64
+}
+
+type InvoiceReportUserBucket struct {
+	UserId int64
+	Count  int64
+	Sum    float64
 }
@@ -14,5 +14,5 @@ type InvoiceDetail struct {
 	Price              decimal.Decimal `bun:"price,notnull"`
 	DiscountPercentage int32           `bun:"discount_percentage,notnull"`
 	Quantity           int32           `bun:"quantity,notnull"`
-	TotalPrice         decimal.Decimal `bun:"total_pice,notnull"`
+	TotalPrice         decimal.Decimal `bun:"total_price,notnull"`
 }
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type InvoiceStatusHistory struct {
+	bun.BaseModel `bun:"table:invoice_status_history"`
+
+	Id         int64     `bun:"id,pk,autoincrement"`
+	InvoiceId  int64     `bun:"invoice_id,notnull"`
+	FromStatus string    `bun:"from_status,nullzero"`
+	ToStatus   string    `bun:"to_status,notnull"`
+	ActorId    int64     `bun:"actor_id,notnull"`
+	ActorRole  string    `bun:"actor_role,notnull"`
+	CreatedAt  time.Time `bun:"created_at,notnull,default:current_timestamp"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+const (
+	LoginAttemptScopeUsername = "username"
+	LoginAttemptScopeIp       = "ip"
+)
+
+// Failed logins counted per username or per client ip, kept in Redis for a sliding window
+type LoginAttempt struct {
+	Scope        string
+	Value        string
+	FailedCount  int
+	LastFailedAt time.Time
+}
+
+type LoginLockout struct {
+	Scope       string
+	Value       string
+	FailedCount int
+	LockedAt    time.Time
+	ExpiresAt   time.Time
+}
@@ -0,0 +1,13 @@
+package model
+
+const (
+	OneTimeTokenPurposeEmailVerification = "email-verification"
+	OneTimeTokenPurposePasswordReset     = "password-reset"
+)
+
+// A one-time token is sent to the user out of band and kept in Redis until used or expired
+type OneTimeToken struct {
+	Purpose string `json:"-"`
+	UserId  int64  `json:"user_id"`
+	Email   string `json:"email"`
+}
@@ -0,0 +1,20 @@
+package model
+
+const (
+	// Redis stream catalog service publishes product changes to, customer service reacts to them instead of holding
+	// foreign keys into products
+	ProductEventStream = "catalog:product-events"
+	// Events customer service gave up on after too many deliveries, kept for someone to look at
+	ProductEventDeadLetterStream = "customer:product-events:dead"
+
+	ProductEventTypeDeleted = "PRODUCT_DELETED"
+)
+
+type ProductEvent struct {
+	Id        string
+	Type      string
+	ProductId int64
+
+	// Times the event was handed to a consumer, including this one
+	Deliveries int64
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	RoleAdmin    = "ADMIN"
+	RoleCustomer = "CUSTOMER"
+
+	// Carried only by tokens customer service signs for its own calls to other services, never stored as a role
+	RoleService = "SERVICE"
+)
+
+const (
+	PermissionProductWrite  = "product:write"
+	PermissionCategoryWrite = "category:write"
+	PermissionUserRead      = "user:read"
+	PermissionUserWrite     = "user:write"
+	PermissionCartRead      = "cart:read"
+	PermissionInvoiceRead   = "invoice:read"
+	PermissionInvoiceWrite  = "invoice:write"
+	PermissionInvoiceRefund = "invoice:refund"
+	PermissionRoleRead      = "role:read"
+	PermissionRoleWrite     = "role:write"
+	PermissionAuditRead     = "audit:read"
+
+	// Granted only to service tokens, so no role can reserve stock of catalog service directly
+	PermissionStockReserve = "stock:reserve"
+)
+
+type Role struct {
+	bun.BaseModel `bun:"table:roles"`
+
+	Name        string    `bun:"name,pk"`
+	Description string    `bun:"description,notnull"`
+	CreatedAt   time.Time `bun:"created_at,notnull,default:current_timestamp"`
+
+	Permissions []string `bun:"-"`
+}
+
+type Permission struct {
+	bun.BaseModel `bun:"table:permissions"`
+
+	Name        string `bun:"name,pk"`
+	Description string `bun:"description,notnull"`
+}
+
+type RolePermission struct {
+	bun.BaseModel `bun:"table:role_permissions"`
+
+	RoleName       string `bun:"role_name,pk"`
+	PermissionName string `bun:"permission_name,pk"`
+}
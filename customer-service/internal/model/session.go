@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// A session is one login, kept in Redis until refresh token expires or is revoked
+type Session struct {
+	Id                   string
+	UserId               int64
+	RefreshTokenHash     string
+	AccessTokenId        string
+	AccessTokenExpiresAt time.Time
+	Device               string
+	IpAddress            string
+	UserAgent            string
+	CreatedAt            time.Time
+}
+
+type Token struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+}
@@ -0,0 +1,35 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+type fileNotifier struct {
+	filePath string
+	mutex    sync.Mutex
+}
+
+// Appends messages to a file so local development can read links without a mail server
+func NewFileNotifier(filePath string) Notifier {
+	return &fileNotifier{
+		filePath: filePath,
+	}
+}
+
+func (fileNotifier *fileNotifier) Send(ctx context.Context, message *Message) error {
+	fileNotifier.mutex.Lock()
+	defer fileNotifier.mutex.Unlock()
+
+	file, err := os.OpenFile(fileNotifier.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().UTC().Format(time.RFC3339), message.To, message.Subject, message.Body)
+	return err
+}
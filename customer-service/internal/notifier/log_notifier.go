@@ -0,0 +1,19 @@
+package notifier
+
+import (
+	"context"
+	"log"
+)
+
+type logNotifier struct {
+}
+
+// Writes messages to application log, meant for local development only
+func NewLogNotifier() Notifier {
+	return &logNotifier{}
+}
+
+func (logNotifier *logNotifier) Send(ctx context.Context, message *Message) error {
+	log.Printf("Notify %s: %s\n%s", message.To, message.Subject, message.Body)
+	return nil
+}
@@ -0,0 +1,14 @@
+package notifier
+
+import "context"
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Delivers messages to users out of band, real providers (SMTP, SES, ...) plug in behind this interface
+type Notifier interface {
+	Send(ctx context.Context, message *Message) error
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/utils"
+
+	"github.com/uptrace/bun"
+)
+
+type auditLogRepository struct {
+	db *bun.DB
+}
+
+type AuditLogRepository interface {
+	Get(ctx context.Context, action string, pageRequest *utils.PageRequest) ([]model.AuditLog, *utils.Page, error)
+	Create(ctx context.Context, newAuditLog *model.AuditLog) error
+}
+
+func NewAuditLogRepository(db *bun.DB) AuditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+func (auditLogRepository *auditLogRepository) Get(ctx context.Context, action string, pageRequest *utils.PageRequest) ([]model.AuditLog, *utils.Page, error) {
+	return paginate[model.AuditLog](ctx, auditLogRepository.db, pageRequest, func(query *bun.SelectQuery) *bun.SelectQuery {
+		if action != "" {
+			query = query.Where("action = ?", action)
+		}
+		return query
+	})
+}
+
+func (auditLogRepository *auditLogRepository) Create(ctx context.Context, newAuditLog *model.AuditLog) error {
+	_, err := getDB(ctx, auditLogRepository.db).NewInsert().Model(newAuditLog).Returning("*").Exec(ctx)
+	return err
+}
@@ -2,61 +2,54 @@ package repository
 
 import (
 	"context"
-	"fmt"
-	"thanhldt060802/infrastructure"
 	"thanhldt060802/internal/model"
 	"thanhldt060802/utils"
+
+	"github.com/uptrace/bun"
 )
 
 type cartItemRepository struct {
+	db *bun.DB
 }
 
 type CartItemRepository interface {
-	Get(ctx context.Context, offset int, limit int, sortFields []utils.SortField) ([]model.CartItem, error)
+	Get(ctx context.Context, pageRequest *utils.PageRequest) ([]model.CartItem, *utils.Page, error)
 	GetById(ctx context.Context, id int64) (*model.CartItem, error)
-	GetByCartId(ctx context.Context, cartId int64, offset int, limit int, sortFields []utils.SortField) ([]model.CartItem, error)
+	GetByCartId(ctx context.Context, cartId int64, pageRequest *utils.PageRequest) ([]model.CartItem, *utils.Page, error)
+	GetAllByCartId(ctx context.Context, cartId int64) ([]model.CartItem, error)
 	Create(ctx context.Context, newCartItem *model.CartItem) error
 	UpdateById(ctx context.Context, id int64, updatedCartItem *model.CartItem) error
 	DeleteById(ctx context.Context, id int64) error
+	DeleteByCartId(ctx context.Context, cartId int64) error
+	DeleteByProductId(ctx context.Context, productId int64) error
 }
 
-func NewCartItemRepository() CartItemRepository {
-	return &cartItemRepository{}
+func NewCartItemRepository(db *bun.DB) CartItemRepository {
+	return &cartItemRepository{db: db}
 }
 
-func (cartItemRepository *cartItemRepository) Get(ctx context.Context, offset int, limit int, sortFields []utils.SortField) ([]model.CartItem, error) {
-	var cartItems []model.CartItem
-	query := infrastructure.DB.NewSelect().Model(&cartItems).
-		Offset(offset).
-		Limit(limit)
-	for _, sortField := range sortFields {
-		query = query.Order(fmt.Sprintf("%s %s", sortField.Field, sortField.Direction))
-	}
-	err := query.Scan(ctx)
-	if err != nil {
-		return nil, err
-	}
-	return cartItems, nil
+func (cartItemRepository *cartItemRepository) Get(ctx context.Context, pageRequest *utils.PageRequest) ([]model.CartItem, *utils.Page, error) {
+	return paginate[model.CartItem](ctx, cartItemRepository.db, pageRequest, nil)
 }
 
 func (cartItemRepository *cartItemRepository) GetById(ctx context.Context, id int64) (*model.CartItem, error) {
 	var cartItem model.CartItem
-	err := infrastructure.DB.NewSelect().Model(&cartItem).Where("id = ?", id).Scan(ctx)
+	err := getDB(ctx, cartItemRepository.db).NewSelect().Model(&cartItem).Where("id = ?", id).Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return &cartItem, nil
 }
 
-func (cartItemRepository *cartItemRepository) GetByCartId(ctx context.Context, cartId int64, offset int, limit int, sortFields []utils.SortField) ([]model.CartItem, error) {
+func (cartItemRepository *cartItemRepository) GetByCartId(ctx context.Context, cartId int64, pageRequest *utils.PageRequest) ([]model.CartItem, *utils.Page, error) {
+	return paginate[model.CartItem](ctx, cartItemRepository.db, pageRequest, func(query *bun.SelectQuery) *bun.SelectQuery {
+		return query.Where("cart_id = ?", cartId)
+	})
+}
+
+func (cartItemRepository *cartItemRepository) GetAllByCartId(ctx context.Context, cartId int64) ([]model.CartItem, error) {
 	var cartItems []model.CartItem
-	query := infrastructure.DB.NewSelect().Model(&cartItems).Where("cart_id = ?", cartId).
-		Offset(offset).
-		Limit(limit)
-	for _, sortField := range sortFields {
-		query = query.Order(fmt.Sprintf("%s %s", sortField.Field, sortField.Direction))
-	}
-	err := query.Scan(ctx)
+	err := getDB(ctx, cartItemRepository.db).NewSelect().Model(&cartItems).Where("cart_id = ?", cartId).Order("id ASC").Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -64,16 +57,26 @@ func (cartItemRepository *cartItemRepository) GetByCartId(ctx context.Context, c
 }
 
 func (cartItemRepository *cartItemRepository) Create(ctx context.Context, newCartItem *model.CartItem) error {
-	_, err := infrastructure.DB.NewInsert().Model(newCartItem).Exec(ctx)
+	_, err := getDB(ctx, cartItemRepository.db).NewInsert().Model(newCartItem).Exec(ctx)
 	return err
 }
 
 func (cartItemRepository *cartItemRepository) UpdateById(ctx context.Context, id int64, updatedCartItem *model.CartItem) error {
-	_, err := infrastructure.DB.NewUpdate().Model(updatedCartItem).Where("id = ?", id).Exec(ctx)
+	_, err := getDB(ctx, cartItemRepository.db).NewUpdate().Model(updatedCartItem).Where("id = ?", id).Exec(ctx)
 	return err
 }
 
 func (cartItemRepository *cartItemRepository) DeleteById(ctx context.Context, id int64) error {
-	_, err := infrastructure.DB.NewDelete().Model(&model.CartItem{}).Where("id = ?", id).Exec(ctx)
+	_, err := getDB(ctx, cartItemRepository.db).NewDelete().Model(&model.CartItem{}).Where("id = ?", id).Exec(ctx)
+	return err
+}
+
+func (cartItemRepository *cartItemRepository) DeleteByCartId(ctx context.Context, cartId int64) error {
+	_, err := getDB(ctx, cartItemRepository.db).NewDelete().Model(&model.CartItem{}).Where("cart_id = ?", cartId).Exec(ctx)
+	return err
+}
+
+func (cartItemRepository *cartItemRepository) DeleteByProductId(ctx context.Context, productId int64) error {
+	_, err := getDB(ctx, cartItemRepository.db).NewDelete().Model(&model.CartItem{}).Where("product_id = ?", productId).Exec(ctx)
 	return err
 }
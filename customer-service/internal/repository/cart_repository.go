@@ -2,55 +2,55 @@ package repository
 
 import (
 	"context"
-	"fmt"
-	"thanhldt060802/infrastructure"
 	"thanhldt060802/internal/model"
 	"thanhldt060802/utils"
+
+	"github.com/uptrace/bun"
 )
 
 type cartRepository struct {
+	db *bun.DB
 }
 
 type CartRepository interface {
-	Get(ctx context.Context, offset int, limit int, sortFields []utils.SortField) ([]model.Cart, error)
+	Get(ctx context.Context, pageRequest *utils.PageRequest) ([]model.Cart, *utils.Page, error)
 	GetById(ctx context.Context, id int64) (*model.Cart, error)
 	GetByUserId(ctx context.Context, userId int64) (*model.Cart, error)
+	GetByIdForUpdate(ctx context.Context, id int64) (*model.Cart, error)
 	Create(ctx context.Context, newCart *model.Cart) error
 	UpdateById(ctx context.Context, id int64, updatedCart *model.Cart) error
 	DeleteById(ctx context.Context, id int64) error
 }
 
-func NewCartRepository() CartRepository {
-	return &cartRepository{}
+func NewCartRepository(db *bun.DB) CartRepository {
+	return &cartRepository{db: db}
 }
 
-func (cartRepository *cartRepository) Get(ctx context.Context, offset int, limit int, sortFields []utils.SortField) ([]model.Cart, error) {
-	var carts []model.Cart
-	query := infrastructure.DB.NewSelect().Model(&carts).
-		Offset(offset).
-		Limit(limit)
-	for _, sortField := range sortFields {
-		query = query.Order(fmt.Sprintf("%s %s", sortField.Field, sortField.Direction))
-	}
-	err := query.Scan(ctx)
+func (cartRepository *cartRepository) Get(ctx context.Context, pageRequest *utils.PageRequest) ([]model.Cart, *utils.Page, error) {
+	return paginate[model.Cart](ctx, cartRepository.db, pageRequest, nil)
+}
+
+func (cartRepository *cartRepository) GetById(ctx context.Context, id int64) (*model.Cart, error) {
+	var cart model.Cart
+	err := getDB(ctx, cartRepository.db).NewSelect().Model(&cart).Where("id = ?", id).Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return carts, nil
+	return &cart, nil
 }
 
-func (cartRepository *cartRepository) GetById(ctx context.Context, id int64) (*model.Cart, error) {
+func (cartRepository *cartRepository) GetByUserId(ctx context.Context, userId int64) (*model.Cart, error) {
 	var cart model.Cart
-	err := infrastructure.DB.NewSelect().Model(&cart).Where("id = ?", id).Scan(ctx)
+	err := getDB(ctx, cartRepository.db).NewSelect().Model(&cart).Where("user_id = ?", userId).Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return &cart, nil
 }
 
-func (cartRepository *cartRepository) GetByUserId(ctx context.Context, userId int64) (*model.Cart, error) {
+func (cartRepository *cartRepository) GetByIdForUpdate(ctx context.Context, id int64) (*model.Cart, error) {
 	var cart model.Cart
-	err := infrastructure.DB.NewSelect().Model(&cart).Where("user_id = ?", userId).Scan(ctx)
+	err := getDB(ctx, cartRepository.db).NewSelect().Model(&cart).Where("id = ?", id).For("UPDATE").Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -58,16 +58,16 @@ func (cartRepository *cartRepository) GetByUserId(ctx context.Context, userId in
 }
 
 func (cartRepository *cartRepository) Create(ctx context.Context, newCart *model.Cart) error {
-	_, err := infrastructure.DB.NewInsert().Model(newCart).Exec(ctx)
+	_, err := getDB(ctx, cartRepository.db).NewInsert().Model(newCart).Exec(ctx)
 	return err
 }
 
 func (cartRepository *cartRepository) UpdateById(ctx context.Context, id int64, updatedCart *model.Cart) error {
-	_, err := infrastructure.DB.NewUpdate().Model(updatedCart).Where("id = ?", id).Exec(ctx)
+	_, err := getDB(ctx, cartRepository.db).NewUpdate().Model(updatedCart).Where("id = ?", id).Exec(ctx)
 	return err
 }
 
 func (cartRepository *cartRepository) DeleteById(ctx context.Context, id int64) error {
-	_, err := infrastructure.DB.NewDelete().Model(&model.Cart{}).Where("id = ?", id).Exec(ctx)
+	_, err := getDB(ctx, cartRepository.db).NewDelete().Model(&model.Cart{}).Where("id = ?", id).Exec(ctx)
 	return err
 }
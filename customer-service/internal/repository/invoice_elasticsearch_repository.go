@@ -6,31 +6,42 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
 	"strconv"
-	"thanhldt060802/infrastructure"
+	"strings"
 	"thanhldt060802/internal/model"
 	"thanhldt060802/utils"
-	"time"
 
+	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/esutil"
 )
 
 type invoiceElasticsearchRepository struct {
+	elasticsearchClient *elasticsearch.Client
 }
 
 type InvoiceElasticsearchRepository interface {
-	SyncAll(ctx context.Context, invoices []model.Invoice) error
-
-	Get(ctx context.Context, offset int, limit int, sortFields []utils.SortField, createdAtGTE string, createdAtLTE string) ([]model.Invoice, error)
-	Sum(ctx context.Context, createdAtGTE string, createdAtLTE string) (*float64, error)
-	SumAvg(ctx context.Context, createdAtGTE string, createdAtLTE string) (*model.InvoiceReport, error)
+	CreateNextVersionIndex(ctx context.Context) (string, error)
+	GetVersionIndices(ctx context.Context) ([]string, error)
+	BulkIndex(ctx context.Context, index string, invoices []model.Invoice) error
+	Count(ctx context.Context, index string) (int64, error)
+	SwitchAlias(ctx context.Context, index string) ([]string, error)
+	DeleteIndices(ctx context.Context, indices []string) error
+
+	SyncCreating(ctx context.Context, newInvoice *model.Invoice) error
+	SyncUpdating(ctx context.Context, updatedInvoice *model.Invoice) error
+	SyncDeletingById(ctx context.Context, id int64) error
+
+	Get(ctx context.Context, pageRequest *utils.PageRequest, createdAtGTE string, createdAtLTE string) ([]model.Invoice, *utils.Page, error)
+	Sum(ctx context.Context, filter *model.InvoiceElasticsearchFilter) (*float64, error)
+	Report(ctx context.Context, filter *model.InvoiceElasticsearchFilter, interval string, userLimit int) (*model.InvoiceElasticsearchReport, error)
 }
 
-func NewInvoiceElasticsearchRepository() InvoiceElasticsearchRepository {
-	return &invoiceElasticsearchRepository{}
+func NewInvoiceElasticsearchRepository(elasticsearchClient *elasticsearch.Client) InvoiceElasticsearchRepository {
+	return &invoiceElasticsearchRepository{elasticsearchClient: elasticsearchClient}
 }
 
-func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) Get(ctx context.Context, offset int, limit int, sortFields []utils.SortField, createdAtGTE string, createdAtLTE string) ([]model.Invoice, error) {
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) Get(ctx context.Context, pageRequest *utils.PageRequest, createdAtGTE string, createdAtLTE string) ([]model.Invoice, *utils.Page, error) {
 	mustConditions := []map[string]interface{}{}
 
 	// If filtering by created_at in range or partial range
@@ -57,26 +68,25 @@ func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) Get(ctx co
 		})
 	}
 
-	// Setup query
+	// Apply sorting to query, pages continue after sort values of the cursor hit
+	sortFields, err := elasticsearchSort(pageRequest, model.MapSortFieldInvoiceSchemaElasticsearch, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Setup query, one hit over the limit tells whether another page exists
 	query := map[string]interface{}{
-		"from": offset,
-		"size": limit,
+		"size":             pageRequest.Limit + 1,
+		"track_total_hits": pageRequest.IncludeTotal,
+		"sort":             sortFields,
 		"query": map[string]interface{}{
 			"bool": map[string]interface{}{
 				"must": mustConditions,
 			},
 		},
 	}
-
-	// Apply sorting to query
-	if len(sortFields) > 0 {
-		_sortFields := []map[string]interface{}{}
-		for _, sortField := range sortFields {
-			_sortFields = append(_sortFields, map[string]interface{}{
-				model.MapSortFieldInvoiceSchemaElasticsearch[sortField.Field]: sortField.Direction,
-			})
-		}
-		query["sort"] = _sortFields
+	if pageRequest.Cursor != nil {
+		query["search_after"] = pageRequest.Cursor.Values
 	}
 
 	fmt.Println(query)
@@ -84,145 +94,342 @@ func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) Get(ctx co
 	// Convert query to JSON
 	queryJSON, err := json.Marshal(query)
 	if err != nil {
-		return nil, fmt.Errorf("marshal query failed")
+		return nil, nil, fmt.Errorf("marshal query failed")
 	}
 
 	// Send request to Elasticsearch
-	res, err := infrastructure.ElasticsearchClient.Search(
-		infrastructure.ElasticsearchClient.Search.WithContext(ctx),
-		infrastructure.ElasticsearchClient.Search.WithIndex("invoices"),
-		infrastructure.ElasticsearchClient.Search.WithBody(bytes.NewReader(queryJSON)),
+	res, err := invoiceElasticsearchRepository.elasticsearchClient.Search(
+		invoiceElasticsearchRepository.elasticsearchClient.Search.WithContext(ctx),
+		invoiceElasticsearchRepository.elasticsearchClient.Search.WithIndex("invoices"),
+		invoiceElasticsearchRepository.elasticsearchClient.Search.WithBody(bytes.NewReader(queryJSON)),
 	)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer res.Body.Close()
 
 	// Parse response
 	if res.IsError() {
-		return nil, fmt.Errorf("get invoices from elasticsearch failed: %s", res.String())
+		return nil, nil, fmt.Errorf("get invoices from elasticsearch failed: %s", res.String())
 	}
 	var elasticsearchResponse struct {
 		Hits struct {
-			Hits []struct {
-				Source model.Invoice `json:"_source"`
-			} `json:"hits"`
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []elasticsearchHit[model.Invoice] `json:"hits"`
 		} `json:"hits"`
 	}
 	if err := json.NewDecoder(res.Body).Decode(&elasticsearchResponse); err != nil {
-		return nil, fmt.Errorf("unmarshal elasticsearch response failed: %s", err.Error())
+		return nil, nil, fmt.Errorf("unmarshal elasticsearch response failed: %s", err.Error())
 	}
 
 	// Extract invoices
-	invoices := make([]model.Invoice, len(elasticsearchResponse.Hits.Hits))
-	for i, hit := range elasticsearchResponse.Hits.Hits {
+	hits, page := elasticsearchPage(pageRequest, elasticsearchResponse.Hits.Hits)
+	invoices := make([]model.Invoice, len(hits))
+	for i, hit := range hits {
 		invoices[i] = hit.Source
 	}
+	if pageRequest.IncludeTotal {
+		page.Total = &elasticsearchResponse.Hits.Total.Value
+	}
+
+	return invoices, page, nil
+}
+
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) CreateNextVersionIndex(ctx context.Context) (string, error) {
+	versionIndices, err := invoiceElasticsearchRepository.GetVersionIndices(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// Next version is one above the highest existing version
+	nextVersion := 1
+	for _, versionIndex := range versionIndices {
+		version, err := strconv.Atoi(strings.TrimPrefix(versionIndex, "invoices_v"))
+		if err == nil && version >= nextVersion {
+			nextVersion = version + 1
+		}
+	}
+	newIndex := fmt.Sprintf("invoices_v%d", nextVersion)
+
+	// Create index using custom invoice schema
+	res, err := invoiceElasticsearchRepository.elasticsearchClient.Indices.Create(newIndex,
+		invoiceElasticsearchRepository.elasticsearchClient.Indices.Create.WithBody(bytes.NewReader([]byte(model.InvoiceSchemaElasticsearch))),
+		invoiceElasticsearchRepository.elasticsearchClient.Indices.Create.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("create %s index on elasticsearch failed: %s", newIndex, err.Error())
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return "", fmt.Errorf("create %s index on elasticsearch failed: %s", newIndex, res.String())
+	}
+
+	return newIndex, nil
+}
+
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) GetVersionIndices(ctx context.Context) ([]string, error) {
+	res, err := invoiceElasticsearchRepository.elasticsearchClient.Indices.Get([]string{"invoices_v*"},
+		invoiceElasticsearchRepository.elasticsearchClient.Indices.Get.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("get invoices indices failed: %s", err.Error())
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("get invoices indices failed: %s", res.String())
+	}
+
+	var indices map[string]any
+	if err := json.NewDecoder(res.Body).Decode(&indices); err != nil {
+		return nil, fmt.Errorf("parse invoices indices failed: %s", err.Error())
+	}
+
+	versionIndices := make([]string, 0, len(indices))
+	for index := range indices {
+		versionIndices = append(versionIndices, index)
+	}
+	sort.Strings(versionIndices)
 
-	return invoices, nil
+	return versionIndices, nil
 }
 
-func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) SyncAll(ctx context.Context, invoices []model.Invoice) error {
-	// Check if index already exists
-	existsRes, err := infrastructure.ElasticsearchClient.Indices.Exists([]string{"invoices"})
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) BulkIndex(ctx context.Context, index string, invoices []model.Invoice) error {
+	// Create BulkIndexer on Elasticsearch
+	indexer, err := esutil.NewBulkIndexer(esutil.BulkIndexerConfig{
+		Client: invoiceElasticsearchRepository.elasticsearchClient,
+		Index:  index,
+	})
 	if err != nil {
-		return fmt.Errorf("check index existence failed: %s", err.Error())
+		return err
 	}
-	defer existsRes.Body.Close()
 
-	// If index does not exists
-	if existsRes.StatusCode == 404 {
-		// Create index using custom invoice schema
-		createRes, err := infrastructure.ElasticsearchClient.Indices.Create("invoice",
-			infrastructure.ElasticsearchClient.Indices.Create.WithBody(bytes.NewReader([]byte(model.InvoiceSchemaElasticsearch))))
+	// Add all invoice to BulkIndexer on Elasticsearch
+	for _, invoice := range invoices {
+		data, err := json.Marshal(invoice)
 		if err != nil {
+			indexer.Close(ctx)
+			return fmt.Errorf("marshal invoice with id = %d failed: %s", invoice.Id, err.Error())
+		}
+
+		err = indexer.Add(ctx, esutil.BulkIndexerItem{
+			Action:     "index",
+			DocumentID: strconv.FormatInt(invoice.Id, 10),
+			Body:       bytes.NewReader(data),
+			OnFailure: func(ctx context.Context, item esutil.BulkIndexerItem, resp esutil.BulkIndexerResponseItem, err error) {
+				if err != nil {
+					log.Printf("Bulk index failed: %s", err.Error())
+				} else {
+					log.Printf("Index invoice with id = %s failed: %s", item.DocumentID, resp.Error.Reason)
+				}
+			},
+		})
+		if err != nil {
+			indexer.Close(ctx)
 			return err
 		}
-		defer createRes.Body.Close()
+	}
+
+	if err := indexer.Close(ctx); err != nil {
+		return fmt.Errorf("close bulk indexer failed: %s", err.Error())
+	}
+	if stats := indexer.Stats(); stats.NumFailed > 0 {
+		return fmt.Errorf("bulk index %d invoices into %s failed", stats.NumFailed, index)
+	}
+
+	return nil
+}
+
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) Count(ctx context.Context, index string) (int64, error) {
+	// Make every indexed document visible to count
+	refreshRes, err := invoiceElasticsearchRepository.elasticsearchClient.Indices.Refresh(
+		invoiceElasticsearchRepository.elasticsearchClient.Indices.Refresh.WithIndex(index),
+		invoiceElasticsearchRepository.elasticsearchClient.Indices.Refresh.WithContext(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("refresh %s index failed: %s", index, err.Error())
+	}
+	refreshRes.Body.Close()
+
+	res, err := invoiceElasticsearchRepository.elasticsearchClient.Count(
+		invoiceElasticsearchRepository.elasticsearchClient.Count.WithIndex(index),
+		invoiceElasticsearchRepository.elasticsearchClient.Count.WithContext(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("count documents of %s index failed: %s", index, err.Error())
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return 0, fmt.Errorf("count documents of %s index failed: %s", index, res.String())
+	}
+
+	var countRes struct {
+		Count int64 `json:"count"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&countRes); err != nil {
+		return 0, fmt.Errorf("parse count response failed: %s", err.Error())
+	}
+
+	return countRes.Count, nil
+}
+
+// Points invoices alias to index in one atomic request and returns indices the alias pointed to before
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) SwitchAlias(ctx context.Context, index string) ([]string, error) {
+	aliasRes, err := invoiceElasticsearchRepository.elasticsearchClient.Indices.GetAlias(
+		invoiceElasticsearchRepository.elasticsearchClient.Indices.GetAlias.WithName("invoices"),
+		invoiceElasticsearchRepository.elasticsearchClient.Indices.GetAlias.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("get invoices alias failed: %s", err.Error())
+	}
+	defer aliasRes.Body.Close()
+
+	oldIndices := []string{}
+	if aliasRes.StatusCode != 404 {
+		if aliasRes.IsError() {
+			return nil, fmt.Errorf("get invoices alias failed: %s", aliasRes.String())
+		}
 
-		if createRes.IsError() {
-			return fmt.Errorf("create invoices index on elasticsearch faield: %s", createRes.String())
+		var aliases map[string]any
+		if err := json.NewDecoder(aliasRes.Body).Decode(&aliases); err != nil {
+			return nil, fmt.Errorf("parse invoices alias failed: %s", err.Error())
 		}
+		for oldIndex := range aliases {
+			oldIndices = append(oldIndices, oldIndex)
+		}
+	}
 
-		// Create BulkIndexer on Elasticsearch
-		indexer, err := esutil.NewBulkIndexer(esutil.BulkIndexerConfig{
-			Client: infrastructure.ElasticsearchClient,
-			Index:  "invoices",
+	actions := []map[string]any{}
+	for _, oldIndex := range oldIndices {
+		actions = append(actions, map[string]any{
+			"remove": map[string]any{"index": oldIndex, "alias": "invoices"},
 		})
+	}
+
+	// Index created by the old sync all holds the alias name, drop it in the same request
+	if len(oldIndices) == 0 {
+		existsRes, err := invoiceElasticsearchRepository.elasticsearchClient.Indices.Exists([]string{"invoices"},
+			invoiceElasticsearchRepository.elasticsearchClient.Indices.Exists.WithContext(ctx))
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("check index existence failed: %s", err.Error())
 		}
-		defer func() {
-			if err := indexer.Close(ctx); err != nil {
-				log.Printf("Close bulk indexer failed: %s", err.Error())
-			}
-		}()
-
-		// Add all invoice to BulkIndexer on Elasticsearch
-		for _, invoice := range invoices {
-			data, err := json.Marshal(invoice)
-			if err != nil {
-				log.Printf("Marshal invoice with id = %d: %s", invoice.Id, err.Error())
-				continue
-			}
-
-			// Add invoice to BulkIndexer
-			err = indexer.Add(ctx, esutil.BulkIndexerItem{
-				Action:     "index",
-				DocumentID: strconv.FormatInt(invoice.Id, 10),
-				Body:       bytes.NewReader(data),
-				OnFailure: func(ctx context.Context, item esutil.BulkIndexerItem, resp esutil.BulkIndexerResponseItem, err error) {
-					if err != nil {
-						log.Printf("Bulk index failed: %s", err.Error())
-					} else {
-						log.Printf("Index invoice with id = %s failed: %s", item.DocumentID, resp.Error.Reason)
-					}
-				},
+		existsRes.Body.Close()
+
+		if existsRes.StatusCode == 200 {
+			actions = append(actions, map[string]any{
+				"remove_index": map[string]any{"index": "invoices"},
 			})
-			if err != nil {
-				return err
-			}
 		}
+	}
+
+	actions = append(actions, map[string]any{
+		"add": map[string]any{"index": index, "alias": "invoices"},
+	})
 
+	res, err := invoiceElasticsearchRepository.elasticsearchClient.Indices.UpdateAliases(
+		esutil.NewJSONReader(map[string]any{"actions": actions}),
+		invoiceElasticsearchRepository.elasticsearchClient.Indices.UpdateAliases.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("switch invoices alias failed: %s", err.Error())
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("switch invoices alias failed: %s", res.String())
+	}
+
+	return oldIndices, nil
+}
+
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) DeleteIndices(ctx context.Context, indices []string) error {
+	if len(indices) == 0 {
 		return nil
 	}
 
-	return fmt.Errorf("index invoices already exists after first sync all")
+	res, err := invoiceElasticsearchRepository.elasticsearchClient.Indices.Delete(indices,
+		invoiceElasticsearchRepository.elasticsearchClient.Indices.Delete.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("delete indices failed: %s", err.Error())
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("delete indices failed: %s", res.String())
+	}
+
+	return nil
 }
 
-func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) Sum(ctx context.Context, createdAtGTE string, createdAtLTE string) (*float64, error) {
-	mustConditions := []map[string]interface{}{}
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) SyncCreating(ctx context.Context, newInvoice *model.Invoice) error {
+	// Add invoice to Elasticsearch
+	res, err := invoiceElasticsearchRepository.elasticsearchClient.Index(
+		"invoices",
+		esutil.NewJSONReader(newInvoice),
+		invoiceElasticsearchRepository.elasticsearchClient.Index.WithDocumentID(strconv.FormatInt(newInvoice.Id, 10)),
+		invoiceElasticsearchRepository.elasticsearchClient.Index.WithRefresh("true"),
+	)
+	if err != nil {
+		return fmt.Errorf("add invoice to elasticsearch failed: %s", err.Error())
+	}
+	defer res.Body.Close()
 
-	// If filtering by created_at in range or partial range
-	createdAtRange := map[string]interface{}{}
-	if createdAtGTE != "" {
-		createdAtRange["gte"] = createdAtGTE
+	if res.IsError() {
+		return fmt.Errorf("add invoice to elasticsearch failed: %s", res.String())
 	}
-	if createdAtLTE != "" {
-		createdAtRange["lte"] = createdAtLTE
+
+	return nil
+}
+
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) SyncUpdating(ctx context.Context, updatedInvoice *model.Invoice) error {
+	// Update invoice on Elasticsearch
+	res, err := invoiceElasticsearchRepository.elasticsearchClient.Index(
+		"invoices",
+		esutil.NewJSONReader(updatedInvoice),
+		invoiceElasticsearchRepository.elasticsearchClient.Index.WithDocumentID(strconv.FormatInt(updatedInvoice.Id, 10)),
+		invoiceElasticsearchRepository.elasticsearchClient.Index.WithRefresh("true"),
+	)
+	if err != nil {
+		return fmt.Errorf("update invoice on elasticsearch failed: %s", err.Error())
 	}
-	if len(createdAtRange) > 0 {
-		createdAtRange["format"] = "strict_date_optional_time" // For format YYYY-MM-ddTHH:mm:ss
-		mustConditions = append(mustConditions, map[string]interface{}{
-			"range": map[string]interface{}{
-				"created_at": createdAtRange,
-			},
-		})
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("update invoice on elasticsearch failed: %s", res.String())
 	}
 
-	// If not filtering -> get all
-	if len(mustConditions) == 0 {
-		mustConditions = append(mustConditions, map[string]interface{}{
-			"match_all": map[string]interface{}{},
-		})
+	return nil
+}
+
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) SyncDeletingById(ctx context.Context, id int64) error {
+	// Delete invoice from Elasticsearch
+	res, err := invoiceElasticsearchRepository.elasticsearchClient.Delete(
+		"invoices",
+		strconv.FormatInt(id, 10),
+		invoiceElasticsearchRepository.elasticsearchClient.Delete.WithRefresh("true"),
+	)
+	if err != nil {
+		return fmt.Errorf("delete invoice from elasticsearch failed: %s", err.Error())
+	}
+	defer res.Body.Close()
+
+	// Already deleted
+	if res.StatusCode == 404 {
+		return nil
 	}
 
+	if res.IsError() {
+		return fmt.Errorf("delete invoice from elasticsearch failed: %s", res.String())
+	}
+
+	return nil
+}
+
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) Sum(ctx context.Context, filter *model.InvoiceElasticsearchFilter) (*float64, error) {
 	// Setup query
 	query := map[string]interface{}{
 		"size": 0,
 		"query": map[string]interface{}{
 			"bool": map[string]interface{}{
-				"must": mustConditions,
+				"filter": invoiceFilterConditions(filter),
 			},
 		},
 		"aggs": map[string]interface{}{
@@ -234,8 +441,6 @@ func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) Sum(ctx co
 		},
 	}
 
-	fmt.Println(query)
-
 	// Convert query to JSON
 	queryJSON, err := json.Marshal(query)
 	if err != nil {
@@ -243,10 +448,10 @@ func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) Sum(ctx co
 	}
 
 	// Send request to Elasticsearch
-	res, err := infrastructure.ElasticsearchClient.Search(
-		infrastructure.ElasticsearchClient.Search.WithContext(ctx),
-		infrastructure.ElasticsearchClient.Search.WithIndex("invoices"),
-		infrastructure.ElasticsearchClient.Search.WithBody(bytes.NewReader(queryJSON)),
+	res, err := invoiceElasticsearchRepository.elasticsearchClient.Search(
+		invoiceElasticsearchRepository.elasticsearchClient.Search.WithContext(ctx),
+		invoiceElasticsearchRepository.elasticsearchClient.Search.WithIndex("invoices"),
+		invoiceElasticsearchRepository.elasticsearchClient.Search.WithBody(bytes.NewReader(queryJSON)),
 	)
 	if err != nil {
 		return nil, err
@@ -271,43 +476,13 @@ func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) Sum(ctx co
 	return &elasticsearchResponse.Aggregations.TotalAmountSum.Value, nil
 }
 
-func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) SumAvg(ctx context.Context, createdAtGTE string, createdAtLTE string) (*model.InvoiceReport, error) {
-	invoiceReport := &model.InvoiceReport{}
-
-	mustConditions := []map[string]interface{}{}
-
-	// If filtering by created_at in range or partial range
-	createdAtRange := map[string]interface{}{}
-	if createdAtGTE != "" {
-		createdAtRange["gte"] = createdAtGTE
-		startTime, err := time.Parse("2006-01-02T15:04:05", createdAtGTE)
-		if err != nil {
-			return nil, err
-		}
-		invoiceReport.StartTime = &startTime
-	}
-	if createdAtLTE != "" {
-		createdAtRange["lte"] = createdAtLTE
-		endTime, err := time.Parse("2006-01-02T15:04:05", createdAtLTE)
-		if err != nil {
-			return nil, err
-		}
-		invoiceReport.EndTime = &endTime
-	}
-	if len(createdAtRange) > 0 {
-		createdAtRange["format"] = "strict_date_optional_time" // For format YYYY-MM-ddTHH:mm:ss
-		mustConditions = append(mustConditions, map[string]interface{}{
-			"range": map[string]interface{}{
-				"created_at": createdAtRange,
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) Report(ctx context.Context, filter *model.InvoiceElasticsearchFilter, interval string, userLimit int) (*model.InvoiceElasticsearchReport, error) {
+	totalAmountSum := map[string]interface{}{
+		"total_amount_sum": map[string]interface{}{
+			"sum": map[string]interface{}{
+				"field": "total_amount",
 			},
-		})
-	}
-
-	// If not filtering -> get all
-	if len(mustConditions) == 0 {
-		mustConditions = append(mustConditions, map[string]interface{}{
-			"match_all": map[string]interface{}{},
-		})
+		},
 	}
 
 	// Setup query
@@ -315,25 +490,51 @@ func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) SumAvg(ctx
 		"size": 0,
 		"query": map[string]interface{}{
 			"bool": map[string]interface{}{
-				"must": mustConditions,
+				"filter": invoiceFilterConditions(filter),
 			},
 		},
 		"aggs": map[string]interface{}{
-			"total_amount_sum": map[string]interface{}{
-				"sum": map[string]interface{}{
+			"total_amount_stats": map[string]interface{}{
+				"stats": map[string]interface{}{
 					"field": "total_amount",
 				},
 			},
-			"total_amount_avg": map[string]interface{}{
-				"avg": map[string]interface{}{
-					"field": "total_amount",
+			"total_amount_percentiles": map[string]interface{}{
+				"percentiles": map[string]interface{}{
+					"field":    "total_amount",
+					"percents": model.InvoiceReportPercents,
+					"keyed":    false,
+				},
+			},
+			// Empty days inside the range are kept so the series has no gaps
+			"series": map[string]interface{}{
+				"date_histogram": map[string]interface{}{
+					"field":             "created_at",
+					"calendar_interval": interval,
+					"time_zone":         model.InvoiceReportTimeZone,
+					"format":            "yyyy-MM-dd",
+					"min_doc_count":     0,
 				},
+				"aggs": totalAmountSum,
+			},
+			"by_status": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": "status.keyword",
+					"size":  len(model.InvoiceStatusTransitions),
+				},
+				"aggs": totalAmountSum,
+			},
+			"by_user": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": "user_id",
+					"size":  userLimit,
+					"order": map[string]interface{}{"total_amount_sum": "desc"},
+				},
+				"aggs": totalAmountSum,
 			},
 		},
 	}
 
-	fmt.Println(query)
-
 	// Convert query to JSON
 	queryJSON, err := json.Marshal(query)
 	if err != nil {
@@ -341,10 +542,10 @@ func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) SumAvg(ctx
 	}
 
 	// Send request to Elasticsearch
-	res, err := infrastructure.ElasticsearchClient.Search(
-		infrastructure.ElasticsearchClient.Search.WithContext(ctx),
-		infrastructure.ElasticsearchClient.Search.WithIndex("invoices"),
-		infrastructure.ElasticsearchClient.Search.WithBody(bytes.NewReader(queryJSON)),
+	res, err := invoiceElasticsearchRepository.elasticsearchClient.Search(
+		invoiceElasticsearchRepository.elasticsearchClient.Search.WithContext(ctx),
+		invoiceElasticsearchRepository.elasticsearchClient.Search.WithIndex("invoices"),
+		invoiceElasticsearchRepository.elasticsearchClient.Search.WithBody(bytes.NewReader(queryJSON)),
 	)
 	if err != nil {
 		return nil, err
@@ -353,24 +554,111 @@ func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) SumAvg(ctx
 
 	// Parse response
 	if res.IsError() {
-		return nil, fmt.Errorf("get invoices from elasticsearch failed: %s", res.String())
+		return nil, fmt.Errorf("report invoices from elasticsearch failed: %s", res.String())
+	}
+	type sumAgg struct {
+		Value float64 `json:"value"`
 	}
 	var elasticsearchResponse struct {
 		Aggregations struct {
-			TotalAmountSum struct {
-				Value float64 `json:"value"`
-			} `json:"total_amount_sum"`
-			TotalAmountAvg struct {
-				Value float64 `json:"value"`
-			} `json:"total_amount_avg"`
+			TotalAmountStats struct {
+				Count int64    `json:"count"`
+				Sum   float64  `json:"sum"`
+				Avg   *float64 `json:"avg"`
+				Min   *float64 `json:"min"`
+				Max   *float64 `json:"max"`
+			} `json:"total_amount_stats"`
+			TotalAmountPercentiles struct {
+				Values []struct {
+					Key   float64  `json:"key"`
+					Value *float64 `json:"value"`
+				} `json:"values"`
+			} `json:"total_amount_percentiles"`
+			Series struct {
+				Buckets []struct {
+					KeyAsString    string `json:"key_as_string"`
+					DocCount       int64  `json:"doc_count"`
+					TotalAmountSum sumAgg `json:"total_amount_sum"`
+				} `json:"buckets"`
+			} `json:"series"`
+			ByStatus struct {
+				Buckets []struct {
+					Key            string `json:"key"`
+					DocCount       int64  `json:"doc_count"`
+					TotalAmountSum sumAgg `json:"total_amount_sum"`
+				} `json:"buckets"`
+			} `json:"by_status"`
+			ByUser struct {
+				Buckets []struct {
+					Key            int64  `json:"key"`
+					DocCount       int64  `json:"doc_count"`
+					TotalAmountSum sumAgg `json:"total_amount_sum"`
+				} `json:"buckets"`
+			} `json:"by_user"`
 		} `json:"aggregations"`
 	}
 	if err := json.NewDecoder(res.Body).Decode(&elasticsearchResponse); err != nil {
 		return nil, fmt.Errorf("unmarshal elasticsearch response failed: %s", err.Error())
 	}
 
-	invoiceReport.Sum = elasticsearchResponse.Aggregations.TotalAmountSum.Value
-	invoiceReport.Avg = elasticsearchResponse.Aggregations.TotalAmountAvg.Value
+	aggregations := elasticsearchResponse.Aggregations
+	report := &model.InvoiceElasticsearchReport{
+		Count:       aggregations.TotalAmountStats.Count,
+		Sum:         aggregations.TotalAmountStats.Sum,
+		Avg:         aggregations.TotalAmountStats.Avg,
+		Min:         aggregations.TotalAmountStats.Min,
+		Max:         aggregations.TotalAmountStats.Max,
+		Percentiles: make([]model.InvoiceReportPercentile, len(aggregations.TotalAmountPercentiles.Values)),
+		Series:      make([]model.InvoiceReportTimeBucket, len(aggregations.Series.Buckets)),
+		Statuses:    make([]model.InvoiceReportStatusBucket, len(aggregations.ByStatus.Buckets)),
+		Users:       make([]model.InvoiceReportUserBucket, len(aggregations.ByUser.Buckets)),
+	}
+	for i, percentile := range aggregations.TotalAmountPercentiles.Values {
+		report.Percentiles[i] = model.InvoiceReportPercentile{Percent: percentile.Key, Value: percentile.Value}
+	}
+	for i, bucket := range aggregations.Series.Buckets {
+		report.Series[i] = model.InvoiceReportTimeBucket{Date: bucket.KeyAsString, Count: bucket.DocCount, Sum: bucket.TotalAmountSum.Value}
+	}
+	for i, bucket := range aggregations.ByStatus.Buckets {
+		report.Statuses[i] = model.InvoiceReportStatusBucket{Status: bucket.Key, Count: bucket.DocCount, Sum: bucket.TotalAmountSum.Value}
+	}
+	for i, bucket := range aggregations.ByUser.Buckets {
+		report.Users[i] = model.InvoiceReportUserBucket{UserId: bucket.Key, Count: bucket.DocCount, Sum: bucket.TotalAmountSum.Value}
+	}
+
+	return report, nil
+}
+
+// Range bounds have no offset, they are read in report timezone like the buckets
+func invoiceFilterConditions(filter *model.InvoiceElasticsearchFilter) []map[string]interface{} {
+	filterConditions := []map[string]interface{}{}
+
+	// If filtering by created_at in range or partial range
+	createdAtRange := map[string]interface{}{}
+	if filter.CreatedAtGTE != "" {
+		createdAtRange["gte"] = filter.CreatedAtGTE
+	}
+	if filter.CreatedAtLTE != "" {
+		createdAtRange["lte"] = filter.CreatedAtLTE
+	}
+	if len(createdAtRange) > 0 {
+		createdAtRange["format"] = "strict_date_optional_time" // For format YYYY-MM-ddTHH:mm:ss
+		createdAtRange["time_zone"] = model.InvoiceReportTimeZone
+		filterConditions = append(filterConditions, map[string]interface{}{
+			"range": map[string]interface{}{
+				"created_at": createdAtRange,
+			},
+		})
+	}
+
+	// If filtering by status
+	if len(filter.Statuses) > 0 {
+		filterConditions = append(filterConditions, map[string]interface{}{
+			"terms": map[string]interface{}{
+				"status.keyword": filter.Statuses,
+			},
+		})
+	}
 
-	return invoiceReport, nil
+	return filterConditions
 }
@@ -2,90 +2,86 @@ package repository
 
 import (
 	"context"
-	"fmt"
-	"thanhldt060802/infrastructure"
 	"thanhldt060802/internal/model"
 	"thanhldt060802/utils"
+
+	"github.com/uptrace/bun"
 )
 
 type invoiceRepository struct {
+	db *bun.DB
 }
 
 type InvoiceRepository interface {
-	Get(ctx context.Context, offset int, limit int, sortFields []utils.SortField) ([]model.Invoice, error)
+	Get(ctx context.Context, pageRequest *utils.PageRequest) ([]model.Invoice, *utils.Page, error)
 	GetById(ctx context.Context, id int64) (*model.Invoice, error)
-	GetByUserId(ctx context.Context, userId int64, offset int, limit int, sortFields []utils.SortField) ([]model.Invoice, error)
+	GetByIdForUpdate(ctx context.Context, id int64) (*model.Invoice, error)
+	GetByUserId(ctx context.Context, userId int64, pageRequest *utils.PageRequest) ([]model.Invoice, *utils.Page, error)
 	Create(ctx context.Context, newInvoice *model.Invoice) error
 	UpdateById(ctx context.Context, id int64, updatedInvoice *model.Invoice) error
 	DeleteById(ctx context.Context, id int64) error
 
-	GetAll(ctx context.Context) ([]model.Invoice, error)
+	GetAll(ctx context.Context, afterId int64, limit int) ([]model.Invoice, error)
 }
 
-func NewInvoiceRepository() InvoiceRepository {
-	return &invoiceRepository{}
+func NewInvoiceRepository(db *bun.DB) InvoiceRepository {
+	return &invoiceRepository{db: db}
 }
 
-func (invoiceRepository *invoiceRepository) Get(ctx context.Context, offset int, limit int, sortFields []utils.SortField) ([]model.Invoice, error) {
-	var invoices []model.Invoice
-	query := infrastructure.DB.NewSelect().Model(&invoices).
-		Offset(offset).
-		Limit(limit)
-	for _, sortField := range sortFields {
-		query = query.Order(fmt.Sprintf("%s %s", sortField.Field, sortField.Direction))
-	}
-	err := query.Scan(ctx)
-	if err != nil {
-		return nil, err
-	}
-	return invoices, nil
+func (invoiceRepository *invoiceRepository) Get(ctx context.Context, pageRequest *utils.PageRequest) ([]model.Invoice, *utils.Page, error) {
+	return paginate[model.Invoice](ctx, invoiceRepository.db, pageRequest, nil)
 }
 
 func (invoiceRepository *invoiceRepository) GetById(ctx context.Context, id int64) (*model.Invoice, error) {
 	var invoice model.Invoice
-	err := infrastructure.DB.NewSelect().Model(&invoice).Where("id = ?", id).Scan(ctx)
+	err := getDB(ctx, invoiceRepository.db).NewSelect().Model(&invoice).Where("id = ?", id).Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return &invoice, nil
 }
 
-func (invoiceRepository *invoiceRepository) GetByUserId(ctx context.Context, userId int64, offset int, limit int, sortFields []utils.SortField) ([]model.Invoice, error) {
-	var invoices []model.Invoice
-	query := infrastructure.DB.NewSelect().Model(&invoices).Where("user_id = ?", userId).
-		Offset(offset).
-		Limit(limit)
-	for _, sortField := range sortFields {
-		query = query.Order(fmt.Sprintf("%s %s", sortField.Field, sortField.Direction))
-	}
-	err := query.Scan(ctx)
+func (invoiceRepository *invoiceRepository) GetByIdForUpdate(ctx context.Context, id int64) (*model.Invoice, error) {
+	var invoice model.Invoice
+	err := getDB(ctx, invoiceRepository.db).NewSelect().Model(&invoice).Where("id = ?", id).For("UPDATE").Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return invoices, nil
+	return &invoice, nil
+}
+
+func (invoiceRepository *invoiceRepository) GetByUserId(ctx context.Context, userId int64, pageRequest *utils.PageRequest) ([]model.Invoice, *utils.Page, error) {
+	return paginate[model.Invoice](ctx, invoiceRepository.db, pageRequest, func(query *bun.SelectQuery) *bun.SelectQuery {
+		return query.Where("user_id = ?", userId)
+	})
 }
 
 func (invoiceRepository *invoiceRepository) Create(ctx context.Context, newInvoice *model.Invoice) error {
-	_, err := infrastructure.DB.NewInsert().Model(newInvoice).Exec(ctx)
+	_, err := getDB(ctx, invoiceRepository.db).NewInsert().Model(newInvoice).Returning("*").Exec(ctx)
 	return err
 }
 
 func (invoiceRepository *invoiceRepository) UpdateById(ctx context.Context, id int64, updatedInvoice *model.Invoice) error {
-	_, err := infrastructure.DB.NewUpdate().Model(updatedInvoice).Where("id = ?", id).Exec(ctx)
+	_, err := getDB(ctx, invoiceRepository.db).NewUpdate().Model(updatedInvoice).Where("id = ?", id).Exec(ctx)
 	return err
 }
 
 func (invoiceRepository *invoiceRepository) DeleteById(ctx context.Context, id int64) error {
-	_, err := infrastructure.DB.NewDelete().Model(&model.Invoice{}).Where("id = ?", id).Exec(ctx)
+	_, err := getDB(ctx, invoiceRepository.db).NewDelete().Model(&model.Invoice{}).Where("id = ?", id).Exec(ctx)
 	return err
 }
 
 // Integrate with Elasticsearch
 
-func (invoiceRepository *invoiceRepository) GetAll(ctx context.Context) ([]model.Invoice, error) {
+// Pages by id so rows inserted while paging can not shift pages
+func (invoiceRepository *invoiceRepository) GetAll(ctx context.Context, afterId int64, limit int) ([]model.Invoice, error) {
 	var invoices []model.Invoice
 
-	err := infrastructure.DB.NewSelect().Model(&invoices).Scan(ctx)
+	err := getDB(ctx, invoiceRepository.db).NewSelect().Model(&invoices).
+		Where("id > ?", afterId).
+		Order("id ASC").
+		Limit(limit).
+		Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
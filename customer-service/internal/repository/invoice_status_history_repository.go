@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"thanhldt060802/internal/model"
+
+	"github.com/uptrace/bun"
+)
+
+type invoiceStatusHistoryRepository struct {
+	db *bun.DB
+}
+
+type InvoiceStatusHistoryRepository interface {
+	GetByInvoiceId(ctx context.Context, invoiceId int64) ([]model.InvoiceStatusHistory, error)
+	Create(ctx context.Context, newInvoiceStatusHistory *model.InvoiceStatusHistory) error
+}
+
+func NewInvoiceStatusHistoryRepository(db *bun.DB) InvoiceStatusHistoryRepository {
+	return &invoiceStatusHistoryRepository{db: db}
+}
+
+func (invoiceStatusHistoryRepository *invoiceStatusHistoryRepository) GetByInvoiceId(ctx context.Context, invoiceId int64) ([]model.InvoiceStatusHistory, error) {
+	var invoiceStatusHistories []model.InvoiceStatusHistory
+	err := getDB(ctx, invoiceStatusHistoryRepository.db).NewSelect().Model(&invoiceStatusHistories).
+		Where("invoice_id = ?", invoiceId).
+		Order("created_at ASC", "id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return invoiceStatusHistories, nil
+}
+
+func (invoiceStatusHistoryRepository *invoiceStatusHistoryRepository) Create(ctx context.Context, newInvoiceStatusHistory *model.InvoiceStatusHistory) error {
+	_, err := getDB(ctx, invoiceStatusHistoryRepository.db).NewInsert().Model(newInvoiceStatusHistory).Returning("*").Exec(ctx)
+	return err
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"thanhldt060802/internal/model"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrLoginLockoutNotFound = errors.New("login lockout not found or expired")
+
+type loginAttemptRepository struct {
+	redisClient *redis.Client
+}
+
+type LoginAttemptRepository interface {
+	Get(ctx context.Context, scope string, value string) (*model.LoginAttempt, error)
+	RecordFailure(ctx context.Context, scope string, value string, windowDuration time.Duration) (*model.LoginAttempt, error)
+	Reset(ctx context.Context, scope string, value string) error
+
+	GetLockouts(ctx context.Context) ([]model.LoginLockout, error)
+	GetLockout(ctx context.Context, scope string, value string) (*model.LoginLockout, error)
+	CreateLockout(ctx context.Context, newLoginLockout *model.LoginLockout, lockoutDuration time.Duration) error
+	DeleteLockout(ctx context.Context, scope string, value string) error
+}
+
+func NewLoginAttemptRepository(redisClient *redis.Client) LoginAttemptRepository {
+	return &loginAttemptRepository{redisClient: redisClient}
+}
+
+// Missing counter means no recent failure, it is returned as zero count rather than an error
+func (loginAttemptRepository *loginAttemptRepository) Get(ctx context.Context, scope string, value string) (*model.LoginAttempt, error) {
+	values, err := loginAttemptRepository.redisClient.HGetAll(ctx, loginAttemptKey(scope, value)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return toLoginAttempt(scope, value, values), nil
+}
+
+// Window restarts on every failure, so counter only resets after a quiet period
+func (loginAttemptRepository *loginAttemptRepository) RecordFailure(ctx context.Context, scope string, value string, windowDuration time.Duration) (*model.LoginAttempt, error) {
+	pipe := loginAttemptRepository.redisClient.TxPipeline()
+	pipe.HIncrBy(ctx, loginAttemptKey(scope, value), "failed_count", 1)
+	pipe.HSet(ctx, loginAttemptKey(scope, value), "last_failed_at", time.Now().UnixMilli())
+	pipe.Expire(ctx, loginAttemptKey(scope, value), windowDuration)
+	getCmd := pipe.HGetAll(ctx, loginAttemptKey(scope, value))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	return toLoginAttempt(scope, value, getCmd.Val()), nil
+}
+
+func (loginAttemptRepository *loginAttemptRepository) Reset(ctx context.Context, scope string, value string) error {
+	return loginAttemptRepository.redisClient.Del(ctx, loginAttemptKey(scope, value)).Err()
+}
+
+func (loginAttemptRepository *loginAttemptRepository) GetLockouts(ctx context.Context) ([]model.LoginLockout, error) {
+	loginLockouts := []model.LoginLockout{}
+
+	iter := loginAttemptRepository.redisClient.Scan(ctx, 0, "login-lockout:*", 100).Iterator()
+	for iter.Next(ctx) {
+		parts := strings.SplitN(iter.Val(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+
+		loginLockout, err := loginAttemptRepository.GetLockout(ctx, parts[1], parts[2])
+		if err == ErrLoginLockoutNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		loginLockouts = append(loginLockouts, *loginLockout)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return loginLockouts, nil
+}
+
+func (loginAttemptRepository *loginAttemptRepository) GetLockout(ctx context.Context, scope string, value string) (*model.LoginLockout, error) {
+	pipe := loginAttemptRepository.redisClient.Pipeline()
+	getCmd := pipe.HGetAll(ctx, loginLockoutKey(scope, value))
+	ttlCmd := pipe.PTTL(ctx, loginLockoutKey(scope, value))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	if len(getCmd.Val()) == 0 || ttlCmd.Val() <= 0 {
+		return nil, ErrLoginLockoutNotFound
+	}
+
+	failedCount, _ := strconv.Atoi(getCmd.Val()["failed_count"])
+	lockedAt, _ := strconv.ParseInt(getCmd.Val()["locked_at"], 10, 64)
+
+	return &model.LoginLockout{
+		Scope:       scope,
+		Value:       value,
+		FailedCount: failedCount,
+		LockedAt:    time.Unix(lockedAt, 0),
+		ExpiresAt:   time.Now().Add(ttlCmd.Val()),
+	}, nil
+}
+
+func (loginAttemptRepository *loginAttemptRepository) CreateLockout(ctx context.Context, newLoginLockout *model.LoginLockout, lockoutDuration time.Duration) error {
+	pipe := loginAttemptRepository.redisClient.TxPipeline()
+	pipe.HSet(ctx, loginLockoutKey(newLoginLockout.Scope, newLoginLockout.Value),
+		"failed_count", newLoginLockout.FailedCount,
+		"locked_at", newLoginLockout.LockedAt.Unix(),
+	)
+	pipe.Expire(ctx, loginLockoutKey(newLoginLockout.Scope, newLoginLockout.Value), lockoutDuration)
+	// Counting starts over once the lockout ends
+	pipe.Del(ctx, loginAttemptKey(newLoginLockout.Scope, newLoginLockout.Value))
+	_, err := pipe.Exec(ctx)
+
+	return err
+}
+
+func (loginAttemptRepository *loginAttemptRepository) DeleteLockout(ctx context.Context, scope string, value string) error {
+	deleted, err := loginAttemptRepository.redisClient.Del(ctx, loginLockoutKey(scope, value), loginAttemptKey(scope, value)).Result()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrLoginLockoutNotFound
+	}
+
+	return nil
+}
+
+func loginAttemptKey(scope string, value string) string {
+	return fmt.Sprintf("login-attempt:%s:%s", scope, value)
+}
+
+func loginLockoutKey(scope string, value string) string {
+	return fmt.Sprintf("login-lockout:%s:%s", scope, value)
+}
+
+func toLoginAttempt(scope string, value string, values map[string]string) *model.LoginAttempt {
+	failedCount, _ := strconv.Atoi(values["failed_count"])
+	lastFailedAt, _ := strconv.ParseInt(values["last_failed_at"], 10, 64)
+
+	return &model.LoginAttempt{
+		Scope:        scope,
+		Value:        value,
+		FailedCount:  failedCount,
+		LastFailedAt: time.UnixMilli(lastFailedAt),
+	}
+}
@@ -0,0 +1,30 @@
+package memory
+
+import (
+	"context"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"thanhldt060802/utils"
+)
+
+type auditLogRepository struct {
+	auditLogs *table[model.AuditLog]
+}
+
+func NewAuditLogRepository() repository.AuditLogRepository {
+	return &auditLogRepository{
+		auditLogs: newTable(func(auditLog *model.AuditLog) *int64 { return &auditLog.Id }),
+	}
+}
+
+func (auditLogRepository *auditLogRepository) Get(ctx context.Context, action string, pageRequest *utils.PageRequest) ([]model.AuditLog, *utils.Page, error) {
+	return paginate(auditLogRepository.auditLogs.filter(func(auditLog *model.AuditLog) bool {
+		return action == "" || auditLog.Action == action
+	}), pageRequest)
+}
+
+func (auditLogRepository *auditLogRepository) Create(ctx context.Context, newAuditLog *model.AuditLog) error {
+	defaultNow(&newAuditLog.CreatedAt)
+	auditLogRepository.auditLogs.insert(newAuditLog)
+	return nil
+}
@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"context"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"thanhldt060802/utils"
+)
+
+type cartItemRepository struct {
+	cartItems *table[model.CartItem]
+}
+
+func NewCartItemRepository() repository.CartItemRepository {
+	return &cartItemRepository{
+		cartItems: newTable(func(cartItem *model.CartItem) *int64 { return &cartItem.Id }),
+	}
+}
+
+func (cartItemRepository *cartItemRepository) Get(ctx context.Context, pageRequest *utils.PageRequest) ([]model.CartItem, *utils.Page, error) {
+	return paginate(cartItemRepository.cartItems.filter(nil), pageRequest)
+}
+
+func (cartItemRepository *cartItemRepository) GetById(ctx context.Context, id int64) (*model.CartItem, error) {
+	return cartItemRepository.cartItems.getById(id)
+}
+
+func (cartItemRepository *cartItemRepository) GetByCartId(ctx context.Context, cartId int64, pageRequest *utils.PageRequest) ([]model.CartItem, *utils.Page, error) {
+	return paginate(cartItemRepository.cartItems.filter(func(cartItem *model.CartItem) bool {
+		return cartItem.CartId == cartId
+	}), pageRequest)
+}
+
+func (cartItemRepository *cartItemRepository) GetAllByCartId(ctx context.Context, cartId int64) ([]model.CartItem, error) {
+	return cartItemRepository.cartItems.filter(func(cartItem *model.CartItem) bool {
+		return cartItem.CartId == cartId
+	}), nil
+}
+
+func (cartItemRepository *cartItemRepository) Create(ctx context.Context, newCartItem *model.CartItem) error {
+	cartItemRepository.cartItems.insert(newCartItem)
+	return nil
+}
+
+func (cartItemRepository *cartItemRepository) UpdateById(ctx context.Context, id int64, updatedCartItem *model.CartItem) error {
+	cartItemRepository.cartItems.updateById(id, updatedCartItem)
+	return nil
+}
+
+func (cartItemRepository *cartItemRepository) DeleteById(ctx context.Context, id int64) error {
+	cartItemRepository.cartItems.delete(func(cartItem *model.CartItem) bool {
+		return cartItem.Id == id
+	})
+	return nil
+}
+
+func (cartItemRepository *cartItemRepository) DeleteByCartId(ctx context.Context, cartId int64) error {
+	cartItemRepository.cartItems.delete(func(cartItem *model.CartItem) bool {
+		return cartItem.CartId == cartId
+	})
+	return nil
+}
+
+func (cartItemRepository *cartItemRepository) DeleteByProductId(ctx context.Context, productId int64) error {
+	cartItemRepository.cartItems.delete(func(cartItem *model.CartItem) bool {
+		return cartItem.ProductId == productId
+	})
+	return nil
+}
@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"thanhldt060802/utils"
+)
+
+type cartRepository struct {
+	carts *table[model.Cart]
+}
+
+func NewCartRepository() repository.CartRepository {
+	return &cartRepository{
+		carts: newTable(func(cart *model.Cart) *int64 { return &cart.Id }),
+	}
+}
+
+func (cartRepository *cartRepository) Get(ctx context.Context, pageRequest *utils.PageRequest) ([]model.Cart, *utils.Page, error) {
+	return paginate(cartRepository.carts.filter(nil), pageRequest)
+}
+
+func (cartRepository *cartRepository) GetById(ctx context.Context, id int64) (*model.Cart, error) {
+	return cartRepository.carts.getById(id)
+}
+
+func (cartRepository *cartRepository) GetByUserId(ctx context.Context, userId int64) (*model.Cart, error) {
+	return cartRepository.carts.find(func(cart *model.Cart) bool {
+		return cart.UserId == userId
+	})
+}
+
+// Nothing to lock, RunInTx of the in-memory transaction manager does not run calls concurrently anyway
+func (cartRepository *cartRepository) GetByIdForUpdate(ctx context.Context, id int64) (*model.Cart, error) {
+	return cartRepository.carts.getById(id)
+}
+
+func (cartRepository *cartRepository) Create(ctx context.Context, newCart *model.Cart) error {
+	defaultNow(&newCart.CreatedAt, &newCart.UpdatedAt)
+	cartRepository.carts.insert(newCart)
+	return nil
+}
+
+func (cartRepository *cartRepository) UpdateById(ctx context.Context, id int64, updatedCart *model.Cart) error {
+	cartRepository.carts.updateById(id, updatedCart)
+	return nil
+}
+
+func (cartRepository *cartRepository) DeleteById(ctx context.Context, id int64) error {
+	cartRepository.carts.delete(func(cart *model.Cart) bool {
+		return cart.Id == id
+	})
+	return nil
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"thanhldt060802/utils"
+)
+
+type invoiceDetailRepository struct {
+	invoiceDetails *table[model.InvoiceDetail]
+}
+
+func NewInvoiceDetailRepository() repository.InvoiceDetailRepository {
+	return &invoiceDetailRepository{
+		invoiceDetails: newTable(func(invoiceDetail *model.InvoiceDetail) *int64 { return &invoiceDetail.Id }),
+	}
+}
+
+func (invoiceDetailRepository *invoiceDetailRepository) Get(ctx context.Context, pageRequest *utils.PageRequest) ([]model.InvoiceDetail, *utils.Page, error) {
+	return paginate(invoiceDetailRepository.invoiceDetails.filter(nil), pageRequest)
+}
+
+func (invoiceDetailRepository *invoiceDetailRepository) GetById(ctx context.Context, id int64) (*model.InvoiceDetail, error) {
+	return invoiceDetailRepository.invoiceDetails.getById(id)
+}
+
+func (invoiceDetailRepository *invoiceDetailRepository) GetByInvoiceId(ctx context.Context, invoiceId int64, pageRequest *utils.PageRequest) ([]model.InvoiceDetail, *utils.Page, error) {
+	return paginate(invoiceDetailRepository.invoiceDetails.filter(func(invoiceDetail *model.InvoiceDetail) bool {
+		return invoiceDetail.InvoiceId == invoiceId
+	}), pageRequest)
+}
+
+func (invoiceDetailRepository *invoiceDetailRepository) Create(ctx context.Context, newInvoiceDetail *model.InvoiceDetail) error {
+	invoiceDetailRepository.invoiceDetails.insert(newInvoiceDetail)
+	return nil
+}
+
+func (invoiceDetailRepository *invoiceDetailRepository) CreateMany(ctx context.Context, newInvoiceDetails []model.InvoiceDetail) error {
+	for i := range newInvoiceDetails {
+		invoiceDetailRepository.invoiceDetails.insert(&newInvoiceDetails[i])
+	}
+	return nil
+}
+
+func (invoiceDetailRepository *invoiceDetailRepository) UpdateById(ctx context.Context, id int64, updatedInvoiceDetail *model.InvoiceDetail) error {
+	invoiceDetailRepository.invoiceDetails.updateById(id, updatedInvoiceDetail)
+	return nil
+}
+
+func (invoiceDetailRepository *invoiceDetailRepository) DeleteById(ctx context.Context, id int64) error {
+	invoiceDetailRepository.invoiceDetails.delete(func(invoiceDetail *model.InvoiceDetail) bool {
+		return invoiceDetail.Id == id
+	})
+	return nil
+}
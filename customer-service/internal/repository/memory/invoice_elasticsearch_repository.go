@@ -0,0 +1,247 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"thanhldt060802/utils"
+	"time"
+)
+
+type invoiceElasticsearchRepository struct {
+	invoices *elasticsearchIndices[model.Invoice]
+}
+
+func NewInvoiceElasticsearchRepository() repository.InvoiceElasticsearchRepository {
+	return &invoiceElasticsearchRepository{
+		invoices: newElasticsearchIndices("invoices", func(invoice *model.Invoice) int64 { return invoice.Id }),
+	}
+}
+
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) Get(ctx context.Context, pageRequest *utils.PageRequest, createdAtGTE string, createdAtLTE string) ([]model.Invoice, *utils.Page, error) {
+	if err := checkElasticsearchSort(pageRequest, model.MapSortFieldInvoiceSchemaElasticsearch); err != nil {
+		return nil, nil, err
+	}
+
+	createdAtRange, err := newDateRange(createdAtGTE, createdAtLTE, time.UTC)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get invoices from elasticsearch failed: %s", err.Error())
+	}
+
+	return paginate(invoiceElasticsearchRepository.invoices.search(func(invoice *model.Invoice) bool {
+		return createdAtRange(invoice.CreatedAt)
+	}), pageRequest)
+}
+
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) CreateNextVersionIndex(ctx context.Context) (string, error) {
+	return invoiceElasticsearchRepository.invoices.createNextVersionIndex(), nil
+}
+
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) GetVersionIndices(ctx context.Context) ([]string, error) {
+	return invoiceElasticsearchRepository.invoices.getVersionIndices(), nil
+}
+
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) BulkIndex(ctx context.Context, index string, invoices []model.Invoice) error {
+	return invoiceElasticsearchRepository.invoices.bulkIndex(index, invoices)
+}
+
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) Count(ctx context.Context, index string) (int64, error) {
+	return invoiceElasticsearchRepository.invoices.count(index)
+}
+
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) SwitchAlias(ctx context.Context, index string) ([]string, error) {
+	return invoiceElasticsearchRepository.invoices.switchAlias(index)
+}
+
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) DeleteIndices(ctx context.Context, indices []string) error {
+	invoiceElasticsearchRepository.invoices.deleteIndices(indices)
+	return nil
+}
+
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) SyncCreating(ctx context.Context, newInvoice *model.Invoice) error {
+	invoiceElasticsearchRepository.invoices.put(newInvoice)
+	return nil
+}
+
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) SyncUpdating(ctx context.Context, updatedInvoice *model.Invoice) error {
+	invoiceElasticsearchRepository.invoices.put(updatedInvoice)
+	return nil
+}
+
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) SyncDeletingById(ctx context.Context, id int64) error {
+	invoiceElasticsearchRepository.invoices.delete(id)
+	return nil
+}
+
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) Sum(ctx context.Context, filter *model.InvoiceElasticsearchFilter) (*float64, error) {
+	invoices, err := invoiceElasticsearchRepository.filter(filter)
+	if err != nil {
+		return nil, fmt.Errorf("get invoices from elasticsearch failed: %s", err.Error())
+	}
+
+	sum := float64(0)
+	for _, invoice := range invoices {
+		sum += float64(invoice.TotalAmount)
+	}
+
+	return &sum, nil
+}
+
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) Report(ctx context.Context, filter *model.InvoiceElasticsearchFilter, interval string, userLimit int) (*model.InvoiceElasticsearchReport, error) {
+	location, err := time.LoadLocation(model.InvoiceReportTimeZone)
+	if err != nil {
+		return nil, err
+	}
+	invoices, err := invoiceElasticsearchRepository.filter(filter)
+	if err != nil {
+		return nil, fmt.Errorf("report invoices from elasticsearch failed: %s", err.Error())
+	}
+
+	report := &model.InvoiceElasticsearchReport{
+		Count:       int64(len(invoices)),
+		Percentiles: make([]model.InvoiceReportPercentile, len(model.InvoiceReportPercents)),
+		Series:      []model.InvoiceReportTimeBucket{},
+		Statuses:    []model.InvoiceReportStatusBucket{},
+		Users:       []model.InvoiceReportUserBucket{},
+	}
+
+	// Stats and percentiles, the latter interpolated between closest ranks where Elasticsearch estimates them
+	amounts := make([]float64, len(invoices))
+	for i, invoice := range invoices {
+		amounts[i] = float64(invoice.TotalAmount)
+		report.Sum += amounts[i]
+	}
+	sort.Float64s(amounts)
+	if len(amounts) > 0 {
+		avg := report.Sum / float64(len(amounts))
+		report.Avg = &avg
+		report.Min = &amounts[0]
+		report.Max = &amounts[len(amounts)-1]
+	}
+	for i, percent := range model.InvoiceReportPercents {
+		report.Percentiles[i] = model.InvoiceReportPercentile{Percent: percent, Value: percentile(amounts, percent)}
+	}
+
+	// Series has no gaps between first and last bucket, like min_doc_count 0 without extended bounds
+	seriesBuckets := map[string]*model.InvoiceReportTimeBucket{}
+	var firstStart, lastStart time.Time
+	for _, invoice := range invoices {
+		start := bucketStart(invoice.CreatedAt.In(location), interval)
+		if firstStart.IsZero() || start.Before(firstStart) {
+			firstStart = start
+		}
+		if lastStart.IsZero() || start.After(lastStart) {
+			lastStart = start
+		}
+		date := start.Format("2006-01-02")
+		if seriesBuckets[date] == nil {
+			seriesBuckets[date] = &model.InvoiceReportTimeBucket{Date: date}
+		}
+		seriesBuckets[date].Count++
+		seriesBuckets[date].Sum += float64(invoice.TotalAmount)
+	}
+	for start := firstStart; len(invoices) > 0 && !start.After(lastStart); start = nextBucketStart(start, interval) {
+		date := start.Format("2006-01-02")
+		if bucket, ok := seriesBuckets[date]; ok {
+			report.Series = append(report.Series, *bucket)
+		} else {
+			report.Series = append(report.Series, model.InvoiceReportTimeBucket{Date: date})
+		}
+	}
+
+	// Terms buckets come by count, users by revenue, key breaks ties
+	statusBuckets := map[string]*model.InvoiceReportStatusBucket{}
+	userBuckets := map[int64]*model.InvoiceReportUserBucket{}
+	for _, invoice := range invoices {
+		if statusBuckets[invoice.Status] == nil {
+			statusBuckets[invoice.Status] = &model.InvoiceReportStatusBucket{Status: invoice.Status}
+		}
+		statusBuckets[invoice.Status].Count++
+		statusBuckets[invoice.Status].Sum += float64(invoice.TotalAmount)
+
+		if userBuckets[invoice.UserId] == nil {
+			userBuckets[invoice.UserId] = &model.InvoiceReportUserBucket{UserId: invoice.UserId}
+		}
+		userBuckets[invoice.UserId].Count++
+		userBuckets[invoice.UserId].Sum += float64(invoice.TotalAmount)
+	}
+	for _, bucket := range statusBuckets {
+		report.Statuses = append(report.Statuses, *bucket)
+	}
+	sort.Slice(report.Statuses, func(i, j int) bool {
+		if report.Statuses[i].Count != report.Statuses[j].Count {
+			return report.Statuses[i].Count > report.Statuses[j].Count
+		}
+		return report.Statuses[i].Status < report.Statuses[j].Status
+	})
+	for _, bucket := range userBuckets {
+		report.Users = append(report.Users, *bucket)
+	}
+	sort.Slice(report.Users, func(i, j int) bool {
+		if report.Users[i].Sum != report.Users[j].Sum {
+			return report.Users[i].Sum > report.Users[j].Sum
+		}
+		return report.Users[i].UserId < report.Users[j].UserId
+	})
+	if len(report.Users) > userLimit {
+		report.Users = report.Users[:userLimit]
+	}
+
+	return report, nil
+}
+
+// Bounds without offset are read in report timezone, like the buckets
+func (invoiceElasticsearchRepository *invoiceElasticsearchRepository) filter(filter *model.InvoiceElasticsearchFilter) ([]model.Invoice, error) {
+	location, err := time.LoadLocation(model.InvoiceReportTimeZone)
+	if err != nil {
+		return nil, err
+	}
+	createdAtRange, err := newDateRange(filter.CreatedAtGTE, filter.CreatedAtLTE, location)
+	if err != nil {
+		return nil, err
+	}
+
+	return invoiceElasticsearchRepository.invoices.search(func(invoice *model.Invoice) bool {
+		return createdAtRange(invoice.CreatedAt) && (len(filter.Statuses) == 0 || slices.Contains(filter.Statuses, invoice.Status))
+	}), nil
+}
+
+// Weeks start on Monday like calendar_interval week
+func bucketStart(date time.Time, interval string) time.Time {
+	year, month, day := date.Date()
+	switch interval {
+	case "week":
+		weekday := (int(date.Weekday()) + 6) % 7
+		return time.Date(year, month, day-weekday, 0, 0, 0, 0, date.Location())
+	case "month":
+		return time.Date(year, month, 1, 0, 0, 0, 0, date.Location())
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, date.Location())
+}
+
+func nextBucketStart(start time.Time, interval string) time.Time {
+	switch interval {
+	case "week":
+		return start.AddDate(0, 0, 7)
+	case "month":
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+func percentile(sortedValues []float64, percent float64) *float64 {
+	if len(sortedValues) == 0 {
+		return nil
+	}
+
+	rank := percent / 100 * float64(len(sortedValues)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	value := sortedValues[lower] + (sortedValues[upper]-sortedValues[lower])*(rank-float64(lower))
+
+	return &value
+}
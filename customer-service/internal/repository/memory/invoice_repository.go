@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"thanhldt060802/utils"
+)
+
+type invoiceRepository struct {
+	invoices *table[model.Invoice]
+}
+
+func NewInvoiceRepository() repository.InvoiceRepository {
+	return &invoiceRepository{
+		invoices: newTable(func(invoice *model.Invoice) *int64 { return &invoice.Id }),
+	}
+}
+
+func (invoiceRepository *invoiceRepository) Get(ctx context.Context, pageRequest *utils.PageRequest) ([]model.Invoice, *utils.Page, error) {
+	return paginate(invoiceRepository.invoices.filter(nil), pageRequest)
+}
+
+func (invoiceRepository *invoiceRepository) GetById(ctx context.Context, id int64) (*model.Invoice, error) {
+	return invoiceRepository.invoices.getById(id)
+}
+
+func (invoiceRepository *invoiceRepository) GetByIdForUpdate(ctx context.Context, id int64) (*model.Invoice, error) {
+	return invoiceRepository.invoices.getById(id)
+}
+
+func (invoiceRepository *invoiceRepository) GetByUserId(ctx context.Context, userId int64, pageRequest *utils.PageRequest) ([]model.Invoice, *utils.Page, error) {
+	return paginate(invoiceRepository.invoices.filter(func(invoice *model.Invoice) bool {
+		return invoice.UserId == userId
+	}), pageRequest)
+}
+
+func (invoiceRepository *invoiceRepository) Create(ctx context.Context, newInvoice *model.Invoice) error {
+	defaultNow(&newInvoice.CreatedAt, &newInvoice.UpdatedAt)
+	invoiceRepository.invoices.insert(newInvoice)
+	return nil
+}
+
+func (invoiceRepository *invoiceRepository) UpdateById(ctx context.Context, id int64, updatedInvoice *model.Invoice) error {
+	invoiceRepository.invoices.updateById(id, updatedInvoice)
+	return nil
+}
+
+func (invoiceRepository *invoiceRepository) DeleteById(ctx context.Context, id int64) error {
+	invoiceRepository.invoices.delete(func(invoice *model.Invoice) bool {
+		return invoice.Id == id
+	})
+	return nil
+}
+
+// Integrate with Elasticsearch
+
+func (invoiceRepository *invoiceRepository) GetAll(ctx context.Context, afterId int64, limit int) ([]model.Invoice, error) {
+	invoices := invoiceRepository.invoices.filter(func(invoice *model.Invoice) bool {
+		return invoice.Id > afterId
+	})
+	if len(invoices) > limit {
+		invoices = invoices[:limit]
+	}
+
+	return invoices, nil
+}
@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+)
+
+type invoiceStatusHistoryRepository struct {
+	invoiceStatusHistories *table[model.InvoiceStatusHistory]
+}
+
+func NewInvoiceStatusHistoryRepository() repository.InvoiceStatusHistoryRepository {
+	return &invoiceStatusHistoryRepository{
+		invoiceStatusHistories: newTable(func(invoiceStatusHistory *model.InvoiceStatusHistory) *int64 { return &invoiceStatusHistory.Id }),
+	}
+}
+
+func (invoiceStatusHistoryRepository *invoiceStatusHistoryRepository) GetByInvoiceId(ctx context.Context, invoiceId int64) ([]model.InvoiceStatusHistory, error) {
+	invoiceStatusHistories := invoiceStatusHistoryRepository.invoiceStatusHistories.filter(func(invoiceStatusHistory *model.InvoiceStatusHistory) bool {
+		return invoiceStatusHistory.InvoiceId == invoiceId
+	})
+
+	// Rows are kept in id order, a stable sort leaves id as the tiebreaker
+	slices.SortStableFunc(invoiceStatusHistories, func(a model.InvoiceStatusHistory, b model.InvoiceStatusHistory) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return invoiceStatusHistories, nil
+}
+
+func (invoiceStatusHistoryRepository *invoiceStatusHistoryRepository) Create(ctx context.Context, newInvoiceStatusHistory *model.InvoiceStatusHistory) error {
+	defaultNow(&newInvoiceStatusHistory.CreatedAt)
+	invoiceStatusHistoryRepository.invoiceStatusHistories.insert(newInvoiceStatusHistory)
+	return nil
+}
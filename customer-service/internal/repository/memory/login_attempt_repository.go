@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"time"
+)
+
+type loginAttemptKey struct {
+	scope string
+	value string
+}
+
+type expiringLoginAttempt struct {
+	loginAttempt model.LoginAttempt
+	expiresAt    time.Time
+}
+
+type loginAttemptRepository struct {
+	mutex         sync.Mutex
+	loginAttempts map[loginAttemptKey]expiringLoginAttempt
+	loginLockouts map[loginAttemptKey]model.LoginLockout
+}
+
+func NewLoginAttemptRepository() repository.LoginAttemptRepository {
+	return &loginAttemptRepository{
+		loginAttempts: map[loginAttemptKey]expiringLoginAttempt{},
+		loginLockouts: map[loginAttemptKey]model.LoginLockout{},
+	}
+}
+
+func (loginAttemptRepository *loginAttemptRepository) Get(ctx context.Context, scope string, value string) (*model.LoginAttempt, error) {
+	loginAttemptRepository.mutex.Lock()
+	defer loginAttemptRepository.mutex.Unlock()
+
+	loginAttempt := loginAttemptRepository.getLocked(loginAttemptKey{scope, value})
+	return &loginAttempt, nil
+}
+
+func (loginAttemptRepository *loginAttemptRepository) RecordFailure(ctx context.Context, scope string, value string, windowDuration time.Duration) (*model.LoginAttempt, error) {
+	loginAttemptRepository.mutex.Lock()
+	defer loginAttemptRepository.mutex.Unlock()
+
+	key := loginAttemptKey{scope, value}
+	loginAttempt := loginAttemptRepository.getLocked(key)
+	loginAttempt.FailedCount++
+	loginAttempt.LastFailedAt = time.UnixMilli(time.Now().UnixMilli())
+	loginAttemptRepository.loginAttempts[key] = expiringLoginAttempt{
+		loginAttempt: loginAttempt,
+		expiresAt:    time.Now().Add(windowDuration),
+	}
+
+	return &loginAttempt, nil
+}
+
+func (loginAttemptRepository *loginAttemptRepository) Reset(ctx context.Context, scope string, value string) error {
+	loginAttemptRepository.mutex.Lock()
+	defer loginAttemptRepository.mutex.Unlock()
+
+	delete(loginAttemptRepository.loginAttempts, loginAttemptKey{scope, value})
+	return nil
+}
+
+func (loginAttemptRepository *loginAttemptRepository) GetLockouts(ctx context.Context) ([]model.LoginLockout, error) {
+	loginAttemptRepository.mutex.Lock()
+	defer loginAttemptRepository.mutex.Unlock()
+
+	loginLockouts := []model.LoginLockout{}
+	for key := range loginAttemptRepository.loginLockouts {
+		if loginLockout, ok := loginAttemptRepository.getLockoutLocked(key); ok {
+			loginLockouts = append(loginLockouts, loginLockout)
+		}
+	}
+	sort.Slice(loginLockouts, func(i, j int) bool {
+		if loginLockouts[i].Scope != loginLockouts[j].Scope {
+			return loginLockouts[i].Scope < loginLockouts[j].Scope
+		}
+		return loginLockouts[i].Value < loginLockouts[j].Value
+	})
+
+	return loginLockouts, nil
+}
+
+func (loginAttemptRepository *loginAttemptRepository) GetLockout(ctx context.Context, scope string, value string) (*model.LoginLockout, error) {
+	loginAttemptRepository.mutex.Lock()
+	defer loginAttemptRepository.mutex.Unlock()
+
+	loginLockout, ok := loginAttemptRepository.getLockoutLocked(loginAttemptKey{scope, value})
+	if !ok {
+		return nil, repository.ErrLoginLockoutNotFound
+	}
+
+	return &loginLockout, nil
+}
+
+// Counting starts over once the lockout ends
+func (loginAttemptRepository *loginAttemptRepository) CreateLockout(ctx context.Context, newLoginLockout *model.LoginLockout, lockoutDuration time.Duration) error {
+	loginAttemptRepository.mutex.Lock()
+	defer loginAttemptRepository.mutex.Unlock()
+
+	key := loginAttemptKey{newLoginLockout.Scope, newLoginLockout.Value}
+	loginLockout := *newLoginLockout
+	loginLockout.LockedAt = time.Unix(newLoginLockout.LockedAt.Unix(), 0)
+	loginLockout.ExpiresAt = time.Now().Add(lockoutDuration)
+	loginAttemptRepository.loginLockouts[key] = loginLockout
+	delete(loginAttemptRepository.loginAttempts, key)
+
+	return nil
+}
+
+func (loginAttemptRepository *loginAttemptRepository) DeleteLockout(ctx context.Context, scope string, value string) error {
+	loginAttemptRepository.mutex.Lock()
+	defer loginAttemptRepository.mutex.Unlock()
+
+	key := loginAttemptKey{scope, value}
+	_, lockedOut := loginAttemptRepository.getLockoutLocked(key)
+	_, attempted := loginAttemptRepository.loginAttempts[key]
+	delete(loginAttemptRepository.loginLockouts, key)
+	delete(loginAttemptRepository.loginAttempts, key)
+	if !lockedOut && !attempted {
+		return repository.ErrLoginLockoutNotFound
+	}
+
+	return nil
+}
+
+// Missing or expired counter reads as zero failures
+func (loginAttemptRepository *loginAttemptRepository) getLocked(key loginAttemptKey) model.LoginAttempt {
+	expiring, ok := loginAttemptRepository.loginAttempts[key]
+	if !ok || !time.Now().Before(expiring.expiresAt) {
+		delete(loginAttemptRepository.loginAttempts, key)
+		return model.LoginAttempt{Scope: key.scope, Value: key.value, LastFailedAt: time.UnixMilli(0)}
+	}
+
+	return expiring.loginAttempt
+}
+
+func (loginAttemptRepository *loginAttemptRepository) getLockoutLocked(key loginAttemptKey) (model.LoginLockout, bool) {
+	loginLockout, ok := loginAttemptRepository.loginLockouts[key]
+	if !ok || !time.Now().Before(loginLockout.ExpiresAt) {
+		delete(loginAttemptRepository.loginLockouts, key)
+		return model.LoginLockout{}, false
+	}
+
+	return loginLockout, true
+}
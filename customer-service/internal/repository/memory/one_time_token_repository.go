@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"thanhldt060802/utils"
+	"time"
+)
+
+type expiringOneTimeToken struct {
+	oneTimeToken model.OneTimeToken
+	expiresAt    time.Time
+}
+
+type oneTimeTokenRepository struct {
+	mutex         sync.Mutex
+	oneTimeTokens map[string]expiringOneTimeToken
+}
+
+func NewOneTimeTokenRepository() repository.OneTimeTokenRepository {
+	return &oneTimeTokenRepository{
+		oneTimeTokens: map[string]expiringOneTimeToken{},
+	}
+}
+
+// Only a hash of the token is kept, like the Redis key
+func (oneTimeTokenRepository *oneTimeTokenRepository) Create(ctx context.Context, token string, newOneTimeToken *model.OneTimeToken, expireDuration time.Duration) error {
+	oneTimeTokenRepository.mutex.Lock()
+	defer oneTimeTokenRepository.mutex.Unlock()
+
+	oneTimeTokenRepository.oneTimeTokens[oneTimeTokenKey(newOneTimeToken.Purpose, token)] = expiringOneTimeToken{
+		oneTimeToken: *newOneTimeToken,
+		expiresAt:    time.Now().Add(expireDuration),
+	}
+
+	return nil
+}
+
+func (oneTimeTokenRepository *oneTimeTokenRepository) Consume(ctx context.Context, purpose string, token string) (*model.OneTimeToken, error) {
+	oneTimeTokenRepository.mutex.Lock()
+	defer oneTimeTokenRepository.mutex.Unlock()
+
+	key := oneTimeTokenKey(purpose, token)
+	expiring, ok := oneTimeTokenRepository.oneTimeTokens[key]
+	delete(oneTimeTokenRepository.oneTimeTokens, key)
+	if !ok || !time.Now().Before(expiring.expiresAt) {
+		return nil, repository.ErrOneTimeTokenNotFound
+	}
+
+	oneTimeToken := expiring.oneTimeToken
+	oneTimeToken.Purpose = purpose
+
+	return &oneTimeToken, nil
+}
+
+func oneTimeTokenKey(purpose string, token string) string {
+	return purpose + ":" + utils.HashSecret(token)
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"time"
+)
+
+type outboxEventRepository struct {
+	outboxEvents *table[model.OutboxEvent]
+}
+
+func NewOutboxEventRepository() repository.OutboxEventRepository {
+	return &outboxEventRepository{
+		outboxEvents: newTable(func(outboxEvent *model.OutboxEvent) *int64 { return &outboxEvent.Id }),
+	}
+}
+
+func (outboxEventRepository *outboxEventRepository) ClaimDue(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]model.OutboxEvent, error) {
+	outboxEvents := outboxEventRepository.outboxEvents.filter(func(outboxEvent *model.OutboxEvent) bool {
+		return outboxEvent.Status == model.OutboxEventStatusPending && !outboxEvent.NextAttemptAt.After(now)
+	})
+	if len(outboxEvents) > limit {
+		outboxEvents = outboxEvents[:limit]
+	}
+
+	for i := range outboxEvents {
+		outboxEvents[i].NextAttemptAt = leaseUntil
+		outboxEvents[i].UpdatedAt = now
+		if err := outboxEventRepository.Update(ctx, &outboxEvents[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return outboxEvents, nil
+}
+
+// Events not dispatched yet, claimed ones included, so tests can inspect them without claiming
+func (outboxEventRepository *outboxEventRepository) GetPending() []model.OutboxEvent {
+	return outboxEventRepository.outboxEvents.filter(func(outboxEvent *model.OutboxEvent) bool {
+		return outboxEvent.Status == model.OutboxEventStatusPending
+	})
+}
+
+func (outboxEventRepository *outboxEventRepository) CreateMany(ctx context.Context, newOutboxEvents []model.OutboxEvent) error {
+	for i := range newOutboxEvents {
+		defaultNow(&newOutboxEvents[i].NextAttemptAt, &newOutboxEvents[i].CreatedAt, &newOutboxEvents[i].UpdatedAt)
+		outboxEventRepository.outboxEvents.insert(&newOutboxEvents[i])
+	}
+	return nil
+}
+
+func (outboxEventRepository *outboxEventRepository) GetAggregateIdsSince(ctx context.Context, aggregateType string, since time.Time) ([]int64, error) {
+	ids := []int64{}
+	for _, outboxEvent := range outboxEventRepository.outboxEvents.filter(func(outboxEvent *model.OutboxEvent) bool {
+		return outboxEvent.AggregateType == aggregateType && !outboxEvent.CreatedAt.Before(since)
+	}) {
+		if !slices.Contains(ids, outboxEvent.AggregateId) {
+			ids = append(ids, outboxEvent.AggregateId)
+		}
+	}
+
+	return ids, nil
+}
+
+// Only dispatch state is written, like the column list of the SQL update
+func (outboxEventRepository *outboxEventRepository) Update(ctx context.Context, updatedOutboxEvent *model.OutboxEvent) error {
+	outboxEventRepository.outboxEvents.update(func(outboxEvent *model.OutboxEvent) bool {
+		return outboxEvent.Id == updatedOutboxEvent.Id
+	}, func(outboxEvent *model.OutboxEvent) {
+		outboxEvent.Status = updatedOutboxEvent.Status
+		outboxEvent.Attempts = updatedOutboxEvent.Attempts
+		outboxEvent.LastError = updatedOutboxEvent.LastError
+		outboxEvent.NextAttemptAt = updatedOutboxEvent.NextAttemptAt
+		outboxEvent.UpdatedAt = updatedOutboxEvent.UpdatedAt
+	})
+	return nil
+}
+
+func (outboxEventRepository *outboxEventRepository) DeleteDoneBefore(ctx context.Context, before time.Time) error {
+	outboxEventRepository.outboxEvents.delete(func(outboxEvent *model.OutboxEvent) bool {
+		return outboxEvent.Status == model.OutboxEventStatusDone && outboxEvent.UpdatedAt.Before(before)
+	})
+	return nil
+}
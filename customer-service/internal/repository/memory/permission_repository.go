@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+)
+
+type permissionRepository struct {
+	permissions []model.Permission
+}
+
+// Permissions are seeded with the schema and never written by the application, so they are given up front
+func NewPermissionRepository(permissions ...model.Permission) repository.PermissionRepository {
+	sortedPermissions := slices.Clone(permissions)
+	sort.Slice(sortedPermissions, func(i, j int) bool {
+		return sortedPermissions[i].Name < sortedPermissions[j].Name
+	})
+
+	return &permissionRepository{permissions: sortedPermissions}
+}
+
+func (permissionRepository *permissionRepository) Get(ctx context.Context) ([]model.Permission, error) {
+	return slices.Clone(permissionRepository.permissions), nil
+}
+
+func (permissionRepository *permissionRepository) GetByNames(ctx context.Context, names []string) ([]model.Permission, error) {
+	permissions := []model.Permission{}
+	for _, permission := range permissionRepository.permissions {
+		if slices.Contains(names, permission.Name) {
+			permissions = append(permissions, permission)
+		}
+	}
+
+	return permissions, nil
+}
@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sort"
+	"sync"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+)
+
+type roleRepository struct {
+	mutex           sync.RWMutex
+	roles           map[string]model.Role
+	permissionNames map[string][]string
+}
+
+func NewRoleRepository() repository.RoleRepository {
+	return &roleRepository{
+		roles:           map[string]model.Role{},
+		permissionNames: map[string][]string{},
+	}
+}
+
+func (roleRepository *roleRepository) Get(ctx context.Context) ([]model.Role, error) {
+	roleRepository.mutex.RLock()
+	defer roleRepository.mutex.RUnlock()
+
+	roles := []model.Role{}
+	for _, role := range roleRepository.roles {
+		role.Permissions = append([]string{}, roleRepository.permissionNames[role.Name]...)
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].Name < roles[j].Name
+	})
+
+	return roles, nil
+}
+
+func (roleRepository *roleRepository) GetByName(ctx context.Context, name string) (*model.Role, error) {
+	roleRepository.mutex.RLock()
+	defer roleRepository.mutex.RUnlock()
+
+	role, ok := roleRepository.roles[name]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	role.Permissions = append([]string{}, roleRepository.permissionNames[name]...)
+
+	return &role, nil
+}
+
+func (roleRepository *roleRepository) Create(ctx context.Context, newRole *model.Role) error {
+	roleRepository.mutex.Lock()
+	defer roleRepository.mutex.Unlock()
+
+	defaultNow(&newRole.CreatedAt)
+	role := *newRole
+	role.Permissions = nil
+	roleRepository.roles[newRole.Name] = role
+
+	return nil
+}
+
+// Permissions of the role go with it, like the cascading foreign key
+func (roleRepository *roleRepository) DeleteByName(ctx context.Context, name string) error {
+	roleRepository.mutex.Lock()
+	defer roleRepository.mutex.Unlock()
+
+	delete(roleRepository.roles, name)
+	delete(roleRepository.permissionNames, name)
+
+	return nil
+}
+
+func (roleRepository *roleRepository) GetPermissionNames(ctx context.Context, roleName string) ([]string, error) {
+	roleRepository.mutex.RLock()
+	defer roleRepository.mutex.RUnlock()
+
+	return append([]string{}, roleRepository.permissionNames[roleName]...), nil
+}
+
+// Permissions the role already has are skipped, like ON CONFLICT DO NOTHING
+func (roleRepository *roleRepository) AddPermissions(ctx context.Context, roleName string, permissionNames []string) error {
+	roleRepository.mutex.Lock()
+	defer roleRepository.mutex.Unlock()
+
+	for _, permissionName := range permissionNames {
+		if !slices.Contains(roleRepository.permissionNames[roleName], permissionName) {
+			roleRepository.permissionNames[roleName] = append(roleRepository.permissionNames[roleName], permissionName)
+		}
+	}
+	sort.Strings(roleRepository.permissionNames[roleName])
+
+	return nil
+}
+
+func (roleRepository *roleRepository) RemovePermission(ctx context.Context, roleName string, permissionName string) error {
+	roleRepository.mutex.Lock()
+	defer roleRepository.mutex.Unlock()
+
+	roleRepository.permissionNames[roleName] = slices.DeleteFunc(roleRepository.permissionNames[roleName], func(name string) bool {
+		return name == permissionName
+	})
+
+	return nil
+}
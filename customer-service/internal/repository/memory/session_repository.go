@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"time"
+)
+
+type expiringSession struct {
+	session   model.Session
+	expiresAt time.Time
+}
+
+type sessionRepository struct {
+	mutex            sync.Mutex
+	sessions         map[string]expiringSession
+	deniedExpiresAts map[string]time.Time
+}
+
+func NewSessionRepository() repository.SessionRepository {
+	return &sessionRepository{
+		sessions:         map[string]expiringSession{},
+		deniedExpiresAts: map[string]time.Time{},
+	}
+}
+
+func (sessionRepository *sessionRepository) GetById(ctx context.Context, id string) (*model.Session, error) {
+	sessionRepository.mutex.Lock()
+	defer sessionRepository.mutex.Unlock()
+
+	session, ok := sessionRepository.getLocked(id)
+	if !ok {
+		return nil, repository.ErrSessionNotFound
+	}
+
+	return &session, nil
+}
+
+func (sessionRepository *sessionRepository) GetByUserId(ctx context.Context, userId int64) ([]model.Session, error) {
+	sessionRepository.mutex.Lock()
+	defer sessionRepository.mutex.Unlock()
+
+	sessions := []model.Session{}
+	for id := range sessionRepository.sessions {
+		if session, ok := sessionRepository.getLocked(id); ok && session.UserId == userId {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+	})
+
+	return sessions, nil
+}
+
+func (sessionRepository *sessionRepository) Create(ctx context.Context, newSession *model.Session, expireDuration time.Duration) error {
+	sessionRepository.mutex.Lock()
+	defer sessionRepository.mutex.Unlock()
+
+	sessionRepository.sessions[newSession.Id] = expiringSession{
+		session:   *newSession,
+		expiresAt: time.Now().Add(expireDuration),
+	}
+
+	return nil
+}
+
+// A stale refresh token revokes the whole session, same as the Redis script
+func (sessionRepository *sessionRepository) Rotate(ctx context.Context, id string, refreshTokenHash string, newRefreshTokenHash string, accessTokenId string, accessTokenExpiresAt time.Time, expireDuration time.Duration) error {
+	sessionRepository.mutex.Lock()
+	defer sessionRepository.mutex.Unlock()
+
+	session, ok := sessionRepository.getLocked(id)
+	if !ok {
+		return repository.ErrSessionNotFound
+	}
+	if session.RefreshTokenHash != refreshTokenHash {
+		delete(sessionRepository.sessions, id)
+		sessionRepository.denyLocked(session.AccessTokenId, session.AccessTokenExpiresAt)
+		return repository.ErrRefreshTokenReused
+	}
+
+	session.RefreshTokenHash = newRefreshTokenHash
+	session.AccessTokenId = accessTokenId
+	session.AccessTokenExpiresAt = accessTokenExpiresAt
+	sessionRepository.sessions[id] = expiringSession{
+		session:   session,
+		expiresAt: time.Now().Add(expireDuration),
+	}
+
+	return nil
+}
+
+func (sessionRepository *sessionRepository) DeleteById(ctx context.Context, id string) (*model.Session, error) {
+	sessionRepository.mutex.Lock()
+	defer sessionRepository.mutex.Unlock()
+
+	session, ok := sessionRepository.getLocked(id)
+	if !ok {
+		return nil, repository.ErrSessionNotFound
+	}
+	delete(sessionRepository.sessions, id)
+
+	return &session, nil
+}
+
+func (sessionRepository *sessionRepository) DeleteByUserId(ctx context.Context, userId int64, exceptId string) ([]model.Session, error) {
+	sessionRepository.mutex.Lock()
+	defer sessionRepository.mutex.Unlock()
+
+	sessions := []model.Session{}
+	for id := range sessionRepository.sessions {
+		session, ok := sessionRepository.getLocked(id)
+		if !ok || session.UserId != userId || id == exceptId {
+			continue
+		}
+		delete(sessionRepository.sessions, id)
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+func (sessionRepository *sessionRepository) DenyAccessToken(ctx context.Context, accessTokenId string, accessTokenExpiresAt time.Time) error {
+	sessionRepository.mutex.Lock()
+	defer sessionRepository.mutex.Unlock()
+
+	sessionRepository.denyLocked(accessTokenId, accessTokenExpiresAt)
+
+	return nil
+}
+
+// Tells whether an access token was denied and has not expired yet, the auth middleware reads this from Redis
+func (sessionRepository *sessionRepository) IsAccessTokenDenied(accessTokenId string) bool {
+	sessionRepository.mutex.Lock()
+	defer sessionRepository.mutex.Unlock()
+
+	return time.Now().Before(sessionRepository.deniedExpiresAts[accessTokenId])
+}
+
+// Expired sessions are dropped on read, like keys Redis let expire
+func (sessionRepository *sessionRepository) getLocked(id string) (model.Session, bool) {
+	expiring, ok := sessionRepository.sessions[id]
+	if !ok {
+		return model.Session{}, false
+	}
+	if !time.Now().Before(expiring.expiresAt) {
+		delete(sessionRepository.sessions, id)
+		return model.Session{}, false
+	}
+
+	return expiring.session, true
+}
+
+func (sessionRepository *sessionRepository) denyLocked(accessTokenId string, accessTokenExpiresAt time.Time) {
+	if accessTokenId == "" || !time.Now().Before(accessTokenExpiresAt) {
+		return
+	}
+	sessionRepository.deniedExpiresAts[accessTokenId] = accessTokenExpiresAt
+}
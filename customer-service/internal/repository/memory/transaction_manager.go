@@ -0,0 +1,18 @@
+package memory
+
+import (
+	"context"
+	"thanhldt060802/internal/repository"
+)
+
+type transactionManager struct {
+}
+
+func NewTransactionManager() repository.TransactionManager {
+	return &transactionManager{}
+}
+
+// Runs fn as is, writes made before fn fails are not rolled back
+func (transactionManager *transactionManager) RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
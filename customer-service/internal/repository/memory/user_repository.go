@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"thanhldt060802/utils"
+)
+
+type userRepository struct {
+	users *table[model.User]
+}
+
+func NewUserRepository() repository.UserRepository {
+	return &userRepository{
+		users: newTable(func(user *model.User) *int64 { return &user.Id }),
+	}
+}
+
+func (userRepository *userRepository) Get(ctx context.Context, pageRequest *utils.PageRequest) ([]model.User, *utils.Page, error) {
+	return paginate(userRepository.users.filter(nil), pageRequest)
+}
+
+func (userRepository *userRepository) GetById(ctx context.Context, id int64) (*model.User, error) {
+	return userRepository.users.getById(id)
+}
+
+func (userRepository *userRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	return userRepository.users.find(func(user *model.User) bool {
+		return user.Username == username
+	})
+}
+
+func (userRepository *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	return userRepository.users.find(func(user *model.User) bool {
+		return user.Email == email
+	})
+}
+
+func (userRepository *userRepository) CountByRoleName(ctx context.Context, roleName string) (int, error) {
+	return userRepository.users.count(func(user *model.User) bool {
+		return user.RoleName == roleName
+	}), nil
+}
+
+func (userRepository *userRepository) Create(ctx context.Context, newUser *model.User) error {
+	defaultNow(&newUser.CreatedAt, &newUser.UpdatedAt)
+	userRepository.users.insert(newUser)
+	return nil
+}
+
+func (userRepository *userRepository) UpdateById(ctx context.Context, id int64, updatedUser *model.User) error {
+	userRepository.users.updateById(id, updatedUser)
+	return nil
+}
+
+func (userRepository *userRepository) DeleteById(ctx context.Context, id int64) error {
+	userRepository.users.delete(func(user *model.User) bool {
+		return user.Id == id
+	})
+	return nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/utils"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrOneTimeTokenNotFound = errors.New("token is not valid or expired")
+
+type oneTimeTokenRepository struct {
+	redisClient *redis.Client
+}
+
+type OneTimeTokenRepository interface {
+	Create(ctx context.Context, token string, newOneTimeToken *model.OneTimeToken, expireDuration time.Duration) error
+	Consume(ctx context.Context, purpose string, token string) (*model.OneTimeToken, error)
+}
+
+func NewOneTimeTokenRepository(redisClient *redis.Client) OneTimeTokenRepository {
+	return &oneTimeTokenRepository{redisClient: redisClient}
+}
+
+func (oneTimeTokenRepository *oneTimeTokenRepository) Create(ctx context.Context, token string, newOneTimeToken *model.OneTimeToken, expireDuration time.Duration) error {
+	value, err := json.Marshal(newOneTimeToken)
+	if err != nil {
+		return err
+	}
+
+	return oneTimeTokenRepository.redisClient.SetEx(ctx, oneTimeTokenKey(newOneTimeToken.Purpose, token), value, expireDuration).Err()
+}
+
+// Token is read and deleted in one command so it can never be used twice
+func (oneTimeTokenRepository *oneTimeTokenRepository) Consume(ctx context.Context, purpose string, token string) (*model.OneTimeToken, error) {
+	value, err := oneTimeTokenRepository.redisClient.GetDel(ctx, oneTimeTokenKey(purpose, token)).Result()
+	if err == redis.Nil {
+		return nil, ErrOneTimeTokenNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	oneTimeToken := &model.OneTimeToken{}
+	if err := json.Unmarshal([]byte(value), oneTimeToken); err != nil {
+		return nil, err
+	}
+	oneTimeToken.Purpose = purpose
+
+	return oneTimeToken, nil
+}
+
+func oneTimeTokenKey(purpose string, token string) string {
+	return fmt.Sprintf("one-time-token:%s:%s", purpose, utils.HashSecret(token))
+}
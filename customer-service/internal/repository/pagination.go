@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"thanhldt060802/utils"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+// Keyset pagination over rows of T: rows after (or before) the cursor are found by comparing sort columns,
+// so pages stay stable while rows are inserted and deep pages cost the same as the first one.
+// Filter narrows the query and is reused as is by the optional count.
+func paginate[T any](ctx context.Context, db *bun.DB, pageRequest *utils.PageRequest, filter func(query *bun.SelectQuery) *bun.SelectQuery) ([]T, *utils.Page, error) {
+	// Only real columns can be sorted by, which also keeps user input out of ORDER BY
+	table := getDB(ctx, db).Dialect().Tables().Get(reflect.TypeOf((*T)(nil)).Elem())
+	fields := make([]*schema.Field, len(pageRequest.SortFields))
+	for i, sortField := range pageRequest.SortFields {
+		field, ok := table.FieldMap[sortField.Field]
+		// Nullable columns have no position to continue from
+		if !ok || field.IsPtr || field.Tag.HasOption("nullzero") {
+			return nil, nil, fmt.Errorf("%w: can not sort by %s", utils.ErrInvalidPagination, sortField.Field)
+		}
+		fields[i] = field
+	}
+	if pageRequest.Cursor != nil && len(pageRequest.Cursor.Values) != len(fields) {
+		return nil, nil, fmt.Errorf("%w: cursor does not match sort_by", utils.ErrInvalidPagination)
+	}
+
+	backward := pageRequest.Cursor != nil && pageRequest.Cursor.Backward
+
+	var items []T
+	query := getDB(ctx, db).NewSelect().Model(&items)
+	if filter != nil {
+		query = filter(query)
+	}
+
+	// (a > x) OR (a = x AND b > y) OR ..., comparisons flip for descending fields and when paging backward
+	if pageRequest.Cursor != nil {
+		values := pageRequest.Cursor.Values
+		query = query.WhereGroup(" AND ", func(query *bun.SelectQuery) *bun.SelectQuery {
+			for i := range fields {
+				query = query.WhereGroup(" OR ", func(query *bun.SelectQuery) *bun.SelectQuery {
+					for j := 0; j < i; j++ {
+						query = query.Where("? = ?", bun.Ident(fields[j].Name), values[j])
+					}
+					operator := ">"
+					if (pageRequest.SortFields[i].Direction == "DESC") != backward {
+						operator = "<"
+					}
+					return query.Where(fmt.Sprintf("? %s ?", operator), bun.Ident(fields[i].Name), values[i])
+				})
+			}
+			return query
+		})
+	}
+
+	for i, sortField := range pageRequest.SortFields {
+		direction := sortField.Direction
+		if backward {
+			direction = reverseDirection(direction)
+		}
+		query = query.OrderExpr(fmt.Sprintf("? %s", direction), bun.Ident(fields[i].Name))
+	}
+
+	// One extra row tells whether another page exists
+	if err := query.Limit(pageRequest.Limit + 1).Scan(ctx); err != nil {
+		return nil, nil, err
+	}
+	hasMore := len(items) > pageRequest.Limit
+	if hasMore {
+		items = items[:pageRequest.Limit]
+	}
+	if backward {
+		slices.Reverse(items)
+	}
+
+	page := pageRequest.NewPage(nil, nil, false)
+	if len(items) > 0 {
+		page = pageRequest.NewPage(sortValues(fields, &items[0]), sortValues(fields, &items[len(items)-1]), hasMore)
+	}
+
+	if pageRequest.IncludeTotal {
+		countQuery := getDB(ctx, db).NewSelect().Model((*T)(nil))
+		if filter != nil {
+			countQuery = filter(countQuery)
+		}
+		count, err := countQuery.Count(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		total := int64(count)
+		page.Total = &total
+	}
+
+	return items, page, nil
+}
+
+func sortValues[T any](fields []*schema.Field, item *T) []any {
+	values := make([]any, len(fields))
+	for i, field := range fields {
+		values[i] = field.Value(reflect.ValueOf(item).Elem()).Interface()
+	}
+
+	return values
+}
+
+func reverseDirection(direction string) string {
+	if direction == "DESC" {
+		return "ASC"
+	}
+	return "DESC"
+}
+
+// Sort of an Elasticsearch search paged with search_after, reversed when paging backward like the SQL one,
+// relevance goes first when searching by text
+func elasticsearchSort(pageRequest *utils.PageRequest, fieldMap map[string]string, byScore bool) ([]map[string]interface{}, error) {
+	backward := pageRequest.Cursor != nil && pageRequest.Cursor.Backward
+
+	sortFields := []map[string]interface{}{}
+	if byScore {
+		direction := "DESC"
+		if backward {
+			direction = reverseDirection(direction)
+		}
+		sortFields = append(sortFields, map[string]interface{}{"_score": direction})
+	}
+	for _, sortField := range pageRequest.SortFields {
+		field, ok := fieldMap[sortField.Field]
+		if !ok {
+			return nil, fmt.Errorf("%w: can not sort by %s", utils.ErrInvalidPagination, sortField.Field)
+		}
+		direction := sortField.Direction
+		if backward {
+			direction = reverseDirection(direction)
+		}
+		sortFields = append(sortFields, map[string]interface{}{field: direction})
+	}
+	if pageRequest.Cursor != nil && len(pageRequest.Cursor.Values) != len(sortFields) {
+		return nil, fmt.Errorf("%w: cursor does not match sort_by", utils.ErrInvalidPagination)
+	}
+
+	return sortFields, nil
+}
+
+// Hit of an Elasticsearch search, sort values of the hit are where the next search_after starts
+type elasticsearchHit[T any] struct {
+	Source    T                   `json:"_source"`
+	Highlight map[string][]string `json:"highlight"`
+	Sort      []any               `json:"sort"`
+}
+
+// Hits are fetched one over the limit, the extra one is dropped and a backward page is put back in natural order
+func elasticsearchPage[T any](pageRequest *utils.PageRequest, hits []elasticsearchHit[T]) ([]elasticsearchHit[T], *utils.Page) {
+	hasMore := len(hits) > pageRequest.Limit
+	if hasMore {
+		hits = hits[:pageRequest.Limit]
+	}
+	if pageRequest.Cursor != nil && pageRequest.Cursor.Backward {
+		slices.Reverse(hits)
+	}
+
+	if len(hits) == 0 {
+		return hits, pageRequest.NewPage(nil, nil, false)
+	}
+	return hits, pageRequest.NewPage(hits[0].Sort, hits[len(hits)-1].Sort, hasMore)
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"thanhldt060802/internal/model"
+
+	"github.com/uptrace/bun"
+)
+
+type permissionRepository struct {
+	db *bun.DB
+}
+
+type PermissionRepository interface {
+	Get(ctx context.Context) ([]model.Permission, error)
+	GetByNames(ctx context.Context, names []string) ([]model.Permission, error)
+}
+
+func NewPermissionRepository(db *bun.DB) PermissionRepository {
+	return &permissionRepository{db: db}
+}
+
+func (permissionRepository *permissionRepository) Get(ctx context.Context) ([]model.Permission, error) {
+	var permissions []model.Permission
+	if err := getDB(ctx, permissionRepository.db).NewSelect().Model(&permissions).Order("name ASC").Scan(ctx); err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+func (permissionRepository *permissionRepository) GetByNames(ctx context.Context, names []string) ([]model.Permission, error) {
+	var permissions []model.Permission
+	if err := getDB(ctx, permissionRepository.db).NewSelect().Model(&permissions).Where("name IN (?)", bun.In(names)).Scan(ctx); err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"thanhldt060802/internal/model"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	productEventGroup = "customer-service"
+
+	// Events a consumer read but did not acknowledge for this long are read again, by any consumer of the group
+	productEventClaimMinIdle = time.Minute
+)
+
+type productEventRepository struct {
+	redisClient *redis.Client
+	consumer    string
+}
+
+type ProductEventRepository interface {
+	Read(ctx context.Context, count int) ([]model.ProductEvent, error)
+	Ack(ctx context.Context, ids ...string) error
+	DeadLetter(ctx context.Context, productEvent *model.ProductEvent, reason string) error
+}
+
+// Consumer names this replica in the group, replicas share events instead of each handling all of them
+func NewProductEventRepository(redisClient *redis.Client, consumer string) ProductEventRepository {
+	return &productEventRepository{
+		redisClient: redisClient,
+		consumer:    consumer,
+	}
+}
+
+// Events left unacknowledged long enough come first, then new ones, at most count in total
+func (productEventRepository *productEventRepository) Read(ctx context.Context, count int) ([]model.ProductEvent, error) {
+	// Group starts from the beginning of stream, handling an event twice is harmless
+	err := productEventRepository.redisClient.XGroupCreateMkStream(ctx, model.ProductEventStream, productEventGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, err
+	}
+
+	messages, _, err := productEventRepository.redisClient.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   model.ProductEventStream,
+		Group:    productEventGroup,
+		Consumer: productEventRepository.consumer,
+		MinIdle:  productEventClaimMinIdle,
+		Start:    "0-0",
+		Count:    int64(count),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	// Claimed events were delivered before, their count tells the caller when to give up on them
+	deliveries := map[string]int64{}
+	if len(messages) > 0 {
+		pendingEntries, err := productEventRepository.redisClient.XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream:   model.ProductEventStream,
+			Group:    productEventGroup,
+			Start:    messages[0].ID,
+			End:      messages[len(messages)-1].ID,
+			Count:    int64(len(messages)),
+			Consumer: productEventRepository.consumer,
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, pendingEntry := range pendingEntries {
+			deliveries[pendingEntry.ID] = pendingEntry.RetryCount
+		}
+	}
+
+	if len(messages) < count {
+		streams, err := productEventRepository.redisClient.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    productEventGroup,
+			Consumer: productEventRepository.consumer,
+			Streams:  []string{model.ProductEventStream, ">"},
+			Count:    int64(count - len(messages)),
+			Block:    -1,
+		}).Result()
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+		for _, stream := range streams {
+			messages = append(messages, stream.Messages...)
+		}
+	}
+
+	productEvents := make([]model.ProductEvent, len(messages))
+	for i, message := range messages {
+		productEvents[i] = toProductEvent(message)
+		productEvents[i].Deliveries = max(deliveries[message.ID], 1)
+	}
+
+	return productEvents, nil
+}
+
+func (productEventRepository *productEventRepository) Ack(ctx context.Context, ids ...string) error {
+	return productEventRepository.redisClient.XAck(ctx, model.ProductEventStream, productEventGroup, ids...).Err()
+}
+
+// Event is copied to the dead-letter stream and acknowledged at once, so it is neither lost nor read again
+func (productEventRepository *productEventRepository) DeadLetter(ctx context.Context, productEvent *model.ProductEvent, reason string) error {
+	_, err := productEventRepository.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: model.ProductEventDeadLetterStream,
+			Values: map[string]any{
+				"event_id":   productEvent.Id,
+				"type":       productEvent.Type,
+				"product_id": productEvent.ProductId,
+				"deliveries": productEvent.Deliveries,
+				"reason":     reason,
+			},
+		})
+		pipe.XAck(ctx, model.ProductEventStream, productEventGroup, productEvent.Id)
+		return nil
+	})
+
+	return err
+}
+
+// Malformed fields are left zero, the caller decides what to do with such an event
+func toProductEvent(message redis.XMessage) model.ProductEvent {
+	productEvent := model.ProductEvent{Id: message.ID}
+	if eventType, ok := message.Values["type"].(string); ok {
+		productEvent.Type = eventType
+	}
+	if productId, ok := message.Values["product_id"].(string); ok {
+		productEvent.ProductId, _ = strconv.ParseInt(productId, 10, 64)
+	}
+
+	return productEvent
+}
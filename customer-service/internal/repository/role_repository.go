@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"thanhldt060802/internal/model"
+
+	"github.com/uptrace/bun"
+)
+
+type roleRepository struct {
+	db *bun.DB
+}
+
+type RoleRepository interface {
+	Get(ctx context.Context) ([]model.Role, error)
+	GetByName(ctx context.Context, name string) (*model.Role, error)
+	Create(ctx context.Context, newRole *model.Role) error
+	DeleteByName(ctx context.Context, name string) error
+
+	GetPermissionNames(ctx context.Context, roleName string) ([]string, error)
+	AddPermissions(ctx context.Context, roleName string, permissionNames []string) error
+	RemovePermission(ctx context.Context, roleName string, permissionName string) error
+}
+
+func NewRoleRepository(db *bun.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+func (roleRepository *roleRepository) Get(ctx context.Context) ([]model.Role, error) {
+	var roles []model.Role
+	if err := getDB(ctx, roleRepository.db).NewSelect().Model(&roles).Order("name ASC").Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	var rolePermissions []model.RolePermission
+	if err := getDB(ctx, roleRepository.db).NewSelect().Model(&rolePermissions).Order("role_name ASC", "permission_name ASC").Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	permissionNames := map[string][]string{}
+	for _, rolePermission := range rolePermissions {
+		permissionNames[rolePermission.RoleName] = append(permissionNames[rolePermission.RoleName], rolePermission.PermissionName)
+	}
+	for i := range roles {
+		roles[i].Permissions = permissionNames[roles[i].Name]
+	}
+
+	return roles, nil
+}
+
+func (roleRepository *roleRepository) GetByName(ctx context.Context, name string) (*model.Role, error) {
+	var role model.Role
+	if err := getDB(ctx, roleRepository.db).NewSelect().Model(&role).Where("name = ?", name).Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	permissionNames, err := roleRepository.GetPermissionNames(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	role.Permissions = permissionNames
+
+	return &role, nil
+}
+
+func (roleRepository *roleRepository) Create(ctx context.Context, newRole *model.Role) error {
+	_, err := getDB(ctx, roleRepository.db).NewInsert().Model(newRole).Returning("*").Exec(ctx)
+	return err
+}
+
+func (roleRepository *roleRepository) DeleteByName(ctx context.Context, name string) error {
+	_, err := getDB(ctx, roleRepository.db).NewDelete().Model((*model.Role)(nil)).Where("name = ?", name).Exec(ctx)
+	return err
+}
+
+func (roleRepository *roleRepository) GetPermissionNames(ctx context.Context, roleName string) ([]string, error) {
+	permissionNames := []string{}
+	err := getDB(ctx, roleRepository.db).NewSelect().Model((*model.RolePermission)(nil)).Column("permission_name").
+		Where("role_name = ?", roleName).
+		Order("permission_name ASC").
+		Scan(ctx, &permissionNames)
+	if err != nil {
+		return nil, err
+	}
+	return permissionNames, nil
+}
+
+func (roleRepository *roleRepository) AddPermissions(ctx context.Context, roleName string, permissionNames []string) error {
+	rolePermissions := make([]model.RolePermission, len(permissionNames))
+	for i, permissionName := range permissionNames {
+		rolePermissions[i] = model.RolePermission{
+			RoleName:       roleName,
+			PermissionName: permissionName,
+		}
+	}
+
+	_, err := getDB(ctx, roleRepository.db).NewInsert().Model(&rolePermissions).On("CONFLICT DO NOTHING").Exec(ctx)
+	return err
+}
+
+func (roleRepository *roleRepository) RemovePermission(ctx context.Context, roleName string, permissionName string) error {
+	_, err := getDB(ctx, roleRepository.db).NewDelete().Model((*model.RolePermission)(nil)).
+		Where("role_name = ?", roleName).
+		Where("permission_name = ?", permissionName).
+		Exec(ctx)
+	return err
+}
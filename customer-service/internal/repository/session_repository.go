@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"thanhldt060802/internal/model"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrSessionNotFound    = errors.New("session not found or expired")
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// Swaps refresh token hash only if caller holds the current one, a stale token means it was stolen and revokes the whole session
+var rotateSessionScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return {0}
+end
+if redis.call('HGET', KEYS[1], 'refresh_token_hash') ~= ARGV[1] then
+	local session = redis.call('HMGET', KEYS[1], 'access_token_id', 'access_token_expires_at')
+	redis.call('DEL', KEYS[1])
+	return {-1, session[1], session[2]}
+end
+redis.call('HSET', KEYS[1], 'refresh_token_hash', ARGV[2], 'access_token_id', ARGV[3], 'access_token_expires_at', ARGV[4])
+redis.call('EXPIRE', KEYS[1], ARGV[5])
+return {1}
+`)
+
+type sessionRepository struct {
+	redisClient *redis.Client
+}
+
+type SessionRepository interface {
+	GetById(ctx context.Context, id string) (*model.Session, error)
+	GetByUserId(ctx context.Context, userId int64) ([]model.Session, error)
+	Create(ctx context.Context, newSession *model.Session, expireDuration time.Duration) error
+	Rotate(ctx context.Context, id string, refreshTokenHash string, newRefreshTokenHash string, accessTokenId string, accessTokenExpiresAt time.Time, expireDuration time.Duration) error
+	DeleteById(ctx context.Context, id string) (*model.Session, error)
+	DeleteByUserId(ctx context.Context, userId int64, exceptId string) ([]model.Session, error)
+
+	DenyAccessToken(ctx context.Context, accessTokenId string, accessTokenExpiresAt time.Time) error
+}
+
+func NewSessionRepository(redisClient *redis.Client) SessionRepository {
+	return &sessionRepository{redisClient: redisClient}
+}
+
+func (sessionRepository *sessionRepository) GetById(ctx context.Context, id string) (*model.Session, error) {
+	values, err := sessionRepository.redisClient.HGetAll(ctx, sessionKey(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, ErrSessionNotFound
+	}
+
+	return toSession(id, values), nil
+}
+
+// Newest sessions come first, ids of expired sessions are dropped from the index on the way
+func (sessionRepository *sessionRepository) GetByUserId(ctx context.Context, userId int64) ([]model.Session, error) {
+	ids, err := sessionRepository.redisClient.SMembers(ctx, userSessionsKey(userId)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := sessionRepository.redisClient.Pipeline()
+	getCmds := make([]*redis.MapStringStringCmd, len(ids))
+	for i, id := range ids {
+		getCmds[i] = pipe.HGetAll(ctx, sessionKey(id))
+	}
+	if len(ids) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	sessions := []model.Session{}
+	expiredIds := []any{}
+	for i, id := range ids {
+		if len(getCmds[i].Val()) == 0 {
+			expiredIds = append(expiredIds, id)
+			continue
+		}
+		sessions = append(sessions, *toSession(id, getCmds[i].Val()))
+	}
+	if len(expiredIds) > 0 {
+		if err := sessionRepository.redisClient.SRem(ctx, userSessionsKey(userId), expiredIds...).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+	})
+
+	return sessions, nil
+}
+
+func (sessionRepository *sessionRepository) Create(ctx context.Context, newSession *model.Session, expireDuration time.Duration) error {
+	pipe := sessionRepository.redisClient.TxPipeline()
+	pipe.HSet(ctx, sessionKey(newSession.Id),
+		"user_id", newSession.UserId,
+		"refresh_token_hash", newSession.RefreshTokenHash,
+		"access_token_id", newSession.AccessTokenId,
+		"access_token_expires_at", newSession.AccessTokenExpiresAt.Unix(),
+		"device", newSession.Device,
+		"ip_address", newSession.IpAddress,
+		"user_agent", newSession.UserAgent,
+		"created_at", newSession.CreatedAt.Unix(),
+	)
+	pipe.Expire(ctx, sessionKey(newSession.Id), expireDuration)
+	// Index lives as long as the newest session of the user
+	pipe.SAdd(ctx, userSessionsKey(newSession.UserId), newSession.Id)
+	pipe.Expire(ctx, userSessionsKey(newSession.UserId), expireDuration)
+	_, err := pipe.Exec(ctx)
+
+	return err
+}
+
+func (sessionRepository *sessionRepository) Rotate(ctx context.Context, id string, refreshTokenHash string, newRefreshTokenHash string, accessTokenId string, accessTokenExpiresAt time.Time, expireDuration time.Duration) error {
+	result, err := rotateSessionScript.Run(ctx, sessionRepository.redisClient, []string{sessionKey(id)},
+		refreshTokenHash, newRefreshTokenHash, accessTokenId, accessTokenExpiresAt.Unix(), int64(expireDuration/time.Second),
+	).Slice()
+	if err != nil {
+		return err
+	}
+
+	switch result[0].(int64) {
+	case 0:
+		return ErrSessionNotFound
+	case -1:
+		// Access token issued with the stolen refresh token must die with the session
+		reusedAccessTokenId, _ := result[1].(string)
+		reusedAccessTokenExpiresAt, _ := result[2].(string)
+		if reusedAccessTokenId != "" {
+			expiresAtUnix, _ := strconv.ParseInt(reusedAccessTokenExpiresAt, 10, 64)
+			if err := sessionRepository.DenyAccessToken(ctx, reusedAccessTokenId, time.Unix(expiresAtUnix, 0)); err != nil {
+				return err
+			}
+		}
+		return ErrRefreshTokenReused
+	}
+
+	return nil
+}
+
+func (sessionRepository *sessionRepository) DeleteById(ctx context.Context, id string) (*model.Session, error) {
+	pipe := sessionRepository.redisClient.TxPipeline()
+	getCmd := pipe.HGetAll(ctx, sessionKey(id))
+	pipe.Del(ctx, sessionKey(id))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	if len(getCmd.Val()) == 0 {
+		return nil, ErrSessionNotFound
+	}
+
+	session := toSession(id, getCmd.Val())
+	if err := sessionRepository.redisClient.SRem(ctx, userSessionsKey(session.UserId), id).Err(); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// Index may still hold ids of sessions that already expired, those are skipped
+func (sessionRepository *sessionRepository) DeleteByUserId(ctx context.Context, userId int64, exceptId string) ([]model.Session, error) {
+	ids, err := sessionRepository.redisClient.SMembers(ctx, userSessionsKey(userId)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []model.Session{}
+	for _, id := range ids {
+		if id == exceptId {
+			continue
+		}
+
+		session, err := sessionRepository.DeleteById(ctx, id)
+		if err == ErrSessionNotFound {
+			if err := sessionRepository.redisClient.SRem(ctx, userSessionsKey(userId), id).Err(); err != nil {
+				return nil, err
+			}
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+
+	return sessions, nil
+}
+
+// Denied token ids only live until the token would expire anyway
+func (sessionRepository *sessionRepository) DenyAccessToken(ctx context.Context, accessTokenId string, accessTokenExpiresAt time.Time) error {
+	expireDuration := time.Until(accessTokenExpiresAt)
+	if expireDuration <= 0 {
+		return nil
+	}
+
+	return sessionRepository.redisClient.SetEx(ctx, fmt.Sprintf("token-denylist:%s", accessTokenId), 1, expireDuration).Err()
+}
+
+func sessionKey(id string) string {
+	return fmt.Sprintf("session:%s", id)
+}
+
+func userSessionsKey(userId int64) string {
+	return fmt.Sprintf("user-sessions:%d", userId)
+}
+
+func toSession(id string, values map[string]string) *model.Session {
+	userId, _ := strconv.ParseInt(values["user_id"], 10, 64)
+	accessTokenExpiresAt, _ := strconv.ParseInt(values["access_token_expires_at"], 10, 64)
+	createdAt, _ := strconv.ParseInt(values["created_at"], 10, 64)
+
+	return &model.Session{
+		Id:                   id,
+		UserId:               userId,
+		RefreshTokenHash:     values["refresh_token_hash"],
+		AccessTokenId:        values["access_token_id"],
+		AccessTokenExpiresAt: time.Unix(accessTokenExpiresAt, 0),
+		Device:               values["device"],
+		IpAddress:            values["ip_address"],
+		UserAgent:            values["user_agent"],
+		CreatedAt:            time.Unix(createdAt, 0),
+	}
+}
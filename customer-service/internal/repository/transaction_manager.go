@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/uptrace/bun"
+)
+
+type txContextKey struct{}
+
+type transactionManager struct {
+	db *bun.DB
+}
+
+type TransactionManager interface {
+	RunInTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+func NewTransactionManager(db *bun.DB) TransactionManager {
+	return &transactionManager{db: db}
+}
+
+// Every repository call made with the context passed to fn runs inside the same transaction
+func (transactionManager *transactionManager) RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	// Already inside a transaction -> join it
+	if _, ok := ctx.Value(txContextKey{}).(bun.Tx); ok {
+		return fn(ctx)
+	}
+
+	return transactionManager.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		return fn(context.WithValue(ctx, txContextKey{}, tx))
+	})
+}
+
+// Transaction of ctx if there is one, otherwise db of the repository
+func getDB(ctx context.Context, db *bun.DB) bun.IDB {
+	if tx, ok := ctx.Value(txContextKey{}).(bun.Tx); ok {
+		return tx
+	}
+	return db
+}
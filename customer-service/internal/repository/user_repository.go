@@ -2,47 +2,38 @@ package repository
 
 import (
 	"context"
-	"fmt"
-	"thanhldt060802/infrastructure"
 	"thanhldt060802/internal/model"
 	"thanhldt060802/utils"
+
+	"github.com/uptrace/bun"
 )
 
 type userRepository struct {
+	db *bun.DB
 }
 
 type UserRepository interface {
-	Get(ctx context.Context, offset int, limit int, sortFields []utils.SortField) ([]model.User, error)
+	Get(ctx context.Context, pageRequest *utils.PageRequest) ([]model.User, *utils.Page, error)
 	GetById(ctx context.Context, id int64) (*model.User, error)
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	CountByRoleName(ctx context.Context, roleName string) (int, error)
 	Create(ctx context.Context, newUser *model.User) error
 	UpdateById(ctx context.Context, id int64, updatedUser *model.User) error
 	DeleteById(ctx context.Context, id int64) error
 }
 
-func NewUserRepository() UserRepository {
-	return &userRepository{}
+func NewUserRepository(db *bun.DB) UserRepository {
+	return &userRepository{db: db}
 }
 
-func (userRepository *userRepository) Get(ctx context.Context, offser int, limit int, sortFields []utils.SortField) ([]model.User, error) {
-	var users []model.User
-	query := infrastructure.DB.NewSelect().Model(&users).
-		Offset(offser).
-		Limit(limit)
-	for _, sortField := range sortFields {
-		query = query.Order(fmt.Sprintf("%s %s", sortField.Field, sortField.Direction))
-	}
-	err := query.Scan(ctx)
-	if err != nil {
-		return nil, err
-	}
-	return users, nil
+func (userRepository *userRepository) Get(ctx context.Context, pageRequest *utils.PageRequest) ([]model.User, *utils.Page, error) {
+	return paginate[model.User](ctx, userRepository.db, pageRequest, nil)
 }
 
 func (userRepository *userRepository) GetById(ctx context.Context, id int64) (*model.User, error) {
 	var user model.User
-	err := infrastructure.DB.NewSelect().Model(&user).Where("id = ?", id).Scan(ctx)
+	err := getDB(ctx, userRepository.db).NewSelect().Model(&user).Where("id = ?", id).Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -51,7 +42,7 @@ func (userRepository *userRepository) GetById(ctx context.Context, id int64) (*m
 
 func (userRepository *userRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
 	var user model.User
-	err := infrastructure.DB.NewSelect().Model(&user).Where("username = ?", username).Scan(ctx)
+	err := getDB(ctx, userRepository.db).NewSelect().Model(&user).Where("username = ?", username).Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -60,24 +51,28 @@ func (userRepository *userRepository) GetByUsername(ctx context.Context, usernam
 
 func (userRepository *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	err := infrastructure.DB.NewSelect().Model(&user).Where("email = ?", email).Scan(ctx)
+	err := getDB(ctx, userRepository.db).NewSelect().Model(&user).Where("email = ?", email).Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
+func (userRepository *userRepository) CountByRoleName(ctx context.Context, roleName string) (int, error) {
+	return getDB(ctx, userRepository.db).NewSelect().Model((*model.User)(nil)).Where("role_name = ?", roleName).Count(ctx)
+}
+
 func (userRepository *userRepository) Create(ctx context.Context, newUser *model.User) error {
-	_, err := infrastructure.DB.NewInsert().Model(newUser).Exec(ctx)
+	_, err := getDB(ctx, userRepository.db).NewInsert().Model(newUser).Exec(ctx)
 	return err
 }
 
 func (userRepository *userRepository) UpdateById(ctx context.Context, id int64, updatedUser *model.User) error {
-	_, err := infrastructure.DB.NewUpdate().Model(updatedUser).Where("id = ?", id).Exec(ctx)
+	_, err := getDB(ctx, userRepository.db).NewUpdate().Model(updatedUser).Where("id = ?", id).Exec(ctx)
 	return err
 }
 
 func (userRepository *userRepository) DeleteById(ctx context.Context, id int64) error {
-	_, err := infrastructure.DB.NewDelete().Model(&model.User{}).Where("id = ?", id).Exec(ctx)
+	_, err := getDB(ctx, userRepository.db).NewDelete().Model(&model.User{}).Where("id = ?", id).Exec(ctx)
 	return err
 }
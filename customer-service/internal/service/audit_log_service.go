@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"thanhldt060802/internal/dto"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"thanhldt060802/utils"
+)
+
+type auditLogService struct {
+	auditLogRepository repository.AuditLogRepository
+}
+
+type AuditLogService interface {
+	GetAuditLogs(ctx context.Context, reqDTO *dto.GetAuditLogsWithQueryParamRequest) ([]model.AuditLog, *utils.Page, error)
+}
+
+func NewAuditLogService(auditLogRepository repository.AuditLogRepository) AuditLogService {
+	return &auditLogService{
+		auditLogRepository: auditLogRepository,
+	}
+}
+
+func (auditLogService *auditLogService) GetAuditLogs(ctx context.Context, reqDTO *dto.GetAuditLogsWithQueryParamRequest) ([]model.AuditLog, *utils.Page, error) {
+	pageRequest, err := utils.NewPageRequest(reqDTO.Cursor, reqDTO.Limit, reqDTO.SortBy, reqDTO.IncludeTotal)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	auditLogs, page, err := auditLogService.auditLogRepository.Get(ctx, reqDTO.Action, pageRequest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return auditLogs, page, nil
+}
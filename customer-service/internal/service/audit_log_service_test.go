@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"thanhldt060802/internal/dto"
+	"thanhldt060802/internal/model"
+)
+
+func TestAuditLogService_GetAuditLogs(t *testing.T) {
+	tests := []struct {
+		name        string
+		action      string
+		sortBy      string
+		wantTargets []string
+		wantErr     string
+	}{
+		{name: "all actions", wantTargets: []string{"username:alice", "ip:10.0.0.1", "username:alice"}},
+		{name: "newest first", sortBy: "created_at:desc", wantTargets: []string{"username:alice", "ip:10.0.0.1", "username:alice"}},
+		{name: "one action", action: model.AuditActionLoginLockoutCleared, wantTargets: []string{"username:alice"}},
+		{name: "unknown action", action: "LOGIN", wantTargets: []string{}},
+		{name: "nullable sort field", sortBy: "actor_id", wantErr: "can not sort by actor_id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			for _, auditLog := range []model.AuditLog{
+				{Action: model.AuditActionLoginLockout, Target: "username:alice"},
+				{Action: model.AuditActionLoginLockout, Target: "ip:10.0.0.1"},
+				{Action: model.AuditActionLoginLockoutCleared, ActorId: 1, Target: "username:alice"},
+			} {
+				if err := f.auditLogRepository.Create(context.Background(), &auditLog); err != nil {
+					t.Fatal(err)
+				}
+			}
+			auditLogService := NewAuditLogService(f.auditLogRepository)
+
+			auditLogs, page, err := auditLogService.GetAuditLogs(context.Background(), &dto.GetAuditLogsWithQueryParamRequest{
+				Limit: 10, SortBy: tt.sortBy, IncludeTotal: true, Action: tt.action,
+			})
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			if len(auditLogs) != len(tt.wantTargets) {
+				t.Fatalf("got %d audit logs, want %d", len(auditLogs), len(tt.wantTargets))
+			}
+			for i, auditLog := range auditLogs {
+				if auditLog.Target != tt.wantTargets[i] {
+					t.Errorf("auditLogs[%d] = %s, want %s", i, auditLog.Target, tt.wantTargets[i])
+				}
+			}
+			if *page.Total != int64(len(tt.wantTargets)) {
+				t.Errorf("total = %d, want %d", *page.Total, len(tt.wantTargets))
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"thanhldt060802/internal/client"
 	"thanhldt060802/internal/dto"
 	"thanhldt060802/internal/model"
 	"thanhldt060802/internal/repository"
@@ -12,33 +14,42 @@ import (
 type cartItemService struct {
 	cartItemRepository repository.CartItemRepository
 	cartRepository     repository.CartRepository
+
+	productClient client.ProductClient
 }
 
 type CartItemService interface {
-	GetCartItems(ctx context.Context, reqDTO *dto.GetCartItemsWithQueryParamRequest) ([]model.CartItem, error)
+	GetCartItems(ctx context.Context, reqDTO *dto.GetCartItemsWithQueryParamRequest) ([]model.CartItem, *utils.Page, error)
 	GetCartItemById(ctx context.Context, reqDTO *dto.GetCartItemByIdRequest) (*model.CartItem, error)
-	GetCartItemsByCartId(ctx context.Context, reqDTO *dto.GetCartItemsByCartIdWithQueryParamRequest) ([]model.CartItem, error)
+	GetCartItemsByCartId(ctx context.Context, reqDTO *dto.GetCartItemsByCartIdWithQueryParamRequest) ([]model.CartItem, *utils.Page, error)
 	CreateCartItem(ctx context.Context, reqDTO *dto.CreateCartItemRequest) error
 	UpdateCartItemById(ctx context.Context, reqDTO *dto.UpdateCartItemRequest) error
 	DeleteCartItemById(ctx context.Context, reqDTO *dto.DeleteCartItemRequest) error
+
+	GetProductsOfCartItems(ctx context.Context, cartItems []model.CartItem) ([]client.Product, error)
 }
 
-func NewCartItemService(cartItemRepository repository.CartItemRepository, cartRepository repository.CartRepository) CartItemService {
+func NewCartItemService(cartItemRepository repository.CartItemRepository, cartRepository repository.CartRepository, productClient client.ProductClient) CartItemService {
 	return &cartItemService{
 		cartItemRepository: cartItemRepository,
 		cartRepository:     cartRepository,
+
+		productClient: productClient,
 	}
 }
 
-func (cartItemService *cartItemService) GetCartItems(ctx context.Context, reqDTO *dto.GetCartItemsWithQueryParamRequest) ([]model.CartItem, error) {
-	sortFields := utils.ParseSortBy(reqDTO.SortBy)
+func (cartItemService *cartItemService) GetCartItems(ctx context.Context, reqDTO *dto.GetCartItemsWithQueryParamRequest) ([]model.CartItem, *utils.Page, error) {
+	pageRequest, err := utils.NewPageRequest(reqDTO.Cursor, reqDTO.Limit, reqDTO.SortBy, reqDTO.IncludeTotal)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	cartItemItems, err := cartItemService.cartItemRepository.Get(ctx, reqDTO.Offset, reqDTO.Limit, sortFields)
+	cartItemItems, page, err := cartItemService.cartItemRepository.Get(ctx, pageRequest)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return cartItemItems, nil
+	return cartItemItems, page, nil
 }
 
 func (cartItemService *cartItemService) GetCartItemById(ctx context.Context, reqDTO *dto.GetCartItemByIdRequest) (*model.CartItem, error) {
@@ -50,15 +61,18 @@ func (cartItemService *cartItemService) GetCartItemById(ctx context.Context, req
 	return foundCartItem, nil
 }
 
-func (cartItemService *cartItemService) GetCartItemsByCartId(ctx context.Context, reqDTO *dto.GetCartItemsByCartIdWithQueryParamRequest) ([]model.CartItem, error) {
-	sortFields := utils.ParseSortBy(reqDTO.SortBy)
+func (cartItemService *cartItemService) GetCartItemsByCartId(ctx context.Context, reqDTO *dto.GetCartItemsByCartIdWithQueryParamRequest) ([]model.CartItem, *utils.Page, error) {
+	pageRequest, err := utils.NewPageRequest(reqDTO.Cursor, reqDTO.Limit, reqDTO.SortBy, reqDTO.IncludeTotal)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	cartItemItems, err := cartItemService.cartItemRepository.GetByCartId(ctx, reqDTO.CartId, reqDTO.Offset, reqDTO.Limit, sortFields)
+	cartItemItems, page, err := cartItemService.cartItemRepository.GetByCartId(ctx, reqDTO.CartId, pageRequest)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return cartItemItems, nil
+	return cartItemItems, page, nil
 }
 
 func (cartItemService *cartItemService) CreateCartItem(ctx context.Context, reqDTO *dto.CreateCartItemRequest) error {
@@ -67,6 +81,13 @@ func (cartItemService *cartItemService) CreateCartItem(ctx context.Context, reqD
 		return fmt.Errorf("id of cart is not valid")
 	}
 
+	if _, err := cartItemService.productClient.GetProductById(ctx, reqDTO.Body.ProductId); err != nil {
+		if errors.Is(err, client.ErrProductNotFound) {
+			return fmt.Errorf("id of product is not valid")
+		}
+		return err
+	}
+
 	newCartItem := model.CartItem{
 		CartId:    reqDTO.CartId,
 		ProductId: reqDTO.Body.ProductId,
@@ -129,3 +150,17 @@ func (cartItemService *cartItemService) DeleteCartItemById(ctx context.Context,
 
 	return nil
 }
+
+func (cartItemService *cartItemService) GetProductsOfCartItems(ctx context.Context, cartItems []model.CartItem) ([]client.Product, error) {
+	productIds := make([]int64, len(cartItems))
+	for i, cartItem := range cartItems {
+		productIds[i] = cartItem.ProductId
+	}
+
+	products, err := cartItemService.productClient.GetProductsByIds(ctx, productIds)
+	if err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"thanhldt060802/internal/dto"
+	"thanhldt060802/internal/model"
+)
+
+func TestCartItemService_GetCartItems(t *testing.T) {
+	tests := []struct {
+		name           string
+		cartId         int64
+		sortBy         string
+		wantProductIds []int64
+		wantErr        string
+	}{
+		{name: "all carts", wantProductIds: []int64{1, 2, 1}},
+		{name: "all carts by product", sortBy: "product_id", wantProductIds: []int64{1, 1, 2}},
+		{name: "one cart", cartId: 1, wantProductIds: []int64{1, 2}},
+		{name: "unknown cart", cartId: 99, wantProductIds: []int64{}},
+		{name: "unknown sort field", sortBy: "price", wantErr: "can not sort by price"},
+		{name: "unknown sort field of one cart", cartId: 1, sortBy: "price", wantErr: "can not sort by price"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createCartItems(t)
+			cartItemService := NewCartItemService(f.cartItemRepository, f.cartRepository, f.productClient)
+
+			var cartItems []model.CartItem
+			var err error
+			if tt.cartId == 0 {
+				cartItems, _, err = cartItemService.GetCartItems(context.Background(), &dto.GetCartItemsWithQueryParamRequest{Limit: 10, SortBy: tt.sortBy})
+			} else {
+				cartItems, _, err = cartItemService.GetCartItemsByCartId(context.Background(), &dto.GetCartItemsByCartIdWithQueryParamRequest{CartId: tt.cartId, Limit: 10, SortBy: tt.sortBy})
+			}
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			if len(cartItems) != len(tt.wantProductIds) {
+				t.Fatalf("got %d cart items, want %d", len(cartItems), len(tt.wantProductIds))
+			}
+			for i, cartItem := range cartItems {
+				if cartItem.ProductId != tt.wantProductIds[i] {
+					t.Errorf("cartItems[%d] of product %d, want %d", i, cartItem.ProductId, tt.wantProductIds[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCartItemService_GetCartItemById(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      int64
+		wantErr error
+	}{
+		{name: "found", id: 2},
+		{name: "unknown", id: 99, wantErr: sql.ErrNoRows},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createCartItems(t)
+			cartItemService := NewCartItemService(f.cartItemRepository, f.cartRepository, f.productClient)
+
+			cartItem, err := cartItemService.GetCartItemById(context.Background(), &dto.GetCartItemByIdRequest{Id: tt.id})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && cartItem.Id != tt.id {
+				t.Errorf("got cart item %d, want %d", cartItem.Id, tt.id)
+			}
+		})
+	}
+}
+
+func TestCartItemService_CreateCartItem(t *testing.T) {
+	tests := []struct {
+		name      string
+		cartId    int64
+		productId int64
+		wantErr   string
+	}{
+		{name: "created", cartId: 1, productId: 2},
+		{name: "invalid cart id", cartId: 99, productId: 2, wantErr: "id of cart is not valid"},
+		{name: "invalid product id", cartId: 1, productId: 99, wantErr: "id of product is not valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createUser(t, "alice", model.RoleCustomer, true)
+			cartItemService := NewCartItemService(f.cartItemRepository, f.cartRepository, f.productClient)
+
+			reqDTO := &dto.CreateCartItemRequest{CartId: tt.cartId}
+			reqDTO.Body.ProductId = tt.productId
+
+			checkErr(t, cartItemService.CreateCartItem(context.Background(), reqDTO), tt.wantErr)
+			cartItems, _, _ := f.cartItemRepository.GetByCartId(context.Background(), 1, mustPageRequest(t))
+			if tt.wantErr != "" {
+				if len(cartItems) != 0 {
+					t.Errorf("cart items = %+v, want none", cartItems)
+				}
+				return
+			}
+			if len(cartItems) != 1 || cartItems[0].ProductId != tt.productId || cartItems[0].Quantity != 1 {
+				t.Errorf("cart items = %+v, want one of product %d", cartItems, tt.productId)
+			}
+		})
+	}
+}
+
+func TestCartItemService_UpdateCartItemById(t *testing.T) {
+	quantityOf := func(quantity int32) *int32 { return &quantity }
+
+	tests := []struct {
+		name         string
+		cartId       int64
+		id           int64
+		quantity     *int32
+		wantQuantity int32
+		wantErr      string
+	}{
+		{name: "updated", cartId: 1, id: 1, quantity: quantityOf(3), wantQuantity: 3},
+		{name: "quantity left out", cartId: 1, id: 1, wantQuantity: 1},
+		{name: "invalid cart id", cartId: 99, id: 1, quantity: quantityOf(3), wantErr: "id of cart is not valid"},
+		{name: "invalid cart item id", cartId: 1, id: 99, quantity: quantityOf(3), wantErr: "id of cart item is not valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createCartItems(t)
+			cartItemService := NewCartItemService(f.cartItemRepository, f.cartRepository, f.productClient)
+
+			reqDTO := &dto.UpdateCartItemRequest{CartId: tt.cartId, Id: tt.id}
+			reqDTO.Body.Quantity = tt.quantity
+
+			checkErr(t, cartItemService.UpdateCartItemById(context.Background(), reqDTO), tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			cartItem, _ := f.cartItemRepository.GetById(context.Background(), tt.id)
+			if cartItem.Quantity != tt.wantQuantity {
+				t.Errorf("quantity = %d, want %d", cartItem.Quantity, tt.wantQuantity)
+			}
+		})
+	}
+}
+
+func TestCartItemService_DeleteCartItemById(t *testing.T) {
+	tests := []struct {
+		name    string
+		cartId  int64
+		id      int64
+		wantErr string
+	}{
+		{name: "deleted", cartId: 1, id: 1},
+		{name: "invalid cart id", cartId: 99, id: 1, wantErr: "id of cart is not valid"},
+		{name: "invalid cart item id", cartId: 1, id: 99, wantErr: "id of cart item is not valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createCartItems(t)
+			cartItemService := NewCartItemService(f.cartItemRepository, f.cartRepository, f.productClient)
+
+			checkErr(t, cartItemService.DeleteCartItemById(context.Background(), &dto.DeleteCartItemRequest{CartId: tt.cartId, Id: tt.id}), tt.wantErr)
+			_, err := f.cartItemRepository.GetById(context.Background(), 1)
+			if deleted := errors.Is(err, sql.ErrNoRows); deleted != (tt.wantErr == "") {
+				t.Errorf("deleted = %v, want %v", deleted, tt.wantErr == "")
+			}
+		})
+	}
+}
+
+func TestCartItemService_GetProductsOfCartItems(t *testing.T) {
+	tests := []struct {
+		name           string
+		cartItems      []model.CartItem
+		wantProductIds []int64
+	}{
+		{name: "same product once", cartItems: []model.CartItem{{ProductId: 1}, {ProductId: 2}, {ProductId: 1}}, wantProductIds: []int64{1, 2}},
+		{name: "unknown product left out", cartItems: []model.CartItem{{ProductId: 99}, {ProductId: 2}}, wantProductIds: []int64{2}},
+		{name: "no cart items", cartItems: []model.CartItem{}, wantProductIds: []int64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			cartItemService := NewCartItemService(f.cartItemRepository, f.cartRepository, f.productClient)
+
+			products, err := cartItemService.GetProductsOfCartItems(context.Background(), tt.cartItems)
+			checkErr(t, err, "")
+			if len(products) != len(tt.wantProductIds) {
+				t.Fatalf("got %d products, want %d", len(products), len(tt.wantProductIds))
+			}
+			for i, product := range products {
+				if product.Id != tt.wantProductIds[i] {
+					t.Errorf("products[%d] = %d, want %d", i, product.Id, tt.wantProductIds[i])
+				}
+			}
+		})
+	}
+}
+
+// Cart 1 of alice holds products 1 and 2, cart 2 of bob holds product 1
+func (f *fixture) createCartItems(t *testing.T) {
+	t.Helper()
+
+	_, aliceCart := f.createUser(t, "alice", model.RoleCustomer, true)
+	_, bobCart := f.createUser(t, "bob", model.RoleCustomer, true)
+	for _, cartItem := range []model.CartItem{
+		{CartId: aliceCart.Id, ProductId: 1, Quantity: 1},
+		{CartId: aliceCart.Id, ProductId: 2, Quantity: 1},
+		{CartId: bobCart.Id, ProductId: 1, Quantity: 2},
+	} {
+		if err := f.cartItemRepository.Create(context.Background(), &cartItem); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
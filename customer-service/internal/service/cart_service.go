@@ -13,7 +13,7 @@ type cartService struct {
 }
 
 type CartService interface {
-	GetCarts(ctx context.Context, reqDTO *dto.GetCartsWithQueryParamRequest) ([]model.Cart, error)
+	GetCarts(ctx context.Context, reqDTO *dto.GetCartsWithQueryParamRequest) ([]model.Cart, *utils.Page, error)
 	GetCartByUserId(ctx context.Context, reqDTO *dto.GetCartByUserIdRequest) (*model.Cart, error)
 }
 
@@ -23,15 +23,18 @@ func NewCartService(cartRepository repository.CartRepository) CartService {
 	}
 }
 
-func (cartService *cartService) GetCarts(ctx context.Context, reqDTO *dto.GetCartsWithQueryParamRequest) ([]model.Cart, error) {
-	sortFields := utils.ParseSortBy(reqDTO.SortBy)
+func (cartService *cartService) GetCarts(ctx context.Context, reqDTO *dto.GetCartsWithQueryParamRequest) ([]model.Cart, *utils.Page, error) {
+	pageRequest, err := utils.NewPageRequest(reqDTO.Cursor, reqDTO.Limit, reqDTO.SortBy, reqDTO.IncludeTotal)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	carts, err := cartService.cartRepository.Get(ctx, reqDTO.Offset, reqDTO.Limit, sortFields)
+	carts, page, err := cartService.cartRepository.Get(ctx, pageRequest)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return carts, nil
+	return carts, page, nil
 }
 
 func (cartService *cartService) GetCartByUserId(ctx context.Context, reqDTO *dto.GetCartByUserIdRequest) (*model.Cart, error) {
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"thanhldt060802/internal/dto"
+	"thanhldt060802/internal/model"
+)
+
+func TestCartService_GetCarts(t *testing.T) {
+	tests := []struct {
+		name        string
+		sortBy      string
+		wantUserIds []int64
+		wantErr     string
+	}{
+		{name: "by id", wantUserIds: []int64{1, 2}},
+		{name: "by user id descending", sortBy: "user_id:desc", wantUserIds: []int64{2, 1}},
+		{name: "unknown sort field", sortBy: "owner", wantErr: "can not sort by owner"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createUser(t, "alice", model.RoleCustomer, true)
+			f.createUser(t, "bob", model.RoleCustomer, true)
+			cartService := NewCartService(f.cartRepository)
+
+			carts, _, err := cartService.GetCarts(context.Background(), &dto.GetCartsWithQueryParamRequest{Limit: 10, SortBy: tt.sortBy})
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			if len(carts) != len(tt.wantUserIds) {
+				t.Fatalf("got %d carts, want %d", len(carts), len(tt.wantUserIds))
+			}
+			for i, cart := range carts {
+				if cart.UserId != tt.wantUserIds[i] {
+					t.Errorf("carts[%d] of user %d, want %d", i, cart.UserId, tt.wantUserIds[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCartService_GetCartByUserId(t *testing.T) {
+	tests := []struct {
+		name    string
+		userId  int64
+		wantErr error
+	}{
+		{name: "found", userId: 1},
+		{name: "unknown user", userId: 99, wantErr: sql.ErrNoRows},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			_, aliceCart := f.createUser(t, "alice", model.RoleCustomer, true)
+			cartService := NewCartService(f.cartRepository)
+
+			cart, err := cartService.GetCartByUserId(context.Background(), &dto.GetCartByUserIdRequest{UserId: tt.userId})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && cart.Id != aliceCart.Id {
+				t.Errorf("got cart %d, want %d", cart.Id, aliceCart.Id)
+			}
+		})
+	}
+}
@@ -13,9 +13,9 @@ type invoiceDetailService struct {
 }
 
 type InvoiceDetailService interface {
-	GetInvoiceDetails(ctx context.Context, reqDTO *dto.GetInvoiceDetailsWithQueryParamRequest) ([]model.InvoiceDetail, error)
+	GetInvoiceDetails(ctx context.Context, reqDTO *dto.GetInvoiceDetailsWithQueryParamRequest) ([]model.InvoiceDetail, *utils.Page, error)
 	GetInvoiceDetailById(ctx context.Context, reqDTO *dto.GetInvoiceDetailByIdRequest) (*model.InvoiceDetail, error)
-	GetInvoiceDetailsByInvoiceId(ctx context.Context, reqDTO *dto.GetInvoiceDetailsByInvoiceIdWithQueryParamRequest) ([]model.InvoiceDetail, error)
+	GetInvoiceDetailsByInvoiceId(ctx context.Context, reqDTO *dto.GetInvoiceDetailsByInvoiceIdWithQueryParamRequest) ([]model.InvoiceDetail, *utils.Page, error)
 }
 
 func NewInvoiceDetailService(invoiceDetailRepository repository.InvoiceDetailRepository) InvoiceDetailService {
@@ -24,15 +24,18 @@ func NewInvoiceDetailService(invoiceDetailRepository repository.InvoiceDetailRep
 	}
 }
 
-func (invoiceDetailService *invoiceDetailService) GetInvoiceDetails(ctx context.Context, reqDTO *dto.GetInvoiceDetailsWithQueryParamRequest) ([]model.InvoiceDetail, error) {
-	sortFields := utils.ParseSortBy(reqDTO.SortBy)
+func (invoiceDetailService *invoiceDetailService) GetInvoiceDetails(ctx context.Context, reqDTO *dto.GetInvoiceDetailsWithQueryParamRequest) ([]model.InvoiceDetail, *utils.Page, error) {
+	pageRequest, err := utils.NewPageRequest(reqDTO.Cursor, reqDTO.Limit, reqDTO.SortBy, reqDTO.IncludeTotal)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	invoiceDetails, err := invoiceDetailService.invoiceDetailRepository.Get(ctx, reqDTO.Offset, reqDTO.Limit, sortFields)
+	invoiceDetails, page, err := invoiceDetailService.invoiceDetailRepository.Get(ctx, pageRequest)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return invoiceDetails, nil
+	return invoiceDetails, page, nil
 }
 
 func (invoiceDetailService *invoiceDetailService) GetInvoiceDetailById(ctx context.Context, reqDTO *dto.GetInvoiceDetailByIdRequest) (*model.InvoiceDetail, error) {
@@ -44,13 +47,16 @@ func (invoiceDetailService *invoiceDetailService) GetInvoiceDetailById(ctx conte
 	return foundInvoiceDetail, nil
 }
 
-func (invoiceDetailService *invoiceDetailService) GetInvoiceDetailsByInvoiceId(ctx context.Context, reqDTO *dto.GetInvoiceDetailsByInvoiceIdWithQueryParamRequest) ([]model.InvoiceDetail, error) {
-	sortFields := utils.ParseSortBy(reqDTO.SortBy)
+func (invoiceDetailService *invoiceDetailService) GetInvoiceDetailsByInvoiceId(ctx context.Context, reqDTO *dto.GetInvoiceDetailsByInvoiceIdWithQueryParamRequest) ([]model.InvoiceDetail, *utils.Page, error) {
+	pageRequest, err := utils.NewPageRequest(reqDTO.Cursor, reqDTO.Limit, reqDTO.SortBy, reqDTO.IncludeTotal)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	invoiceDetails, err := invoiceDetailService.invoiceDetailRepository.GetByInvoiceId(ctx, reqDTO.InvoiceId, reqDTO.Offset, reqDTO.Limit, sortFields)
+	invoiceDetails, page, err := invoiceDetailService.invoiceDetailRepository.GetByInvoiceId(ctx, reqDTO.InvoiceId, pageRequest)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return invoiceDetails, nil
+	return invoiceDetails, page, nil
 }
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+	"thanhldt060802/internal/dto"
+	"thanhldt060802/internal/model"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestInvoiceDetailService_GetInvoiceDetails(t *testing.T) {
+	tests := []struct {
+		name           string
+		invoiceId      int64
+		sortBy         string
+		nextPage       bool
+		wantProductIds []int64
+		wantErr        string
+	}{
+		{name: "all invoices", wantProductIds: []int64{1, 2}},
+		{name: "all invoices by total price", sortBy: "total_price:desc", wantProductIds: []int64{3, 1}},
+		{name: "next page by total price", sortBy: "total_price:desc", nextPage: true, wantProductIds: []int64{2}},
+		{name: "one invoice", invoiceId: 2, wantProductIds: []int64{3}},
+		{name: "unknown sort field", sortBy: "name", wantErr: "can not sort by name"},
+		{name: "unknown sort field of one invoice", invoiceId: 1, sortBy: "name", wantErr: "can not sort by name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createInvoiceDetails(t)
+			invoiceDetailService := NewInvoiceDetailService(f.invoiceDetailRepository)
+
+			get := func(cursor string) ([]model.InvoiceDetail, string, error) {
+				if tt.invoiceId == 0 {
+					invoiceDetails, page, err := invoiceDetailService.GetInvoiceDetails(context.Background(), &dto.GetInvoiceDetailsWithQueryParamRequest{
+						Cursor: cursor, Limit: 2, SortBy: tt.sortBy,
+					})
+					if err != nil {
+						return nil, "", err
+					}
+					return invoiceDetails, page.NextCursor, nil
+				}
+				invoiceDetails, page, err := invoiceDetailService.GetInvoiceDetailsByInvoiceId(context.Background(), &dto.GetInvoiceDetailsByInvoiceIdWithQueryParamRequest{
+					InvoiceId: tt.invoiceId, Cursor: cursor, Limit: 2, SortBy: tt.sortBy,
+				})
+				if err != nil {
+					return nil, "", err
+				}
+				return invoiceDetails, page.NextCursor, nil
+			}
+
+			invoiceDetails, nextCursor, err := get("")
+			if tt.nextPage && err == nil {
+				invoiceDetails, _, err = get(nextCursor)
+			}
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			productIds := []int64{}
+			for _, invoiceDetail := range invoiceDetails {
+				productIds = append(productIds, invoiceDetail.ProductId)
+			}
+			if !slices.Equal(productIds, tt.wantProductIds) {
+				t.Errorf("product ids = %v, want %v", productIds, tt.wantProductIds)
+			}
+		})
+	}
+}
+
+func TestInvoiceDetailService_GetInvoiceDetailById(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      int64
+		wantErr error
+	}{
+		{name: "found", id: 3},
+		{name: "unknown", id: 99, wantErr: sql.ErrNoRows},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createInvoiceDetails(t)
+			invoiceDetailService := NewInvoiceDetailService(f.invoiceDetailRepository)
+
+			invoiceDetail, err := invoiceDetailService.GetInvoiceDetailById(context.Background(), &dto.GetInvoiceDetailByIdRequest{Id: tt.id})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && invoiceDetail.Id != tt.id {
+				t.Errorf("got invoice detail %d, want %d", invoiceDetail.Id, tt.id)
+			}
+		})
+	}
+}
+
+// Invoice 1 holds products 1 and 2, invoice 2 holds product 3 which has the highest total price
+func (f *fixture) createInvoiceDetails(t *testing.T) {
+	t.Helper()
+
+	err := f.invoiceDetailRepository.CreateMany(context.Background(), []model.InvoiceDetail{
+		{InvoiceId: 1, ProductId: 1, Price: decimal.NewFromInt(200000), Quantity: 1, TotalPrice: decimal.NewFromInt(200000)},
+		{InvoiceId: 1, ProductId: 2, Price: decimal.NewFromInt(50000), Quantity: 3, TotalPrice: decimal.NewFromInt(150000)},
+		{InvoiceId: 2, ProductId: 3, Price: decimal.NewFromInt(1000000), DiscountPercentage: 20, Quantity: 1, TotalPrice: decimal.NewFromInt(800000)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -3,48 +3,90 @@ package service
 import (
 	"context"
 	"fmt"
+	"log"
+	"slices"
+	"thanhldt060802/internal/client"
 	"thanhldt060802/internal/dto"
 	"thanhldt060802/internal/model"
 	"thanhldt060802/internal/repository"
 	"thanhldt060802/utils"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
+// Rows read from database per bulk request when reindexing
+const reindexBatchSize = 500
+
 type invoiceService struct {
 	invoiceRepository              repository.InvoiceRepository
 	invoiceElasticsearchRepository repository.InvoiceElasticsearchRepository
+
+	invoiceDetailRepository        repository.InvoiceDetailRepository
+	invoiceStatusHistoryRepository repository.InvoiceStatusHistoryRepository
+	outboxEventRepository          repository.OutboxEventRepository
+	userRepository                 repository.UserRepository
+	cartRepository                 repository.CartRepository
+	cartItemRepository             repository.CartItemRepository
+	productClient                  client.ProductClient
+	transactionManager             repository.TransactionManager
 }
 
 type InvoiceService interface {
-	GetInvoices(ctx context.Context, reqDTO *dto.GetInvoicesWithQueryParamRequest) ([]model.Invoice, error)
+	GetInvoices(ctx context.Context, reqDTO *dto.GetInvoicesWithQueryParamRequest) ([]model.Invoice, *utils.Page, error)
 	GetInvoiceById(ctx context.Context, reqDTO *dto.GetInvoiceByIdRequest) (*model.Invoice, error)
-	GetInvoicesByUserId(ctx context.Context, reqDTO *dto.GetInvoicesByUserIdWithQueryParamRequest) ([]model.Invoice, error)
-	UpdateInvoiceById(ctx context.Context, reqDTO *dto.UpdateInvoiceRequest) error
+	GetInvoicesByUserId(ctx context.Context, reqDTO *dto.GetInvoicesByUserIdWithQueryParamRequest) ([]model.Invoice, *utils.Page, error)
+	CheckoutCart(ctx context.Context, reqDTO *dto.CheckoutCartRequest) (*model.Invoice, error)
+	ChangeInvoiceStatus(ctx context.Context, reqDTO *dto.ChangeInvoiceStatusRequest) error
+	GetInvoiceStatusHistoriesById(ctx context.Context, reqDTO *dto.GetInvoiceStatusHistoriesByIdRequest) ([]model.InvoiceStatusHistory, error)
 	DeleteInvoiceById(ctx context.Context, reqDTO *dto.DeleteInvoiceRequest) error
 
 	SyncAllInvoicesToElasticsearch(ctx context.Context) error
 
-	GetInvoicesWithElasticsearch(ctx context.Context, reqDTO *dto.GetInvoicesWithElasticsearchRequest) ([]model.Invoice, error)
+	GetInvoicesWithElasticsearch(ctx context.Context, reqDTO *dto.GetInvoicesWithElasticsearchRequest) ([]model.Invoice, *utils.Page, error)
 	SumInvoicesWithElasticsearch(ctx context.Context, reqDTO *dto.AggregateInvoicesWithElasticsearchRequest) (*float64, error)
-	SumAvgInvoicesWithElasticsearch(ctx context.Context, reqDTO *dto.AggregateInvoicesWithElasticsearchRequest) (*model.InvoiceReport, error)
+	ReportInvoicesWithElasticsearch(ctx context.Context, reqDTO *dto.ReportInvoicesWithElasticsearchRequest) (*model.InvoiceElasticsearchReport, error)
 }
 
-func NewInvoiceService(invoiceRepository repository.InvoiceRepository, invoiceElasticsearchRepository repository.InvoiceElasticsearchRepository) InvoiceService {
+func NewInvoiceService(
+	invoiceRepository repository.InvoiceRepository,
+	invoiceElasticsearchRepository repository.InvoiceElasticsearchRepository,
+	invoiceDetailRepository repository.InvoiceDetailRepository,
+	invoiceStatusHistoryRepository repository.InvoiceStatusHistoryRepository,
+	outboxEventRepository repository.OutboxEventRepository,
+	userRepository repository.UserRepository,
+	cartRepository repository.CartRepository,
+	cartItemRepository repository.CartItemRepository,
+	productClient client.ProductClient,
+	transactionManager repository.TransactionManager,
+) InvoiceService {
 	return &invoiceService{
 		invoiceRepository:              invoiceRepository,
 		invoiceElasticsearchRepository: invoiceElasticsearchRepository,
+
+		invoiceDetailRepository:        invoiceDetailRepository,
+		invoiceStatusHistoryRepository: invoiceStatusHistoryRepository,
+		outboxEventRepository:          outboxEventRepository,
+		userRepository:                 userRepository,
+		cartRepository:                 cartRepository,
+		cartItemRepository:             cartItemRepository,
+		productClient:                  productClient,
+		transactionManager:             transactionManager,
 	}
 }
 
-func (invoiceService *invoiceService) GetInvoices(ctx context.Context, reqDTO *dto.GetInvoicesWithQueryParamRequest) ([]model.Invoice, error) {
-	sortFields := utils.ParseSortBy(reqDTO.SortBy)
+func (invoiceService *invoiceService) GetInvoices(ctx context.Context, reqDTO *dto.GetInvoicesWithQueryParamRequest) ([]model.Invoice, *utils.Page, error) {
+	pageRequest, err := utils.NewPageRequest(reqDTO.Cursor, reqDTO.Limit, reqDTO.SortBy, reqDTO.IncludeTotal)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	invoices, err := invoiceService.invoiceRepository.Get(ctx, reqDTO.Offset, reqDTO.Limit, sortFields)
+	invoices, page, err := invoiceService.invoiceRepository.Get(ctx, pageRequest)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return invoices, nil
+	return invoices, page, nil
 }
 
 func (invoiceService *invoiceService) GetInvoiceById(ctx context.Context, reqDTO *dto.GetInvoiceByIdRequest) (*model.Invoice, error) {
@@ -56,33 +98,217 @@ func (invoiceService *invoiceService) GetInvoiceById(ctx context.Context, reqDTO
 	return foundInvoice, nil
 }
 
-func (invoiceService *invoiceService) GetInvoicesByUserId(ctx context.Context, reqDTO *dto.GetInvoicesByUserIdWithQueryParamRequest) ([]model.Invoice, error) {
-	sortFields := utils.ParseSortBy(reqDTO.SortBy)
+func (invoiceService *invoiceService) GetInvoicesByUserId(ctx context.Context, reqDTO *dto.GetInvoicesByUserIdWithQueryParamRequest) ([]model.Invoice, *utils.Page, error) {
+	pageRequest, err := utils.NewPageRequest(reqDTO.Cursor, reqDTO.Limit, reqDTO.SortBy, reqDTO.IncludeTotal)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	invoices, err := invoiceService.invoiceRepository.GetByUserId(ctx, reqDTO.UserId, reqDTO.Offset, reqDTO.Limit, sortFields)
+	invoices, page, err := invoiceService.invoiceRepository.GetByUserId(ctx, reqDTO.UserId, pageRequest)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return invoices, nil
+	return invoices, page, nil
 }
 
-func (invoiceService *invoiceService) UpdateInvoiceById(ctx context.Context, reqDTO *dto.UpdateInvoiceRequest) error {
-	foundInvoice, err := invoiceService.invoiceRepository.GetById(ctx, reqDTO.Id)
+func (invoiceService *invoiceService) CheckoutCart(ctx context.Context, reqDTO *dto.CheckoutCartRequest) (*model.Invoice, error) {
+	foundUser, err := invoiceService.userRepository.GetById(ctx, reqDTO.UserId)
 	if err != nil {
-		return fmt.Errorf("id of invoice is not valid")
+		return nil, fmt.Errorf("id of user is not valid")
+	}
+	if foundUser.EmailVerifiedAt == nil {
+		return nil, fmt.Errorf("email of user is not verified")
 	}
 
-	if reqDTO.Body.Status != nil {
-		foundInvoice.Status = *reqDTO.Body.Status
+	foundCart, err := invoiceService.cartRepository.GetById(ctx, reqDTO.CartId)
+	if err != nil || foundCart.UserId != reqDTO.UserId {
+		return nil, fmt.Errorf("id of cart is not valid")
 	}
-	foundInvoice.UpdatedAt = time.Now().UTC()
 
-	if err := invoiceService.invoiceRepository.UpdateById(ctx, reqDTO.Id, foundInvoice); err != nil {
-		return err
+	cartItems, err := invoiceService.cartItemRepository.GetAllByCartId(ctx, reqDTO.CartId)
+	if err != nil {
+		return nil, err
+	}
+	if len(cartItems) == 0 {
+		return nil, fmt.Errorf("cart is empty")
 	}
 
-	return nil
+	productIds := make([]int64, len(cartItems))
+	for i, cartItem := range cartItems {
+		productIds[i] = cartItem.ProductId
+	}
+	products, err := invoiceService.productClient.GetProductsByIdsNoCache(ctx, productIds)
+	if err != nil {
+		return nil, err
+	}
+	productMap := make(map[int64]client.Product, len(products))
+	for _, product := range products {
+		productMap[product.Id] = product
+	}
+
+	// Snapshot price and discount of each product at checkout time
+	newInvoiceDetails := make([]model.InvoiceDetail, len(cartItems))
+	totalAmount := decimal.Zero
+	for i, cartItem := range cartItems {
+		product, ok := productMap[cartItem.ProductId]
+		if !ok {
+			return nil, fmt.Errorf("product with id = %d not found", cartItem.ProductId)
+		}
+
+		price := decimal.NewFromInt(product.Price)
+		totalPrice := price.
+			Mul(decimal.NewFromInt32(cartItem.Quantity)).
+			Mul(decimal.NewFromInt32(100 - product.DiscountPercentage)).
+			Div(decimal.NewFromInt(100))
+
+		newInvoiceDetails[i] = model.InvoiceDetail{
+			ProductId:          cartItem.ProductId,
+			Price:              price,
+			DiscountPercentage: product.DiscountPercentage,
+			Quantity:           cartItem.Quantity,
+			TotalPrice:         totalPrice,
+		}
+		totalAmount = totalAmount.Add(totalPrice)
+	}
+
+	// Hold stock in catalog service before the transaction, so no database lock waits on a call to another service.
+	// It is released below if the transaction fails
+	reservationItems := make([]client.StockReservationItem, len(cartItems))
+	for i, cartItem := range cartItems {
+		reservationItems[i] = client.StockReservationItem{
+			ProductId: cartItem.ProductId,
+			Quantity:  cartItem.Quantity,
+		}
+	}
+	stockReservation, err := invoiceService.productClient.ReserveStock(ctx, reservationItems)
+	if err != nil {
+		return nil, err
+	}
+
+	var newInvoice model.Invoice
+
+	err = invoiceService.transactionManager.RunInTx(ctx, func(ctx context.Context) error {
+		// Lock cart so concurrent checkouts of the same cart are serialized
+		foundCart, err := invoiceService.cartRepository.GetByIdForUpdate(ctx, reqDTO.CartId)
+		if err != nil {
+			return fmt.Errorf("id of cart is not valid")
+		}
+
+		// Items read before the lock are what stock was reserved for, a cart changed meanwhile (e.g. by a concurrent
+		// checkout) must not be billed with that reservation
+		lockedCartItems, err := invoiceService.cartItemRepository.GetAllByCartId(ctx, reqDTO.CartId)
+		if err != nil {
+			return err
+		}
+		if !sameCartItems(cartItems, lockedCartItems) {
+			return fmt.Errorf("cart changed during checkout")
+		}
+
+		newInvoice = model.Invoice{
+			UserId:             reqDTO.UserId,
+			TotalAmount:        totalAmount.Round(0).IntPart(),
+			Status:             model.InvoiceStatusPending,
+			StockReservationId: stockReservation.Id,
+		}
+		if err := invoiceService.invoiceRepository.Create(ctx, &newInvoice); err != nil {
+			return err
+		}
+
+		newInvoiceStatusHistory := model.InvoiceStatusHistory{
+			InvoiceId: newInvoice.Id,
+			ToStatus:  newInvoice.Status,
+			ActorId:   reqDTO.UserId,
+			ActorRole: reqDTO.RoleName,
+		}
+		if err := invoiceService.invoiceStatusHistoryRepository.Create(ctx, &newInvoiceStatusHistory); err != nil {
+			return err
+		}
+
+		for i := range newInvoiceDetails {
+			newInvoiceDetails[i].InvoiceId = newInvoice.Id
+		}
+		if err := invoiceService.invoiceDetailRepository.CreateMany(ctx, newInvoiceDetails); err != nil {
+			return err
+		}
+
+		if err := invoiceService.cartItemRepository.DeleteByCartId(ctx, reqDTO.CartId); err != nil {
+			return err
+		}
+
+		foundCart.UpdatedAt = time.Now().UTC()
+		if err := invoiceService.cartRepository.UpdateById(ctx, reqDTO.CartId, foundCart); err != nil {
+			return err
+		}
+
+		// Confirm is committed with the invoice, so it is retried by outbox until catalog service accepts it instead of
+		// being lost and left for the reservation sweeper to expire
+		outboxEvents := append(
+			newInvoiceOutboxEvents(model.OutboxEventTypeUpsert, newInvoice.Id),
+			newInvoiceOutboxEvents(model.OutboxEventTypeConfirmStockReservation, newInvoice.Id)...,
+		)
+		return invoiceService.outboxEventRepository.CreateMany(ctx, outboxEvents)
+	})
+	if err != nil {
+		if err := invoiceService.productClient.ReleaseStockReservation(ctx, stockReservation.Id); err != nil {
+			log.Printf("Release stock reservation with id = %d failed: %s", stockReservation.Id, err.Error())
+		}
+		return nil, err
+	}
+
+	return &newInvoice, nil
+}
+
+func (invoiceService *invoiceService) ChangeInvoiceStatus(ctx context.Context, reqDTO *dto.ChangeInvoiceStatusRequest) error {
+	return invoiceService.transactionManager.RunInTx(ctx, func(ctx context.Context) error {
+		// Lock invoice so two concurrent transitions can not both pass the check below
+		foundInvoice, err := invoiceService.invoiceRepository.GetByIdForUpdate(ctx, reqDTO.InvoiceId)
+		if err != nil || (reqDTO.OwnerId != nil && foundInvoice.UserId != *reqDTO.OwnerId) {
+			return fmt.Errorf("id of invoice is not valid")
+		}
+
+		if !model.CanChangeInvoiceStatus(foundInvoice.Status, reqDTO.Status) {
+			return fmt.Errorf("status of invoice can not change from %s to %s", foundInvoice.Status, reqDTO.Status)
+		}
+
+		newInvoiceStatusHistory := model.InvoiceStatusHistory{
+			InvoiceId:  foundInvoice.Id,
+			FromStatus: foundInvoice.Status,
+			ToStatus:   reqDTO.Status,
+			ActorId:    reqDTO.ActorId,
+			ActorRole:  reqDTO.ActorRole,
+		}
+
+		foundInvoice.Status = reqDTO.Status
+		foundInvoice.UpdatedAt = time.Now().UTC()
+		if err := invoiceService.invoiceRepository.UpdateById(ctx, foundInvoice.Id, foundInvoice); err != nil {
+			return err
+		}
+
+		if err := invoiceService.invoiceStatusHistoryRepository.Create(ctx, &newInvoiceStatusHistory); err != nil {
+			return err
+		}
+
+		outboxEvents := newInvoiceOutboxEvents(model.OutboxEventTypeUpsert, foundInvoice.Id)
+		if foundInvoice.StockReservationId != 0 && slices.Contains(model.InvoiceStockReturningStatuses, foundInvoice.Status) {
+			outboxEvents = append(outboxEvents, newInvoiceOutboxEvents(model.OutboxEventTypeReleaseStockReservation, foundInvoice.Id)...)
+		}
+
+		return invoiceService.outboxEventRepository.CreateMany(ctx, outboxEvents)
+	})
+}
+
+func (invoiceService *invoiceService) GetInvoiceStatusHistoriesById(ctx context.Context, reqDTO *dto.GetInvoiceStatusHistoriesByIdRequest) ([]model.InvoiceStatusHistory, error) {
+	if _, err := invoiceService.invoiceRepository.GetById(ctx, reqDTO.Id); err != nil {
+		return nil, fmt.Errorf("id of invoice is not valid")
+	}
+
+	invoiceStatusHistories, err := invoiceService.invoiceStatusHistoryRepository.GetByInvoiceId(ctx, reqDTO.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return invoiceStatusHistories, nil
 }
 
 func (invoiceService *invoiceService) DeleteInvoiceById(ctx context.Context, reqDTO *dto.DeleteInvoiceRequest) error {
@@ -90,41 +316,111 @@ func (invoiceService *invoiceService) DeleteInvoiceById(ctx context.Context, req
 		return fmt.Errorf("id of invoice is not valid")
 	}
 
-	if err := invoiceService.invoiceRepository.DeleteById(ctx, reqDTO.Id); err != nil {
-		return err
-	}
+	return invoiceService.transactionManager.RunInTx(ctx, func(ctx context.Context) error {
+		if err := invoiceService.invoiceRepository.DeleteById(ctx, reqDTO.Id); err != nil {
+			return err
+		}
 
-	return nil
+		return invoiceService.outboxEventRepository.CreateMany(ctx, newInvoiceOutboxEvents(model.OutboxEventTypeDelete, reqDTO.Id))
+	})
 }
 
 func (invoiceService *invoiceService) SyncAllInvoicesToElasticsearch(ctx context.Context) error {
-	invoices, err := invoiceService.invoiceRepository.GetAll(ctx)
+	startedAt := time.Now().UTC()
 
+	newIndex, err := invoiceService.invoiceElasticsearchRepository.CreateNextVersionIndex(ctx)
 	if err != nil {
 		return err
 	}
 
-	if err := invoiceService.invoiceElasticsearchRepository.SyncAll(ctx, invoices); err != nil {
+	if err := invoiceService.buildInvoicesIndex(ctx, newIndex); err != nil {
+		// Drop the half built index so the next run starts clean
+		if err := invoiceService.invoiceElasticsearchRepository.DeleteIndices(ctx, []string{newIndex}); err != nil {
+			log.Printf("Delete index %s failed: %s", newIndex, err.Error())
+		}
+		return err
+	}
+
+	if _, err := invoiceService.invoiceElasticsearchRepository.SwitchAlias(ctx, newIndex); err != nil {
+		return err
+	}
+
+	// Changes dispatched while building went to the old index only, replay them on the new one
+	invoiceIds, err := invoiceService.outboxEventRepository.GetAggregateIdsSince(ctx, model.OutboxAggregateTypeInvoice, startedAt)
+	if err != nil {
+		return err
+	}
+	if err := invoiceService.outboxEventRepository.CreateMany(ctx, newInvoiceOutboxEvents(model.OutboxEventTypeUpsert, invoiceIds...)); err != nil {
+		return err
+	}
+
+	versionIndices, err := invoiceService.invoiceElasticsearchRepository.GetVersionIndices(ctx)
+	if err != nil {
+		return err
+	}
+	oldIndices := make([]string, 0, len(versionIndices))
+	for _, versionIndex := range versionIndices {
+		if versionIndex != newIndex {
+			oldIndices = append(oldIndices, versionIndex)
+		}
+	}
+	if err := invoiceService.invoiceElasticsearchRepository.DeleteIndices(ctx, oldIndices); err != nil {
+		log.Printf("Clean up old invoices indices failed: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (invoiceService *invoiceService) buildInvoicesIndex(ctx context.Context, index string) error {
+	indexed := int64(0)
+	afterId := int64(0)
+	for {
+		invoices, err := invoiceService.invoiceRepository.GetAll(ctx, afterId, reindexBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(invoices) == 0 {
+			break
+		}
+
+		if err := invoiceService.invoiceElasticsearchRepository.BulkIndex(ctx, index, invoices); err != nil {
+			return err
+		}
+
+		indexed += int64(len(invoices))
+		afterId = invoices[len(invoices)-1].Id
+	}
+
+	count, err := invoiceService.invoiceElasticsearchRepository.Count(ctx, index)
+	if err != nil {
 		return err
 	}
+	if count != indexed {
+		return fmt.Errorf("index %s has %d documents but %d invoices were indexed", index, count, indexed)
+	}
 
 	return nil
 }
 
-func (invoiceService *invoiceService) GetInvoicesWithElasticsearch(ctx context.Context, reqDTO *dto.GetInvoicesWithElasticsearchRequest) ([]model.Invoice, error) {
-	sortFields := utils.ParseSortBy(reqDTO.SortBy)
+func (invoiceService *invoiceService) GetInvoicesWithElasticsearch(ctx context.Context, reqDTO *dto.GetInvoicesWithElasticsearchRequest) ([]model.Invoice, *utils.Page, error) {
+	pageRequest, err := utils.NewPageRequest(reqDTO.Cursor, reqDTO.Limit, reqDTO.SortBy, reqDTO.IncludeTotal)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	invoices, err := invoiceService.invoiceElasticsearchRepository.Get(ctx, reqDTO.Offset, reqDTO.Limit, sortFields,
+	invoices, page, err := invoiceService.invoiceElasticsearchRepository.Get(ctx, pageRequest,
 		reqDTO.CreatedAtGTE, reqDTO.CreatedAtLTE)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return invoices, nil
+	return invoices, page, nil
 }
 
 func (invoiceService *invoiceService) SumInvoicesWithElasticsearch(ctx context.Context, reqDTO *dto.AggregateInvoicesWithElasticsearchRequest) (*float64, error) {
-	sum, err := invoiceService.invoiceElasticsearchRepository.Sum(ctx, reqDTO.CreatedAtGTE, reqDTO.CreatedAtLTE)
+	filter := newInvoiceElasticsearchFilter(reqDTO.CreatedAtGTE, reqDTO.CreatedAtLTE, reqDTO.Statuses)
+
+	sum, err := invoiceService.invoiceElasticsearchRepository.Sum(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -132,11 +428,47 @@ func (invoiceService *invoiceService) SumInvoicesWithElasticsearch(ctx context.C
 	return sum, nil
 }
 
-func (invoiceService *invoiceService) SumAvgInvoicesWithElasticsearch(ctx context.Context, reqDTO *dto.AggregateInvoicesWithElasticsearchRequest) (*model.InvoiceReport, error) {
-	invoiceReport, err := invoiceService.invoiceElasticsearchRepository.SumAvg(ctx, reqDTO.CreatedAtGTE, reqDTO.CreatedAtLTE)
+func (invoiceService *invoiceService) ReportInvoicesWithElasticsearch(ctx context.Context, reqDTO *dto.ReportInvoicesWithElasticsearchRequest) (*model.InvoiceElasticsearchReport, error) {
+	filter := newInvoiceElasticsearchFilter(reqDTO.CreatedAtGTE, reqDTO.CreatedAtLTE, reqDTO.Statuses)
+
+	report, err := invoiceService.invoiceElasticsearchRepository.Report(ctx, filter, reqDTO.Interval, reqDTO.UserLimit)
 	if err != nil {
 		return nil, err
 	}
 
-	return invoiceReport, nil
+	report.Filter = filter
+	report.Interval = reqDTO.Interval
+
+	return report, nil
+}
+
+// Cancelled and refunded invoices are not revenue unless asked for explicitly
+func newInvoiceElasticsearchFilter(createdAtGTE string, createdAtLTE string, statuses []string) *model.InvoiceElasticsearchFilter {
+	if len(statuses) == 0 {
+		statuses = model.InvoiceRevenueStatuses
+	}
+
+	return &model.InvoiceElasticsearchFilter{
+		CreatedAtGTE: createdAtGTE,
+		CreatedAtLTE: createdAtLTE,
+		Statuses:     statuses,
+	}
+}
+
+func sameCartItems(cartItems []model.CartItem, otherCartItems []model.CartItem) bool {
+	if len(cartItems) != len(otherCartItems) {
+		return false
+	}
+
+	quantities := make(map[int64]int32, len(cartItems))
+	for _, cartItem := range cartItems {
+		quantities[cartItem.ProductId] = cartItem.Quantity
+	}
+	for _, otherCartItem := range otherCartItems {
+		if quantity, ok := quantities[otherCartItem.ProductId]; !ok || quantity != otherCartItem.Quantity {
+			return false
+		}
+	}
+
+	return true
 }
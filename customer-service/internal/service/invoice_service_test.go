@@ -0,0 +1,557 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+	"thanhldt060802/internal/client"
+	"thanhldt060802/internal/dto"
+	"thanhldt060802/internal/model"
+	"time"
+)
+
+func TestInvoiceService_GetInvoices(t *testing.T) {
+	tests := []struct {
+		name        string
+		userId      int64
+		sortBy      string
+		wantAmounts []int64
+		wantErr     string
+	}{
+		{name: "all users", wantAmounts: []int64{100000, 300000, 50000}},
+		{name: "all users by amount", sortBy: "total_amount:desc", wantAmounts: []int64{300000, 100000, 50000}},
+		{name: "one user", userId: 1, wantAmounts: []int64{100000, 50000}},
+		{name: "one user by amount", userId: 1, sortBy: "total_amount", wantAmounts: []int64{50000, 100000}},
+		{name: "unknown sort field", sortBy: "amount", wantErr: "can not sort by amount"},
+		{name: "unknown sort field of one user", userId: 1, sortBy: "amount", wantErr: "can not sort by amount"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createInvoices(t)
+			invoiceService := f.newInvoiceService()
+
+			var invoices []model.Invoice
+			var err error
+			if tt.userId == 0 {
+				invoices, _, err = invoiceService.GetInvoices(context.Background(), &dto.GetInvoicesWithQueryParamRequest{Limit: 10, SortBy: tt.sortBy})
+			} else {
+				invoices, _, err = invoiceService.GetInvoicesByUserId(context.Background(), &dto.GetInvoicesByUserIdWithQueryParamRequest{UserId: tt.userId, Limit: 10, SortBy: tt.sortBy})
+			}
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			if amounts := totalAmounts(invoices); !slices.Equal(amounts, tt.wantAmounts) {
+				t.Errorf("amounts = %v, want %v", amounts, tt.wantAmounts)
+			}
+		})
+	}
+}
+
+func TestInvoiceService_GetInvoiceById(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      int64
+		wantErr error
+	}{
+		{name: "found", id: 2},
+		{name: "unknown", id: 99, wantErr: sql.ErrNoRows},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createInvoices(t)
+			invoiceService := f.newInvoiceService()
+
+			invoice, err := invoiceService.GetInvoiceById(context.Background(), &dto.GetInvoiceByIdRequest{Id: tt.id})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && invoice.Id != tt.id {
+				t.Errorf("got invoice %d, want %d", invoice.Id, tt.id)
+			}
+		})
+	}
+}
+
+func TestInvoiceService_CheckoutCart(t *testing.T) {
+	tests := []struct {
+		name      string
+		verified  bool
+		userId    int64
+		cartId    int64
+		cartItems []model.CartItem
+		wantTotal int64
+		wantStock map[int64]int32
+		wantErr   string
+	}{
+		{name: "checked out", verified: true, cartItems: []model.CartItem{{ProductId: 1, Quantity: 2}, {ProductId: 2, Quantity: 1}},
+			wantTotal: 410000, wantStock: map[int64]int32{1: 3, 2: 0}},
+		{name: "unknown user", verified: true, userId: 99, wantErr: "id of user is not valid"},
+		{name: "email not verified", cartItems: []model.CartItem{{ProductId: 1, Quantity: 1}}, wantErr: "email of user is not verified"},
+		{name: "cart of another user", verified: true, cartId: 2, cartItems: []model.CartItem{{ProductId: 1, Quantity: 1}}, wantErr: "id of cart is not valid"},
+		{name: "empty cart", verified: true, wantErr: "cart is empty"},
+		{name: "product gone", verified: true, cartItems: []model.CartItem{{ProductId: 99, Quantity: 1}}, wantErr: "product with id = 99 not found"},
+		{name: "stock not enough", verified: true, cartItems: []model.CartItem{{ProductId: 1, Quantity: 1}, {ProductId: 2, Quantity: 2}},
+			wantStock: map[int64]int32{1: 5, 2: 1}, wantErr: "stock of product with id = 2 is not enough"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			alice, aliceCart := f.createUser(t, "alice", model.RoleCustomer, tt.verified)
+			f.createUser(t, "bob", model.RoleCustomer, true)
+			for _, cartItem := range tt.cartItems {
+				cartItem.CartId = aliceCart.Id
+				f.cartItemRepository.Create(context.Background(), &cartItem)
+			}
+			invoiceService := f.newInvoiceService()
+
+			reqDTO := &dto.CheckoutCartRequest{UserId: alice.Id, RoleName: alice.RoleName, CartId: aliceCart.Id}
+			if tt.userId != 0 {
+				reqDTO.UserId = tt.userId
+			}
+			if tt.cartId != 0 {
+				reqDTO.CartId = tt.cartId
+			}
+
+			invoice, err := invoiceService.CheckoutCart(context.Background(), reqDTO)
+			checkErr(t, err, tt.wantErr)
+			for productId, wantStock := range tt.wantStock {
+				product, _ := f.productClient.GetProductById(context.Background(), productId)
+				if product.Stock != wantStock {
+					t.Errorf("stock of product %d = %d, want %d", productId, product.Stock, wantStock)
+				}
+			}
+			if tt.wantErr != "" {
+				return
+			}
+
+			if invoice.TotalAmount != tt.wantTotal || invoice.Status != model.InvoiceStatusPending {
+				t.Errorf("invoice = %+v, want PENDING of %d", invoice, tt.wantTotal)
+			}
+			invoiceDetails, _, _ := f.invoiceDetailRepository.GetByInvoiceId(context.Background(), invoice.Id, mustPageRequest(t))
+			if len(invoiceDetails) != len(tt.cartItems) || invoiceDetails[0].DiscountPercentage != 10 || invoiceDetails[0].TotalPrice.IntPart() != 360000 {
+				t.Errorf("invoice details = %+v, want price snapshots of cart items", invoiceDetails)
+			}
+			if cartItems, _ := f.cartItemRepository.GetAllByCartId(context.Background(), aliceCart.Id); len(cartItems) != 0 {
+				t.Errorf("cart items = %+v, want cart emptied", cartItems)
+			}
+			invoiceStatusHistories, _ := f.invoiceStatusHistoryRepository.GetByInvoiceId(context.Background(), invoice.Id)
+			if len(invoiceStatusHistories) != 1 || invoiceStatusHistories[0].ActorId != alice.Id {
+				t.Errorf("status histories = %+v, want one by %d", invoiceStatusHistories, alice.Id)
+			}
+			if outboxEvents := f.pendingOutboxEvents(t); len(outboxEvents) != 2 || outboxEvents[1].EventType != model.OutboxEventTypeConfirmStockReservation {
+				t.Errorf("outbox events = %+v, want upsert and stock reservation confirm of invoice %d", outboxEvents, invoice.Id)
+			}
+
+			// Stock reservation stays pending until outbox confirms it
+			if dispatched := f.dispatchOutboxEvents(t); dispatched != 2 {
+				t.Errorf("dispatched = %d, want upsert and stock reservation confirm", dispatched)
+			}
+			if product, _ := f.productClient.GetProductById(context.Background(), 1); product.Stock != 3 {
+				t.Errorf("stock of product 1 = %d, want confirmed stock kept taken", product.Stock)
+			}
+		})
+	}
+}
+
+// Runs onReserve between reading the cart and locking it, like a request that lands while stock is reserved
+type reserveHookProductClient struct {
+	client.ProductClient
+	onReserve func()
+}
+
+func (productClient *reserveHookProductClient) ReserveStock(ctx context.Context, items []client.StockReservationItem) (*client.StockReservation, error) {
+	productClient.onReserve()
+	return productClient.ProductClient.ReserveStock(ctx, items)
+}
+
+func TestInvoiceService_CheckoutCart_CartChangedWhileReserving(t *testing.T) {
+	f := newFixture(t)
+	alice, aliceCart := f.createUser(t, "alice", model.RoleCustomer, true)
+	f.cartItemRepository.Create(context.Background(), &model.CartItem{CartId: aliceCart.Id, ProductId: 1, Quantity: 2})
+	f.productClient = &reserveHookProductClient{ProductClient: f.productClient, onReserve: func() {
+		f.cartItemRepository.Create(context.Background(), &model.CartItem{CartId: aliceCart.Id, ProductId: 2, Quantity: 1})
+	}}
+	invoiceService := f.newInvoiceService()
+
+	_, err := invoiceService.CheckoutCart(context.Background(), &dto.CheckoutCartRequest{UserId: alice.Id, RoleName: alice.RoleName, CartId: aliceCart.Id})
+	checkErr(t, err, "cart changed during checkout")
+	if product, _ := f.productClient.GetProductById(context.Background(), 1); product.Stock != 5 {
+		t.Errorf("stock of product 1 = %d, want reservation released", product.Stock)
+	}
+	if cartItems, _ := f.cartItemRepository.GetAllByCartId(context.Background(), aliceCart.Id); len(cartItems) != 2 {
+		t.Errorf("cart items = %+v, want cart kept", cartItems)
+	}
+}
+
+func TestInvoiceService_ChangeInvoiceStatus(t *testing.T) {
+	ownerIdOf := func(id int64) *int64 { return &id }
+
+	tests := []struct {
+		name      string
+		invoiceId int64
+		status    string
+		ownerId   *int64
+		wantErr   string
+	}{
+		{name: "paid by admin", invoiceId: 4, status: model.InvoiceStatusPaid},
+		{name: "cancelled by owner", invoiceId: 4, status: model.InvoiceStatusCancel, ownerId: ownerIdOf(1)},
+		{name: "invoice of another user", invoiceId: 4, status: model.InvoiceStatusCancel, ownerId: ownerIdOf(2), wantErr: "id of invoice is not valid"},
+		{name: "unknown invoice", invoiceId: 99, status: model.InvoiceStatusPaid, wantErr: "id of invoice is not valid"},
+		{name: "skipped status", invoiceId: 4, status: model.InvoiceStatusDone, wantErr: "status of invoice can not change from PENDING to DONE"},
+		{name: "final status", invoiceId: 3, status: model.InvoiceStatusPaid, wantErr: "status of invoice can not change from CANCEL to PAID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createInvoices(t)
+			f.createInvoice(t, 1, model.InvoiceStatusPending, 20000)
+			invoiceService := f.newInvoiceService()
+
+			err := invoiceService.ChangeInvoiceStatus(context.Background(), &dto.ChangeInvoiceStatusRequest{
+				InvoiceId: tt.invoiceId, Status: tt.status, ActorId: 3, ActorRole: model.RoleAdmin, OwnerId: tt.ownerId,
+			})
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				if outboxEvents := f.pendingOutboxEvents(t); len(outboxEvents) != 0 {
+					t.Errorf("outbox events = %+v, want none", outboxEvents)
+				}
+				return
+			}
+
+			invoice, _ := f.invoiceRepository.GetById(context.Background(), tt.invoiceId)
+			if invoice.Status != tt.status {
+				t.Errorf("status = %s, want %s", invoice.Status, tt.status)
+			}
+			invoiceStatusHistories, _ := invoiceService.GetInvoiceStatusHistoriesById(context.Background(), &dto.GetInvoiceStatusHistoriesByIdRequest{Id: tt.invoiceId})
+			if len(invoiceStatusHistories) != 1 || invoiceStatusHistories[0].FromStatus != model.InvoiceStatusPending || invoiceStatusHistories[0].ToStatus != tt.status {
+				t.Errorf("status histories = %+v, want PENDING to %s", invoiceStatusHistories, tt.status)
+			}
+			if outboxEvents := f.pendingOutboxEvents(t); len(outboxEvents) != 1 {
+				t.Errorf("outbox events = %+v, want one", outboxEvents)
+			}
+		})
+	}
+}
+
+func TestInvoiceService_ChangeInvoiceStatus_ReturnsStock(t *testing.T) {
+	tests := []struct {
+		name      string
+		confirmed bool
+		statuses  []string
+	}{
+		{name: "cancelled before stock reservation is confirmed", statuses: []string{model.InvoiceStatusCancel}},
+		{name: "cancelled after stock reservation is confirmed", confirmed: true, statuses: []string{model.InvoiceStatusCancel}},
+		{name: "refunded when paid", confirmed: true, statuses: []string{model.InvoiceStatusPaid, model.InvoiceStatusRefunded}},
+		{name: "refunded when shipped", confirmed: true,
+			statuses: []string{model.InvoiceStatusPaid, model.InvoiceStatusShipped, model.InvoiceStatusRefunded}},
+		{name: "refunded when done", confirmed: true,
+			statuses: []string{model.InvoiceStatusPaid, model.InvoiceStatusShipped, model.InvoiceStatusDone, model.InvoiceStatusRefunded}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			alice, aliceCart := f.createUser(t, "alice", model.RoleCustomer, true)
+			f.cartItemRepository.Create(context.Background(), &model.CartItem{CartId: aliceCart.Id, ProductId: 1, Quantity: 2})
+			invoiceService := f.newInvoiceService()
+
+			invoice, err := invoiceService.CheckoutCart(context.Background(), &dto.CheckoutCartRequest{UserId: alice.Id, RoleName: alice.RoleName, CartId: aliceCart.Id})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.confirmed {
+				f.dispatchOutboxEvents(t)
+			}
+
+			for _, status := range tt.statuses {
+				err := invoiceService.ChangeInvoiceStatus(context.Background(), &dto.ChangeInvoiceStatusRequest{
+					InvoiceId: invoice.Id, Status: status, ActorId: 3, ActorRole: model.RoleAdmin,
+				})
+				checkErr(t, err, "")
+				if product, _ := f.productClient.GetProductById(context.Background(), 1); product.Stock != 3 {
+					t.Fatalf("stock of product 1 = %d after %s, want stock kept taken until outbox runs", product.Stock, status)
+				}
+			}
+
+			f.dispatchOutboxEvents(t)
+			if product, _ := f.productClient.GetProductById(context.Background(), 1); product.Stock != 5 {
+				t.Errorf("stock of product 1 = %d, want reserved stock returned", product.Stock)
+			}
+			if outboxEvents := f.pendingOutboxEvents(t); len(outboxEvents) != 0 {
+				t.Errorf("outbox events = %+v, want all dispatched", outboxEvents)
+			}
+
+			// A retried release must not return the stock twice
+			checkErr(t, f.productClient.ReleaseStockReservation(context.Background(), invoice.StockReservationId), "")
+			if product, _ := f.productClient.GetProductById(context.Background(), 1); product.Stock != 5 {
+				t.Errorf("stock of product 1 = %d after second release, want 5", product.Stock)
+			}
+		})
+	}
+}
+
+func TestInvoiceService_GetInvoiceStatusHistoriesById(t *testing.T) {
+	tests := []struct {
+		name      string
+		id        int64
+		wantCount int
+		wantErr   string
+	}{
+		{name: "no history", id: 1, wantCount: 0},
+		{name: "unknown invoice", id: 99, wantErr: "id of invoice is not valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createInvoices(t)
+			invoiceService := f.newInvoiceService()
+
+			invoiceStatusHistories, err := invoiceService.GetInvoiceStatusHistoriesById(context.Background(), &dto.GetInvoiceStatusHistoriesByIdRequest{Id: tt.id})
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr == "" && len(invoiceStatusHistories) != tt.wantCount {
+				t.Errorf("got %d status histories, want %d", len(invoiceStatusHistories), tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestInvoiceService_DeleteInvoiceById(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      int64
+		wantErr string
+	}{
+		{name: "deleted", id: 1},
+		{name: "unknown invoice", id: 99, wantErr: "id of invoice is not valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createInvoices(t)
+			invoiceService := f.newInvoiceService()
+
+			checkErr(t, invoiceService.DeleteInvoiceById(context.Background(), &dto.DeleteInvoiceRequest{Id: tt.id}), tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			if _, err := f.invoiceRepository.GetById(context.Background(), tt.id); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("invoice still exists: %v", err)
+			}
+			outboxEvents := f.pendingOutboxEvents(t)
+			if len(outboxEvents) != 1 || outboxEvents[0].EventType != model.OutboxEventTypeDelete {
+				t.Errorf("outbox events = %+v, want one DELETE", outboxEvents)
+			}
+		})
+	}
+}
+
+func TestInvoiceService_SyncAllInvoicesToElasticsearch(t *testing.T) {
+	tests := []struct {
+		name        string
+		runs        int
+		wantIndices []string
+	}{
+		{name: "first sync", runs: 1, wantIndices: []string{"invoices_v1"}},
+		{name: "old index is dropped", runs: 2, wantIndices: []string{"invoices_v2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createInvoices(t)
+			invoiceService := f.newInvoiceService()
+
+			for range tt.runs {
+				checkErr(t, invoiceService.SyncAllInvoicesToElasticsearch(context.Background()), "")
+			}
+
+			versionIndices, _ := f.invoiceElasticsearchRepository.GetVersionIndices(context.Background())
+			if !slices.Equal(versionIndices, tt.wantIndices) {
+				t.Errorf("indices = %v, want %v", versionIndices, tt.wantIndices)
+			}
+			invoices, _, err := invoiceService.GetInvoicesWithElasticsearch(context.Background(), &dto.GetInvoicesWithElasticsearchRequest{Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if amounts := totalAmounts(invoices); !slices.Equal(amounts, []int64{100000, 300000, 50000}) {
+				t.Errorf("indexed amounts = %v", amounts)
+			}
+		})
+	}
+}
+
+func TestInvoiceService_GetInvoicesWithElasticsearch(t *testing.T) {
+	tests := []struct {
+		name         string
+		sortBy       string
+		createdAtGTE string
+		createdAtLTE string
+		wantAmounts  []int64
+		wantErr      string
+	}{
+		{name: "all", wantAmounts: []int64{100000, 300000, 50000}},
+		{name: "by status", sortBy: "status", wantAmounts: []int64{50000, 300000, 100000}},
+		{name: "upper bound date is inclusive", sortBy: "total_amount:desc", createdAtLTE: "2026-03-02", wantAmounts: []int64{300000, 100000}},
+		{name: "lower bound", createdAtGTE: "2026-03-02T20:00:00Z", wantAmounts: []int64{300000, 50000}},
+		{name: "unknown sort field", sortBy: "amount", wantErr: "can not sort by amount"},
+		{name: "malformed date", createdAtGTE: "yesterday", wantErr: "failed to parse date field [yesterday]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.indexInvoices(t)
+			invoiceService := f.newInvoiceService()
+
+			invoices, page, err := invoiceService.GetInvoicesWithElasticsearch(context.Background(), &dto.GetInvoicesWithElasticsearchRequest{
+				Limit: 10, SortBy: tt.sortBy, IncludeTotal: true, CreatedAtGTE: tt.createdAtGTE, CreatedAtLTE: tt.createdAtLTE,
+			})
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			if amounts := totalAmounts(invoices); !slices.Equal(amounts, tt.wantAmounts) {
+				t.Errorf("amounts = %v, want %v", amounts, tt.wantAmounts)
+			}
+			if page.Total == nil || *page.Total != int64(len(tt.wantAmounts)) {
+				t.Errorf("total = %v, want %d", page.Total, len(tt.wantAmounts))
+			}
+		})
+	}
+}
+
+func TestInvoiceService_SumInvoicesWithElasticsearch(t *testing.T) {
+	tests := []struct {
+		name         string
+		createdAtGTE string
+		statuses     []string
+		wantSum      float64
+		wantErr      string
+	}{
+		{name: "revenue statuses by default", wantSum: 400000},
+		{name: "given statuses", statuses: []string{model.InvoiceStatusCancel}, wantSum: 50000},
+		{name: "day in report timezone", createdAtGTE: "2026-03-03", wantSum: 300000},
+		{name: "malformed date", createdAtGTE: "yesterday", wantErr: "failed to parse date field [yesterday]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.indexInvoices(t)
+			invoiceService := f.newInvoiceService()
+
+			sum, err := invoiceService.SumInvoicesWithElasticsearch(context.Background(), &dto.AggregateInvoicesWithElasticsearchRequest{
+				CreatedAtGTE: tt.createdAtGTE, Statuses: tt.statuses,
+			})
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr == "" && *sum != tt.wantSum {
+				t.Errorf("sum = %v, want %v", *sum, tt.wantSum)
+			}
+		})
+	}
+}
+
+func TestInvoiceService_ReportInvoicesWithElasticsearch(t *testing.T) {
+	tests := []struct {
+		name         string
+		createdAtLTE string
+		interval     string
+		wantDates    []string
+		wantErr      string
+	}{
+		{name: "daily with empty day", interval: "day", wantDates: []string{"2026-03-01", "2026-03-02", "2026-03-03"}},
+		{name: "weekly", interval: "week", wantDates: []string{"2026-02-23", "2026-03-02"}},
+		{name: "malformed date", interval: "day", createdAtLTE: "tomorrow", wantErr: "failed to parse date field [tomorrow]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.indexInvoices(t)
+			invoiceService := f.newInvoiceService()
+
+			report, err := invoiceService.ReportInvoicesWithElasticsearch(context.Background(), &dto.ReportInvoicesWithElasticsearchRequest{
+				CreatedAtLTE: tt.createdAtLTE, Interval: tt.interval, UserLimit: 1,
+			})
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+
+			if report.Count != 2 || report.Sum != 400000 || *report.Avg != 200000 || *report.Min != 100000 || *report.Max != 300000 {
+				t.Errorf("report stats = %d %v %v %v %v", report.Count, report.Sum, *report.Avg, *report.Min, *report.Max)
+			}
+			if !slices.Equal(report.Filter.Statuses, model.InvoiceRevenueStatuses) || report.Interval != tt.interval {
+				t.Errorf("report filter = %+v, interval = %s", report.Filter, report.Interval)
+			}
+			dates := []string{}
+			for _, bucket := range report.Series {
+				dates = append(dates, bucket.Date)
+			}
+			if !slices.Equal(dates, tt.wantDates) {
+				t.Errorf("series dates = %v, want %v", dates, tt.wantDates)
+			}
+			if len(report.Statuses) != 2 || report.Statuses[0].Status != model.InvoiceStatusDone {
+				t.Errorf("statuses = %+v, want DONE and PAID", report.Statuses)
+			}
+			if len(report.Users) != 1 || report.Users[0].UserId != 2 {
+				t.Errorf("users = %+v, want only top user 2", report.Users)
+			}
+		})
+	}
+}
+
+// Invoices 1 and 3 of alice, invoice 2 of bob
+func (f *fixture) createInvoices(t *testing.T) {
+	t.Helper()
+
+	alice, _ := f.createUser(t, "alice", model.RoleCustomer, true)
+	bob, _ := f.createUser(t, "bob", model.RoleCustomer, true)
+	f.createInvoice(t, alice.Id, model.InvoiceStatusPaid, 100000)
+	f.createInvoice(t, bob.Id, model.InvoiceStatusDone, 300000)
+	f.createInvoice(t, alice.Id, model.InvoiceStatusCancel, 50000)
+}
+
+// Same invoices indexed with creation times around midnight of the report timezone, UTC+7:
+// invoice 2 is March 2 in UTC but March 3 in the report timezone
+func (f *fixture) indexInvoices(t *testing.T) {
+	t.Helper()
+
+	for _, invoice := range []model.Invoice{
+		{Id: 1, UserId: 1, TotalAmount: 100000, Status: model.InvoiceStatusPaid, CreatedAt: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)},
+		{Id: 2, UserId: 2, TotalAmount: 300000, Status: model.InvoiceStatusDone, CreatedAt: time.Date(2026, 3, 2, 20, 0, 0, 0, time.UTC)},
+		{Id: 3, UserId: 1, TotalAmount: 50000, Status: model.InvoiceStatusCancel, CreatedAt: time.Date(2026, 3, 3, 5, 0, 0, 0, time.UTC)},
+	} {
+		if err := f.invoiceElasticsearchRepository.SyncCreating(context.Background(), &invoice); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Read from the in-memory table, claiming them would lease them to the test
+func (f *fixture) pendingOutboxEvents(t *testing.T) []model.OutboxEvent {
+	t.Helper()
+
+	return f.outboxEventRepository.(interface{ GetPending() []model.OutboxEvent }).GetPending()
+}
+
+func (f *fixture) dispatchOutboxEvents(t *testing.T) int {
+	t.Helper()
+
+	outboxService := NewOutboxService(f.outboxEventRepository, f.invoiceRepository, f.invoiceElasticsearchRepository, f.productClient, f.transactionManager, f.appConfig)
+	dispatched, err := outboxService.DispatchOutboxEvents(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dispatched
+}
+
+func totalAmounts(invoices []model.Invoice) []int64 {
+	amounts := make([]int64, len(invoices))
+	for i, invoice := range invoices {
+		amounts[i] = invoice.TotalAmount
+	}
+
+	return amounts
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"thanhldt060802/config"
+	"thanhldt060802/internal/client"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"time"
+)
+
+const (
+	outboxBaseBackoff = 2 * time.Second
+	outboxMaxBackoff  = 10 * time.Minute
+
+	// Claimed events are not handed to another dispatcher for this long. A batch running over it may be dispatched twice,
+	// which every event type tolerates
+	outboxLease = 10 * time.Minute
+
+	// Dispatched events are kept for a while so a reindex can replay what happened during it
+	outboxDoneRetention = 24 * time.Hour
+)
+
+type outboxService struct {
+	outboxEventRepository          repository.OutboxEventRepository
+	invoiceRepository              repository.InvoiceRepository
+	invoiceElasticsearchRepository repository.InvoiceElasticsearchRepository
+	productClient                  client.ProductClient
+	transactionManager             repository.TransactionManager
+
+	batchSize   int
+	maxAttempts int
+}
+
+type OutboxService interface {
+	DispatchOutboxEvents(ctx context.Context) (int, error)
+}
+
+func NewOutboxService(
+	outboxEventRepository repository.OutboxEventRepository,
+	invoiceRepository repository.InvoiceRepository,
+	invoiceElasticsearchRepository repository.InvoiceElasticsearchRepository,
+	productClient client.ProductClient,
+	transactionManager repository.TransactionManager,
+	appConfig *config.Config,
+) OutboxService {
+	return &outboxService{
+		outboxEventRepository:          outboxEventRepository,
+		invoiceRepository:              invoiceRepository,
+		invoiceElasticsearchRepository: invoiceElasticsearchRepository,
+		productClient:                  productClient,
+		transactionManager:             transactionManager,
+
+		batchSize:   appConfig.GetOutboxBatchSize(),
+		maxAttempts: appConfig.GetOutboxMaxAttempts(),
+	}
+}
+
+func (outboxService *outboxService) DispatchOutboxEvents(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+
+	// Claiming takes row locks only for its own statement, events are dispatched after it so no lock or connection is
+	// held while waiting on another service
+	outboxEvents, err := outboxService.outboxEventRepository.ClaimDue(ctx, now, now.Add(outboxLease), outboxService.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	dispatched := 0
+	for i := range outboxEvents {
+		outboxEvent := &outboxEvents[i]
+		dispatchedAt := time.Now().UTC()
+		outboxEvent.UpdatedAt = dispatchedAt
+
+		if err := outboxService.dispatch(ctx, outboxEvent); err != nil {
+			outboxEvent.Attempts++
+			outboxEvent.LastError = err.Error()
+			if int(outboxEvent.Attempts) >= outboxService.maxAttempts {
+				outboxEvent.Status = model.OutboxEventStatusDead
+				log.Printf("Outbox event with id = %d is dead after %d attempts: %s", outboxEvent.Id, outboxEvent.Attempts, err.Error())
+			} else {
+				outboxEvent.NextAttemptAt = dispatchedAt.Add(outboxBackoff(outboxEvent.Attempts))
+			}
+			continue
+		}
+
+		outboxEvent.Status = model.OutboxEventStatusDone
+		dispatched++
+	}
+
+	err = outboxService.transactionManager.RunInTx(ctx, func(ctx context.Context) error {
+		for i := range outboxEvents {
+			if err := outboxService.outboxEventRepository.Update(ctx, &outboxEvents[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := outboxService.outboxEventRepository.DeleteDoneBefore(ctx, time.Now().UTC().Add(-outboxDoneRetention)); err != nil {
+		log.Printf("Delete dispatched outbox events failed: %s", err.Error())
+	}
+
+	return dispatched, nil
+}
+
+// Upsert always indexes the current row, so events of the same invoice can be retried in any order
+func (outboxService *outboxService) dispatch(ctx context.Context, outboxEvent *model.OutboxEvent) error {
+	if outboxEvent.AggregateType != model.OutboxAggregateTypeInvoice {
+		return fmt.Errorf("aggregate type %s is not supported", outboxEvent.AggregateType)
+	}
+
+	switch outboxEvent.EventType {
+	case model.OutboxEventTypeUpsert:
+		foundInvoice, err := outboxService.invoiceRepository.GetById(ctx, outboxEvent.AggregateId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return outboxService.invoiceElasticsearchRepository.SyncDeletingById(ctx, outboxEvent.AggregateId)
+		}
+		if err != nil {
+			return err
+		}
+		return outboxService.invoiceElasticsearchRepository.SyncUpdating(ctx, foundInvoice)
+	case model.OutboxEventTypeDelete:
+		return outboxService.invoiceElasticsearchRepository.SyncDeletingById(ctx, outboxEvent.AggregateId)
+	case model.OutboxEventTypeConfirmStockReservation:
+		// A deleted invoice no longer needs its stock, the reservation is left to expire in catalog service. A cancelled
+		// or refunded one has a release queued after this event, confirming it first would only be undone
+		foundInvoice, err := outboxService.invoiceRepository.GetById(ctx, outboxEvent.AggregateId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if foundInvoice.StockReservationId == 0 || slices.Contains(model.InvoiceStockReturningStatuses, foundInvoice.Status) {
+			return nil
+		}
+		return outboxService.productClient.ConfirmStockReservation(ctx, foundInvoice.StockReservationId)
+	case model.OutboxEventTypeReleaseStockReservation:
+		foundInvoice, err := outboxService.invoiceRepository.GetById(ctx, outboxEvent.AggregateId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if foundInvoice.StockReservationId == 0 {
+			return nil
+		}
+		return outboxService.productClient.ReleaseStockReservation(ctx, foundInvoice.StockReservationId)
+	}
+
+	return fmt.Errorf("event type %s is not supported", outboxEvent.EventType)
+}
+
+func outboxBackoff(attempts int32) time.Duration {
+	backoff := outboxBaseBackoff
+	for i := int32(1); i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > outboxMaxBackoff {
+		backoff = outboxMaxBackoff
+	}
+	return backoff
+}
+
+func newInvoiceOutboxEvents(eventType string, invoiceIds ...int64) []model.OutboxEvent {
+	outboxEvents := make([]model.OutboxEvent, len(invoiceIds))
+	for i, invoiceId := range invoiceIds {
+		outboxEvents[i] = model.OutboxEvent{
+			AggregateType: model.OutboxAggregateTypeInvoice,
+			AggregateId:   invoiceId,
+			EventType:     eventType,
+			Status:        model.OutboxEventStatusPending,
+		}
+	}
+	return outboxEvents
+}
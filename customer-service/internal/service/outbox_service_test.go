@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"thanhldt060802/internal/client"
+	"thanhldt060802/internal/dto"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"time"
+)
+
+func TestOutboxService_DispatchOutboxEvents(t *testing.T) {
+	tests := []struct {
+		name            string
+		outboxEvents    []model.OutboxEvent
+		wantDispatched  int
+		wantAmounts     []int64
+		wantPending     int
+		wantLastError   string
+		wantNextAttempt bool
+		claimed         bool
+	}{
+		{name: "upsert", outboxEvents: []model.OutboxEvent{
+			{AggregateType: model.OutboxAggregateTypeInvoice, AggregateId: 1, EventType: model.OutboxEventTypeUpsert},
+			{AggregateType: model.OutboxAggregateTypeInvoice, AggregateId: 2, EventType: model.OutboxEventTypeUpsert},
+		}, wantDispatched: 2, wantAmounts: []int64{100000, 300000}},
+		{name: "upsert of deleted invoice removes it", outboxEvents: []model.OutboxEvent{
+			{AggregateType: model.OutboxAggregateTypeInvoice, AggregateId: 1, EventType: model.OutboxEventTypeUpsert},
+			{AggregateType: model.OutboxAggregateTypeInvoice, AggregateId: 99, EventType: model.OutboxEventTypeUpsert},
+		}, wantDispatched: 2, wantAmounts: []int64{100000}},
+		{name: "delete", outboxEvents: []model.OutboxEvent{
+			{AggregateType: model.OutboxAggregateTypeInvoice, AggregateId: 1, EventType: model.OutboxEventTypeUpsert},
+			{AggregateType: model.OutboxAggregateTypeInvoice, AggregateId: 1, EventType: model.OutboxEventTypeDelete},
+		}, wantDispatched: 2, wantAmounts: []int64{}},
+		{name: "not due yet", outboxEvents: []model.OutboxEvent{
+			{AggregateType: model.OutboxAggregateTypeInvoice, AggregateId: 1, EventType: model.OutboxEventTypeUpsert, NextAttemptAt: time.Now().UTC().Add(time.Minute)},
+		}, wantDispatched: 0, wantAmounts: []int64{}, wantPending: 1},
+		{name: "claimed by a stopped dispatcher is left until its lease ends", outboxEvents: []model.OutboxEvent{
+			{AggregateType: model.OutboxAggregateTypeInvoice, AggregateId: 1, EventType: model.OutboxEventTypeUpsert},
+		}, wantDispatched: 0, wantAmounts: []int64{}, wantPending: 1, claimed: true},
+		{name: "unsupported aggregate type is retried later", outboxEvents: []model.OutboxEvent{
+			{AggregateType: "USER", AggregateId: 1, EventType: model.OutboxEventTypeUpsert},
+		}, wantDispatched: 0, wantAmounts: []int64{}, wantPending: 1, wantLastError: "aggregate type USER is not supported", wantNextAttempt: true},
+		{name: "unsupported event type is dead after max attempts", outboxEvents: []model.OutboxEvent{
+			{AggregateType: model.OutboxAggregateTypeInvoice, AggregateId: 1, EventType: "PATCH", Attempts: 1},
+		}, wantDispatched: 0, wantAmounts: []int64{}, wantPending: 0},
+		{name: "one batch per run", outboxEvents: slices.Repeat([]model.OutboxEvent{
+			{AggregateType: model.OutboxAggregateTypeInvoice, AggregateId: 3, EventType: model.OutboxEventTypeUpsert},
+		}, 12), wantDispatched: 10, wantAmounts: []int64{50000}, wantPending: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createInvoices(t)
+			for i := range tt.outboxEvents {
+				tt.outboxEvents[i].Status = model.OutboxEventStatusPending
+			}
+			if err := f.outboxEventRepository.CreateMany(context.Background(), tt.outboxEvents); err != nil {
+				t.Fatal(err)
+			}
+			if tt.claimed {
+				now := time.Now().UTC()
+				if _, err := f.outboxEventRepository.ClaimDue(context.Background(), now, now.Add(time.Minute), 10); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Elasticsearch and catalog service must not be called while a transaction holds claimed rows
+			transactionManager := &txTrackingTransactionManager{TransactionManager: f.transactionManager}
+			outboxService := NewOutboxService(f.outboxEventRepository, f.invoiceRepository,
+				&outsideTxInvoiceElasticsearchRepository{f.invoiceElasticsearchRepository, transactionManager},
+				&outsideTxProductClient{f.productClient, transactionManager}, transactionManager, f.appConfig)
+
+			dispatched, err := outboxService.DispatchOutboxEvents(context.Background())
+			checkErr(t, err, "")
+			if dispatched != tt.wantDispatched {
+				t.Errorf("dispatched = %d, want %d", dispatched, tt.wantDispatched)
+			}
+
+			invoices, _, err := f.newInvoiceService().GetInvoicesWithElasticsearch(context.Background(), &dto.GetInvoicesWithElasticsearchRequest{Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if amounts := totalAmounts(invoices); !slices.Equal(amounts, tt.wantAmounts) {
+				t.Errorf("indexed amounts = %v, want %v", amounts, tt.wantAmounts)
+			}
+
+			pendingOutboxEvents := f.pendingOutboxEvents(t)
+			if len(pendingOutboxEvents) != tt.wantPending {
+				t.Fatalf("got %d pending outbox events, want %d", len(pendingOutboxEvents), tt.wantPending)
+			}
+			if tt.wantLastError != "" && pendingOutboxEvents[0].LastError != tt.wantLastError {
+				t.Errorf("last error = %s, want %s", pendingOutboxEvents[0].LastError, tt.wantLastError)
+			}
+			if tt.wantNextAttempt && (pendingOutboxEvents[0].Attempts != 1 || !pendingOutboxEvents[0].NextAttemptAt.After(time.Now().UTC())) {
+				t.Errorf("outbox event = %+v, want one attempt and next attempt backed off", pendingOutboxEvents[0])
+			}
+		})
+	}
+}
+
+type txTrackingTransactionManager struct {
+	repository.TransactionManager
+	open bool
+}
+
+func (transactionManager *txTrackingTransactionManager) RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	transactionManager.open = true
+	defer func() { transactionManager.open = false }()
+
+	return transactionManager.TransactionManager.RunInTx(ctx, fn)
+}
+
+type outsideTxInvoiceElasticsearchRepository struct {
+	repository.InvoiceElasticsearchRepository
+	transactionManager *txTrackingTransactionManager
+}
+
+func (invoiceElasticsearchRepository *outsideTxInvoiceElasticsearchRepository) SyncUpdating(ctx context.Context, updatedInvoice *model.Invoice) error {
+	if invoiceElasticsearchRepository.transactionManager.open {
+		return errors.New("elasticsearch called inside transaction")
+	}
+	return invoiceElasticsearchRepository.InvoiceElasticsearchRepository.SyncUpdating(ctx, updatedInvoice)
+}
+
+func (invoiceElasticsearchRepository *outsideTxInvoiceElasticsearchRepository) SyncDeletingById(ctx context.Context, id int64) error {
+	if invoiceElasticsearchRepository.transactionManager.open {
+		return errors.New("elasticsearch called inside transaction")
+	}
+	return invoiceElasticsearchRepository.InvoiceElasticsearchRepository.SyncDeletingById(ctx, id)
+}
+
+type outsideTxProductClient struct {
+	client.ProductClient
+	transactionManager *txTrackingTransactionManager
+}
+
+func (productClient *outsideTxProductClient) ConfirmStockReservation(ctx context.Context, id int64) error {
+	if productClient.transactionManager.open {
+		return errors.New("catalog service called inside transaction")
+	}
+	return productClient.ProductClient.ConfirmStockReservation(ctx, id)
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"log"
+	"thanhldt060802/config"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+)
+
+type productEventService struct {
+	productEventRepository repository.ProductEventRepository
+	cartItemRepository     repository.CartItemRepository
+
+	batchSize     int
+	maxDeliveries int
+}
+
+type ProductEventService interface {
+	ConsumeProductEvents(ctx context.Context) (int, error)
+}
+
+func NewProductEventService(
+	productEventRepository repository.ProductEventRepository,
+	cartItemRepository repository.CartItemRepository,
+	appConfig *config.Config,
+) ProductEventService {
+	return &productEventService{
+		productEventRepository: productEventRepository,
+		cartItemRepository:     cartItemRepository,
+
+		batchSize:     appConfig.GetProductEventBatchSize(),
+		maxDeliveries: appConfig.GetProductEventMaxDeliveries(),
+	}
+}
+
+// Handled events are acknowledged, a failed one stays unacknowledged and is read again later until it has been delivered
+// too many times, then it is moved to the dead-letter stream
+func (productEventService *productEventService) ConsumeProductEvents(ctx context.Context) (int, error) {
+	productEvents, err := productEventService.productEventRepository.Read(ctx, productEventService.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	handledIds := make([]string, 0, len(productEvents))
+	for _, productEvent := range productEvents {
+		if err := productEventService.handle(ctx, &productEvent); err != nil {
+			log.Printf("Handle product event with id = %s failed: %s", productEvent.Id, err.Error())
+			if productEvent.Deliveries < int64(productEventService.maxDeliveries) {
+				continue
+			}
+
+			if err := productEventService.productEventRepository.DeadLetter(ctx, &productEvent, err.Error()); err != nil {
+				log.Printf("Move product event with id = %s to dead-letter stream failed: %s", productEvent.Id, err.Error())
+				continue
+			}
+			log.Printf("Product event with id = %s is dead after %d deliveries", productEvent.Id, productEvent.Deliveries)
+			continue
+		}
+		handledIds = append(handledIds, productEvent.Id)
+	}
+
+	if len(handledIds) == 0 {
+		return 0, nil
+	}
+	if err := productEventService.productEventRepository.Ack(ctx, handledIds...); err != nil {
+		return 0, err
+	}
+
+	return len(handledIds), nil
+}
+
+// Events customer service has no use for are skipped, so catalog service can add new ones first
+func (productEventService *productEventService) handle(ctx context.Context, productEvent *model.ProductEvent) error {
+	switch productEvent.Type {
+	case model.ProductEventTypeDeleted:
+		// Invoice details keep the product id, they are a snapshot of the product at checkout
+		return productEventService.cartItemRepository.DeleteByProductId(ctx, productEvent.ProductId)
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestProductEventService_ConsumeProductEvents(t *testing.T) {
+	tests := []struct {
+		name               string
+		events             []map[string]any
+		claimedByOther     bool
+		wantHandled        int
+		wantCartProductIds []int64
+	}{
+		{name: "no events", wantHandled: 0, wantCartProductIds: []int64{1, 2, 1}},
+		{name: "deleted product leaves carts", events: []map[string]any{
+			{"type": model.ProductEventTypeDeleted, "product_id": 1},
+		}, wantHandled: 1, wantCartProductIds: []int64{2}},
+		{name: "same event twice is harmless", events: []map[string]any{
+			{"type": model.ProductEventTypeDeleted, "product_id": 1},
+			{"type": model.ProductEventTypeDeleted, "product_id": 1},
+		}, wantHandled: 2, wantCartProductIds: []int64{2}},
+		{name: "unknown event is skipped", events: []map[string]any{
+			{"type": "PRODUCT_RENAMED", "product_id": 2},
+		}, wantHandled: 1, wantCartProductIds: []int64{1, 2, 1}},
+		{name: "event left unacknowledged by another consumer is read again", events: []map[string]any{
+			{"type": model.ProductEventTypeDeleted, "product_id": 2},
+		}, claimedByOther: true, wantHandled: 1, wantCartProductIds: []int64{1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			ctx := context.Background()
+			redisServer := miniredis.RunT(t)
+			redisClient := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+			t.Cleanup(func() { redisClient.Close() })
+
+			_, aliceCart := f.createUser(t, "alice", model.RoleCustomer, true)
+			_, bobCart := f.createUser(t, "bob", model.RoleCustomer, true)
+			for _, cartItem := range []model.CartItem{
+				{CartId: aliceCart.Id, ProductId: 1, Quantity: 1},
+				{CartId: aliceCart.Id, ProductId: 2, Quantity: 2},
+				{CartId: bobCart.Id, ProductId: 1, Quantity: 1},
+			} {
+				if err := f.cartItemRepository.Create(ctx, &cartItem); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, values := range tt.events {
+				if err := redisClient.XAdd(ctx, &redis.XAddArgs{Stream: model.ProductEventStream, Values: values}).Err(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.claimedByOther {
+				// Other replica stopped right after reading, its events are handed over once idle long enough
+				if _, err := repository.NewProductEventRepository(redisClient, "other").Read(ctx, 10); err != nil {
+					t.Fatal(err)
+				}
+				redisServer.SetTime(time.Now().Add(2 * time.Minute))
+			}
+
+			f.appConfig.ProductEventBatchSize = "10"
+			f.appConfig.ProductEventMaxDeliveries = "3"
+			productEventService := NewProductEventService(repository.NewProductEventRepository(redisClient, "test"), f.cartItemRepository, f.appConfig)
+
+			handled, err := productEventService.ConsumeProductEvents(ctx)
+			checkErr(t, err, "")
+			if handled != tt.wantHandled {
+				t.Errorf("handled = %d, want %d", handled, tt.wantHandled)
+			}
+
+			handled, err = productEventService.ConsumeProductEvents(ctx)
+			checkErr(t, err, "")
+			if handled != 0 {
+				t.Errorf("handled %d events again, want every event acknowledged", handled)
+			}
+
+			cartItems, _, err := f.cartItemRepository.Get(ctx, mustPageRequest(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			productIds := []int64{}
+			for _, cartItem := range cartItems {
+				productIds = append(productIds, cartItem.ProductId)
+			}
+			if !slices.Equal(productIds, tt.wantCartProductIds) {
+				t.Errorf("product ids in carts = %v, want %v", productIds, tt.wantCartProductIds)
+			}
+		})
+	}
+}
+
+// Fails every removal, like a database that keeps rejecting the statement
+type failingCartItemRepository struct {
+	repository.CartItemRepository
+}
+
+func (cartItemRepository *failingCartItemRepository) DeleteByProductId(ctx context.Context, productId int64) error {
+	return errors.New("database is down")
+}
+
+func TestProductEventService_ConsumeProductEvents_DeadLetter(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	redisServer := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+
+	for _, values := range []map[string]any{
+		{"type": model.ProductEventTypeDeleted, "product_id": 1},
+		{"type": "PRODUCT_RENAMED", "product_id": 2},
+	} {
+		if err := redisClient.XAdd(ctx, &redis.XAddArgs{Stream: model.ProductEventStream, Values: values}).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f.appConfig.ProductEventBatchSize = "10"
+	f.appConfig.ProductEventMaxDeliveries = "3"
+	productEventService := NewProductEventService(repository.NewProductEventRepository(redisClient, "test"),
+		&failingCartItemRepository{f.cartItemRepository}, f.appConfig)
+
+	for i, wantHandled := range []int{1, 0, 0} {
+		handled, err := productEventService.ConsumeProductEvents(ctx)
+		checkErr(t, err, "")
+		if handled != wantHandled {
+			t.Errorf("handled = %d on delivery %d, want %d", handled, i+1, wantHandled)
+		}
+		if deadLetters := redisServer.Exists(model.ProductEventDeadLetterStream); deadLetters != (i == 2) {
+			t.Fatalf("dead-letter stream exists = %v on delivery %d, want it only after the last one", deadLetters, i+1)
+		}
+		redisServer.SetTime(time.Now().Add(time.Duration(i+1) * 2 * time.Minute))
+	}
+
+	deadLetters, err := redisClient.XRange(ctx, model.ProductEventDeadLetterStream, "-", "+").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deadLetters) != 1 || deadLetters[0].Values["deliveries"] != "3" || deadLetters[0].Values["reason"] != "database is down" ||
+		deadLetters[0].Values["product_id"] != "1" {
+		t.Errorf("dead letters = %+v, want the deleted product event after 3 deliveries", deadLetters)
+	}
+
+	pending, err := redisClient.XPending(ctx, model.ProductEventStream, "customer-service").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending.Count != 0 {
+		t.Errorf("pending = %d, want dead event acknowledged", pending.Count)
+	}
+	if handled, err := productEventService.ConsumeProductEvents(ctx); err != nil || handled != 0 {
+		t.Errorf("handled = %d, %v, want dead event not read again", handled, err)
+	}
+}
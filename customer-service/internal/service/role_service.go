@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"thanhldt060802/internal/dto"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+)
+
+type roleService struct {
+	roleRepository       repository.RoleRepository
+	permissionRepository repository.PermissionRepository
+	userRepository       repository.UserRepository
+}
+
+type RoleService interface {
+	GetRoles(ctx context.Context) ([]model.Role, error)
+	GetRoleByName(ctx context.Context, reqDTO *dto.GetRoleByNameRequest) (*model.Role, error)
+	CreateRole(ctx context.Context, reqDTO *dto.CreateRoleRequest) error
+	DeleteRoleByName(ctx context.Context, reqDTO *dto.DeleteRoleRequest) error
+	GetPermissions(ctx context.Context) ([]model.Permission, error)
+	AddPermissionsToRole(ctx context.Context, reqDTO *dto.AddPermissionsToRoleRequest) error
+	RemovePermissionFromRole(ctx context.Context, reqDTO *dto.RemovePermissionFromRoleRequest) error
+}
+
+func NewRoleService(roleRepository repository.RoleRepository, permissionRepository repository.PermissionRepository, userRepository repository.UserRepository) RoleService {
+	return &roleService{
+		roleRepository:       roleRepository,
+		permissionRepository: permissionRepository,
+		userRepository:       userRepository,
+	}
+}
+
+func (roleService *roleService) GetRoles(ctx context.Context) ([]model.Role, error) {
+	roles, err := roleService.roleRepository.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (roleService *roleService) GetRoleByName(ctx context.Context, reqDTO *dto.GetRoleByNameRequest) (*model.Role, error) {
+	foundRole, err := roleService.roleRepository.GetByName(ctx, reqDTO.Name)
+	if err != nil {
+		return nil, fmt.Errorf("name of role is not valid")
+	}
+
+	return foundRole, nil
+}
+
+func (roleService *roleService) CreateRole(ctx context.Context, reqDTO *dto.CreateRoleRequest) error {
+	if _, err := roleService.roleRepository.GetByName(ctx, reqDTO.Body.Name); err == nil {
+		return fmt.Errorf("name of role is already exists")
+	}
+
+	newRole := model.Role{
+		Name:        reqDTO.Body.Name,
+		Description: reqDTO.Body.Description,
+	}
+	if err := roleService.roleRepository.Create(ctx, &newRole); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (roleService *roleService) DeleteRoleByName(ctx context.Context, reqDTO *dto.DeleteRoleRequest) error {
+	if reqDTO.Name == model.RoleAdmin || reqDTO.Name == model.RoleCustomer {
+		return fmt.Errorf("built-in role can not be deleted")
+	}
+
+	if _, err := roleService.roleRepository.GetByName(ctx, reqDTO.Name); err != nil {
+		return fmt.Errorf("name of role is not valid")
+	}
+
+	userCount, err := roleService.userRepository.CountByRoleName(ctx, reqDTO.Name)
+	if err != nil {
+		return err
+	}
+	if userCount > 0 {
+		return fmt.Errorf("role is still assigned to %d users", userCount)
+	}
+
+	if err := roleService.roleRepository.DeleteByName(ctx, reqDTO.Name); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (roleService *roleService) GetPermissions(ctx context.Context) ([]model.Permission, error) {
+	permissions, err := roleService.permissionRepository.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (roleService *roleService) AddPermissionsToRole(ctx context.Context, reqDTO *dto.AddPermissionsToRoleRequest) error {
+	if _, err := roleService.roleRepository.GetByName(ctx, reqDTO.Name); err != nil {
+		return fmt.Errorf("name of role is not valid")
+	}
+
+	foundPermissions, err := roleService.permissionRepository.GetByNames(ctx, reqDTO.Body.PermissionNames)
+	if err != nil {
+		return err
+	}
+	foundPermissionNames := make(map[string]bool, len(foundPermissions))
+	for _, permission := range foundPermissions {
+		foundPermissionNames[permission.Name] = true
+	}
+	for _, permissionName := range reqDTO.Body.PermissionNames {
+		if !foundPermissionNames[permissionName] {
+			return fmt.Errorf("name of permission %s is not valid", permissionName)
+		}
+	}
+
+	if err := roleService.roleRepository.AddPermissions(ctx, reqDTO.Name, reqDTO.Body.PermissionNames); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (roleService *roleService) RemovePermissionFromRole(ctx context.Context, reqDTO *dto.RemovePermissionFromRoleRequest) error {
+	// Otherwise nobody could grant permissions back
+	if reqDTO.Name == model.RoleAdmin && reqDTO.PermissionName == model.PermissionRoleWrite {
+		return fmt.Errorf("permission %s can not be removed from role %s", model.PermissionRoleWrite, model.RoleAdmin)
+	}
+
+	if _, err := roleService.roleRepository.GetByName(ctx, reqDTO.Name); err != nil {
+		return fmt.Errorf("name of role is not valid")
+	}
+
+	if err := roleService.roleRepository.RemovePermission(ctx, reqDTO.Name, reqDTO.PermissionName); err != nil {
+		return err
+	}
+
+	return nil
+}
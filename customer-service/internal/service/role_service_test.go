@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"thanhldt060802/internal/dto"
+	"thanhldt060802/internal/model"
+)
+
+func TestRoleService_GetRoles(t *testing.T) {
+	f := newFixture(t)
+	roleService := NewRoleService(f.roleRepository, f.permissionRepository, f.userRepository)
+
+	roles, err := roleService.GetRoles(context.Background())
+	checkErr(t, err, "")
+	if len(roles) != 2 || roles[0].Name != model.RoleAdmin || roles[1].Name != model.RoleCustomer {
+		t.Fatalf("roles = %+v, want ADMIN and CUSTOMER", roles)
+	}
+	if !slices.Equal(roles[0].Permissions, []string{model.PermissionRoleRead, model.PermissionRoleWrite}) {
+		t.Errorf("permissions of ADMIN = %v", roles[0].Permissions)
+	}
+}
+
+func TestRoleService_GetRoleByName(t *testing.T) {
+	tests := []struct {
+		name     string
+		roleName string
+		wantErr  string
+	}{
+		{name: "found", roleName: model.RoleAdmin},
+		{name: "unknown", roleName: "GUEST", wantErr: "name of role is not valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			roleService := NewRoleService(f.roleRepository, f.permissionRepository, f.userRepository)
+
+			role, err := roleService.GetRoleByName(context.Background(), &dto.GetRoleByNameRequest{Name: tt.roleName})
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr == "" && role.Name != tt.roleName {
+				t.Errorf("role = %s, want %s", role.Name, tt.roleName)
+			}
+		})
+	}
+}
+
+func TestRoleService_CreateRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		roleName string
+		wantErr  string
+	}{
+		{name: "created", roleName: "STAFF"},
+		{name: "duplicate", roleName: model.RoleCustomer, wantErr: "name of role is already exists"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			roleService := NewRoleService(f.roleRepository, f.permissionRepository, f.userRepository)
+
+			reqDTO := &dto.CreateRoleRequest{}
+			reqDTO.Body.Name = tt.roleName
+			reqDTO.Body.Description = "Store staff"
+
+			checkErr(t, roleService.CreateRole(context.Background(), reqDTO), tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			if _, err := f.roleRepository.GetByName(context.Background(), tt.roleName); err != nil {
+				t.Errorf("created role: %v", err)
+			}
+		})
+	}
+}
+
+func TestRoleService_DeleteRoleByName(t *testing.T) {
+	tests := []struct {
+		name     string
+		roleName string
+		assigned bool
+		wantErr  string
+	}{
+		{name: "deleted", roleName: "STAFF"},
+		{name: "built-in", roleName: model.RoleCustomer, wantErr: "built-in role can not be deleted"},
+		{name: "unknown", roleName: "GUEST", wantErr: "name of role is not valid"},
+		{name: "still assigned", roleName: "STAFF", assigned: true, wantErr: "role is still assigned to 1 users"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.roleRepository.Create(context.Background(), &model.Role{Name: "STAFF"})
+			if tt.assigned {
+				f.createUser(t, "alice", "STAFF", true)
+			}
+			roleService := NewRoleService(f.roleRepository, f.permissionRepository, f.userRepository)
+
+			checkErr(t, roleService.DeleteRoleByName(context.Background(), &dto.DeleteRoleRequest{Name: tt.roleName}), tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			if _, err := f.roleRepository.GetByName(context.Background(), tt.roleName); err == nil {
+				t.Error("role still exists")
+			}
+		})
+	}
+}
+
+func TestRoleService_GetPermissions(t *testing.T) {
+	f := newFixture(t)
+	roleService := NewRoleService(f.roleRepository, f.permissionRepository, f.userRepository)
+
+	permissions, err := roleService.GetPermissions(context.Background())
+	checkErr(t, err, "")
+	if len(permissions) != 4 {
+		t.Errorf("got %d permissions, want 4", len(permissions))
+	}
+}
+
+func TestRoleService_AddPermissionsToRole(t *testing.T) {
+	tests := []struct {
+		name            string
+		roleName        string
+		permissionNames []string
+		wantPermissions []string
+		wantErr         string
+	}{
+		{name: "added", roleName: model.RoleCustomer, permissionNames: []string{model.PermissionInvoiceWrite, model.PermissionInvoiceRead},
+			wantPermissions: []string{model.PermissionInvoiceRead, model.PermissionInvoiceWrite}},
+		{name: "already granted", roleName: model.RoleAdmin, permissionNames: []string{model.PermissionRoleRead},
+			wantPermissions: []string{model.PermissionRoleRead, model.PermissionRoleWrite}},
+		{name: "unknown role", roleName: "GUEST", permissionNames: []string{model.PermissionInvoiceRead}, wantErr: "name of role is not valid"},
+		{name: "unknown permission", roleName: model.RoleCustomer, permissionNames: []string{model.PermissionInvoiceRead, "invoice:delete"},
+			wantErr: "name of permission invoice:delete is not valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			roleService := NewRoleService(f.roleRepository, f.permissionRepository, f.userRepository)
+
+			reqDTO := &dto.AddPermissionsToRoleRequest{Name: tt.roleName}
+			reqDTO.Body.PermissionNames = tt.permissionNames
+
+			checkErr(t, roleService.AddPermissionsToRole(context.Background(), reqDTO), tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			permissionNames, _ := f.roleRepository.GetPermissionNames(context.Background(), tt.roleName)
+			if !slices.Equal(permissionNames, tt.wantPermissions) {
+				t.Errorf("permissions = %v, want %v", permissionNames, tt.wantPermissions)
+			}
+		})
+	}
+}
+
+func TestRoleService_RemovePermissionFromRole(t *testing.T) {
+	tests := []struct {
+		name           string
+		roleName       string
+		permissionName string
+		wantErr        string
+	}{
+		{name: "removed", roleName: model.RoleAdmin, permissionName: model.PermissionRoleRead},
+		{name: "role write of admin", roleName: model.RoleAdmin, permissionName: model.PermissionRoleWrite,
+			wantErr: "permission role:write can not be removed from role ADMIN"},
+		{name: "unknown role", roleName: "GUEST", permissionName: model.PermissionRoleRead, wantErr: "name of role is not valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			roleService := NewRoleService(f.roleRepository, f.permissionRepository, f.userRepository)
+
+			reqDTO := &dto.RemovePermissionFromRoleRequest{Name: tt.roleName, PermissionName: tt.permissionName}
+			checkErr(t, roleService.RemovePermissionFromRole(context.Background(), reqDTO), tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			permissionNames, _ := f.roleRepository.GetPermissionNames(context.Background(), tt.roleName)
+			if slices.Contains(permissionNames, tt.permissionName) {
+				t.Errorf("permissions = %v, still has %s", permissionNames, tt.permissionName)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"thanhldt060802/config"
+	"thanhldt060802/internal/client"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"thanhldt060802/internal/repository/memory"
+	"thanhldt060802/internal/testsupport"
+	"thanhldt060802/utils"
+	"time"
+)
+
+// Services of one test share these in-memory repositories, like they share a database in the application
+type fixture struct {
+	userRepository                 repository.UserRepository
+	cartRepository                 repository.CartRepository
+	cartItemRepository             repository.CartItemRepository
+	invoiceRepository              repository.InvoiceRepository
+	invoiceDetailRepository        repository.InvoiceDetailRepository
+	invoiceStatusHistoryRepository repository.InvoiceStatusHistoryRepository
+	outboxEventRepository          repository.OutboxEventRepository
+	sessionRepository              repository.SessionRepository
+	roleRepository                 repository.RoleRepository
+	permissionRepository           repository.PermissionRepository
+	oneTimeTokenRepository         repository.OneTimeTokenRepository
+	loginAttemptRepository         repository.LoginAttemptRepository
+	auditLogRepository             repository.AuditLogRepository
+	transactionManager             repository.TransactionManager
+	invoiceElasticsearchRepository repository.InvoiceElasticsearchRepository
+
+	productClient client.ProductClient
+	notifier      *testsupport.MemoryNotifier
+	jwtKeys       *utils.JWTKeys
+	appConfig     *config.Config
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	jwtKeys, err := utils.NewJWTKeys(filepath.Join(t.TempDir(), "jwt_private.pem"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fixture{
+		userRepository:                 memory.NewUserRepository(),
+		cartRepository:                 memory.NewCartRepository(),
+		cartItemRepository:             memory.NewCartItemRepository(),
+		invoiceRepository:              memory.NewInvoiceRepository(),
+		invoiceDetailRepository:        memory.NewInvoiceDetailRepository(),
+		invoiceStatusHistoryRepository: memory.NewInvoiceStatusHistoryRepository(),
+		outboxEventRepository:          memory.NewOutboxEventRepository(),
+		sessionRepository:              memory.NewSessionRepository(),
+		roleRepository:                 memory.NewRoleRepository(),
+		permissionRepository: memory.NewPermissionRepository(
+			model.Permission{Name: model.PermissionInvoiceRead, Description: "Read invoices"},
+			model.Permission{Name: model.PermissionInvoiceWrite, Description: "Write invoices"},
+			model.Permission{Name: model.PermissionRoleRead, Description: "Read roles"},
+			model.Permission{Name: model.PermissionRoleWrite, Description: "Write roles"},
+		),
+		oneTimeTokenRepository:         memory.NewOneTimeTokenRepository(),
+		loginAttemptRepository:         memory.NewLoginAttemptRepository(),
+		auditLogRepository:             memory.NewAuditLogRepository(),
+		transactionManager:             memory.NewTransactionManager(),
+		invoiceElasticsearchRepository: memory.NewInvoiceElasticsearchRepository(),
+
+		productClient: testsupport.NewMemoryProductClient(
+			client.Product{Id: 1, Name: "Shirt", Price: 200000, DiscountPercentage: 10, Stock: 5},
+			client.Product{Id: 2, Name: "Hat", Price: 50000, Stock: 1},
+		),
+		notifier: testsupport.NewMemoryNotifier(),
+		jwtKeys:  jwtKeys,
+		appConfig: &config.Config{
+			AccessTokenExpireMinutes:          "15",
+			RefreshTokenExpireMinutes:         "60",
+			EmailVerificationExpireMinutes:    "60",
+			PasswordResetExpireMinutes:        "30",
+			PasswordResetURL:                  "http://localhost:3000/reset-password",
+			LoginMaxFailedAttemptsPerUsername: "3",
+			LoginMaxFailedAttemptsPerIp:       "20",
+			LoginFailedAttemptWindowMinutes:   "15",
+			LoginLockoutMinutes:               "15",
+			OutboxBatchSize:                   "10",
+			OutboxMaxAttempts:                 "2",
+		},
+	}
+
+	ctx := context.Background()
+	for _, role := range []model.Role{{Name: model.RoleAdmin}, {Name: model.RoleCustomer}} {
+		if err := f.roleRepository.Create(ctx, &role); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.roleRepository.AddPermissions(ctx, model.RoleAdmin, []string{model.PermissionRoleRead, model.PermissionRoleWrite}); err != nil {
+		t.Fatal(err)
+	}
+
+	return f
+}
+
+func (f *fixture) newUserService() UserService {
+	return NewUserService(f.userRepository, f.cartRepository, f.sessionRepository, f.roleRepository, f.oneTimeTokenRepository,
+		f.loginAttemptRepository, f.auditLogRepository, f.notifier, f.jwtKeys, f.appConfig)
+}
+
+func (f *fixture) newInvoiceService() InvoiceService {
+	return NewInvoiceService(f.invoiceRepository, f.invoiceElasticsearchRepository, f.invoiceDetailRepository, f.invoiceStatusHistoryRepository,
+		f.outboxEventRepository, f.userRepository, f.cartRepository, f.cartItemRepository, f.productClient, f.transactionManager)
+}
+
+// Creates a user with a cart, password of every test user is its username
+func (f *fixture) createUser(t *testing.T, username string, roleName string, verified bool) (*model.User, *model.Cart) {
+	t.Helper()
+
+	hashedPassword, err := utils.HashPassword(username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newUser := model.User{
+		FullName:       strings.ToUpper(username),
+		Email:          username + "@example.com",
+		Username:       username,
+		HashedPassword: hashedPassword,
+		RoleName:       roleName,
+	}
+	if verified {
+		now := time.Now().UTC()
+		newUser.EmailVerifiedAt = &now
+	}
+	if err := f.userRepository.Create(context.Background(), &newUser); err != nil {
+		t.Fatal(err)
+	}
+
+	newCart := model.Cart{UserId: newUser.Id}
+	if err := f.cartRepository.Create(context.Background(), &newCart); err != nil {
+		t.Fatal(err)
+	}
+
+	return &newUser, &newCart
+}
+
+func (f *fixture) createInvoice(t *testing.T, userId int64, status string, totalAmount int64) *model.Invoice {
+	t.Helper()
+
+	newInvoice := model.Invoice{UserId: userId, Status: status, TotalAmount: totalAmount}
+	if err := f.invoiceRepository.Create(context.Background(), &newInvoice); err != nil {
+		t.Fatal(err)
+	}
+
+	return &newInvoice
+}
+
+// Verification messages end with the token, password reset messages carry it in a link
+func (f *fixture) lastToken(t *testing.T, to string) string {
+	t.Helper()
+
+	if link, err := url.Parse(f.lastLink(t, to)); err == nil && link.Query().Has("token") {
+		return link.Query().Get("token")
+	}
+	lines := f.lastLines(t, to)
+
+	return lines[len(lines)-1]
+}
+
+// Line of the newest message that holds a link, or its last line when there is none
+func (f *fixture) lastLink(t *testing.T, to string) string {
+	t.Helper()
+
+	lines := f.lastLines(t, to)
+	for _, line := range lines {
+		if strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://") {
+			return line
+		}
+	}
+
+	return lines[len(lines)-1]
+}
+
+func (f *fixture) lastLines(t *testing.T, to string) []string {
+	t.Helper()
+
+	messages := f.notifier.Messages(to)
+	if len(messages) == 0 {
+		t.Fatalf("no message was sent to %s", to)
+	}
+
+	return strings.Split(messages[len(messages)-1].Body, "\n")
+}
+
+// Memory session repository tells which access tokens are denied, Redis one only answers the auth middleware
+func (f *fixture) isAccessTokenDenied(accessTokenId string) bool {
+	return f.sessionRepository.(interface{ IsAccessTokenDenied(string) bool }).IsAccessTokenDenied(accessTokenId)
+}
+
+func mustPageRequest(t *testing.T) *utils.PageRequest {
+	t.Helper()
+
+	pageRequest, err := utils.NewPageRequest("", 10, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pageRequest
+}
+
+// Empty wantErr means no error is expected, otherwise error message must contain it
+func checkErr(t *testing.T, err error, wantErr string) {
+	t.Helper()
+
+	if wantErr == "" {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+	if err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Fatalf("error = %v, want error containing %q", err, wantErr)
+	}
+}
@@ -2,24 +2,49 @@ package service
 
 import (
 	"context"
-	"encoding/json"
+	"database/sql"
+	"errors"
 	"fmt"
+	"net/url"
+	"strings"
 	"thanhldt060802/config"
-	"thanhldt060802/infrastructure"
 	"thanhldt060802/internal/dto"
 	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/notifier"
 	"thanhldt060802/internal/repository"
 	"thanhldt060802/utils"
 	"time"
 )
 
+var (
+	ErrInvalidCredentials   = errors.New("username or password is not valid")
+	ErrTooManyLoginAttempts = errors.New("too many failed login attempts")
+)
+
+// Delay before next login attempt doubles with every recent failure up to the cap
+const (
+	loginDelayBase = time.Second
+	loginDelayMax  = 30 * time.Second
+)
+
+// Checked against when username does not exist, so unknown user and bad password take the same time
+var dummyHashedPassword, _ = utils.HashPassword("dummy-password")
+
 type userService struct {
-	userRepository repository.UserRepository
-	cartRepository repository.CartRepository
+	userRepository         repository.UserRepository
+	cartRepository         repository.CartRepository
+	sessionRepository      repository.SessionRepository
+	roleRepository         repository.RoleRepository
+	oneTimeTokenRepository repository.OneTimeTokenRepository
+	loginAttemptRepository repository.LoginAttemptRepository
+	auditLogRepository     repository.AuditLogRepository
+	notifier               notifier.Notifier
+	jwtKeys                *utils.JWTKeys
+	appConfig              *config.Config
 }
 
 type UserService interface {
-	GetUsers(ctx context.Context, reqDTO *dto.GetUsersWithQueryParamRequest) ([]model.User, error)
+	GetUsers(ctx context.Context, reqDTO *dto.GetUsersWithQueryParamRequest) ([]model.User, *utils.Page, error)
 	GetUserById(ctx context.Context, reqDTO *dto.GetUserByIdRequest) (*model.User, error)
 	GetUserByUsername(ctx context.Context, reqDTO *dto.GetUserByUsernameRequest) (*model.User, error)
 	GetUserByEmail(ctx context.Context, reqDTO *dto.GetUserByEmailRequest) (*model.User, error)
@@ -27,26 +52,64 @@ type UserService interface {
 	UpdateUserById(ctx context.Context, reqDTO *dto.UpdateUserRequest) error
 	DeleteUserById(ctx context.Context, reqDTO *dto.DeleteUserRequest) error
 
-	LoginUser(ctx context.Context, reqDTO *dto.LoginUserRequest) (*string, error)
+	RegisterUser(ctx context.Context, reqDTO *dto.RegisterRequest) error
+	VerifyEmail(ctx context.Context, reqDTO *dto.VerifyEmailRequest) error
+	ResendEmailVerification(ctx context.Context, reqDTO *dto.ResendEmailVerificationRequest) error
+
+	ChangePassword(ctx context.Context, reqDTO *dto.ChangePasswordRequest) error
+	RequestPasswordReset(ctx context.Context, reqDTO *dto.RequestPasswordResetRequest) error
+	ConfirmPasswordReset(ctx context.Context, reqDTO *dto.ConfirmPasswordResetRequest) error
+
+	LoginUser(ctx context.Context, reqDTO *dto.LoginUserRequest) (*model.Token, error)
+	RefreshToken(ctx context.Context, reqDTO *dto.RefreshTokenRequest) (*model.Token, error)
 	LogoutUser(ctx context.Context, reqDTO *dto.LogoutUserRequest) error
+
+	GetSessionsByUserId(ctx context.Context, reqDTO *dto.GetSessionsByUserIdRequest) ([]model.Session, error)
+	DeleteSession(ctx context.Context, reqDTO *dto.DeleteSessionRequest) error
+	DeleteSessionsByUserId(ctx context.Context, reqDTO *dto.DeleteSessionsByUserIdRequest) error
+
+	GetLoginLockouts(ctx context.Context) ([]model.LoginLockout, error)
+	ClearLoginLockout(ctx context.Context, reqDTO *dto.ClearLoginLockoutRequest) error
 }
 
-func NewUserService(userRepository repository.UserRepository, cartRepository repository.CartRepository) UserService {
+func NewUserService(
+	userRepository repository.UserRepository,
+	cartRepository repository.CartRepository,
+	sessionRepository repository.SessionRepository,
+	roleRepository repository.RoleRepository,
+	oneTimeTokenRepository repository.OneTimeTokenRepository,
+	loginAttemptRepository repository.LoginAttemptRepository,
+	auditLogRepository repository.AuditLogRepository,
+	notifier notifier.Notifier,
+	jwtKeys *utils.JWTKeys,
+	appConfig *config.Config,
+) UserService {
 	return &userService{
-		userRepository: userRepository,
-		cartRepository: cartRepository,
+		userRepository:         userRepository,
+		cartRepository:         cartRepository,
+		sessionRepository:      sessionRepository,
+		roleRepository:         roleRepository,
+		oneTimeTokenRepository: oneTimeTokenRepository,
+		loginAttemptRepository: loginAttemptRepository,
+		auditLogRepository:     auditLogRepository,
+		notifier:               notifier,
+		jwtKeys:                jwtKeys,
+		appConfig:              appConfig,
 	}
 }
 
-func (userService *userService) GetUsers(ctx context.Context, reqDTO *dto.GetUsersWithQueryParamRequest) ([]model.User, error) {
-	sortFields := utils.ParseSortBy(reqDTO.SortBy)
+func (userService *userService) GetUsers(ctx context.Context, reqDTO *dto.GetUsersWithQueryParamRequest) ([]model.User, *utils.Page, error) {
+	pageRequest, err := utils.NewPageRequest(reqDTO.Cursor, reqDTO.Limit, reqDTO.SortBy, reqDTO.IncludeTotal)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	users, err := userService.userRepository.Get(ctx, reqDTO.Offset, reqDTO.Limit, sortFields)
+	users, page, err := userService.userRepository.Get(ctx, pageRequest)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return users, nil
+	return users, page, nil
 }
 
 func (userService *userService) GetUserById(ctx context.Context, reqDTO *dto.GetUserByIdRequest) (*model.User, error) {
@@ -83,19 +146,25 @@ func (userService *userService) CreateUser(ctx context.Context, reqDTO *dto.Crea
 	if _, err := userService.userRepository.GetByEmail(ctx, reqDTO.Body.Email); err == nil {
 		return fmt.Errorf("email of user is already exists")
 	}
+	if _, err := userService.roleRepository.GetByName(ctx, reqDTO.Body.RoleName); err != nil {
+		return fmt.Errorf("role name of user is not valid")
+	}
 
 	hashedPassword, err := utils.HashPassword(reqDTO.Body.Password)
 	if err != nil {
 		return fmt.Errorf("hash password failed")
 	}
 
+	// Accounts created by staff are trusted, email is not verified again
+	now := time.Now().UTC()
 	newUser := model.User{
-		FullName:       reqDTO.Body.FullName,
-		Email:          reqDTO.Body.Email,
-		Username:       reqDTO.Body.Username,
-		HashedPassword: hashedPassword,
-		Address:        reqDTO.Body.Address,
-		RoleName:       reqDTO.Body.RoleName,
+		FullName:        reqDTO.Body.FullName,
+		Email:           reqDTO.Body.Email,
+		Username:        reqDTO.Body.Username,
+		HashedPassword:  hashedPassword,
+		Address:         reqDTO.Body.Address,
+		RoleName:        reqDTO.Body.RoleName,
+		EmailVerifiedAt: &now,
 	}
 	if err := userService.userRepository.Create(ctx, &newUser); err != nil {
 		return err
@@ -120,24 +189,34 @@ func (userService *userService) UpdateUserById(ctx context.Context, reqDTO *dto.
 	if reqDTO.Body.FullName != nil {
 		foundUser.FullName = *reqDTO.Body.FullName
 	}
-	if reqDTO.Body.Email != nil && reqDTO.Body.Email != &foundUser.Email {
+	emailChanged := false
+	if reqDTO.Body.Email != nil && *reqDTO.Body.Email != foundUser.Email {
 		if _, err = userService.userRepository.GetByEmail(ctx, *reqDTO.Body.Email); err == nil {
 			return fmt.Errorf("email of user is already exists")
 		}
 		foundUser.Email = *reqDTO.Body.Email
+		foundUser.EmailVerifiedAt = nil
+		emailChanged = true
 	}
+	passwordChanged := false
 	if reqDTO.Body.Password != nil {
 		hashedPassword, err := utils.HashPassword(*reqDTO.Body.Password)
 		if err != nil {
 			return fmt.Errorf("hash password failed")
 		}
 		foundUser.HashedPassword = hashedPassword
+		passwordChanged = true
 	}
 	if reqDTO.Body.Address != nil {
 		foundUser.Address = *reqDTO.Body.Address
 	}
-	if reqDTO.Body.RoleName != nil {
+	roleChanged := false
+	if reqDTO.Body.RoleName != nil && *reqDTO.Body.RoleName != foundUser.RoleName {
+		if _, err := userService.roleRepository.GetByName(ctx, *reqDTO.Body.RoleName); err != nil {
+			return fmt.Errorf("role name of user is not valid")
+		}
 		foundUser.RoleName = *reqDTO.Body.RoleName
+		roleChanged = true
 	}
 	foundUser.UpdatedAt = time.Now().UTC()
 
@@ -145,6 +224,18 @@ func (userService *userService) UpdateUserById(ctx context.Context, reqDTO *dto.
 		return err
 	}
 
+	// Tokens carry role and permissions, so old ones must not outlive a role change
+	if passwordChanged || roleChanged {
+		if err := userService.revokeSessions(ctx, foundUser.Id, ""); err != nil {
+			return err
+		}
+	}
+	if emailChanged {
+		if err := userService.sendEmailVerification(ctx, foundUser); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -166,15 +257,279 @@ func (userService *userService) DeleteUserById(ctx context.Context, reqDTO *dto.
 		return err
 	}
 
+	if err := userService.revokeSessions(ctx, reqDTO.Id, ""); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-func (userService *userService) LoginUser(ctx context.Context, reqDTO *dto.LoginUserRequest) (*string, error) {
-	foundUser, err := userService.userRepository.GetByUsername(ctx, reqDTO.Body.Username)
+// Self-service registration always creates an unverified customer, privileged roles are only assigned through CreateUser
+func (userService *userService) RegisterUser(ctx context.Context, reqDTO *dto.RegisterRequest) error {
+	if _, err := userService.userRepository.GetByUsername(ctx, reqDTO.Body.Username); err == nil {
+		return fmt.Errorf("username of user is already exists")
+	}
+	if _, err := userService.userRepository.GetByEmail(ctx, reqDTO.Body.Email); err == nil {
+		return fmt.Errorf("email of user is already exists")
+	}
+
+	hashedPassword, err := utils.HashPassword(reqDTO.Body.Password)
 	if err != nil {
+		return fmt.Errorf("hash password failed")
+	}
+
+	newUser := model.User{
+		FullName:       reqDTO.Body.FullName,
+		Email:          reqDTO.Body.Email,
+		Username:       reqDTO.Body.Username,
+		HashedPassword: hashedPassword,
+		Address:        reqDTO.Body.Address,
+		RoleName:       model.RoleCustomer,
+	}
+	if err := userService.userRepository.Create(ctx, &newUser); err != nil {
+		return err
+	}
+
+	newCart := model.Cart{
+		UserId: newUser.Id,
+	}
+	if err := userService.cartRepository.Create(ctx, &newCart); err != nil {
+		return err
+	}
+
+	if err := userService.sendEmailVerification(ctx, &newUser); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (userService *userService) VerifyEmail(ctx context.Context, reqDTO *dto.VerifyEmailRequest) error {
+	foundOneTimeToken, err := userService.oneTimeTokenRepository.Consume(ctx, model.OneTimeTokenPurposeEmailVerification, reqDTO.Body.Token)
+	if err != nil {
+		return err
+	}
+
+	foundUser, err := userService.userRepository.GetById(ctx, foundOneTimeToken.UserId)
+	if err != nil {
+		return fmt.Errorf("id of user is not valid")
+	}
+
+	// Token was sent to an address the user has since replaced
+	if foundUser.Email != foundOneTimeToken.Email {
+		return repository.ErrOneTimeTokenNotFound
+	}
+	if foundUser.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	foundUser.EmailVerifiedAt = &now
+	foundUser.UpdatedAt = now
+	if err := userService.userRepository.UpdateById(ctx, foundUser.Id, foundUser); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (userService *userService) ResendEmailVerification(ctx context.Context, reqDTO *dto.ResendEmailVerificationRequest) error {
+	foundUser, err := userService.userRepository.GetById(ctx, reqDTO.UserId)
+	if err != nil {
+		return fmt.Errorf("id of user is not valid")
+	}
+	if foundUser.EmailVerifiedAt != nil {
+		return fmt.Errorf("email of user is already verified")
+	}
+
+	if err := userService.sendEmailVerification(ctx, foundUser); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (userService *userService) GetSessionsByUserId(ctx context.Context, reqDTO *dto.GetSessionsByUserIdRequest) ([]model.Session, error) {
+	sessions, err := userService.sessionRepository.GetByUserId(ctx, reqDTO.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("get sessions from redis failed")
+	}
+
+	return sessions, nil
+}
+
+func (userService *userService) DeleteSession(ctx context.Context, reqDTO *dto.DeleteSessionRequest) error {
+	foundSession, err := userService.sessionRepository.GetById(ctx, reqDTO.SessionId)
+	if err != nil || foundSession.UserId != reqDTO.UserId {
+		return fmt.Errorf("id of session is not valid")
+	}
+
+	if _, err := userService.sessionRepository.DeleteById(ctx, foundSession.Id); err != nil && err != repository.ErrSessionNotFound {
+		return fmt.Errorf("delete session from redis failed")
+	}
+
+	if err := userService.sessionRepository.DenyAccessToken(ctx, foundSession.AccessTokenId, foundSession.AccessTokenExpiresAt); err != nil {
+		return fmt.Errorf("revoke token in redis failed")
+	}
+
+	return nil
+}
+
+func (userService *userService) DeleteSessionsByUserId(ctx context.Context, reqDTO *dto.DeleteSessionsByUserIdRequest) error {
+	if _, err := userService.userRepository.GetById(ctx, reqDTO.Id); err != nil {
+		return fmt.Errorf("id of user is not valid")
+	}
+
+	if err := userService.revokeSessions(ctx, reqDTO.Id, ""); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (userService *userService) GetLoginLockouts(ctx context.Context) ([]model.LoginLockout, error) {
+	loginLockouts, err := userService.loginAttemptRepository.GetLockouts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get login lockouts from redis failed")
+	}
+
+	return loginLockouts, nil
+}
+
+func (userService *userService) ClearLoginLockout(ctx context.Context, reqDTO *dto.ClearLoginLockoutRequest) error {
+	value := reqDTO.Value
+	if reqDTO.Scope == model.LoginAttemptScopeUsername {
+		value = strings.ToLower(value)
+	}
+
+	if err := userService.loginAttemptRepository.DeleteLockout(ctx, reqDTO.Scope, value); err != nil {
+		return err
+	}
+
+	newAuditLog := model.AuditLog{
+		Action:    model.AuditActionLoginLockoutCleared,
+		ActorId:   reqDTO.ActorId,
+		Target:    fmt.Sprintf("%s:%s", reqDTO.Scope, value),
+		IpAddress: reqDTO.IpAddress,
+	}
+	if err := userService.auditLogRepository.Create(ctx, &newAuditLog); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Current session survives so the user stays logged in on the device that changed the password
+func (userService *userService) ChangePassword(ctx context.Context, reqDTO *dto.ChangePasswordRequest) error {
+	foundUser, err := userService.userRepository.GetById(ctx, reqDTO.UserId)
+	if err != nil {
+		return fmt.Errorf("id of user is not valid")
+	}
+	if utils.CheckPassword(foundUser.HashedPassword, reqDTO.CurrentPassword) != nil {
+		return fmt.Errorf("current password does not match")
+	}
+
+	hashedPassword, err := utils.HashPassword(reqDTO.NewPassword)
+	if err != nil {
+		return fmt.Errorf("hash password failed")
+	}
+	foundUser.HashedPassword = hashedPassword
+	foundUser.UpdatedAt = time.Now().UTC()
+
+	if err := userService.userRepository.UpdateById(ctx, foundUser.Id, foundUser); err != nil {
+		return err
+	}
+
+	if err := userService.revokeSessions(ctx, foundUser.Id, reqDTO.SessionId); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Unknown email is not reported so the endpoint can not be used to find registered accounts
+func (userService *userService) RequestPasswordReset(ctx context.Context, reqDTO *dto.RequestPasswordResetRequest) error {
+	foundUser, err := userService.userRepository.GetByEmail(ctx, reqDTO.Body.Email)
+	if err != nil {
+		return nil
+	}
+
+	token, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return fmt.Errorf("generate password reset token failed")
+	}
+
+	newOneTimeToken := model.OneTimeToken{
+		Purpose: model.OneTimeTokenPurposePasswordReset,
+		UserId:  foundUser.Id,
+		Email:   foundUser.Email,
+	}
+	expireDuration := *userService.appConfig.GetPasswordResetExpireMinutes()
+	if err := userService.oneTimeTokenRepository.Create(ctx, token, &newOneTimeToken, expireDuration); err != nil {
+		return fmt.Errorf("save password reset token to redis failed: %w", err)
+	}
+
+	resetLink := fmt.Sprintf("%s?token=%s", userService.appConfig.PasswordResetURL, url.QueryEscape(token))
+	message := notifier.Message{
+		To:      foundUser.Email,
+		Subject: "Reset your password",
+		Body:    fmt.Sprintf("Hello %s,\n\nOpen this link to reset your password, it expires in %s:\n\n%s\n\nIgnore this message if you did not request it.", foundUser.FullName, expireDuration, resetLink),
+	}
+	if err := userService.notifier.Send(ctx, &message); err != nil {
+		return fmt.Errorf("send password reset failed: %w", err)
+	}
+
+	return nil
+}
+
+func (userService *userService) ConfirmPasswordReset(ctx context.Context, reqDTO *dto.ConfirmPasswordResetRequest) error {
+	foundOneTimeToken, err := userService.oneTimeTokenRepository.Consume(ctx, model.OneTimeTokenPurposePasswordReset, reqDTO.Body.Token)
+	if err != nil {
+		return err
+	}
+
+	foundUser, err := userService.userRepository.GetById(ctx, foundOneTimeToken.UserId)
+	if err != nil || foundUser.Email != foundOneTimeToken.Email {
+		return repository.ErrOneTimeTokenNotFound
+	}
+
+	hashedPassword, err := utils.HashPassword(reqDTO.Body.NewPassword)
+	if err != nil {
+		return fmt.Errorf("hash password failed")
+	}
+	foundUser.HashedPassword = hashedPassword
+	foundUser.UpdatedAt = time.Now().UTC()
+
+	if err := userService.userRepository.UpdateById(ctx, foundUser.Id, foundUser); err != nil {
+		return err
+	}
+
+	if err := userService.revokeSessions(ctx, foundUser.Id, ""); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (userService *userService) LoginUser(ctx context.Context, reqDTO *dto.LoginUserRequest) (*model.Token, error) {
+	loginAttemptTargets := userService.newLoginAttemptTargets(reqDTO)
+	if err := userService.checkLoginAttempts(ctx, loginAttemptTargets); err != nil {
+		return nil, err
+	}
+
+	foundUser, err := userService.userRepository.GetByUsername(ctx, reqDTO.Body.Username)
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.CheckPassword(dummyHashedPassword, reqDTO.Body.Password)
+		return nil, userService.recordLoginFailure(ctx, loginAttemptTargets, reqDTO.IpAddress)
+	} else if err != nil {
 		return nil, err
 	} else if utils.CheckPassword(foundUser.HashedPassword, reqDTO.Body.Password) != nil {
-		return nil, fmt.Errorf("password does not match")
+		return nil, userService.recordLoginFailure(ctx, loginAttemptTargets, reqDTO.IpAddress)
+	}
+
+	// Only username counter is reset, one valid account must not clear failures of a whole ip
+	if err := userService.loginAttemptRepository.Reset(ctx, model.LoginAttemptScopeUsername, loginAttemptTargets[0].value); err != nil {
+		return nil, fmt.Errorf("reset login attempts in redis failed")
 	}
 
 	foundCart, err := userService.cartRepository.GetByUserId(ctx, foundUser.Id)
@@ -182,45 +537,268 @@ func (userService *userService) LoginUser(ctx context.Context, reqDTO *dto.Login
 		return nil, err
 	}
 
-	token, err := utils.GenerateToken(foundUser.Id, foundUser.RoleName, foundCart.Id)
+	sessionId, err := utils.GenerateRandomString(16)
 	if err != nil {
-		return nil, fmt.Errorf("generate token failed")
+		return nil, fmt.Errorf("generate session id failed")
 	}
 
-	expireDuration := config.AppConfig.GetTokenExpireMinutes()
-	if expireDuration == nil {
-		return nil, fmt.Errorf("convert expire failed")
+	permissions, err := userService.roleRepository.GetPermissionNames(ctx, foundUser.RoleName)
+	if err != nil {
+		return nil, err
 	}
 
-	redisKey := fmt.Sprintf("token:%s", *token)
-	userData := map[string]interface{}{
-		"user_id":   foundUser.Id,
-		"role_name": foundUser.RoleName,
-		"cart_id":   foundCart.Id,
+	token, session, err := userService.newToken(foundUser, permissions, foundCart.Id, sessionId)
+	if err != nil {
+		return nil, err
 	}
-	userDataBytes, _ := json.Marshal(userData)
 
-	status, err := infrastructure.RedisClient.SetEx(ctx, redisKey, userDataBytes, *expireDuration).Result()
+	session.UserId = foundUser.Id
+	session.Device = reqDTO.Body.Device
+	if session.Device == "" {
+		session.Device = describeDevice(reqDTO.UserAgent)
+	}
+	session.IpAddress = reqDTO.IpAddress
+	session.UserAgent = reqDTO.UserAgent
+	session.CreatedAt = time.Now().UTC()
+	if err := userService.sessionRepository.Create(ctx, session, *userService.appConfig.GetRefreshTokenExpireMinutes()); err != nil {
+		return nil, fmt.Errorf("save session to redis failed: %w", err)
+	}
+
+	return token, nil
+}
+
+func (userService *userService) RefreshToken(ctx context.Context, reqDTO *dto.RefreshTokenRequest) (*model.Token, error) {
+	sessionId, _, found := strings.Cut(reqDTO.Body.RefreshToken, ".")
+	if !found || sessionId == "" {
+		return nil, fmt.Errorf("invalid refresh token")
+	}
+
+	foundSession, err := userService.sessionRepository.GetById(ctx, sessionId)
 	if err != nil {
-		return nil, fmt.Errorf("save token to redis failed: %w", err)
+		return nil, err
 	}
-	if status != "OK" {
-		return nil, fmt.Errorf("unexpected response from Redis: %s", status)
+
+	// Role may have changed since login, so claims are rebuilt from database
+	foundUser, err := userService.userRepository.GetById(ctx, foundSession.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	foundCart, err := userService.cartRepository.GetByUserId(ctx, foundUser.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := userService.roleRepository.GetPermissionNames(ctx, foundUser.RoleName)
+	if err != nil {
+		return nil, err
+	}
+
+	token, session, err := userService.newToken(foundUser, permissions, foundCart.Id, sessionId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := userService.sessionRepository.Rotate(ctx, sessionId, utils.HashSecret(reqDTO.Body.RefreshToken), session.RefreshTokenHash,
+		session.AccessTokenId, session.AccessTokenExpiresAt, *userService.appConfig.GetRefreshTokenExpireMinutes()); err != nil {
+		return nil, err
 	}
 
 	return token, nil
 }
 
 func (userService *userService) LogoutUser(ctx context.Context, reqDTO *dto.LogoutUserRequest) error {
-	redisKey := fmt.Sprintf("token:%s", reqDTO.Body.Token)
+	if _, err := userService.sessionRepository.DeleteById(ctx, reqDTO.SessionId); err != nil && err != repository.ErrSessionNotFound {
+		return fmt.Errorf("delete session from redis failed")
+	}
+
+	if err := userService.sessionRepository.DenyAccessToken(ctx, reqDTO.AccessTokenId, reqDTO.AccessTokenExpiresAt); err != nil {
+		return fmt.Errorf("revoke token in redis failed")
+	}
+
+	return nil
+}
+
+func (userService *userService) sendEmailVerification(ctx context.Context, user *model.User) error {
+	token, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return fmt.Errorf("generate email verification token failed")
+	}
+
+	newOneTimeToken := model.OneTimeToken{
+		Purpose: model.OneTimeTokenPurposeEmailVerification,
+		UserId:  user.Id,
+		Email:   user.Email,
+	}
+	expireDuration := *userService.appConfig.GetEmailVerificationExpireMinutes()
+	if err := userService.oneTimeTokenRepository.Create(ctx, token, &newOneTimeToken, expireDuration); err != nil {
+		return fmt.Errorf("save email verification token to redis failed: %w", err)
+	}
+
+	message := notifier.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body:    fmt.Sprintf("Hello %s,\n\nUse this token with POST /verify-email to verify your email, it expires in %s:\n\n%s", user.FullName, expireDuration, token),
+	}
+	if err := userService.notifier.Send(ctx, &message); err != nil {
+		return fmt.Errorf("send email verification failed: %w", err)
+	}
+
+	return nil
+}
 
-	deleted, err := infrastructure.RedisClient.Del(ctx, redisKey).Result()
+// Deletes refresh sessions of the user and denies their access tokens right away instead of waiting for them to expire
+func (userService *userService) revokeSessions(ctx context.Context, userId int64, exceptSessionId string) error {
+	sessions, err := userService.sessionRepository.DeleteByUserId(ctx, userId, exceptSessionId)
 	if err != nil {
-		return fmt.Errorf("delete token from redis failed")
+		return fmt.Errorf("delete sessions from redis failed")
 	}
-	if deleted == 0 {
-		return fmt.Errorf("token not found or expired")
+
+	for _, session := range sessions {
+		if err := userService.sessionRepository.DenyAccessToken(ctx, session.AccessTokenId, session.AccessTokenExpiresAt); err != nil {
+			return fmt.Errorf("revoke token in redis failed")
+		}
+	}
+
+	return nil
+}
+
+type loginAttemptTarget struct {
+	scope       string
+	value       string
+	maxAttempts int
+}
+
+// Username target always comes first, ip target is skipped when client ip is unknown
+func (userService *userService) newLoginAttemptTargets(reqDTO *dto.LoginUserRequest) []loginAttemptTarget {
+	loginAttemptTargets := []loginAttemptTarget{
+		{model.LoginAttemptScopeUsername, strings.ToLower(reqDTO.Body.Username), userService.appConfig.GetLoginMaxFailedAttemptsPerUsername()},
+	}
+	if reqDTO.IpAddress != "" {
+		loginAttemptTargets = append(loginAttemptTargets, loginAttemptTarget{model.LoginAttemptScopeIp, reqDTO.IpAddress, userService.appConfig.GetLoginMaxFailedAttemptsPerIp()})
+	}
+
+	return loginAttemptTargets
+}
+
+func (userService *userService) checkLoginAttempts(ctx context.Context, loginAttemptTargets []loginAttemptTarget) error {
+	for _, target := range loginAttemptTargets {
+		foundLoginLockout, err := userService.loginAttemptRepository.GetLockout(ctx, target.scope, target.value)
+		if err == nil {
+			return fmt.Errorf("%w, retry after %d seconds", ErrTooManyLoginAttempts, int(time.Until(foundLoginLockout.ExpiresAt).Seconds())+1)
+		} else if err != repository.ErrLoginLockoutNotFound {
+			return fmt.Errorf("check login lockout in redis failed")
+		}
+
+		foundLoginAttempt, err := userService.loginAttemptRepository.Get(ctx, target.scope, target.value)
+		if err != nil {
+			return fmt.Errorf("check login attempts in redis failed")
+		}
+		if retryAfter := time.Until(foundLoginAttempt.LastFailedAt.Add(loginDelay(foundLoginAttempt.FailedCount))); retryAfter > 0 {
+			return fmt.Errorf("%w, retry after %d seconds", ErrTooManyLoginAttempts, int(retryAfter.Seconds())+1)
+		}
 	}
 
 	return nil
 }
+
+// Every failed login is reported the same way whatever the cause, locking a target is written to audit trail
+func (userService *userService) recordLoginFailure(ctx context.Context, loginAttemptTargets []loginAttemptTarget, ipAddress string) error {
+	windowDuration := *userService.appConfig.GetLoginFailedAttemptWindowMinutes()
+	lockoutDuration := *userService.appConfig.GetLoginLockoutMinutes()
+
+	for _, target := range loginAttemptTargets {
+		loginAttempt, err := userService.loginAttemptRepository.RecordFailure(ctx, target.scope, target.value, windowDuration)
+		if err != nil {
+			return fmt.Errorf("record login attempt in redis failed")
+		}
+		if loginAttempt.FailedCount < target.maxAttempts {
+			continue
+		}
+
+		newLoginLockout := model.LoginLockout{
+			Scope:       target.scope,
+			Value:       target.value,
+			FailedCount: loginAttempt.FailedCount,
+			LockedAt:    time.Now().UTC(),
+		}
+		if err := userService.loginAttemptRepository.CreateLockout(ctx, &newLoginLockout, lockoutDuration); err != nil {
+			return fmt.Errorf("save login lockout to redis failed")
+		}
+
+		newAuditLog := model.AuditLog{
+			Action:    model.AuditActionLoginLockout,
+			Target:    fmt.Sprintf("%s:%s", target.scope, target.value),
+			IpAddress: ipAddress,
+			Details:   fmt.Sprintf("locked for %s after %d failed login attempts", lockoutDuration, loginAttempt.FailedCount),
+		}
+		if err := userService.auditLogRepository.Create(ctx, &newAuditLog); err != nil {
+			return err
+		}
+	}
+
+	return ErrInvalidCredentials
+}
+
+func loginDelay(failedCount int) time.Duration {
+	if failedCount <= 0 {
+		return 0
+	}
+
+	delay := loginDelayBase
+	for i := 1; i < failedCount && delay < loginDelayMax; i++ {
+		delay *= 2
+	}
+
+	return min(delay, loginDelayMax)
+}
+
+// Coarse label for sessions whose client did not name its device
+func describeDevice(userAgent string) string {
+	platforms := []struct {
+		keyword string
+		name    string
+	}{
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Macintosh", "Mac"},
+		{"Linux", "Linux"},
+	}
+	for _, platform := range platforms {
+		if strings.Contains(userAgent, platform.keyword) {
+			return platform.name
+		}
+	}
+
+	return "Unknown device"
+}
+
+// Refresh token carries session id in front so the session is found without a lookup table
+func (userService *userService) newToken(user *model.User, permissions []string, cartId int64, sessionId string) (*model.Token, *model.Session, error) {
+	accessToken, claims, err := utils.GenerateToken(userService.jwtKeys, *userService.appConfig.GetAccessTokenExpireMinutes(), user.Id, user.RoleName, permissions, cartId, sessionId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate token failed")
+	}
+
+	refreshTokenSecret, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate refresh token failed")
+	}
+	refreshToken := fmt.Sprintf("%s.%s", sessionId, refreshTokenSecret)
+
+	token := &model.Token{
+		AccessToken:  *accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    claims.ExpiresAt.Time,
+	}
+	session := &model.Session{
+		Id:                   sessionId,
+		RefreshTokenHash:     utils.HashSecret(refreshToken),
+		AccessTokenId:        claims.ID,
+		AccessTokenExpiresAt: claims.ExpiresAt.Time,
+	}
+
+	return token, session, nil
+}
@@ -0,0 +1,904 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"testing"
+	"thanhldt060802/internal/dto"
+	"thanhldt060802/internal/model"
+	"thanhldt060802/internal/repository"
+	"thanhldt060802/utils"
+	"time"
+)
+
+func TestUserService_GetUsers(t *testing.T) {
+	tests := []struct {
+		name      string
+		sortBy    string
+		cursor    func(page *utils.Page) string
+		wantNames []string
+		wantErr   string
+	}{
+		{name: "first page", wantNames: []string{"alice", "bob"}},
+		{name: "next page", cursor: func(page *utils.Page) string { return page.NextCursor }, wantNames: []string{"carol"}},
+		{name: "sorted descending", sortBy: "username:desc", wantNames: []string{"carol", "bob"}},
+		{name: "unknown sort field", sortBy: "password", wantErr: "can not sort by password"},
+		{name: "cursor of another sort", sortBy: "username", cursor: func(page *utils.Page) string { return page.NextCursor }, wantErr: "cursor does not match sort_by"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			for _, username := range []string{"alice", "bob", "carol"} {
+				f.createUser(t, username, model.RoleCustomer, true)
+			}
+			userService := f.newUserService()
+
+			reqDTO := &dto.GetUsersWithQueryParamRequest{Limit: 2, SortBy: tt.sortBy, IncludeTotal: true}
+			if tt.cursor != nil {
+				_, firstPage, err := userService.GetUsers(context.Background(), &dto.GetUsersWithQueryParamRequest{Limit: 2})
+				if err != nil {
+					t.Fatal(err)
+				}
+				reqDTO.Cursor = tt.cursor(firstPage)
+			}
+
+			users, page, err := userService.GetUsers(context.Background(), reqDTO)
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			if len(users) != len(tt.wantNames) {
+				t.Fatalf("got %d users, want %d", len(users), len(tt.wantNames))
+			}
+			for i, user := range users {
+				if user.Username != tt.wantNames[i] {
+					t.Errorf("users[%d] = %s, want %s", i, user.Username, tt.wantNames[i])
+				}
+			}
+			if page.Total == nil || *page.Total != 3 {
+				t.Errorf("total = %v, want 3", page.Total)
+			}
+		})
+	}
+}
+
+func TestUserService_GetUser(t *testing.T) {
+	f := newFixture(t)
+	alice, _ := f.createUser(t, "alice", model.RoleCustomer, true)
+	userService := f.newUserService()
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		get     func() (*model.User, error)
+		wantErr error
+	}{
+		{name: "by id", get: func() (*model.User, error) {
+			return userService.GetUserById(ctx, &dto.GetUserByIdRequest{Id: alice.Id})
+		}},
+		{name: "by unknown id", get: func() (*model.User, error) {
+			return userService.GetUserById(ctx, &dto.GetUserByIdRequest{Id: 99})
+		}, wantErr: sql.ErrNoRows},
+		{name: "by username", get: func() (*model.User, error) {
+			return userService.GetUserByUsername(ctx, &dto.GetUserByUsernameRequest{Username: "alice"})
+		}},
+		{name: "by unknown username", get: func() (*model.User, error) {
+			return userService.GetUserByUsername(ctx, &dto.GetUserByUsernameRequest{Username: "bob"})
+		}, wantErr: sql.ErrNoRows},
+		{name: "by email", get: func() (*model.User, error) {
+			return userService.GetUserByEmail(ctx, &dto.GetUserByEmailRequest{Email: "alice@example.com"})
+		}},
+		{name: "by unknown email", get: func() (*model.User, error) {
+			return userService.GetUserByEmail(ctx, &dto.GetUserByEmailRequest{Email: "bob@example.com"})
+		}, wantErr: sql.ErrNoRows},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := tt.get()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && user.Id != alice.Id {
+				t.Errorf("got user %d, want %d", user.Id, alice.Id)
+			}
+		})
+	}
+}
+
+func TestUserService_CreateUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		email    string
+		roleName string
+		wantErr  string
+	}{
+		{name: "created", username: "bob", email: "bob@example.com", roleName: model.RoleAdmin},
+		{name: "duplicate username", username: "alice", email: "bob@example.com", roleName: model.RoleCustomer, wantErr: "username of user is already exists"},
+		{name: "duplicate email", username: "bob", email: "alice@example.com", roleName: model.RoleCustomer, wantErr: "email of user is already exists"},
+		{name: "unknown role", username: "bob", email: "bob@example.com", roleName: "GUEST", wantErr: "role name of user is not valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createUser(t, "alice", model.RoleCustomer, true)
+			userService := f.newUserService()
+
+			reqDTO := &dto.CreateUserRequest{}
+			reqDTO.Body.FullName = "Bob"
+			reqDTO.Body.Username = tt.username
+			reqDTO.Body.Email = tt.email
+			reqDTO.Body.Password = "secret-password"
+			reqDTO.Body.RoleName = tt.roleName
+
+			err := userService.CreateUser(context.Background(), reqDTO)
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+
+			createdUser, err := f.userRepository.GetByUsername(context.Background(), tt.username)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if createdUser.EmailVerifiedAt == nil || createdUser.RoleName != tt.roleName {
+				t.Errorf("created user = %+v, want verified %s", createdUser, tt.roleName)
+			}
+			if _, err := f.cartRepository.GetByUserId(context.Background(), createdUser.Id); err != nil {
+				t.Errorf("cart of created user: %v", err)
+			}
+		})
+	}
+}
+
+func TestUserService_UpdateUserById(t *testing.T) {
+	stringOf := func(value string) *string { return &value }
+
+	tests := []struct {
+		name                string
+		id                  int64
+		body                func(reqDTO *dto.UpdateUserRequest)
+		wantErr             string
+		wantSessionsRevoked bool
+		wantVerification    bool
+	}{
+		{name: "full name and address", body: func(reqDTO *dto.UpdateUserRequest) {
+			reqDTO.Body.FullName = stringOf("Alice Nguyen")
+			reqDTO.Body.Address = stringOf("Da Nang")
+		}},
+		{name: "email is verified again", body: func(reqDTO *dto.UpdateUserRequest) {
+			reqDTO.Body.Email = stringOf("alice@example.org")
+		}, wantVerification: true},
+		{name: "password revokes sessions", body: func(reqDTO *dto.UpdateUserRequest) {
+			reqDTO.Body.Password = stringOf("new-password")
+		}, wantSessionsRevoked: true},
+		{name: "role revokes sessions", body: func(reqDTO *dto.UpdateUserRequest) {
+			reqDTO.Body.RoleName = stringOf(model.RoleAdmin)
+		}, wantSessionsRevoked: true},
+		{name: "unknown user", id: 99, body: func(reqDTO *dto.UpdateUserRequest) {}, wantErr: "id of user is not valid"},
+		{name: "email of another user", body: func(reqDTO *dto.UpdateUserRequest) {
+			reqDTO.Body.Email = stringOf("bob@example.com")
+		}, wantErr: "email of user is already exists"},
+		{name: "unknown role", body: func(reqDTO *dto.UpdateUserRequest) {
+			reqDTO.Body.RoleName = stringOf("GUEST")
+		}, wantErr: "role name of user is not valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			alice, _ := f.createUser(t, "alice", model.RoleCustomer, true)
+			f.createUser(t, "bob", model.RoleCustomer, true)
+			userService := f.newUserService()
+			token := f.login(t, userService, "alice")
+
+			reqDTO := &dto.UpdateUserRequest{Id: alice.Id}
+			if tt.id != 0 {
+				reqDTO.Id = tt.id
+			}
+			tt.body(reqDTO)
+
+			err := userService.UpdateUserById(context.Background(), reqDTO)
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+
+			updatedUser, err := f.userRepository.GetById(context.Background(), alice.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reqDTO.Body.FullName != nil && updatedUser.FullName != *reqDTO.Body.FullName {
+				t.Errorf("full name = %s, want %s", updatedUser.FullName, *reqDTO.Body.FullName)
+			}
+			if (updatedUser.EmailVerifiedAt == nil) != tt.wantVerification {
+				t.Errorf("email verified at = %v, want verified %v", updatedUser.EmailVerifiedAt, !tt.wantVerification)
+			}
+			if sent := len(f.notifier.Messages("alice@example.org")) > 0; sent != tt.wantVerification {
+				t.Errorf("verification sent = %v, want %v", sent, tt.wantVerification)
+			}
+			if revoked := f.isRevoked(t, token); revoked != tt.wantSessionsRevoked {
+				t.Errorf("session revoked = %v, want %v", revoked, tt.wantSessionsRevoked)
+			}
+		})
+	}
+}
+
+func TestUserService_DeleteUserById(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(f *fixture, alice *model.User, cart *model.Cart)
+		id      int64
+		wantErr string
+	}{
+		{name: "deleted"},
+		{name: "unknown user", id: 99, wantErr: "user id of cart is not valid"},
+		{name: "user without cart", setup: func(f *fixture, alice *model.User, cart *model.Cart) {
+			f.cartRepository.DeleteById(context.Background(), cart.Id)
+		}, wantErr: "user id of cart is not valid"},
+		{name: "cart without user", setup: func(f *fixture, alice *model.User, cart *model.Cart) {
+			f.userRepository.DeleteById(context.Background(), alice.Id)
+		}, wantErr: "id of user is not valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			alice, cart := f.createUser(t, "alice", model.RoleCustomer, true)
+			userService := f.newUserService()
+			token := f.login(t, userService, "alice")
+			if tt.setup != nil {
+				tt.setup(f, alice, cart)
+			}
+
+			id := alice.Id
+			if tt.id != 0 {
+				id = tt.id
+			}
+
+			err := userService.DeleteUserById(context.Background(), &dto.DeleteUserRequest{Id: id})
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+
+			if _, err := f.userRepository.GetById(context.Background(), alice.Id); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("user still exists: %v", err)
+			}
+			if _, err := f.cartRepository.GetById(context.Background(), cart.Id); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("cart still exists: %v", err)
+			}
+			if !f.isRevoked(t, token) {
+				t.Error("session of deleted user is not revoked")
+			}
+		})
+	}
+}
+
+func TestUserService_RegisterUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		email    string
+		wantErr  string
+	}{
+		{name: "registered", username: "bob", email: "bob@example.com"},
+		{name: "duplicate username", username: "alice", email: "bob@example.com", wantErr: "username of user is already exists"},
+		{name: "duplicate email", username: "bob", email: "alice@example.com", wantErr: "email of user is already exists"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createUser(t, "alice", model.RoleCustomer, true)
+			userService := f.newUserService()
+
+			reqDTO := &dto.RegisterRequest{}
+			reqDTO.Body.FullName = "Bob"
+			reqDTO.Body.Username = tt.username
+			reqDTO.Body.Email = tt.email
+			reqDTO.Body.Password = "secret-password"
+
+			err := userService.RegisterUser(context.Background(), reqDTO)
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+
+			registeredUser, err := f.userRepository.GetByUsername(context.Background(), tt.username)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if registeredUser.RoleName != model.RoleCustomer || registeredUser.EmailVerifiedAt != nil {
+				t.Errorf("registered user = %+v, want unverified customer", registeredUser)
+			}
+			if len(f.notifier.Messages(tt.email)) != 1 {
+				t.Error("email verification is not sent")
+			}
+		})
+	}
+}
+
+func TestUserService_VerifyEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   func(f *fixture, userService UserService, bob *model.User) string
+		wantErr error
+	}{
+		{name: "verified", token: func(f *fixture, userService UserService, bob *model.User) string {
+			return f.lastToken(t, bob.Email)
+		}},
+		{name: "token used twice", token: func(f *fixture, userService UserService, bob *model.User) string {
+			token := f.lastToken(t, bob.Email)
+			verifyEmail(t, userService, token)
+			return token
+		}, wantErr: repository.ErrOneTimeTokenNotFound},
+		{name: "unknown token", token: func(f *fixture, userService UserService, bob *model.User) string {
+			return "unknown"
+		}, wantErr: repository.ErrOneTimeTokenNotFound},
+		{name: "email changed since", token: func(f *fixture, userService UserService, bob *model.User) string {
+			token := f.lastToken(t, bob.Email)
+			bob.Email = "bob@example.org"
+			f.userRepository.UpdateById(context.Background(), bob.Id, bob)
+			return token
+		}, wantErr: repository.ErrOneTimeTokenNotFound},
+		{name: "password reset token", token: func(f *fixture, userService UserService, bob *model.User) string {
+			reqDTO := &dto.RequestPasswordResetRequest{}
+			reqDTO.Body.Email = bob.Email
+			userService.RequestPasswordReset(context.Background(), reqDTO)
+			return f.lastToken(t, bob.Email)
+		}, wantErr: repository.ErrOneTimeTokenNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			userService := f.newUserService()
+			bob := f.register(t, userService, "bob")
+
+			err := userService.VerifyEmail(context.Background(), verifyEmailRequest(tt.token(f, userService, bob)))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			verifiedUser, _ := f.userRepository.GetById(context.Background(), bob.Id)
+			if verifiedUser.EmailVerifiedAt == nil {
+				t.Error("email is not verified")
+			}
+		})
+	}
+}
+
+func TestUserService_ResendEmailVerification(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		userId   int64
+		wantErr  string
+	}{
+		{name: "resent", username: "bob"},
+		{name: "already verified", username: "alice", wantErr: "email of user is already verified"},
+		{name: "unknown user", userId: 99, wantErr: "id of user is not valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			userService := f.newUserService()
+			f.createUser(t, "alice", model.RoleCustomer, true)
+			f.register(t, userService, "bob")
+
+			userId := tt.userId
+			if tt.username != "" {
+				user, _ := f.userRepository.GetByUsername(context.Background(), tt.username)
+				userId = user.Id
+			}
+
+			err := userService.ResendEmailVerification(context.Background(), &dto.ResendEmailVerificationRequest{UserId: userId})
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+
+			// Both tokens stay valid until they expire
+			messages := f.notifier.Messages("bob@example.com")
+			if len(messages) != 2 {
+				t.Fatalf("got %d messages, want 2", len(messages))
+			}
+			verifyEmail(t, userService, f.lastToken(t, "bob@example.com"))
+		})
+	}
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	tests := []struct {
+		name            string
+		userId          int64
+		currentPassword string
+		wantErr         string
+	}{
+		{name: "changed", currentPassword: "alice"},
+		{name: "wrong current password", currentPassword: "bob", wantErr: "current password does not match"},
+		{name: "unknown user", userId: 99, currentPassword: "alice", wantErr: "id of user is not valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			alice, _ := f.createUser(t, "alice", model.RoleCustomer, true)
+			userService := f.newUserService()
+			currentToken := f.login(t, userService, "alice")
+			otherToken := f.login(t, userService, "alice")
+
+			userId := alice.Id
+			if tt.userId != 0 {
+				userId = tt.userId
+			}
+
+			err := userService.ChangePassword(context.Background(), &dto.ChangePasswordRequest{
+				UserId:          userId,
+				SessionId:       sessionIdOf(t, f, currentToken),
+				CurrentPassword: tt.currentPassword,
+				NewPassword:     "new-password",
+			})
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+
+			if f.isRevoked(t, currentToken) {
+				t.Error("current session is revoked")
+			}
+			if !f.isRevoked(t, otherToken) {
+				t.Error("other session is not revoked")
+			}
+			if _, err := userService.LoginUser(context.Background(), loginRequest("alice", "new-password")); err != nil {
+				t.Errorf("login with new password: %v", err)
+			}
+		})
+	}
+}
+
+func TestUserService_RequestPasswordReset(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		wantSent bool
+	}{
+		{name: "sent", email: "alice@example.com", wantSent: true},
+		{name: "unknown email is not reported", email: "bob@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createUser(t, "alice", model.RoleCustomer, true)
+			userService := f.newUserService()
+
+			reqDTO := &dto.RequestPasswordResetRequest{}
+			reqDTO.Body.Email = tt.email
+
+			checkErr(t, userService.RequestPasswordReset(context.Background(), reqDTO), "")
+			messages := f.notifier.Messages(tt.email)
+			if sent := len(messages) > 0; sent != tt.wantSent {
+				t.Fatalf("sent = %v, want %v", sent, tt.wantSent)
+			}
+			if tt.wantSent {
+				link, err := url.Parse(f.lastLink(t, tt.email))
+				if err != nil || link.Host != "localhost:3000" || link.Query().Get("token") == "" {
+					t.Errorf("reset link = %v, want link to reset page with token", link)
+				}
+			}
+		})
+	}
+}
+
+func TestUserService_ConfirmPasswordReset(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   func(f *fixture, userService UserService) string
+		wantErr error
+	}{
+		{name: "reset", token: func(f *fixture, userService UserService) string {
+			return f.lastToken(t, "alice@example.com")
+		}},
+		{name: "token used twice", token: func(f *fixture, userService UserService) string {
+			token := f.lastToken(t, "alice@example.com")
+			userService.ConfirmPasswordReset(context.Background(), confirmPasswordResetRequest(token))
+			return token
+		}, wantErr: repository.ErrOneTimeTokenNotFound},
+		{name: "unknown token", token: func(f *fixture, userService UserService) string {
+			return "unknown"
+		}, wantErr: repository.ErrOneTimeTokenNotFound},
+		{name: "user deleted since", token: func(f *fixture, userService UserService) string {
+			alice, _ := f.userRepository.GetByUsername(context.Background(), "alice")
+			userService.DeleteUserById(context.Background(), &dto.DeleteUserRequest{Id: alice.Id})
+			return f.lastToken(t, "alice@example.com")
+		}, wantErr: repository.ErrOneTimeTokenNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createUser(t, "alice", model.RoleCustomer, true)
+			userService := f.newUserService()
+			token := f.login(t, userService, "alice")
+
+			reqDTO := &dto.RequestPasswordResetRequest{}
+			reqDTO.Body.Email = "alice@example.com"
+			if err := userService.RequestPasswordReset(context.Background(), reqDTO); err != nil {
+				t.Fatal(err)
+			}
+
+			err := userService.ConfirmPasswordReset(context.Background(), confirmPasswordResetRequest(tt.token(f, userService)))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if !f.isRevoked(t, token) {
+				t.Error("session is not revoked")
+			}
+			if _, err := userService.LoginUser(context.Background(), loginRequest("alice", "new-password")); err != nil {
+				t.Errorf("login with new password: %v", err)
+			}
+		})
+	}
+}
+
+func TestUserService_LoginUser(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAttempts string
+		setup       func(f *fixture, userService UserService)
+		username    string
+		password    string
+		wantErr     error
+		wantLockout bool
+	}{
+		{name: "logged in", username: "alice", password: "alice"},
+		{name: "username is case insensitive for attempts", username: "Alice", password: "alice", setup: func(f *fixture, userService UserService) {
+			f.loginAttemptRepository.CreateLockout(context.Background(), &model.LoginLockout{
+				Scope: model.LoginAttemptScopeUsername, Value: "alice", FailedCount: 3,
+			}, time.Minute)
+		}, wantErr: ErrTooManyLoginAttempts},
+		{name: "wrong password", username: "alice", password: "bob", wantErr: ErrInvalidCredentials},
+		{name: "unknown username", username: "bob", password: "bob", wantErr: ErrInvalidCredentials},
+		{name: "retried too soon", username: "alice", password: "alice", setup: func(f *fixture, userService UserService) {
+			userService.LoginUser(context.Background(), loginRequest("alice", "bob"))
+		}, wantErr: ErrTooManyLoginAttempts},
+		{name: "locked out after max attempts", maxAttempts: "1", username: "alice", password: "bob", wantErr: ErrInvalidCredentials, wantLockout: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			if tt.maxAttempts != "" {
+				f.appConfig.LoginMaxFailedAttemptsPerUsername = tt.maxAttempts
+			}
+			alice, _ := f.createUser(t, "alice", model.RoleCustomer, true)
+			userService := f.newUserService()
+			if tt.setup != nil {
+				tt.setup(f, userService)
+			}
+
+			token, err := userService.LoginUser(context.Background(), loginRequest(tt.username, tt.password))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			loginLockouts, _ := userService.GetLoginLockouts(context.Background())
+			if locked := len(loginLockouts) > 0; locked != tt.wantLockout && tt.setup == nil {
+				t.Errorf("locked = %v, want %v", locked, tt.wantLockout)
+			}
+			if tt.wantLockout {
+				auditLogs, _, _ := f.auditLogRepository.Get(context.Background(), model.AuditActionLoginLockout, mustPageRequest(t))
+				if len(auditLogs) != 1 || auditLogs[0].Target != "username:alice" {
+					t.Errorf("audit logs = %+v, want one lockout of username:alice", auditLogs)
+				}
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			claims, err := utils.ValidateToken(f.jwtKeys, token.AccessToken)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.UserId != alice.Id || claims.RoleName != model.RoleCustomer {
+				t.Errorf("claims = %+v, want customer %d", claims, alice.Id)
+			}
+			sessions, _ := userService.GetSessionsByUserId(context.Background(), &dto.GetSessionsByUserIdRequest{UserId: alice.Id})
+			if len(sessions) != 1 || sessions[0].Device != "Linux" || sessions[0].IpAddress != "10.0.0.1" {
+				t.Errorf("sessions = %+v, want one Linux session from 10.0.0.1", sessions)
+			}
+		})
+	}
+}
+
+func TestUserService_RefreshToken(t *testing.T) {
+	tests := []struct {
+		name         string
+		refreshToken func(token *model.Token) string
+		setup        func(f *fixture, userService UserService, token *model.Token)
+		wantErr      string
+	}{
+		{name: "refreshed", refreshToken: func(token *model.Token) string { return token.RefreshToken }},
+		{name: "role change is picked up", refreshToken: func(token *model.Token) string { return token.RefreshToken },
+			setup: func(f *fixture, userService UserService, token *model.Token) {
+				alice, _ := f.userRepository.GetByUsername(context.Background(), "alice")
+				alice.RoleName = model.RoleAdmin
+				f.userRepository.UpdateById(context.Background(), alice.Id, alice)
+			}},
+		{name: "malformed", refreshToken: func(token *model.Token) string { return "malformed" }, wantErr: "invalid refresh token"},
+		{name: "unknown session", refreshToken: func(token *model.Token) string { return "unknown.secret" }, wantErr: repository.ErrSessionNotFound.Error()},
+		{name: "reused", refreshToken: func(token *model.Token) string { return token.RefreshToken },
+			setup: func(f *fixture, userService UserService, token *model.Token) {
+				if _, err := userService.RefreshToken(context.Background(), refreshTokenRequest(token.RefreshToken)); err != nil {
+					t.Fatal(err)
+				}
+			}, wantErr: repository.ErrRefreshTokenReused.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createUser(t, "alice", model.RoleCustomer, true)
+			userService := f.newUserService()
+			token := f.login(t, userService, "alice")
+			if tt.setup != nil {
+				tt.setup(f, userService, token)
+			}
+
+			newToken, err := userService.RefreshToken(context.Background(), refreshTokenRequest(tt.refreshToken(token)))
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+
+			if newToken.RefreshToken == token.RefreshToken {
+				t.Error("refresh token is not rotated")
+			}
+			claims, err := utils.ValidateToken(f.jwtKeys, newToken.AccessToken)
+			if err != nil {
+				t.Fatal(err)
+			}
+			alice, _ := f.userRepository.GetByUsername(context.Background(), "alice")
+			if claims.RoleName != alice.RoleName {
+				t.Errorf("role = %s, want %s", claims.RoleName, alice.RoleName)
+			}
+		})
+	}
+}
+
+func TestUserService_LogoutUser(t *testing.T) {
+	tests := []struct {
+		name      string
+		sessionId func(sessionId string) string
+	}{
+		{name: "logged out", sessionId: func(sessionId string) string { return sessionId }},
+		{name: "session already gone", sessionId: func(sessionId string) string { return "unknown" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createUser(t, "alice", model.RoleCustomer, true)
+			userService := f.newUserService()
+			token := f.login(t, userService, "alice")
+			claims, _ := utils.ValidateToken(f.jwtKeys, token.AccessToken)
+
+			err := userService.LogoutUser(context.Background(), &dto.LogoutUserRequest{
+				SessionId:            tt.sessionId(claims.SessionId),
+				AccessTokenId:        claims.ID,
+				AccessTokenExpiresAt: claims.ExpiresAt.Time,
+			})
+			checkErr(t, err, "")
+			if !f.isAccessTokenDenied(claims.ID) {
+				t.Error("access token is not denied")
+			}
+		})
+	}
+}
+
+func TestUserService_DeleteSession(t *testing.T) {
+	tests := []struct {
+		name      string
+		username  string
+		sessionId string
+		wantErr   string
+	}{
+		{name: "deleted", username: "alice"},
+		{name: "session of another user", username: "bob", wantErr: "id of session is not valid"},
+		{name: "unknown session", username: "alice", sessionId: "unknown", wantErr: "id of session is not valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.createUser(t, "alice", model.RoleCustomer, true)
+			f.createUser(t, "bob", model.RoleCustomer, true)
+			userService := f.newUserService()
+			token := f.login(t, userService, "alice")
+
+			user, _ := f.userRepository.GetByUsername(context.Background(), tt.username)
+			sessionId := sessionIdOf(t, f, token)
+			if tt.sessionId != "" {
+				sessionId = tt.sessionId
+			}
+
+			err := userService.DeleteSession(context.Background(), &dto.DeleteSessionRequest{UserId: user.Id, SessionId: sessionId})
+			checkErr(t, err, tt.wantErr)
+			if revoked := f.isRevoked(t, token); revoked != (tt.wantErr == "") {
+				t.Errorf("revoked = %v, want %v", revoked, tt.wantErr == "")
+			}
+		})
+	}
+}
+
+func TestUserService_DeleteSessionsByUserId(t *testing.T) {
+	tests := []struct {
+		name    string
+		userId  int64
+		wantErr string
+	}{
+		{name: "deleted"},
+		{name: "unknown user", userId: 99, wantErr: "id of user is not valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			alice, _ := f.createUser(t, "alice", model.RoleCustomer, true)
+			userService := f.newUserService()
+			tokens := []*model.Token{f.login(t, userService, "alice"), f.login(t, userService, "alice")}
+
+			userId := alice.Id
+			if tt.userId != 0 {
+				userId = tt.userId
+			}
+
+			err := userService.DeleteSessionsByUserId(context.Background(), &dto.DeleteSessionsByUserIdRequest{Id: userId})
+			checkErr(t, err, tt.wantErr)
+			for _, token := range tokens {
+				if revoked := f.isRevoked(t, token); revoked != (tt.wantErr == "") {
+					t.Errorf("revoked = %v, want %v", revoked, tt.wantErr == "")
+				}
+			}
+		})
+	}
+}
+
+func TestUserService_ClearLoginLockout(t *testing.T) {
+	tests := []struct {
+		name    string
+		scope   string
+		value   string
+		wantErr error
+	}{
+		{name: "username lockout", scope: model.LoginAttemptScopeUsername, value: "ALICE"},
+		{name: "ip lockout", scope: model.LoginAttemptScopeIp, value: "10.0.0.1"},
+		{name: "not locked", scope: model.LoginAttemptScopeIp, value: "10.0.0.2", wantErr: repository.ErrLoginLockoutNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.appConfig.LoginMaxFailedAttemptsPerUsername = "1"
+			f.appConfig.LoginMaxFailedAttemptsPerIp = "1"
+			admin, _ := f.createUser(t, "admin", model.RoleAdmin, true)
+			userService := f.newUserService()
+			userService.LoginUser(context.Background(), loginRequest("alice", "alice"))
+
+			loginLockouts, err := userService.GetLoginLockouts(context.Background())
+			if err != nil || len(loginLockouts) != 2 {
+				t.Fatalf("got lockouts %+v, error %v, want 2", loginLockouts, err)
+			}
+
+			err = userService.ClearLoginLockout(context.Background(), &dto.ClearLoginLockoutRequest{
+				Scope: tt.scope, Value: tt.value, ActorId: admin.Id, IpAddress: "10.0.0.9",
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			loginLockouts, _ = userService.GetLoginLockouts(context.Background())
+			if len(loginLockouts) != 1 || loginLockouts[0].Scope == tt.scope {
+				t.Errorf("lockouts = %+v, want only the other scope left", loginLockouts)
+			}
+			auditLogs, _, _ := f.auditLogRepository.Get(context.Background(), model.AuditActionLoginLockoutCleared, mustPageRequest(t))
+			if len(auditLogs) != 1 || auditLogs[0].ActorId != admin.Id {
+				t.Errorf("audit logs = %+v, want one cleared by %d", auditLogs, admin.Id)
+			}
+		})
+	}
+}
+
+func (f *fixture) login(t *testing.T, userService UserService, username string) *model.Token {
+	t.Helper()
+
+	token, err := userService.LoginUser(context.Background(), loginRequest(username, username))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func (f *fixture) register(t *testing.T, userService UserService, username string) *model.User {
+	t.Helper()
+
+	reqDTO := &dto.RegisterRequest{}
+	reqDTO.Body.FullName = username
+	reqDTO.Body.Username = username
+	reqDTO.Body.Email = username + "@example.com"
+	reqDTO.Body.Password = username
+	if err := userService.RegisterUser(context.Background(), reqDTO); err != nil {
+		t.Fatal(err)
+	}
+
+	registeredUser, err := f.userRepository.GetByUsername(context.Background(), username)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return registeredUser
+}
+
+// A revoked login has lost its session and its access token is denied
+func (f *fixture) isRevoked(t *testing.T, token *model.Token) bool {
+	t.Helper()
+
+	claims, err := utils.ValidateToken(f.jwtKeys, token.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.sessionRepository.GetById(context.Background(), claims.SessionId)
+
+	return errors.Is(err, repository.ErrSessionNotFound) && f.isAccessTokenDenied(claims.ID)
+}
+
+func sessionIdOf(t *testing.T, f *fixture, token *model.Token) string {
+	t.Helper()
+
+	claims, err := utils.ValidateToken(f.jwtKeys, token.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return claims.SessionId
+}
+
+func loginRequest(username string, password string) *dto.LoginUserRequest {
+	reqDTO := &dto.LoginUserRequest{IpAddress: "10.0.0.1", UserAgent: "Mozilla/5.0 (X11; Linux x86_64)"}
+	reqDTO.Body.Username = username
+	reqDTO.Body.Password = password
+
+	return reqDTO
+}
+
+func refreshTokenRequest(refreshToken string) *dto.RefreshTokenRequest {
+	reqDTO := &dto.RefreshTokenRequest{}
+	reqDTO.Body.RefreshToken = refreshToken
+
+	return reqDTO
+}
+
+func verifyEmailRequest(token string) *dto.VerifyEmailRequest {
+	reqDTO := &dto.VerifyEmailRequest{}
+	reqDTO.Body.Token = token
+
+	return reqDTO
+}
+
+func verifyEmail(t *testing.T, userService UserService, token string) {
+	t.Helper()
+
+	if err := userService.VerifyEmail(context.Background(), verifyEmailRequest(token)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func confirmPasswordResetRequest(token string) *dto.ConfirmPasswordResetRequest {
+	reqDTO := &dto.ConfirmPasswordResetRequest{}
+	reqDTO.Body.Token = token
+	reqDTO.Body.NewPassword = "new-password"
+
+	return reqDTO
+}
@@ -0,0 +1,40 @@
+package testsupport
+
+import (
+	"context"
+	"sync"
+	"thanhldt060802/internal/notifier"
+)
+
+// Keeps sent messages in memory so tests can read tokens and links users would receive
+type MemoryNotifier struct {
+	mutex    sync.Mutex
+	messages []notifier.Message
+}
+
+func NewMemoryNotifier() *MemoryNotifier {
+	return &MemoryNotifier{}
+}
+
+func (memoryNotifier *MemoryNotifier) Send(ctx context.Context, message *notifier.Message) error {
+	memoryNotifier.mutex.Lock()
+	defer memoryNotifier.mutex.Unlock()
+
+	memoryNotifier.messages = append(memoryNotifier.messages, *message)
+	return nil
+}
+
+// Messages sent to the address so far, oldest first
+func (memoryNotifier *MemoryNotifier) Messages(to string) []notifier.Message {
+	memoryNotifier.mutex.Lock()
+	defer memoryNotifier.mutex.Unlock()
+
+	messages := []notifier.Message{}
+	for _, message := range memoryNotifier.messages {
+		if message.To == to {
+			messages = append(messages, message)
+		}
+	}
+
+	return messages
+}
@@ -0,0 +1,140 @@
+// Package testsupport holds in-memory fakes of catalog service and of notifier, it is imported by tests only and never
+// built into the service binary.
+package testsupport
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"thanhldt060802/internal/client"
+	"time"
+)
+
+type memoryProductClient struct {
+	mutex             sync.Mutex
+	products          map[int64]client.Product
+	stockReservations map[int64]client.StockReservation
+	lastReservationId int64
+}
+
+// Serves the given products and holds stock like catalog service does
+func NewMemoryProductClient(products ...client.Product) client.ProductClient {
+	memoryProductClient := &memoryProductClient{
+		products:          map[int64]client.Product{},
+		stockReservations: map[int64]client.StockReservation{},
+	}
+	for _, product := range products {
+		memoryProductClient.products[product.Id] = product
+	}
+
+	return memoryProductClient
+}
+
+func (memoryProductClient *memoryProductClient) GetProductById(ctx context.Context, id int64) (*client.Product, error) {
+	memoryProductClient.mutex.Lock()
+	defer memoryProductClient.mutex.Unlock()
+
+	product, ok := memoryProductClient.products[id]
+	if !ok {
+		return nil, client.ErrProductNotFound
+	}
+
+	return &product, nil
+}
+
+func (memoryProductClient *memoryProductClient) GetProductsByIds(ctx context.Context, ids []int64) ([]client.Product, error) {
+	return memoryProductClient.GetProductsByIdsNoCache(ctx, ids)
+}
+
+// Unknown ids are left out, like the catalog endpoint
+func (memoryProductClient *memoryProductClient) GetProductsByIdsNoCache(ctx context.Context, ids []int64) ([]client.Product, error) {
+	memoryProductClient.mutex.Lock()
+	defer memoryProductClient.mutex.Unlock()
+
+	products := []client.Product{}
+	seen := map[int64]bool{}
+	for _, id := range ids {
+		if product, ok := memoryProductClient.products[id]; ok && !seen[id] {
+			products = append(products, product)
+		}
+		seen[id] = true
+	}
+
+	return products, nil
+}
+
+func (memoryProductClient *memoryProductClient) ReserveStock(ctx context.Context, items []client.StockReservationItem) (*client.StockReservation, error) {
+	memoryProductClient.mutex.Lock()
+	defer memoryProductClient.mutex.Unlock()
+
+	for _, item := range items {
+		product, ok := memoryProductClient.products[item.ProductId]
+		if !ok {
+			return nil, fmt.Errorf("reserve stock failed: product with id = %d not found", item.ProductId)
+		}
+		if product.Stock < item.Quantity {
+			return nil, fmt.Errorf("reserve stock failed: stock of product with id = %d is not enough", item.ProductId)
+		}
+	}
+	for _, item := range items {
+		product := memoryProductClient.products[item.ProductId]
+		product.Stock -= item.Quantity
+		memoryProductClient.products[item.ProductId] = product
+	}
+
+	memoryProductClient.lastReservationId++
+	stockReservation := client.StockReservation{
+		Id:        memoryProductClient.lastReservationId,
+		Status:    "PENDING",
+		Items:     append([]client.StockReservationItem{}, items...),
+		ExpiresAt: time.Now().UTC().Add(15 * time.Minute),
+	}
+	memoryProductClient.stockReservations[stockReservation.Id] = stockReservation
+
+	return &stockReservation, nil
+}
+
+func (memoryProductClient *memoryProductClient) ConfirmStockReservation(ctx context.Context, id int64) error {
+	memoryProductClient.mutex.Lock()
+	defer memoryProductClient.mutex.Unlock()
+
+	stockReservation, ok := memoryProductClient.stockReservations[id]
+	if !ok {
+		return fmt.Errorf("confirm stock reservation failed: id of stock reservation not found")
+	}
+	if stockReservation.Status == "CONFIRMED" {
+		return nil
+	}
+	if stockReservation.Status != "PENDING" {
+		return fmt.Errorf("confirm stock reservation failed: stock reservation is already %s", stockReservation.Status)
+	}
+
+	stockReservation.Status = "CONFIRMED"
+	memoryProductClient.stockReservations[id] = stockReservation
+
+	return nil
+}
+
+// Held or confirmed stock goes back to products, releasing twice is a no-op
+func (memoryProductClient *memoryProductClient) ReleaseStockReservation(ctx context.Context, id int64) error {
+	memoryProductClient.mutex.Lock()
+	defer memoryProductClient.mutex.Unlock()
+
+	stockReservation, ok := memoryProductClient.stockReservations[id]
+	if !ok {
+		return fmt.Errorf("release stock reservation failed: id of stock reservation not found")
+	}
+	if stockReservation.Status != "PENDING" && stockReservation.Status != "CONFIRMED" {
+		return nil
+	}
+
+	for _, item := range stockReservation.Items {
+		product := memoryProductClient.products[item.ProductId]
+		product.Stock += item.Quantity
+		memoryProductClient.products[item.ProductId] = product
+	}
+	stockReservation.Status = "RELEASED"
+	memoryProductClient.stockReservations[id] = stockReservation
+
+	return nil
+}
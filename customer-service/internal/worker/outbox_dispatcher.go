@@ -0,0 +1,27 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"thanhldt060802/internal/service"
+	"time"
+)
+
+// Periodically pushes pending outbox events to Elasticsearch
+func StartOutboxDispatcher(ctx context.Context, outboxService service.OutboxService, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := outboxService.DispatchOutboxEvents(ctx); err != nil {
+					log.Printf("Dispatch outbox events failed: %s", err.Error())
+				}
+			}
+		}
+	}()
+}
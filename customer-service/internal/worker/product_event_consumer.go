@@ -0,0 +1,27 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"thanhldt060802/internal/service"
+	"time"
+)
+
+// Periodically applies product events published by catalog service
+func StartProductEventConsumer(ctx context.Context, productEventService service.ProductEventService, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := productEventService.ConsumeProductEvents(ctx); err != nil {
+					log.Printf("Consume product events failed: %s", err.Error())
+				}
+			}
+		}
+	}()
+}
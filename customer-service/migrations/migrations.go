@@ -0,0 +1,199 @@
+// Package migrations embeds SQL migrations owned by customer service.
+//
+// Schema migrations are required and recorded in customer_migrations, the service refuses to start until all of them
+// are applied. Seed migrations only add demo data for local databases and are recorded apart in
+// customer_seed_migrations, so they can be applied or rolled back without touching the schema.
+//
+// Tables live in the schema named by POSTGRES_SCHEMA, the service shares no table or foreign key with other services,
+// so each one can be migrated on its own or moved to a database of its own.
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/migrate"
+)
+
+//go:embed schema/*.sql
+var schemaFiles embed.FS
+
+//go:embed seed/*.sql
+var seedFiles embed.FS
+
+const (
+	schemaTable = "customer_migrations"
+	seedTable   = "customer_seed_migrations"
+)
+
+// Tables owned by customer service, deployments from before the split into schemas keep them in public
+var ownedTables = []string{
+	"roles", "permissions", "role_permissions", "users", "audit_logs", "carts", "cart_items", "invoices",
+	"invoice_status_history", "customer_outbox_events", "invoice_details",
+	schemaTable, schemaTable + "_locks", seedTable, seedTable + "_locks",
+}
+
+func NewSchemaMigrator(db *bun.DB) (*migrate.Migrator, error) {
+	return newMigrator(db, schemaFiles, "schema", schemaTable)
+}
+
+func NewSeedMigrator(db *bun.DB) (*migrate.Migrator, error) {
+	return newMigrator(db, seedFiles, "seed", seedTable)
+}
+
+func newMigrator(db *bun.DB, files embed.FS, dir string, table string) (*migrate.Migrator, error) {
+	migrations, err := discover(files, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return migrate.NewMigrator(db, migrations,
+		migrate.WithTableName(table),
+		migrate.WithLocksTableName(table+"_locks"),
+		migrate.WithMarkAppliedOnSuccess(true),
+	), nil
+}
+
+func discover(files embed.FS, dir string) (*migrate.Migrations, error) {
+	migrationFiles, err := fs.Sub(files, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := migrate.NewMigrations()
+	if err := migrations.Discover(migrationFiles); err != nil {
+		return nil, fmt.Errorf("discover %s migrations: %w", dir, err)
+	}
+
+	return migrations, nil
+}
+
+// Runs command of migrate subcommand: up applies pending migrations, down rolls back the last applied group and
+// status lists every migration
+func Run(ctx context.Context, migrator *migrate.Migrator, command string) error {
+	if err := migrator.Init(ctx); err != nil {
+		return fmt.Errorf("create migration tables: %w", err)
+	}
+
+	switch command {
+	case "up":
+		if err := migrator.Lock(ctx); err != nil {
+			return fmt.Errorf("lock migrations: %w", err)
+		}
+		defer migrator.Unlock(ctx)
+
+		group, err := migrator.Migrate(ctx)
+		if err != nil {
+			return fmt.Errorf("migrate up: %w", err)
+		}
+		if group.IsZero() {
+			log.Println("No pending migrations")
+			return nil
+		}
+		log.Printf("Migrated to %s", group)
+	case "down":
+		if err := migrator.Lock(ctx); err != nil {
+			return fmt.Errorf("lock migrations: %w", err)
+		}
+		defer migrator.Unlock(ctx)
+
+		group, err := migrator.Rollback(ctx)
+		if err != nil {
+			return fmt.Errorf("migrate down: %w", err)
+		}
+		if group.IsZero() {
+			log.Println("No migrations to roll back")
+			return nil
+		}
+		log.Printf("Rolled back %s", group)
+	case "status":
+		migrations, err := migrator.MigrationsWithStatus(ctx)
+		if err != nil {
+			return fmt.Errorf("read migrations: %w", err)
+		}
+		for _, migration := range migrations {
+			if migration.IsApplied() {
+				log.Printf("%s applied at %s", migration, migration.MigratedAt.Format("2006-01-02 15:04:05"))
+			} else {
+				log.Printf("%s pending", migration)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q, want up, down or status", command)
+	}
+
+	return nil
+}
+
+// Creates schema of the service, and once when it has no migration history yet, moves tables owned by the service out
+// of public schema so an existing deployment keeps its data and history
+func PrepareSchema(ctx context.Context, db *bun.DB, schema string) error {
+	return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS ?", bun.Ident(schema)); err != nil {
+			return fmt.Errorf("create schema %s: %w", schema, err)
+		}
+		if schema == "public" {
+			return nil
+		}
+
+		hasHistory, err := tableExists(ctx, tx, schema, schemaTable)
+		if err != nil {
+			return err
+		}
+		if hasHistory {
+			return nil
+		}
+
+		for _, table := range ownedTables {
+			exists, err := tableExists(ctx, tx, "public", table)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				continue
+			}
+
+			if _, err := tx.ExecContext(ctx, "ALTER TABLE public.? SET SCHEMA ?", bun.Ident(table), bun.Ident(schema)); err != nil {
+				return fmt.Errorf("move table %s to schema %s: %w", table, schema, err)
+			}
+			log.Printf("Moved table %s from public to schema %s", table, schema)
+		}
+
+		return nil
+	})
+}
+
+func tableExists(ctx context.Context, tx bun.Tx, schema string, table string) (bool, error) {
+	var exists bool
+	err := tx.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_schema = ? AND table_name = ?)", schema, table,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("look up table %s.%s: %w", schema, table, err)
+	}
+
+	return exists, nil
+}
+
+// Fails when the database misses schema migrations embedded in this binary, so the service never runs against
+// tables older than its models
+func CheckSchema(ctx context.Context, db *bun.DB) error {
+	migrator, err := NewSchemaMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	migrations, err := migrator.MigrationsWithStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("read applied migrations from %s, run migrate up first: %w", schemaTable, err)
+	}
+	if pending := migrations.Unapplied(); len(pending) > 0 {
+		return fmt.Errorf("database schema is out of date, pending migrations %s, run migrate up first", pending)
+	}
+
+	return nil
+}
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS invoice_details;
+DROP TABLE IF EXISTS customer_outbox_events;
+DROP TABLE IF EXISTS invoice_status_history;
+DROP TABLE IF EXISTS invoices;
+DROP TABLE IF EXISTS cart_items;
+DROP TABLE IF EXISTS carts;
+DROP TABLE IF EXISTS audit_logs;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
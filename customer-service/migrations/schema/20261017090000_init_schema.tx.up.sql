@@ -0,0 +1,142 @@
+-- Bảng vai trò
+CREATE TABLE IF NOT EXISTS roles (
+    name VARCHAR(255) PRIMARY KEY,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO roles (name, description) VALUES
+('ADMIN', 'Quản trị hệ thống'),
+('STAFF', 'Nhân viên bán hàng'),
+('CUSTOMER', 'Khách hàng')
+ON CONFLICT DO NOTHING;
+
+-- Bảng quyền
+CREATE TABLE IF NOT EXISTS permissions (
+    name VARCHAR(255) PRIMARY KEY,
+    description VARCHAR(255) NOT NULL DEFAULT ''
+);
+INSERT INTO permissions (name, description) VALUES
+('product:write', 'Tạo, sửa, xóa sản phẩm'),
+('category:write', 'Tạo, sửa, xóa danh mục'),
+('user:read', 'Xem người dùng'),
+('user:write', 'Tạo, sửa, xóa người dùng'),
+('cart:read', 'Xem giỏ hàng của người dùng'),
+('invoice:read', 'Xem hóa đơn và thống kê'),
+('invoice:write', 'Đổi trạng thái, xóa hóa đơn'),
+('invoice:refund', 'Hoàn tiền hóa đơn'),
+('role:read', 'Xem vai trò và quyền'),
+('role:write', 'Phân quyền cho vai trò'),
+('audit:read', 'Xem nhật ký kiểm toán')
+ON CONFLICT DO NOTHING;
+
+-- Bảng phân quyền cho vai trò
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_name VARCHAR(255) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission_name VARCHAR(255) NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role_name, permission_name)
+);
+-- Chỉ phân quyền mặc định khi bảng còn trống, giữ nguyên phân quyền đã chỉnh trên database có sẵn
+INSERT INTO role_permissions (role_name, permission_name)
+SELECT role_name, permission_name FROM (
+    SELECT 'ADMIN' AS role_name, name AS permission_name FROM permissions
+    UNION ALL
+    VALUES
+    ('STAFF', 'product:write'),
+    ('STAFF', 'category:write'),
+    ('STAFF', 'user:read'),
+    ('STAFF', 'cart:read'),
+    ('STAFF', 'invoice:read'),
+    ('STAFF', 'invoice:write')
+) AS defaults
+WHERE NOT EXISTS (SELECT 1 FROM role_permissions);
+
+-- Bảng người dùng
+CREATE TABLE IF NOT EXISTS users (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    full_name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    username VARCHAR(255) NOT NULL,
+    hashed_password TEXT NOT NULL,
+    address VARCHAR(255) NOT NULL,
+    role_name VARCHAR(255) NOT NULL REFERENCES roles(name),
+    email_verified_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Bảng nhật ký kiểm toán
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    action VARCHAR(255) NOT NULL,
+    actor_id BIGINT,
+    target VARCHAR(255) NOT NULL,
+    ip_address VARCHAR(255),
+    details TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+
+-- Bảng giỏ hàng
+CREATE TABLE IF NOT EXISTS carts (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Bảng chi tiết mặt hàng trong giỏ hàng
+CREATE TABLE IF NOT EXISTS cart_items (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    cart_id BIGINT NOT NULL REFERENCES carts(id),
+    product_id BIGINT NOT NULL,
+    quantity INT NOT NULL
+);
+
+-- Bảng hóa đơn
+CREATE TABLE IF NOT EXISTS invoices (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    total_amount BIGINT NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    stock_reservation_id BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Bảng lịch sử trạng thái hóa đơn
+CREATE TABLE IF NOT EXISTS invoice_status_history (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    invoice_id BIGINT NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    from_status VARCHAR(255),
+    to_status VARCHAR(255) NOT NULL,
+    actor_id BIGINT NOT NULL,
+    actor_role VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_invoice_status_history_invoice_id ON invoice_status_history (invoice_id);
+
+-- Bảng outbox đồng bộ Elasticsearch của customer service
+CREATE TABLE IF NOT EXISTS customer_outbox_events (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    aggregate_type VARCHAR(255) NOT NULL,
+    aggregate_id BIGINT NOT NULL,
+    event_type VARCHAR(255) NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_customer_outbox_events_status_next_attempt_at ON customer_outbox_events (status, next_attempt_at);
+
+-- Bảng chi tiết hóa đơn
+CREATE TABLE IF NOT EXISTS invoice_details (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    invoice_id BIGINT NOT NULL REFERENCES invoices(id),
+    product_id BIGINT NOT NULL,
+    price BIGINT NOT NULL,
+    discount_percentage INT NOT NULL,
+    quantity INT NOT NULL,
+    total_price BIGINT NOT NULL
+);
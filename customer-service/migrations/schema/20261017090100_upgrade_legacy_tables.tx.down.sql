@@ -0,0 +1 @@
+-- Cột và khóa ngoại thuộc lược đồ của 20261017090000_init_schema, không xóa khi rollback
@@ -0,0 +1,21 @@
+-- Bảng do docker/my_db.sql cũ tạo được giữ nguyên bởi CREATE TABLE IF NOT EXISTS của 20261017090000_init_schema,
+-- bổ sung cột và khóa ngoại mà các bảng đó còn thiếu
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'users' AND column_name = 'email_verified_at'
+    ) THEN
+        ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+        -- Người dùng có từ trước khi cần xác thực email được coi là đã xác thực
+        UPDATE users SET email_verified_at = created_at;
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conrelid = 'users'::regclass AND conname = 'users_role_name_fkey'
+    ) THEN
+        ALTER TABLE users ADD CONSTRAINT users_role_name_fkey FOREIGN KEY (role_name) REFERENCES roles(name);
+    END IF;
+END $$;
+
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS stock_reservation_id BIGINT;
@@ -0,0 +1,2 @@
+ALTER TABLE cart_items DROP CONSTRAINT IF EXISTS cart_items_product_id_fkey;
+ALTER TABLE invoice_details DROP CONSTRAINT IF EXISTS invoice_details_product_id_fkey;
@@ -0,0 +1,20 @@
+-- products thuộc catalog service, khóa ngoại chỉ được tạo khi products có trong cùng schema với customer service.
+-- Khi đã có khóa ngoại, migrate down và migrate seed down của customer service phải chạy trước catalog service
+DO $$
+BEGIN
+    IF to_regclass('products') IS NULL THEN
+        RETURN;
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conrelid = 'cart_items'::regclass AND conname = 'cart_items_product_id_fkey'
+    ) THEN
+        ALTER TABLE cart_items ADD CONSTRAINT cart_items_product_id_fkey FOREIGN KEY (product_id) REFERENCES products(id);
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conrelid = 'invoice_details'::regclass AND conname = 'invoice_details_product_id_fkey'
+    ) THEN
+        ALTER TABLE invoice_details ADD CONSTRAINT invoice_details_product_id_fkey FOREIGN KEY (product_id) REFERENCES products(id);
+    END IF;
+END $$;
@@ -0,0 +1,2 @@
+-- Khóa ngoại sang products của catalog service không được tạo lại
+DROP INDEX IF EXISTS idx_cart_items_product_id;
@@ -0,0 +1,6 @@
+-- products thuộc catalog service, sản phẩm được kiểm tra qua API và sự kiện thay vì khóa ngoại
+ALTER TABLE cart_items DROP CONSTRAINT IF EXISTS cart_items_product_id_fkey;
+ALTER TABLE invoice_details DROP CONSTRAINT IF EXISTS invoice_details_product_id_fkey;
+
+-- Xóa mặt hàng trong giỏ khi catalog service báo sản phẩm bị xóa
+CREATE INDEX IF NOT EXISTS idx_cart_items_product_id ON cart_items (product_id);
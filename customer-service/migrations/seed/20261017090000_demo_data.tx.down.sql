@@ -0,0 +1,2 @@
+-- Dữ liệu mẫu chỉ dùng cho database local, rollback xóa toàn bộ dữ liệu của các bảng được seed
+TRUNCATE invoice_details, invoice_status_history, invoices, cart_items, carts, users RESTART IDENTITY;
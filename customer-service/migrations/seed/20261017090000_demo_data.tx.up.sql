@@ -0,0 +1,179 @@
+-- cart_items và invoice_details dùng sản phẩm mẫu, chạy migrate seed up của catalog service trước
+
+INSERT INTO users (full_name, email, username, hashed_password, address, role_name, created_at) VALUES
+('Nguyễn Văn A', 'a@example.com', 'nguyenvana', '123', 'Hà Nội', 'ADMIN', '2024-01-15 09:12:34'), -- 1
+('Trần Thị B', 'b@example.com', 'tranthib', '123', 'TP. Hồ Chí Minh', 'STAFF', '2024-03-22 14:45:10'), -- 2
+('Lê Văn C', 'c@example.com', 'levanc', '123', 'Đà Nẵng', 'CUSTOMER', '2023-11-02 08:20:50'), -- 3
+('Phạm Thị D', 'd@example.com', 'phamthid', '123', 'Huế', 'CUSTOMER', '2023-12-10 17:15:27'), -- 4
+('Hoàng Văn E', 'e@example.com', 'hoangvane', '123', 'Cần Thơ', 'STAFF', '2024-02-01 10:05:00'), -- 5
+('Đỗ Thị F', 'f@example.com', 'dothif', '123', 'Hải Phòng', 'ADMIN', '2023-10-05 19:40:45'), -- 6
+('Bùi Văn G', 'g@example.com', 'buivang', '123', 'Vinh', 'CUSTOMER', '2024-04-11 07:55:15'), -- 7
+('Võ Thị H', 'h@example.com', 'vothih', '123', 'Nha Trang', 'STAFF', '2023-08-19 13:35:00'), -- 8
+('Đặng Văn I', 'i@example.com', 'dangvani', '123', 'Quảng Ninh', 'CUSTOMER', '2024-01-27 16:20:30'), -- 9
+('Ngô Thị J', 'j@example.com', 'ngothij', '123', 'Bắc Ninh', 'ADMIN', '2023-09-03 11:45:12'), -- 10
+('Nguyễn Văn K', 'k@example.com', 'nguyenvank', '123', 'Hà Tĩnh', 'CUSTOMER', '2024-05-01 09:10:00'), -- 11
+('Trần Thị L', 'l@example.com', 'tranthil', '123', 'Quảng Nam', 'STAFF', '2023-07-25 15:05:22'), -- 12
+('Lê Văn M', 'm@example.com', 'levanm', '123', 'Bình Dương', 'CUSTOMER', '2024-02-14 18:30:50'), -- 13
+('Phạm Thị N', 'n@example.com', 'phamthin', '123', 'Sóc Trăng', 'STAFF', '2023-11-30 20:12:10'), -- 14
+('Hoàng Văn O', 'o@example.com', 'hoangvano', '123', 'Thanh Hóa', 'ADMIN', '2023-06-12 12:34:56'), -- 15
+('Đỗ Thị P', 'p@example.com', 'dothip', '123', 'Hà Giang', 'CUSTOMER', '2024-03-05 08:45:00'), -- 16
+('Bùi Văn Q', 'q@example.com', 'buivanq', '123', 'Nam Định', 'STAFF', '2024-01-18 14:10:00'), -- 17
+('Võ Thị R', 'r@example.com', 'vothir', '123', 'Tuyên Quang', 'CUSTOMER', '2023-10-20 17:00:00'), -- 18
+('Đặng Văn S', 's@example.com', 'dangvans', '123', 'Lào Cai', 'STAFF', '2024-04-07 13:25:00'), -- 19
+('Ngô Thị T', 't@example.com', 'ngothit', '123', 'Đắk Lắk', 'ADMIN', '2023-09-15 09:50:00'); -- 20
+UPDATE users SET email_verified_at = created_at WHERE email LIKE '%@example.com' AND email_verified_at IS NULL;
+
+INSERT INTO carts(user_id, created_at) VALUES
+(1, '2024-01-15 09:12:34'), -- 1
+(2, '2024-03-22 14:45:10'), -- 2
+(3, '2023-11-02 08:20:50'), -- 3
+(4, '2023-12-10 17:15:27'), -- 4
+(5, '2024-02-01 10:05:00'), -- 5
+(6, '2023-10-05 19:40:45'), -- 6
+(7, '2024-04-11 07:55:15'), -- 7
+(8, '2023-08-19 13:35:00'), -- 8
+(9, '2024-01-27 16:20:30'), -- 9
+(10, '2023-09-03 11:45:12'), -- 10
+(11, '2024-05-01 09:10:00'), -- 11
+(12, '2023-07-25 15:05:22'), -- 12
+(13, '2024-02-14 18:30:50'), -- 13
+(14, '2023-11-30 20:12:10'), -- 14
+(15, '2023-06-12 12:34:56'), -- 15
+(16, '2024-03-05 08:45:00'), -- 16
+(17, '2024-01-18 14:10:00'), -- 17
+(18, '2023-10-20 17:00:00'), -- 18
+(19, '2024-04-07 13:25:00'), -- 19
+(20, '2023-09-15 09:50:00'); -- 20
+
+INSERT INTO cart_items(cart_id, product_id, quantity) VALUES
+(1, 3, 2), -- 1
+(1, 7, 1), -- 2
+(1, 11, 1), -- 3
+(2, 2, 3), -- 4
+(2, 10, 2), -- 5
+(3, 4, 1), -- 6
+(3, 5, 2), -- 7
+(3, 9, 1), -- 8
+(4, 1, 1), -- 9
+(4, 8, 2), -- 10
+(5, 6, 1), -- 11
+(5, 13, 1), -- 12
+(5, 15, 2), -- 13
+(6, 14, 1), -- 14
+(6, 3, 3), -- 15
+(7, 20, 2), -- 16
+(7, 4, 1), -- 17
+(8, 27, 1), -- 18
+(8, 23, 1), -- 19
+(9, 35, 1), -- 20
+(9, 18, 2), -- 21
+(10, 25, 1), -- 22
+(10, 9, 2), -- 23
+(11, 12, 1), -- 24
+(11, 11, 1), -- 25
+(12, 22, 1), -- 26
+(12, 4, 1), -- 27
+(12, 10, 1), -- 28
+(13, 28, 2), -- 29
+(13, 30, 3), -- 30
+(14, 13, 1), -- 31
+(14, 5, 1), -- 32
+(15, 24, 2), -- 33
+(15, 33, 1), -- 34
+(16, 2, 1), -- 35
+(16, 14, 1), -- 36
+(16, 35, 2), -- 37
+(17, 1, 1), -- 38
+(17, 19, 2), -- 39
+(18, 3, 2), -- 40
+(18, 12, 1), -- 41
+(18, 5, 1), -- 42
+(19, 6, 1), -- 43
+(19, 23, 1), -- 44
+(19, 11, 1), -- 45
+(20, 8, 1), -- 46
+(20, 21, 1), -- 47
+(20, 33, 1); -- 48
+
+INSERT INTO invoices(user_id, total_amount, status, created_at) VALUES
+(1, 500000, 'PENDING', '2024-01-05 10:00:00'), -- 1
+(1, 1000000, 'DONE', '2024-01-06 11:30:00'), -- 2
+(2, 750000, 'DONE', '2024-01-07 09:00:00'), -- 3
+(2, 950000, 'CANCEL', '2024-01-08 14:00:00'), -- 4
+(3, 300000, 'PENDING', '2024-01-09 10:15:00'), -- 5
+(4, 1200000, 'DONE', '2024-01-10 13:30:00'), -- 6
+(4, 850000, 'PENDING', '2024-01-11 15:00:00'), -- 7
+(5, 950000, 'CANCEL', '2024-01-12 16:30:00'), -- 8
+(6, 500000, 'PENDING', '2024-01-13 14:00:00'), -- 9
+(6, 700000, 'DONE', '2024-01-14 08:00:00'), -- 10
+(7, 1000000, 'PENDING', '2024-01-15 17:00:00'), -- 11
+(8, 850000, 'DONE', '2024-01-16 11:15:00'), -- 12
+(8, 1300000, 'PENDING', '2024-01-17 10:30:00'), -- 13
+(9, 950000, 'CANCEL', '2024-01-18 09:45:00'), -- 14
+(10, 600000, 'PENDING', '2024-01-19 14:00:00'), -- 15
+(11, 750000, 'DONE', '2024-01-20 12:15:00'), -- 16
+(12, 1300000, 'DONE', '2024-01-21 16:30:00'), -- 17
+(12, 900000, 'CANCEL', '2024-01-22 10:00:00'), -- 18
+(13, 850000, 'PENDING', '2024-01-23 14:30:00'), -- 19
+(14, 600000, 'PENDING', '2024-01-24 11:45:00'), -- 20
+(14, 950000, 'DONE', '2024-01-25 15:00:00'), -- 21
+(15, 1000000, 'PENDING', '2024-01-26 08:30:00'), -- 22
+(16, 800000, 'DONE', '2024-01-27 09:30:00'), -- 23
+(17, 750000, 'PENDING', '2024-01-28 12:00:00'), -- 24
+(18, 500000, 'CANCEL', '2024-01-29 10:30:00'), -- 25
+(19, 1200000, 'DONE', '2024-01-30 14:00:00'), -- 26
+(20, 600000, 'PENDING', '2024-02-01 11:00:00'); -- 27
+
+INSERT INTO invoice_details(invoice_id, product_id, price, discount_percentage, quantity, total_price) VALUES
+(1, 1, 800000, 10, 1, 720000), -- 1
+(1, 2, 850000, 15, 1, 722500), -- 2
+(2, 3, 950000, 5, 1, 902500), -- 3
+(2, 4, 1000000, 10, 1, 900000), -- 4
+(3, 5, 750000, 20, 1, 600000), -- 5
+(3, 6, 800000, 15, 2, 1360000), -- 6
+(4, 7, 800000, 5, 1, 760000), -- 7
+(4, 8, 850000, 10, 1, 765000), -- 8
+(5, 9, 950000, 0, 1, 950000), -- 9
+(5, 10, 850000, 5, 2, 1610000), -- 10
+(6, 11, 1200000, 10, 1, 1080000), -- 11
+(6, 12, 900000, 5, 1, 855000), -- 12
+(7, 13, 800000, 15, 1, 680000), -- 13
+(7, 14, 1000000, 0, 1, 1000000), -- 14
+(8, 15, 950000, 5, 2, 1805000), -- 15
+(8, 16, 700000, 20, 1, 560000), -- 16
+(9, 17, 850000, 10, 1, 765000), -- 17
+(9, 18, 800000, 0, 1, 800000), -- 18
+(10, 1, 800000, 10, 1, 720000), -- 19
+(10, 2, 850000, 15, 1, 722500), -- 20
+(11, 3, 950000, 5, 1, 902500), -- 21
+(11, 4, 1000000, 10, 1, 900000), -- 22
+(12, 5, 750000, 20, 1, 600000), -- 23
+(12, 6, 800000, 15, 1, 680000), -- 24
+(13, 7, 800000, 5, 1, 760000), -- 25
+(13, 8, 850000, 10, 1, 765000), -- 26
+(14, 9, 950000, 0, 1, 950000), -- 27
+(14, 10, 850000, 5, 2, 1610000), -- 28
+(15, 11, 1200000, 10, 1, 1080000), -- 29
+(15, 12, 900000, 5, 1, 855000), -- 30
+(16, 13, 800000, 15, 1, 680000), -- 31
+(16, 14, 1000000, 0, 1, 1000000), -- 32
+(17, 15, 950000, 5, 2, 1805000), -- 33
+(17, 16, 700000, 20, 1, 560000), -- 34
+(18, 2, 900000, 10, 1, 810000), -- 35
+(18, 6, 850000, 5, 1, 807500), -- 36
+(19, 11, 950000, 15, 1, 807500), -- 37
+(19, 14, 1000000, 0, 1, 1000000), -- 38
+(20, 9, 950000, 5, 2, 1805000), -- 39
+(20, 6, 850000, 0, 1, 850000), -- 40
+(21, 5, 750000, 15, 1, 637500), -- 41
+(21, 8, 850000, 10, 1, 765000), -- 42
+(22, 12, 1200000, 5, 2, 2280000), -- 43
+(23, 3, 700000, 20, 1, 560000), -- 43
+(23, 5, 800000, 15, 1, 680000), -- 44
+(24, 13, 800000, 10, 1, 720000), -- 45
+(24, 7, 750000, 5, 1, 712500), -- 46
+(25, 10, 1200000, 0, 1, 1200000), -- 47
+(25, 9, 950000, 15, 1, 807500), -- 48
+(26, 15, 950000, 10, 1, 855000), -- 49
+(27, 4, 700000, 20, 1, 560000), -- 50
+(27, 8, 850000, 10, 1, 765000); -- 51
@@ -1,47 +1,202 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
 	"fmt"
-	"thanhldt060802/config"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func GenerateToken(userId int64, roleName string, cartId int64) (*string, error) {
-	expireDuration := config.AppConfig.GetTokenExpireMinutes()
-	if expireDuration == nil {
-		return nil, fmt.Errorf("convert expire failed")
+type TokenClaims struct {
+	UserId      int64    `json:"user_id"`
+	RoleName    string   `json:"role_name"`
+	CartId      int64    `json:"cart_id"`
+	SessionId   string   `json:"sid"`
+	Permissions []string `json:"permissions"`
+	jwt.RegisteredClaims
+}
+
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// Signing key plus every public key a token may still be signed with
+type JWTKeys struct {
+	signingKey   *rsa.PrivateKey
+	signingKeyId string
+	publicKeys   map[string]*rsa.PublicKey
+}
+
+// To rotate, move public key of current private key to JWT_PREVIOUS_PUBLIC_KEY_FILES and replace private key file,
+// old tokens still verify until previous key is dropped after longest token lifetime
+func NewJWTKeys(privateKeyFile string, previousPublicKeyFiles string) (*JWTKeys, error) {
+	privateKey, err := loadOrGeneratePrivateKey(privateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load JWT private key failed: %s", err.Error())
+	}
+	jwtKeys := &JWTKeys{
+		signingKey:   privateKey,
+		signingKeyId: GetKeyId(&privateKey.PublicKey),
+		publicKeys:   map[string]*rsa.PublicKey{},
+	}
+	jwtKeys.publicKeys[jwtKeys.signingKeyId] = &privateKey.PublicKey
+
+	for _, path := range strings.Split(previousPublicKeyFiles, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		publicKey, err := loadPublicKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("load JWT previous public key failed: %s", err.Error())
+		}
+		jwtKeys.publicKeys[GetKeyId(publicKey)] = publicKey
+	}
+
+	log.Printf("Load JWT keys successful, signing with key %s", jwtKeys.signingKeyId)
+	return jwtKeys, nil
+}
+
+func GenerateToken(jwtKeys *JWTKeys, expireDuration time.Duration, userId int64, roleName string, permissions []string, cartId int64, sessionId string) (*string, *TokenClaims, error) {
+	tokenId, err := GenerateRandomString(16)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate token id failed")
+	}
+
+	now := time.Now()
+	claims := &TokenClaims{
+		UserId:      userId,
+		RoleName:    roleName,
+		CartId:      cartId,
+		SessionId:   sessionId,
+		Permissions: permissions,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenId,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expireDuration)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = jwtKeys.signingKeyId
+	tokenStr, err := token.SignedString(jwtKeys.signingKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate token failed")
+	}
+
+	return &tokenStr, claims, nil
+}
+
+// Signature and exp are checked locally, revocation by jti is left to caller
+func ValidateToken(jwtKeys *JWTKeys, tokenStr string) (*TokenClaims, error) {
+	claims := &TokenClaims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		publicKey, ok := jwtKeys.publicKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		return publicKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid || claims.ID == "" {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+
+	return claims, nil
+}
+
+// Keys published on /.well-known/jwks.json, signing key first
+func GetJSONWebKeys(jwtKeys *JWTKeys) []JSONWebKey {
+	jsonWebKeys := []JSONWebKey{toJSONWebKey(jwtKeys.signingKeyId, &jwtKeys.signingKey.PublicKey)}
+	for kid, publicKey := range jwtKeys.publicKeys {
+		if kid != jwtKeys.signingKeyId {
+			jsonWebKeys = append(jsonWebKeys, toJSONWebKey(kid, publicKey))
+		}
 	}
+	return jsonWebKeys
+}
+
+// RFC 7638 thumbprint, so the same key always gets the same kid
+func GetKeyId(publicKey *rsa.PublicKey) string {
+	jsonWebKey := toJSONWebKey("", publicKey)
+	thumbprint := sha256.Sum256([]byte(fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jsonWebKey.E, jsonWebKey.N)))
+	return base64.RawURLEncoding.EncodeToString(thumbprint[:])
+}
 
-	claims := jwt.MapClaims{
-		"user_id":   userId,
-		"role_name": roleName,
-		"cart_id":   cartId,
-		"exp":       time.Now().Add(*expireDuration).Unix(),
+func toJSONWebKey(kid string, publicKey *rsa.PublicKey) JSONWebKey {
+	return JSONWebKey{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: jwt.SigningMethodRS256.Alg(),
+		Kid: kid,
+		N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
 	}
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenStr, err := token.SignedString([]byte(config.AppConfig.JWTSecret))
+// A missing key file is generated once for local development
+func loadOrGeneratePrivateKey(path string) (*rsa.PrivateKey, error) {
+	pemBytes, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return nil, err
+		}
+		pemBytes = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+		if err := os.WriteFile(path, pemBytes, 0600); err != nil {
+			return nil, err
+		}
+		log.Printf("Generate JWT private key to %s", path)
+		return privateKey, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block in %s", path)
+	}
+	if privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return privateKey, nil
+	}
+	parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
 	if err != nil {
-		return nil, fmt.Errorf("generate token failed")
-	}
-
-	return &tokenStr, nil
-}
-
-// func ValidateToken(tokenStr string) (jwt.MapClaims, error) {
-// 	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-// 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-// 			return nil, jwt.ErrSignatureInvalid
-// 		}
-// 		return []byte(config.AppConfig.JWTSecret), nil
-// 	})
-// 	if err != nil {
-// 		return nil, err
-// 	}
-// 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-// 		return claims, nil
-// 	}
-// 	return nil, jwt.ErrSignatureInvalid
-// }
+		return nil, err
+	}
+	privateKey, ok := parsedKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key in %s is not RSA", path)
+	}
+	return privateKey, nil
+}
+
+func loadPublicKey(path string) (*rsa.PublicKey, error) {
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// URL safe random string from n random bytes
+func GenerateRandomString(n int) (string, error) {
+	randomBytes := make([]byte, n)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(randomBytes), nil
+}
+
+// Only hashes of secrets are stored so a Redis dump can not be replayed
+func HashSecret(secret string) string {
+	hashedBytes := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hashedBytes[:])
+}